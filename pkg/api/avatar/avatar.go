@@ -17,6 +17,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,7 +29,8 @@ import (
 )
 
 const (
-	gravatarSource = "https://secure.gravatar.com/avatar/"
+	gravatarSource   = "https://secure.gravatar.com/avatar/"
+	libravatarSource = "https://seccdn.libravatar.org/avatar/"
 )
 
 // Avatar represents the avatar object.
@@ -127,7 +129,20 @@ func (a *AvatarCacheServer) GetAvatarForHash(hash string) *Avatar {
 		alog.Warn("'GetGravatarForHash' called despite gravatars being disabled; returning default profile image")
 		return a.notFound
 	}
-	return a.getAvatarForHash(hash, gravatarSource)
+	return a.getAvatarForHash(hash, a.source())
+}
+
+// source returns the base URL of the configured avatar provider. The hash of the avatar is
+// appended to it.
+func (a *AvatarCacheServer) source() string {
+	switch a.cfg.AvatarProvider {
+	case setting.AvatarProviderLibravatar:
+		return libravatarSource
+	case setting.AvatarProviderCustom:
+		return strings.TrimSuffix(a.cfg.AvatarURL, "/") + "/"
+	default:
+		return gravatarSource
+	}
 }
 
 func (a *AvatarCacheServer) getAvatarForHash(hash string, baseUrl string) *Avatar {
@@ -118,3 +118,40 @@ func setupMockGravatarServer(counter *int, simulateError bool) *httptest.Server
 	}))
 	return server
 }
+
+func TestAvatar_Source(t *testing.T) {
+	cases := []struct {
+		provider string
+		url      string
+		expected string
+	}{
+		{provider: setting.AvatarProviderGravatar, expected: gravatarSource},
+		{provider: setting.AvatarProviderLibravatar, expected: libravatarSource},
+		{provider: setting.AvatarProviderCustom, url: "https://avatars.example.com/avatar", expected: "https://avatars.example.com/avatar/"},
+		{provider: setting.AvatarProviderCustom, url: "https://avatars.example.com/avatar/", expected: "https://avatars.example.com/avatar/"},
+	}
+	for _, c := range cases {
+		t.Run(c.provider, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.AvatarProvider = c.provider
+			cfg.AvatarURL = c.url
+			require.Equal(t, c.expected, newCacheServer(cfg).source())
+		})
+	}
+}
+
+func TestAvatar_CustomProvider(t *testing.T) {
+	callCounter := 0
+	mockServer := setupMockGravatarServer(&callCounter, false)
+	t.Cleanup(mockServer.Close)
+
+	cfg := setting.NewCfg()
+	cfg.AvatarProvider = setting.AvatarProviderCustom
+	cfg.AvatarURL = mockServer.URL + "/avatar/"
+	avc := newCacheServer(cfg)
+
+	av := avc.GetAvatarForHash(CUSTOM_NONSENSE_HASH)
+	// the avatar is fetched from the custom provider, not from gravatar
+	require.Equal(t, 2, callCounter)
+	require.Equal(t, NONSENSE_BODY, av.data.Bytes())
+}
@@ -61,3 +61,12 @@ func (slice DataSourceList) Less(i, j int) bool {
 func (slice DataSourceList) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
+
+// TeamDataSourcePermission is a data source a team has permissions on.
+type TeamDataSourcePermission struct {
+	Id         int64  `json:"id"`
+	UID        string `json:"uid"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Permission string `json:"permission"`
+}
@@ -1,6 +1,8 @@
 package dtos
 
 import (
+	"github.com/prometheus/alertmanager/config"
+
 	pref "github.com/grafana/grafana/pkg/services/preference"
 )
 
@@ -13,6 +15,9 @@ type Prefs struct {
 	Language         string                      `json:"language"`
 	Navbar           pref.NavbarPreference       `json:"navbar,omitempty"`
 	QueryHistory     pref.QueryHistoryPreference `json:"queryHistory,omitempty"`
+	QueryTimeout     string                      `json:"queryTimeout,omitempty"`
+	MuteTimings      []config.MuteTimeInterval   `json:"muteTimings,omitempty"`
+	DefaultTeamID    int64                       `json:"defaultTeamId,omitempty"`
 }
 
 // swagger:model
@@ -29,6 +34,12 @@ type UpdatePrefsCmd struct {
 	Navbar       *pref.NavbarPreference       `json:"navbar,omitempty"`
 	QueryHistory *pref.QueryHistoryPreference `json:"queryHistory,omitempty"`
 	Language     string                       `json:"language"`
+	// Default timeout of datasource queries, as a duration string (e.g. 30s)
+	QueryTimeout string `json:"queryTimeout,omitempty"`
+	// Time intervals in which the alert notifications of a team are muted
+	MuteTimings []config.MuteTimeInterval `json:"muteTimings,omitempty"`
+	// The id of the team users added to the org are added to, only for org preferences
+	DefaultTeamID int64 `json:"defaultTeamId,omitempty"`
 }
 
 // swagger:model
@@ -45,4 +56,10 @@ type PatchPrefsCmd struct {
 	Navbar           *pref.NavbarPreference       `json:"navbar,omitempty"`
 	QueryHistory     *pref.QueryHistoryPreference `json:"queryHistory,omitempty"`
 	HomeDashboardUID *string                      `json:"homeDashboardUID,omitempty"`
+	// Default timeout of datasource queries, as a duration string (e.g. 30s)
+	QueryTimeout *string `json:"queryTimeout,omitempty"`
+	// Time intervals in which the alert notifications of a team are muted
+	MuteTimings []config.MuteTimeInterval `json:"muteTimings,omitempty"`
+	// The id of the team users added to the org are added to, only for org preferences
+	DefaultTeamID *int64 `json:"defaultTeamId,omitempty"`
 }
@@ -151,6 +151,8 @@ func (hs *HTTPServer) inviteExistingUserToOrg(c *models.ReqContext, user *user.U
 		return response.Error(500, "Error while trying to create org user", err)
 	}
 
+	hs.addToDefaultTeam(c.Req.Context(), c.OrgID, user.ID)
+
 	if inviteDto.SendEmail && util.IsEmail(user.Email) {
 		emailCmd := models.SendEmailCommand{
 			To:       []string{user.Email},
@@ -310,6 +312,8 @@ func (hs *HTTPServer) applyUserInvite(ctx context.Context, usr *user.User, invit
 		if !errors.Is(err, models.ErrOrgUserAlreadyAdded) {
 			return false, response.Error(500, "Error while trying to create org user", err)
 		}
+	} else {
+		hs.addToDefaultTeam(ctx, invite.OrgId, usr.ID)
 	}
 
 	// update temp user status
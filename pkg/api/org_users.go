@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/org"
+	pref "github.com/grafana/grafana/pkg/services/preference"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
@@ -95,12 +96,43 @@ func (hs *HTTPServer) addOrgUserHelper(c *models.ReqContext, cmd org.AddOrgUserC
 		return response.Error(500, "Could not add user to organization", err)
 	}
 
+	hs.addToDefaultTeam(c.Req.Context(), cmd.OrgID, cmd.UserID)
+
 	return response.JSON(http.StatusOK, util.DynMap{
 		"message": "User added to organization",
 		"userId":  cmd.UserID,
 	})
 }
 
+// addToDefaultTeam adds a user that was added to an org to the default team of the org, if
+// it is set in the org preferences. Users are still added to the org if it fails, so errors
+// are only logged. The default team is skipped if it was deleted.
+func (hs *HTTPServer) addToDefaultTeam(ctx context.Context, orgID, userID int64) {
+	preference, err := hs.preferenceService.Get(ctx, &pref.GetPreferenceQuery{OrgID: orgID})
+	if err != nil {
+		hs.log.Error("Failed to get org preferences for the default team", "orgId", orgID, "err", err)
+		return
+	}
+	if preference == nil || preference.JSONData == nil || preference.JSONData.DefaultTeamID == 0 {
+		return
+	}
+
+	teamID := preference.JSONData.DefaultTeamID
+	query := models.GetTeamByIdQuery{OrgId: orgID, Id: teamID, UserIdFilter: models.FilterIgnoreUser}
+	if err := hs.teamService.GetTeamById(ctx, &query); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			hs.log.Warn("Default team of the org not found", "orgId", orgID, "teamId", teamID)
+			return
+		}
+		hs.log.Error("Failed to get the default team of the org", "orgId", orgID, "teamId", teamID, "err", err)
+		return
+	}
+
+	if err := addOrUpdateTeamMember(ctx, hs.teamPermissionsService, userID, orgID, teamID, getPermissionName(0)); err != nil {
+		hs.log.Error("Failed to add user to the default team of the org", "orgId", orgID, "teamId", teamID, "userId", userID, "err", err)
+	}
+}
+
 // swagger:route GET /org/users org getOrgUsersForCurrentOrg
 //
 // Get all users within the current organization.
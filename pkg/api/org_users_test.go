@@ -16,22 +16,28 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/org/orgimpl"
 	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+	"github.com/grafana/grafana/pkg/services/preference/preftest"
 	"github.com/grafana/grafana/pkg/services/quota/quotaimpl"
 	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/mockstore"
 	"github.com/grafana/grafana/pkg/services/team/teamimpl"
+	"github.com/grafana/grafana/pkg/services/team/teamtest"
 	"github.com/grafana/grafana/pkg/services/temp_user/tempuserimpl"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/user/userimpl"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web/webtest"
 )
 
 func setUpGetOrgUsersDB(t *testing.T, sqlStore *sqlstore.SQLStore) {
@@ -1011,3 +1017,63 @@ func TestDeleteOrgUsersAPIEndpoint_AccessControl(t *testing.T) {
 		})
 	}
 }
+
+func TestOrgUsersAPIEndpoint_DefaultTeam(t *testing.T) {
+	type teamMember struct {
+		userID, orgID, teamID int64
+		permission            string
+	}
+
+	setup := func(t *testing.T, preference *pref.Preference, teamErr error) (*webtest.Server, *[]teamMember) {
+		t.Helper()
+		var added []teamMember
+		origAddOrUpdateTeamMember := addOrUpdateTeamMember
+		t.Cleanup(func() { addOrUpdateTeamMember = origAddOrUpdateTeamMember })
+		addOrUpdateTeamMember = func(ctx context.Context, resourcePermissionService accesscontrol.TeamPermissionsService, userID, orgID, teamID int64, permission string) error {
+			added = append(added, teamMember{userID, orgID, teamID, permission})
+			return nil
+		}
+
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.log = log.NewNopLogger()
+			hs.orgService = orgtest.NewOrgServiceFake()
+			userService := usertest.NewUserServiceFake()
+			userService.ExpectedUser = &user.User{ID: 2}
+			hs.userService = userService
+			prefService := preftest.NewPreferenceServiceFake()
+			prefService.ExpectedPreference = preference
+			hs.preferenceService = prefService
+			hs.teamService = &teamtest.FakeService{ExpectedTeamDTO: &models.TeamDTO{Id: 3}, ExpectedError: teamErr}
+		})
+		return server, &added
+	}
+
+	addOrgUser := func(t *testing.T, server *webtest.Server) {
+		t.Helper()
+		req := server.NewRequest(http.MethodPost, "/api/org/users", strings.NewReader(`{"loginOrEmail": "user2", "role": "Viewer"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req = webtest.RequestWithSignedInUser(req, &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleAdmin})
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	}
+
+	t.Run("new org member is added to the default team", func(t *testing.T) {
+		server, added := setup(t, &pref.Preference{JSONData: &pref.PreferenceJSONData{DefaultTeamID: 3}}, nil)
+		addOrgUser(t, server)
+		assert.Equal(t, []teamMember{{userID: 2, orgID: 1, teamID: 3, permission: "Member"}}, *added)
+	})
+
+	t.Run("new org member is not added to a team if the default team is unset", func(t *testing.T) {
+		server, added := setup(t, &pref.Preference{}, nil)
+		addOrgUser(t, server)
+		assert.Empty(t, *added)
+	})
+
+	t.Run("default team is skipped if it was deleted", func(t *testing.T) {
+		server, added := setup(t, &pref.Preference{JSONData: &pref.PreferenceJSONData{DefaultTeamID: 3}}, models.ErrTeamNotFound)
+		addOrgUser(t, server)
+		assert.Empty(t, *added)
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/clientmiddleware"
 	"github.com/grafana/grafana/pkg/util/proxyutil"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -99,8 +100,16 @@ func (hs *HTTPServer) pluginResourceRequest(c *models.ReqContext) (*http.Request
 func (hs *HTTPServer) makePluginResourceRequest(w http.ResponseWriter, req *http.Request, pCtx backend.PluginContext) error {
 	proxyutil.PrepareProxyRequest(req)
 
+	// The body is limited while it is read, so that larger bodies are not read into memory.
+	if maxBytes := hs.pluginMaxRequestBodySize(pCtx.PluginID); maxBytes > 0 && req.Body != nil {
+		req.Body = http.MaxBytesReader(w, req.Body, int64(maxBytes))
+	}
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return fmt.Errorf("%w: limit is %d bytes", clientmiddleware.ErrRequestTooLarge, maxBytesErr.Limit)
+		}
 		return fmt.Errorf("failed to read request body: %w", err)
 	}
 
@@ -140,6 +149,15 @@ func (hs *HTTPServer) makePluginResourceRequest(w http.ResponseWriter, req *http
 	return flushStreamErr
 }
 
+// pluginMaxRequestBodySize returns the maximum size in bytes of the bodies of the resource
+// requests to the plugin, 0 means no limit.
+func (hs *HTTPServer) pluginMaxRequestBodySize(pluginID string) int {
+	if maxBytes, ok := hs.Cfg.PluginsMaxRequestBodySizes[pluginID]; ok {
+		return maxBytes
+	}
+	return hs.Cfg.PluginsMaxRequestBodySize
+}
+
 func (hs *HTTPServer) flushStream(stream callResourceClientResponseStream, w http.ResponseWriter) error {
 	processedStreams := 0
 
@@ -218,6 +236,16 @@ func handleCallResourceError(err error, reqCtx *models.ReqContext) {
 		return
 	}
 
+	if errors.Is(err, clientmiddleware.ErrRequestTooLarge) {
+		reqCtx.JsonApiErr(http.StatusRequestEntityTooLarge, "Request body too large", err)
+		return
+	}
+
+	if errors.Is(err, clientmiddleware.ErrReadOnly) {
+		reqCtx.JsonApiErr(http.StatusServiceUnavailable, "Plugins are in read-only mode", err)
+		return
+	}
+
 	reqCtx.JsonApiErr(500, "Failed to call resource", err)
 }
 
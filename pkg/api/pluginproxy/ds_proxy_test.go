@@ -573,6 +573,20 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 		assert.Equal(t, "test_user", req.Header.Get("X-Grafana-User"))
 	})
 
+	t.Run("When SendUserHeader config is enabled with the id identity", func(t *testing.T) {
+		req := getDatasourceProxiedRequest(
+			t,
+			&models.ReqContext{
+				SignedInUser: &user.SignedInUser{
+					UserID: 3,
+					Login:  "test_user",
+				},
+			},
+			&setting.Cfg{SendUserHeader: true, SendUserHeaderIdentity: "id"},
+		)
+		assert.Equal(t, "3", req.Header.Get("X-Grafana-User"))
+	})
+
 	t.Run("When SendUserHeader config is disabled", func(t *testing.T) {
 		req := getDatasourceProxiedRequest(
 			t,
@@ -24,6 +24,7 @@ import (
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/pluginsettings"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/clientmiddleware"
 	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/updatechecker"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -370,6 +371,37 @@ func TestMakePluginResourceRequestContentTypeEmpty(t *testing.T) {
 	require.Zero(t, resp.Header().Get("Content-Type"))
 }
 
+func TestMakePluginResourceRequestBodySizeLimit(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.PluginsMaxRequestBodySize = 4
+	cfg.PluginsMaxRequestBodySizes = map[string]int{"large-plugin": 16}
+
+	send := func(t *testing.T, pluginID string, body string) (*fakePluginClient, error) {
+		t.Helper()
+		pluginClient := &fakePluginClient{}
+		hs := HTTPServer{
+			Cfg:          cfg,
+			log:          log.New(),
+			pluginClient: pluginClient,
+		}
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		return pluginClient, hs.makePluginResourceRequest(resp, req, backend.PluginContext{PluginID: pluginID})
+	}
+
+	t.Run("body larger than the limit is rejected before it reaches the plugin", func(t *testing.T) {
+		pluginClient, err := send(t, "test-plugin", "too large")
+		require.ErrorIs(t, err, clientmiddleware.ErrRequestTooLarge)
+		require.Nil(t, pluginClient.req)
+	})
+
+	t.Run("body within the limit of the plugin is sent", func(t *testing.T) {
+		pluginClient, err := send(t, "large-plugin", "too large")
+		require.NoError(t, err)
+		require.Equal(t, []byte("too large"), pluginClient.req.Body)
+	})
+}
+
 func callGetPluginAsset(sc *scenarioContext) {
 	sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
 }
@@ -3,6 +3,9 @@ package api
 import (
 	"context"
 	"net/http"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
@@ -99,6 +102,9 @@ func (hs *HTTPServer) getPreferencesFor(ctx context.Context, orgID, userID, team
 		dto.Language = preference.JSONData.Language
 		dto.Navbar = preference.JSONData.Navbar
 		dto.QueryHistory = preference.JSONData.QueryHistory
+		dto.QueryTimeout = preference.JSONData.QueryTimeout
+		dto.MuteTimings = preference.JSONData.MuteTimings
+		dto.DefaultTeamID = preference.JSONData.DefaultTeamID
 	}
 
 	return response.JSON(http.StatusOK, &dto)
@@ -128,6 +134,18 @@ func (hs *HTTPServer) updatePreferencesFor(ctx context.Context, orgID, userID, t
 		return response.Error(400, "Invalid theme", nil)
 	}
 
+	if !validQueryTimeout(dtoCmd.QueryTimeout) {
+		return response.Error(400, "Invalid query timeout", nil)
+	}
+
+	if !validMuteTimings(dtoCmd.MuteTimings) {
+		return response.Error(400, "Invalid mute timings", nil)
+	}
+
+	if dtoCmd.DefaultTeamID != 0 && (userID != 0 || teamId != 0) {
+		return response.Error(400, "Default team can only be set in org preferences", nil)
+	}
+
 	dashboardID := dtoCmd.HomeDashboardID
 	if dtoCmd.HomeDashboardUID != nil {
 		query := models.GetDashboardQuery{Uid: *dtoCmd.HomeDashboardUID, OrgId: orgID}
@@ -155,6 +173,9 @@ func (hs *HTTPServer) updatePreferencesFor(ctx context.Context, orgID, userID, t
 		HomeDashboardID: dtoCmd.HomeDashboardID,
 		QueryHistory:    dtoCmd.QueryHistory,
 		Navbar:          dtoCmd.Navbar,
+		QueryTimeout:    dtoCmd.QueryTimeout,
+		MuteTimings:     dtoCmd.MuteTimings,
+		DefaultTeamID:   dtoCmd.DefaultTeamID,
 	}
 
 	if err := hs.preferenceService.Save(ctx, &saveCmd); err != nil {
@@ -186,6 +207,18 @@ func (hs *HTTPServer) patchPreferencesFor(ctx context.Context, orgID, userID, te
 		return response.Error(400, "Invalid theme", nil)
 	}
 
+	if dtoCmd.QueryTimeout != nil && !validQueryTimeout(*dtoCmd.QueryTimeout) {
+		return response.Error(400, "Invalid query timeout", nil)
+	}
+
+	if !validMuteTimings(dtoCmd.MuteTimings) {
+		return response.Error(400, "Invalid mute timings", nil)
+	}
+
+	if dtoCmd.DefaultTeamID != nil && *dtoCmd.DefaultTeamID != 0 && (userID != 0 || teamId != 0) {
+		return response.Error(400, "Default team can only be set in org preferences", nil)
+	}
+
 	// convert dashboard UID to ID in order to store internally if it exists in the query, otherwise take the id from query
 	dashboardID := dtoCmd.HomeDashboardID
 	if dtoCmd.HomeDashboardUID != nil {
@@ -215,6 +248,9 @@ func (hs *HTTPServer) patchPreferencesFor(ctx context.Context, orgID, userID, te
 		Language:        dtoCmd.Language,
 		Navbar:          dtoCmd.Navbar,
 		QueryHistory:    dtoCmd.QueryHistory,
+		QueryTimeout:    dtoCmd.QueryTimeout,
+		MuteTimings:     dtoCmd.MuteTimings,
+		DefaultTeamID:   dtoCmd.DefaultTeamID,
 	}
 
 	if err := hs.preferenceService.Patch(ctx, &patchCmd); err != nil {
@@ -224,6 +260,29 @@ func (hs *HTTPServer) patchPreferencesFor(ctx context.Context, orgID, userID, te
 	return response.Success("Preferences updated")
 }
 
+// validQueryTimeout returns whether s is empty, which resets the query timeout,
+// or a positive duration.
+func validQueryTimeout(s string) bool {
+	if s == "" {
+		return true
+	}
+	d, err := time.ParseDuration(s)
+	return err == nil && d > 0
+}
+
+// validMuteTimings returns whether all mute timings have a unique name. The time
+// intervals are validated when they are unmarshalled.
+func validMuteTimings(muteTimings []config.MuteTimeInterval) bool {
+	names := make(map[string]struct{}, len(muteTimings))
+	for _, mt := range muteTimings {
+		if _, ok := names[mt.Name]; ok || mt.Name == "" {
+			return false
+		}
+		names[mt.Name] = struct{}{}
+	}
+	return true
+}
+
 // swagger:route GET /org/preferences org_preferences getOrgPreferences
 //
 // Get Current Org Prefs.
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
@@ -172,6 +173,13 @@ func (hs *HTTPServer) SearchTeams(c *models.ReqContext) response.Response {
 		SignedInUser: c.SignedInUser,
 		HiddenUsers:  hs.Cfg.HiddenUsers,
 	}
+	if s := c.Query("inactiveSince"); s != "" {
+		inactiveSince, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return response.Error(http.StatusBadRequest, "inactiveSince is invalid, must be an RFC 3339 date", err)
+		}
+		query.InactiveSince = &inactiveSince
+	}
 
 	if err := hs.teamService.SearchTeams(c.Req.Context(), &query); err != nil {
 		return response.Error(500, "Failed to search Teams", err)
@@ -252,6 +260,59 @@ func (hs *HTTPServer) GetTeamByID(c *models.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, &query.Result)
 }
 
+// swagger:route GET /teams/byname/{team_name} teams getTeamByName
+//
+// Get Team By Name.
+//
+// Returns the team of the organization with exactly this name.
+//
+// Responses:
+// 200: getTeamByIDResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 409: conflictError
+// 500: internalServerError
+func (hs *HTTPServer) GetTeamByName(c *models.ReqContext) response.Response {
+	name := web.Params(c.Req)[":name"]
+	if name == "" {
+		return response.Error(http.StatusBadRequest, "team name is missing", nil)
+	}
+
+	// Using accesscontrol the filtering is done based on user permissions
+	userIdFilter := models.FilterIgnoreUser
+	if hs.AccessControl.IsDisabled() {
+		userIdFilter = userFilter(c)
+	}
+
+	query := models.SearchTeamsQuery{
+		OrgId:        c.OrgID,
+		Name:         name,
+		UserIdFilter: userIdFilter,
+		SignedInUser: c.SignedInUser,
+		HiddenUsers:  hs.Cfg.HiddenUsers,
+	}
+
+	if err := hs.teamService.SearchTeams(c.Req.Context(), &query); err != nil {
+		return response.Error(500, "Failed to get Team", err)
+	}
+
+	if len(query.Result.Teams) == 0 {
+		return response.Error(404, "Team not found", models.ErrTeamNotFound)
+	}
+	// Team names are unique within an organization, so this should not happen.
+	if len(query.Result.Teams) > 1 {
+		return response.Error(409, "More than one team has this name", nil)
+	}
+
+	team := query.Result.Teams[0]
+	// Add accesscontrol metadata
+	team.AccessControl = hs.getAccessControlMetadata(c, c.OrgID, "teams:id:", strconv.FormatInt(team.Id, 10))
+
+	team.AvatarUrl = dtos.GetGravatarUrlWithDefault(team.Email, team.Name)
+	return response.JSON(http.StatusOK, team)
+}
+
 // swagger:route GET /teams/{team_id}/preferences teams getTeamPreferences
 //
 // Get Team Preferences.
@@ -325,6 +386,13 @@ type GetTeamByIDParams struct {
 	TeamID string `json:"team_id"`
 }
 
+// swagger:parameters getTeamByName
+type GetTeamByNameParams struct {
+	// in:path
+	// required:true
+	TeamName string `json:"team_name"`
+}
+
 // swagger:parameters deleteTeamByID
 type DeleteTeamByIDParams struct {
 	// in:path
@@ -355,6 +423,10 @@ type SearchTeamsParams struct {
 	// If set it will return results where the query value is contained in the name field. Query values with spaces need to be URL encoded.
 	// required:false
 	Query string `json:"query"`
+	// If set it will only return the teams whose last activity, the last change of the team or its members, is before this RFC 3339 date.
+	// in:query
+	// required:false
+	InactiveSince string `json:"inactiveSince"`
 }
 
 // swagger:parameters createTeam
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /teams/{team_id}/datasources teams getTeamDataSources
+//
+// Get the data sources a team has permissions on.
+//
+// Responses:
+// 200: getTeamDataSourcesResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) GetTeamDataSources(c *models.ReqContext) response.Response {
+	teamId, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+
+	if hs.AccessControl.IsDisabled() {
+		if err := hs.teamGuardian.CanAdmin(c.Req.Context(), c.OrgID, teamId, c.SignedInUser); err != nil {
+			return response.Error(403, "Not allowed to view team data source permissions.", err)
+		}
+	}
+
+	query := datasources.GetDataSourcesQuery{OrgId: c.OrgID, DataSourceLimit: hs.Cfg.DataSourceLimit}
+	if err := hs.DataSourcesService.GetDataSources(c.Req.Context(), &query); err != nil {
+		return response.Error(500, "Failed to query datasources", err)
+	}
+
+	result := []dtos.TeamDataSourcePermission{}
+	for _, ds := range query.Result {
+		permissions, err := hs.dsPermissionsService.GetPermissions(c.Req.Context(), c.SignedInUser, ds.Uid)
+		if err != nil {
+			return response.Error(500, "Failed to get datasource permissions", err)
+		}
+
+		// A team can be granted several permissions on a data source, only the highest is returned.
+		var teamPermission *accesscontrol.ResourcePermission
+		for i := range permissions {
+			p := &permissions[i]
+			if p.TeamId != teamId {
+				continue
+			}
+			if teamPermission == nil || len(p.Actions) > len(teamPermission.Actions) {
+				teamPermission = p
+			}
+		}
+		if teamPermission == nil {
+			continue
+		}
+
+		result = append(result, dtos.TeamDataSourcePermission{
+			Id:         ds.Id,
+			UID:        ds.Uid,
+			Name:       ds.Name,
+			Type:       ds.Type,
+			Permission: hs.dsPermissionsService.MapActions(*teamPermission),
+		})
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// swagger:parameters getTeamDataSources
+type GetTeamDataSourcesParams struct {
+	// in:path
+	// required:true
+	TeamID string `json:"team_id"`
+}
+
+// swagger:response getTeamDataSourcesResponse
+type GetTeamDataSourcesResponse struct {
+	// The response message
+	// in: body
+	Body []dtos.TeamDataSourcePermission `json:"body"`
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+const teamDataSourcesURL = "/api/teams/%d/datasources"
+
+func TestTeamAPIEndpoint_GetTeamDataSources(t *testing.T) {
+	dsService := &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+		{Id: 1, Uid: "prometheus", OrgId: 1, Name: "Prometheus", Type: "prometheus"},
+		{Id: 2, Uid: "loki", OrgId: 1, Name: "Loki", Type: "loki"},
+	}}
+
+	queryPermission := accesscontrol.ResourcePermission{TeamId: 1, Actions: []string{datasources.ActionQuery}}
+	permissionsService := acmock.NewMockedPermissionsService()
+	permissionsService.On("GetPermissions", mock.Anything, mock.Anything, "prometheus").Return([]accesscontrol.ResourcePermission{
+		{TeamId: 2, Actions: []string{datasources.ActionQuery, datasources.ActionWrite}},
+		queryPermission,
+	}, nil)
+	permissionsService.On("GetPermissions", mock.Anything, mock.Anything, "loki").Return([]accesscontrol.ResourcePermission{
+		{BuiltInRole: string(org.RoleViewer), Actions: []string{datasources.ActionQuery}},
+	}, nil)
+	permissionsService.On("MapActions", queryPermission).Return("Query")
+
+	t.Run("with access control", func(t *testing.T) {
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.Cfg = setting.NewCfg()
+			hs.DataSourcesService = dsService
+			hs.dsPermissionsService = permissionsService
+		})
+
+		t.Run("returns the data sources the team has permissions on", func(t *testing.T) {
+			req := server.NewGetRequest(fmt.Sprintf(teamDataSourcesURL, 1))
+			req = webtest.RequestWithSignedInUser(req, userWithPermissions(1, []accesscontrol.Permission{
+				{Action: accesscontrol.ActionTeamsPermissionsRead, Scope: "teams:id:1"},
+			}))
+			res, err := server.Send(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+
+			var result []dtos.TeamDataSourcePermission
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+			require.NoError(t, res.Body.Close())
+			assert.Equal(t, []dtos.TeamDataSourcePermission{
+				{Id: 1, UID: "prometheus", Name: "Prometheus", Type: "prometheus", Permission: "Query"},
+			}, result)
+		})
+
+		t.Run("prevents getting the data sources of another team", func(t *testing.T) {
+			req := server.NewGetRequest(fmt.Sprintf(teamDataSourcesURL, 2))
+			req = webtest.RequestWithSignedInUser(req, userWithPermissions(1, []accesscontrol.Permission{
+				{Action: accesscontrol.ActionTeamsPermissionsRead, Scope: "teams:id:1"},
+			}))
+			res, err := server.Send(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusForbidden, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	})
+
+	t.Run("without access control", func(t *testing.T) {
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.Cfg = setting.NewCfg()
+			hs.Cfg.RBACEnabled = false
+			hs.DataSourcesService = dsService
+			hs.dsPermissionsService = permissionsService
+			hs.teamGuardian = &TeamGuardianMock{result: errors.New("not a team admin")}
+		})
+
+		t.Run("prevents getting the data sources when the user cannot admin the team", func(t *testing.T) {
+			req := server.NewGetRequest(fmt.Sprintf(teamDataSourcesURL, 1))
+			req = webtest.RequestWithSignedInUser(req, &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleEditor})
+			res, err := server.Send(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusForbidden, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	})
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/login"
@@ -87,6 +89,9 @@ func (hs *HTTPServer) AddTeamMember(c *models.ReqContext) response.Response {
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
 	}
+	if cmd.ExpiresAt != nil && !cmd.ExpiresAt.After(time.Now()) {
+		return response.Error(http.StatusBadRequest, "expiresAt must be in the future", nil)
+	}
 
 	if hs.AccessControl.IsDisabled() {
 		if err := hs.teamGuardian.CanAdmin(c.Req.Context(), cmd.OrgId, cmd.TeamId, c.SignedInUser); err != nil {
@@ -107,6 +112,18 @@ func (hs *HTTPServer) AddTeamMember(c *models.ReqContext) response.Response {
 		return response.Error(500, "Failed to add Member to Team", err)
 	}
 
+	if cmd.ExpiresAt != nil {
+		err = hs.teamService.SetTeamMemberExpiry(c.Req.Context(), &models.SetTeamMemberExpiryCommand{
+			OrgId:     cmd.OrgId,
+			TeamId:    cmd.TeamId,
+			UserId:    cmd.UserId,
+			ExpiresAt: cmd.ExpiresAt,
+		})
+		if err != nil {
+			return response.Error(500, "Failed to set expiry of team member", err)
+		}
+	}
+
 	return response.JSON(http.StatusOK, &util.DynMap{
 		"message": "Member added to Team",
 	})
@@ -158,6 +175,85 @@ func (hs *HTTPServer) UpdateTeamMember(c *models.ReqContext) response.Response {
 	return response.Success("Team member updated")
 }
 
+// swagger:route PATCH /teams/{team_id}/members/{user_id} teams patchTeamMember
+//
+// Change the role of a team member.
+//
+// Promotes a member to admin or demotes an admin to member. The last admin of a team cannot be demoted.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) PatchTeamMember(c *models.ReqContext) response.Response {
+	cmd := models.UpdateTeamMemberCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	teamId, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+	userId, err := strconv.ParseInt(web.Params(c.Req)[":userId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "userId is invalid", err)
+	}
+	if cmd.Permission != 0 && cmd.Permission != models.PERMISSION_ADMIN {
+		return response.Error(http.StatusBadRequest, "permission must be 0 (Member) or 4 (Admin)", nil)
+	}
+	orgId := c.OrgID
+
+	if hs.AccessControl.IsDisabled() {
+		if err := hs.teamGuardian.CanAdmin(c.Req.Context(), orgId, teamId, c.SignedInUser); err != nil {
+			return response.Error(403, "Not allowed to update team member", err)
+		}
+	}
+
+	isTeamMember, err := hs.teamService.IsTeamMember(orgId, teamId, userId)
+	if err != nil {
+		return response.Error(500, "Failed to update team member.", err)
+	}
+	if !isTeamMember {
+		return response.Error(404, "Team member not found.", nil)
+	}
+
+	adminsQuery := models.GetTeamAdminsQuery{OrgId: orgId, TeamId: teamId}
+	if err := hs.teamService.GetTeamAdmins(c.Req.Context(), &adminsQuery); err != nil {
+		return response.Error(500, "Failed to update team member.", err)
+	}
+	var previous models.PermissionType
+	for _, id := range adminsQuery.Result {
+		if id == userId {
+			previous = models.PERMISSION_ADMIN
+		}
+	}
+	if previous == models.PERMISSION_ADMIN && cmd.Permission != models.PERMISSION_ADMIN && len(adminsQuery.Result) == 1 {
+		return response.Error(http.StatusBadRequest, "Not allowed to demote the last admin of the team", models.ErrLastTeamAdmin)
+	}
+
+	err = addOrUpdateTeamMember(c.Req.Context(), hs.teamPermissionsService, userId, orgId, teamId, getPermissionName(cmd.Permission))
+	if err != nil {
+		return response.Error(500, "Failed to update team member.", err)
+	}
+
+	if err := hs.bus.Publish(c.Req.Context(), &events.TeamMemberPermissionUpdated{
+		Timestamp:          time.Now(),
+		OrgID:              orgId,
+		TeamID:             teamId,
+		UserID:             userId,
+		Permission:         getPermissionName(cmd.Permission),
+		PreviousPermission: getPermissionName(previous),
+		UpdatedBy:          c.UserID,
+	}); err != nil {
+		return response.Error(500, "Failed to publish event", err)
+	}
+
+	return response.Success("Team member updated")
+}
+
 func getPermissionName(permission models.PermissionType) string {
 	permissionName := permission.String()
 	// Team member permission is 0, which maps to an empty string.
@@ -251,6 +347,19 @@ type UpdateTeamMemberParams struct {
 	UserID int64 `json:"user_id"`
 }
 
+// swagger:parameters patchTeamMember
+type PatchTeamMemberParams struct {
+	// in:body
+	// required:true
+	Body models.UpdateTeamMemberCommand `json:"body"`
+	// in:path
+	// required:true
+	TeamID string `json:"team_id"`
+	// in:path
+	// required:true
+	UserID int64 `json:"user_id"`
+}
+
 // swagger:parameters removeTeamMember
 type RemoveTeamMemberParams struct {
 	// in:path
@@ -12,7 +12,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/models"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/licensing"
@@ -395,6 +398,82 @@ func TestUpdateTeamMembersAPIEndpoint_RBAC(t *testing.T) {
 	})
 }
 
+func TestPatchTeamMembersAPIEndpoint_LegacyAccessControl(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.RBACEnabled = false
+	sc := setupHTTPServerWithCfg(t, true, cfg)
+	sc.hs.orgService, _ = orgimpl.ProvideService(sc.db, cfg, quotatest.New(false, nil))
+	guardian := manager.ProvideService(database.ProvideTeamGuardianStore(sc.db, sc.teamService))
+	sc.hs.teamGuardian = guardian
+
+	var published []*events.TeamMemberPermissionUpdated
+	eventBus := bus.ProvideBus(tracing.InitializeTracerForTest())
+	eventBus.AddEventListener(func(ctx context.Context, e *events.TeamMemberPermissionUpdated) error {
+		published = append(published, e)
+		return nil
+	})
+	sc.hs.bus = eventBus
+
+	// setupTeamTestScenario sets up 3 user (id: 2,3,4) in the team (id: 1)
+	teamMemberCount := 3
+	setupTeamTestScenario(teamMemberCount, sc.db, sc.hs.orgService, t)
+	setInitCtxSignedInOrgAdmin(sc.initCtx)
+
+	patch := func(userID string, permission models.PermissionType) int {
+		input := strings.NewReader(fmt.Sprintf(updateTeamMemberCmd, permission))
+		return callAPI(sc.server, http.MethodPatch, fmt.Sprintf(teamMemberUpdateRoute, "1", userID), input, t).Code
+	}
+	admins := func() []int64 {
+		query := &models.GetTeamAdminsQuery{OrgId: 1, TeamId: 1}
+		require.NoError(t, sc.teamService.GetTeamAdmins(context.Background(), query))
+		return query.Result
+	}
+
+	t.Run("Members can be promoted to admin", func(t *testing.T) {
+		published = nil
+		require.Equal(t, http.StatusOK, patch("2", models.PERMISSION_ADMIN))
+		require.Equal(t, []int64{2}, admins())
+
+		require.Len(t, published, 1)
+		require.Equal(t, int64(2), published[0].UserID)
+		require.Equal(t, int64(1), published[0].TeamID)
+		require.Equal(t, "Admin", published[0].Permission)
+		require.Equal(t, "Member", published[0].PreviousPermission)
+		require.Equal(t, sc.initCtx.UserID, published[0].UpdatedBy)
+	})
+
+	t.Run("Admins can be demoted to member if the team has other admins", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, patch("3", models.PERMISSION_ADMIN))
+		published = nil
+		require.Equal(t, http.StatusOK, patch("3", 0))
+		require.Equal(t, []int64{2}, admins())
+
+		require.Len(t, published, 1)
+		require.Equal(t, "Member", published[0].Permission)
+		require.Equal(t, "Admin", published[0].PreviousPermission)
+	})
+
+	t.Run("The last admin cannot be demoted", func(t *testing.T) {
+		published = nil
+		require.Equal(t, http.StatusBadRequest, patch("2", 0))
+		require.Equal(t, []int64{2}, admins())
+		require.Empty(t, published)
+	})
+
+	t.Run("Permissions other than member and admin are rejected", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, patch("4", models.PERMISSION_EDIT))
+	})
+
+	t.Run("Users that are not in the team are not found", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, patch("99", models.PERMISSION_ADMIN))
+	})
+
+	setInitCtxSignedInEditor(sc.initCtx)
+	t.Run("Users that cannot admin the team cannot change roles", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, patch("4", models.PERMISSION_ADMIN))
+	})
+}
+
 func TestDeleteTeamMembersAPIEndpoint_LegacyAccessControl(t *testing.T) {
 	cfg := setting.NewCfg()
 	cfg.RBACEnabled = false
@@ -159,6 +159,7 @@ const (
 	searchTeamsURL          = "/api/teams/search"
 	createTeamURL           = "/api/teams/"
 	detailTeamURL           = "/api/teams/%d"
+	teamByNameURL           = "/api/teams/byname/%s"
 	detailTeamPreferenceURL = "/api/teams/%d/preferences"
 	teamCmd                 = `{"name": "MyTestTeam%d"}`
 	teamPreferenceCmd       = `{"theme": "dark"}`
@@ -307,6 +308,61 @@ func TestTeamAPIEndpoint_GetTeamByID_RBAC(t *testing.T) {
 	})
 }
 
+func TestTeamAPIEndpoint_GetTeamByName_RBAC(t *testing.T) {
+	teamService := teamtest.NewFakeService()
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.teamService = teamService
+	})
+
+	readPermissions := []accesscontrol.Permission{{Action: accesscontrol.ActionTeamsRead, Scope: accesscontrol.ScopeTeamsAll}}
+
+	t.Run("Access control prevents getting a team by name when missing permissions", func(t *testing.T) {
+		teamService.ExpectedSearchTeams = []*models.TeamDTO{{Id: 1, Name: "MyTestTeam"}}
+		req := server.NewGetRequest(fmt.Sprintf(teamByNameURL, "MyTestTeam"))
+		req = webtest.RequestWithSignedInUser(req, userWithPermissions(1, []accesscontrol.Permission{}))
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	})
+
+	t.Run("Returns the team with the name", func(t *testing.T) {
+		teamService.ExpectedSearchTeams = []*models.TeamDTO{{Id: 1, Name: "MyTestTeam"}}
+		req := server.NewGetRequest(fmt.Sprintf(teamByNameURL, "MyTestTeam"))
+		req = webtest.RequestWithSignedInUser(req, userWithPermissions(1, readPermissions))
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var team models.TeamDTO
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&team))
+		assert.Equal(t, int64(1), team.Id)
+		assert.Equal(t, "MyTestTeam", team.Name)
+		require.NoError(t, res.Body.Close())
+	})
+
+	t.Run("Returns 404 if no team has the name", func(t *testing.T) {
+		teamService.ExpectedSearchTeams = nil
+		req := server.NewGetRequest(fmt.Sprintf(teamByNameURL, "UnknownTeam"))
+		req = webtest.RequestWithSignedInUser(req, userWithPermissions(1, readPermissions))
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	})
+
+	t.Run("Returns 409 if more than one team has the name", func(t *testing.T) {
+		teamService.ExpectedSearchTeams = []*models.TeamDTO{{Id: 1, Name: "MyTestTeam"}, {Id: 2, Name: "MyTestTeam"}}
+		req := server.NewGetRequest(fmt.Sprintf(teamByNameURL, "MyTestTeam"))
+		req = webtest.RequestWithSignedInUser(req, userWithPermissions(1, readPermissions))
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	})
+}
+
 // Given a team with a user, when the user is granted X permission,
 // Then the endpoint should return 200 if the user has accesscontrol.ActionTeamsWrite with teams:id:1 scope
 // else return 403
@@ -78,3 +78,15 @@ type FolderTitleUpdated struct {
 	UID       string    `json:"uid"`
 	OrgID     int64     `json:"org_id"`
 }
+
+// TeamMemberPermissionUpdated is published when the permission of a team member
+// is changed, e.g. for the audit of promotions to team admin.
+type TeamMemberPermissionUpdated struct {
+	Timestamp          time.Time `json:"timestamp"`
+	OrgID              int64     `json:"org_id"`
+	TeamID             int64     `json:"team_id"`
+	UserID             int64     `json:"user_id"`
+	Permission         string    `json:"permission"`
+	PreviousPermission string    `json:"previous_permission"`
+	UpdatedBy          int64     `json:"updated_by"`
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/user"
 )
@@ -26,6 +27,30 @@ type SendEmailCommand struct {
 	ReplyTo       []string
 	EmbeddedFiles []string
 	AttachedFiles []*SendEmailAttachFile
+	// Cc are the carbon copy recipients of the email.
+	Cc []string
+	// CopyToSender adds the From address of the email to its blind carbon copy recipients.
+	CopyToSender bool
+	// SkipArchive does not send a copy of the email to the archive address of the org, e.g.
+	// for all but one of the emails a notification is split into.
+	SkipArchive bool
+	// OptOut are the addresses removed from the blind carbon copies of the email.
+	OptOut []string
+	// Headers are additional headers of the email.
+	Headers map[string]string
+	// TransferEncoding is the Content-Transfer-Encoding of the body, quoted-printable or
+	// base64. The default of the mailer is used if it is empty.
+	TransferEncoding string
+	// Charset is the charset the body is transcoded to, UTF-8 or ISO-8859-1. The body is
+	// sent in UTF-8 if it is empty.
+	Charset string
+	// SMIMECertFile and SMIMEKeyFile are the PEM files of the certificate and private key
+	// the email is signed with using S/MIME. The email is not signed if they are empty.
+	SMIMECertFile string
+	SMIMEKeyFile  string
+	// OrgID is the org the email is sent for, emails of orgs with their own SMTP
+	// settings are sent through their relay.
+	OrgID int64
 }
 
 // SendEmailCommandSync is the command for sending emails synchronously
@@ -41,7 +66,15 @@ type SendWebhookSync struct {
 	HttpMethod  string
 	HttpHeader  map[string]string
 	ContentType string
-	Validation  func(body []byte, statusCode int) error
+	// Timeout and ConnectTimeout bound the request, defaults are used if they are not set.
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+	// ConnectionPool, MaxIdleConns and IdleConnTimeout keep the connections of the
+	// webhooks of the pool alive, the shared connections are used if they are not set.
+	ConnectionPool  string
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+	Validation      func(body []byte, statusCode int) error
 }
 
 type SendResetPasswordEmailCommand struct {
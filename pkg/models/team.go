@@ -26,6 +26,8 @@ type Team struct {
 
 	Created time.Time `json:"created"`
 	Updated time.Time `json:"updated"`
+	// LastActivityAt is the last time the team or its members changed.
+	LastActivityAt time.Time `json:"lastActivityAt"`
 }
 
 // ---------------------
@@ -79,19 +81,22 @@ type SearchTeamsQuery struct {
 	UserIdFilter int64
 	SignedInUser *user.SignedInUser
 	HiddenUsers  map[string]struct{}
+	// InactiveSince only returns the teams without activity since this time, if set.
+	InactiveSince *time.Time
 
 	Result SearchTeamQueryResult
 }
 
 type TeamDTO struct {
-	Id            int64           `json:"id"`
-	OrgId         int64           `json:"orgId"`
-	Name          string          `json:"name"`
-	Email         string          `json:"email"`
-	AvatarUrl     string          `json:"avatarUrl"`
-	MemberCount   int64           `json:"memberCount"`
-	Permission    PermissionType  `json:"permission"`
-	AccessControl map[string]bool `json:"accessControl"`
+	Id             int64           `json:"id"`
+	OrgId          int64           `json:"orgId"`
+	Name           string          `json:"name"`
+	Email          string          `json:"email"`
+	AvatarUrl      string          `json:"avatarUrl"`
+	MemberCount    int64           `json:"memberCount"`
+	LastActivityAt time.Time       `json:"lastActivityAt"`
+	Permission     PermissionType  `json:"permission"`
+	AccessControl  map[string]bool `json:"accessControl"`
 }
 
 type SearchTeamQueryResult struct {
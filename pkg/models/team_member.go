@@ -20,6 +20,8 @@ type TeamMember struct {
 	UserId     int64
 	External   bool // Signals that the membership has been created by an external systems, such as LDAP
 	Permission PermissionType
+	// ExpiresAt is the time the membership is removed at, if set.
+	ExpiresAt *time.Time
 
 	Created time.Time
 	Updated time.Time
@@ -34,6 +36,8 @@ type AddTeamMemberCommand struct {
 	TeamId     int64          `json:"-"`
 	External   bool           `json:"-"`
 	Permission PermissionType `json:"-"`
+	// ExpiresAt makes the membership time-bound, it is removed once it expires.
+	ExpiresAt *time.Time `json:"expiresAt"`
 }
 
 type UpdateTeamMemberCommand struct {
@@ -43,6 +47,13 @@ type UpdateTeamMemberCommand struct {
 	Permission PermissionType `json:"permission"`
 }
 
+type SetTeamMemberExpiryCommand struct {
+	OrgId     int64
+	TeamId    int64
+	UserId    int64
+	ExpiresAt *time.Time
+}
+
 type RemoveTeamMemberCommand struct {
 	OrgId  int64 `json:"-"`
 	UserId int64
@@ -61,6 +72,19 @@ type GetTeamMembersQuery struct {
 	Result       []*TeamMemberDTO
 }
 
+// GetTeamAdminsQuery returns the ids of the admins of a team, without filtering them
+// on the permissions of the signed in user.
+type GetTeamAdminsQuery struct {
+	OrgId  int64
+	TeamId int64
+	Result []int64
+}
+
+type GetExpiredTeamMembersQuery struct {
+	Now    time.Time
+	Result []*TeamMember
+}
+
 // ----------------------
 // Projections and DTOs
 
@@ -76,4 +100,5 @@ type TeamMemberDTO struct {
 	AvatarUrl  string         `json:"avatarUrl"`
 	Labels     []string       `json:"labels"`
 	Permission PermissionType `json:"permission"`
+	ExpiresAt  *time.Time     `json:"expiresAt,omitempty"`
 }
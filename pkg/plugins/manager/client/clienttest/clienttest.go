@@ -21,6 +21,10 @@ type TestClient struct {
 	QueryDataFunc    backend.QueryDataHandlerFunc
 	CallResourceFunc backend.CallResourceHandlerFunc
 	CheckHealthFunc  backend.CheckHealthHandlerFunc
+
+	SubscribeStreamFunc func(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error)
+	PublishStreamFunc   func(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error)
+	RunStreamFunc       func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error
 }
 
 func (c *TestClient) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
@@ -47,6 +51,30 @@ func (c *TestClient) CheckHealth(ctx context.Context, req *backend.CheckHealthRe
 	return nil, nil
 }
 
+func (c *TestClient) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if c.SubscribeStreamFunc != nil {
+		return c.SubscribeStreamFunc(ctx, req)
+	}
+
+	return nil, nil
+}
+
+func (c *TestClient) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	if c.PublishStreamFunc != nil {
+		return c.PublishStreamFunc(ctx, req)
+	}
+
+	return nil, nil
+}
+
+func (c *TestClient) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	if c.RunStreamFunc != nil {
+		return c.RunStreamFunc(ctx, req, sender)
+	}
+
+	return nil
+}
+
 type MiddlewareScenarioContext struct {
 	QueryDataCallChain       []string
 	CallResourceCallChain    []string
@@ -137,6 +165,13 @@ type ClientDecoratorTest struct {
 	CallResourceCtx context.Context
 	CheckHealthReq  *backend.CheckHealthRequest
 	CheckHealthCtx  context.Context
+
+	SubscribeStreamReq *backend.SubscribeStreamRequest
+	SubscribeStreamCtx context.Context
+	PublishStreamReq   *backend.PublishStreamRequest
+	PublishStreamCtx   context.Context
+	RunStreamReq       *backend.RunStreamRequest
+	RunStreamCtx       context.Context
 }
 
 type ClientDecoratorTestOption func(*ClientDecoratorTest)
@@ -162,6 +197,21 @@ func NewClientDecoratorTest(t *testing.T, opts ...ClientDecoratorTestOption) *Cl
 			cdt.CheckHealthCtx = ctx
 			return nil, nil
 		},
+		SubscribeStreamFunc: func(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+			cdt.SubscribeStreamReq = req
+			cdt.SubscribeStreamCtx = ctx
+			return nil, nil
+		},
+		PublishStreamFunc: func(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+			cdt.PublishStreamReq = req
+			cdt.PublishStreamCtx = ctx
+			return nil, nil
+		},
+		RunStreamFunc: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+			cdt.RunStreamReq = req
+			cdt.RunStreamCtx = ctx
+			return nil
+		},
 	}
 	require.NotNil(t, cdt)
 
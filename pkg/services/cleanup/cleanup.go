@@ -17,12 +17,14 @@ import (
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 	"github.com/grafana/grafana/pkg/services/ngalert/image"
 	"github.com/grafana/grafana/pkg/services/queryhistory"
 	"github.com/grafana/grafana/pkg/services/shorturls"
+	"github.com/grafana/grafana/pkg/services/team"
 	tempuser "github.com/grafana/grafana/pkg/services/temp_user"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -30,7 +32,8 @@ import (
 func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockService,
 	shortURLService shorturls.Service, sqlstore db.DB, queryHistoryService queryhistory.Service,
 	dashboardVersionService dashver.Service, dashSnapSvc dashboardsnapshots.Service, deleteExpiredImageService *image.DeleteExpiredService,
-	tempUserService tempuser.Service, tracer tracing.Tracer, annotationCleaner annotations.Cleaner) *CleanUpService {
+	tempUserService tempuser.Service, tracer tracing.Tracer, annotationCleaner annotations.Cleaner,
+	teamService team.Service, teamPermissionsService accesscontrol.TeamPermissionsService) *CleanUpService {
 	s := &CleanUpService{
 		Cfg:                       cfg,
 		ServerLockService:         serverLockService,
@@ -44,6 +47,8 @@ func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockSe
 		tempUserService:           tempUserService,
 		tracer:                    tracer,
 		annotationCleaner:         annotationCleaner,
+		teamService:               teamService,
+		teamPermissionsService:    teamPermissionsService,
 	}
 	return s
 }
@@ -61,8 +66,12 @@ type CleanUpService struct {
 	deleteExpiredImageService *image.DeleteExpiredService
 	tempUserService           tempuser.Service
 	annotationCleaner         annotations.Cleaner
+	teamService               team.Service
+	teamPermissionsService    accesscontrol.TeamPermissionsService
 }
 
+var timeNow = time.Now
+
 type cleanUpJob struct {
 	name string
 	fn   func(context.Context)
@@ -103,6 +112,7 @@ func (srv *CleanUpService) clean(ctx context.Context) {
 		{"expire old user invites", srv.expireOldUserInvites},
 		{"delete stale short URLs", srv.deleteStaleShortURLs},
 		{"delete stale query history", srv.deleteStaleQueryHistory},
+		{"remove expired team members", srv.removeExpiredTeamMembers},
 	}
 
 	logger := srv.log.FromContext(ctx)
@@ -280,3 +290,25 @@ func (srv *CleanUpService) deleteStaleQueryHistory(ctx context.Context) {
 		logger.Debug("Enforced row limit for query_history_star", "rows affected", rowsCount)
 	}
 }
+
+func (srv *CleanUpService) removeExpiredTeamMembers(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	query := models.GetExpiredTeamMembersQuery{Now: timeNow()}
+	if err := srv.teamService.GetExpiredTeamMembers(ctx, &query); err != nil {
+		logger.Error("Problem getting expired team members", "error", err.Error())
+		return
+	}
+
+	removed := 0
+	for _, member := range query.Result {
+		// Removing the membership through the team permissions service also removes the
+		// permissions the user was granted as a member of the team.
+		_, err := srv.teamPermissionsService.SetUserPermission(ctx, member.OrgId, accesscontrol.User{ID: member.UserId}, strconv.FormatInt(member.TeamId, 10), "")
+		if err != nil {
+			logger.Error("Problem removing expired team member", "teamId", member.TeamId, "userId", member.UserId, "error", err.Error())
+			continue
+		}
+		removed++
+	}
+	logger.Debug("Removed expired team members", "rows affected", removed)
+}
@@ -1,11 +1,21 @@
 package cleanup
 
 import (
+	"context"
+	"strconv"
 	"testing"
 	"time"
 
-	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/team/teamimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func TestCleanUpTmpFiles(t *testing.T) {
@@ -35,3 +45,68 @@ func TestCleanUpTmpFiles(t *testing.T) {
 		require.False(t, service.shouldCleanupTempFile(weekAgo, now))
 	})
 }
+
+// fakeTeamPermissionsService removes team members the way the team permissions service does
+// when the permission of a member is set to "".
+type fakeTeamPermissionsService struct {
+	teamService team.Service
+}
+
+func (s *fakeTeamPermissionsService) GetPermissions(ctx context.Context, user *user.SignedInUser, resourceID string) ([]accesscontrol.ResourcePermission, error) {
+	return nil, nil
+}
+
+func (s *fakeTeamPermissionsService) SetUserPermission(ctx context.Context, orgID int64, user accesscontrol.User, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
+	teamID, err := strconv.ParseInt(resourceID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return nil, s.teamService.RemoveTeamMember(ctx, &models.RemoveTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: user.ID})
+}
+
+func TestIntegrationRemoveExpiredTeamMembers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	const orgID int64 = 1
+	store := db.InitTestDB(t)
+	teamSvc := teamimpl.ProvideService(store, store.Cfg)
+	srv := &CleanUpService{
+		log:                    log.New("cleanup"),
+		teamService:            teamSvc,
+		teamPermissionsService: &fakeTeamPermissionsService{teamService: teamSvc},
+	}
+
+	tm, err := teamSvc.CreateTeam("team", "", orgID)
+	require.NoError(t, err)
+	const contractorID, employeeID int64 = 1, 2
+	require.NoError(t, teamSvc.AddTeamMember(contractorID, orgID, tm.Id, false, 0))
+	require.NoError(t, teamSvc.AddTeamMember(employeeID, orgID, tm.Id, false, 0))
+
+	now := time.Now()
+	expiresAt := now.Add(time.Hour).Truncate(time.Second)
+	err = teamSvc.SetTeamMemberExpiry(context.Background(), &models.SetTeamMemberExpiryCommand{
+		OrgId:     orgID,
+		TeamId:    tm.Id,
+		UserId:    contractorID,
+		ExpiresAt: &expiresAt,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { timeNow = time.Now })
+
+	timeNow = func() time.Time { return now }
+	srv.removeExpiredTeamMembers(context.Background())
+	isMember, err := teamSvc.IsTeamMember(orgID, tm.Id, contractorID)
+	require.NoError(t, err)
+	require.True(t, isMember, "member should be kept until the membership expires")
+
+	timeNow = func() time.Time { return expiresAt.Add(time.Minute) }
+	srv.removeExpiredTeamMembers(context.Background())
+	isMember, err = teamSvc.IsTeamMember(orgID, tm.Id, contractorID)
+	require.NoError(t, err)
+	require.False(t, isMember, "member should be removed once the membership expired")
+	isMember, err = teamSvc.IsTeamMember(orgID, tm.Id, employeeID)
+	require.NoError(t, err)
+	require.True(t, isMember, "members without expiry should be kept")
+}
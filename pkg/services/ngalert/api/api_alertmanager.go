@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -31,6 +32,8 @@ type AlertmanagerSrv struct {
 	ac     accesscontrol.AccessControl
 	mam    *notifier.MultiOrgAlertmanager
 	crypto notifier.Crypto
+	// silenceLinkKey verifies signed silence links, see channels.VerifySilenceLink.
+	silenceLinkKey []byte
 }
 
 type UnknownReceiverError struct {
@@ -74,6 +77,10 @@ func (srv AlertmanagerSrv) RouteCreateSilence(c *models.ReqContext, postableSile
 		return ErrResp(http.StatusUnauthorized, fmt.Errorf("user is not authorized to %s silences", errAction), "")
 	}
 
+	if err := srv.verifySilenceLink(c, postableSilence); err != nil {
+		return ErrResp(http.StatusForbidden, err, "")
+	}
+
 	silenceID, err := am.CreateSilence(&postableSilence)
 	if err != nil {
 		if errors.Is(err, notifier.ErrSilenceNotFound) {
@@ -91,6 +98,33 @@ func (srv AlertmanagerSrv) RouteCreateSilence(c *models.ReqContext, postableSile
 	})
 }
 
+// verifySilenceLink verifies the signature and the expiry of the silence link the silence
+// is created from, if any. Silences that are not created from a signed link are not verified.
+func (srv AlertmanagerSrv) verifySilenceLink(c *models.ReqContext, silence apimodels.PostableSilence) error {
+	expires, signature := c.Query(channels.SilenceLinkExpiresParam), c.Query(channels.SilenceLinkSignatureParam)
+	if expires == "" && signature == "" {
+		return nil
+	}
+	matchers := make([]string, 0, len(silence.Matchers))
+	for _, m := range silence.Matchers {
+		if m == nil || m.Name == nil || m.Value == nil {
+			return channels.ErrSilenceLinkInvalid
+		}
+		isEqual, isRegex := m.IsEqual == nil || *m.IsEqual, m.IsRegex != nil && *m.IsRegex
+		op := "="
+		switch {
+		case isEqual && isRegex:
+			op = "=~"
+		case !isEqual && isRegex:
+			op = "!~"
+		case !isEqual:
+			op = "!="
+		}
+		matchers = append(matchers, *m.Name+op+*m.Value)
+	}
+	return channels.VerifySilenceLink(srv.silenceLinkKey, matchers, expires, signature, time.Now())
+}
+
 func (srv AlertmanagerSrv) RouteDeleteAlertingConfig(c *models.ReqContext) response.Response {
 	am, errResp := srv.AlertmanagerFor(c.OrgID)
 	if errResp != nil {
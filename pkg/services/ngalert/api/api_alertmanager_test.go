@@ -2,9 +2,14 @@ package api
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
 	"testing"
 	"time"
 
@@ -22,6 +27,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/secrets/fakes"
@@ -482,6 +488,82 @@ func TestRouteCreateSilence(t *testing.T) {
 	}
 }
 
+func TestRouteCreateSilenceFromSignedLink(t *testing.T) {
+	sut := createSut(t, nil)
+	sut.silenceLinkKey = []byte("secret")
+
+	sign := func(key []byte, expiresAt time.Time) url.Values {
+		t.Helper()
+		u, err := url.Parse("http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1")
+		require.NoError(t, err)
+		q := u.Query()
+		expires := strconv.FormatInt(expiresAt.Unix(), 10)
+		mac := hmac.New(sha256.New, key)
+		_, _ = mac.Write([]byte(`{"matchers":["alertname=alert1"],"expires":"` + expires + `"}`))
+		q.Set(channels.SilenceLinkExpiresParam, expires)
+		q.Set(channels.SilenceLinkSignatureParam, hex.EncodeToString(mac.Sum(nil)))
+		return q
+	}
+
+	testCases := []struct {
+		name           string
+		query          url.Values
+		matcher        string
+		expectedStatus int
+	}{
+		{
+			name:           "unsigned silence",
+			matcher:        "alert1",
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "valid signed link",
+			query:          sign(sut.silenceLinkKey, time.Now().Add(time.Hour)),
+			matcher:        "alert1",
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "expired signed link",
+			query:          sign(sut.silenceLinkKey, time.Now().Add(-time.Hour)),
+			matcher:        "alert1",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "link signed with another key",
+			query:          sign([]byte("other"), time.Now().Add(time.Hour)),
+			matcher:        "alert1",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "matchers changed after signing",
+			query:          sign(sut.silenceLinkKey, time.Now().Add(time.Hour)),
+			matcher:        "alert2",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := models.ReqContext{
+				Context: &web.Context{
+					Req: &http.Request{URL: &url.URL{RawQuery: tc.query.Encode()}},
+				},
+				SignedInUser: &user.SignedInUser{
+					OrgRole: org.RoleEditor,
+					OrgID:   1,
+				},
+			}
+
+			silence := silenceGen(withEmptyID)()
+			name, value, isEqual, isRegex := "alertname", tc.matcher, true, false
+			silence.Matchers = amv2.Matchers{&amv2.Matcher{Name: &name, Value: &value, IsEqual: &isEqual, IsRegex: &isRegex}}
+
+			response := sut.RouteCreateSilence(&rc, silence)
+			require.Equal(t, tc.expectedStatus, response.Status())
+		})
+	}
+}
+
 func createSut(t *testing.T, accessControl accesscontrol.AccessControl) AlertmanagerSrv {
 	t.Helper()
 
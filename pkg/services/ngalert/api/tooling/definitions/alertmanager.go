@@ -892,6 +892,9 @@ func (c *PostableApiAlertingConfig) validate() error {
 	var hasGrafReceivers, hasAMReceivers bool
 	for _, r := range c.Receivers {
 		receivers[r.Name] = struct{}{}
+		if r.MaxConcurrentNotifications < 0 {
+			return fmt.Errorf("invalid max_concurrent_notifications %d of receiver %s, must not be negative", r.MaxConcurrentNotifications, r.Name)
+		}
 		switch r.Type() {
 		case GrafanaReceiverType:
 			hasGrafReceivers = true
@@ -1170,10 +1173,26 @@ func (r *PostableApiReceiver) Type() ReceiverType {
 
 type GettableGrafanaReceivers struct {
 	GrafanaManagedReceivers []*GettableGrafanaReceiver `yaml:"grafana_managed_receiver_configs,omitempty" json:"grafana_managed_receiver_configs,omitempty"`
+
+	// FallbackChain tries the receivers in order, stopping at the first that succeeds,
+	// instead of sending the notifications to all of them.
+	FallbackChain bool `yaml:"fallback_chain,omitempty" json:"fallback_chain,omitempty"`
+
+	// MaxConcurrentNotifications is the maximum number of notifications that the receivers
+	// send at the same time. Notifications are not limited if it is 0.
+	MaxConcurrentNotifications int `yaml:"max_concurrent_notifications,omitempty" json:"max_concurrent_notifications,omitempty"`
 }
 
 type PostableGrafanaReceivers struct {
 	GrafanaManagedReceivers []*PostableGrafanaReceiver `yaml:"grafana_managed_receiver_configs,omitempty" json:"grafana_managed_receiver_configs,omitempty"`
+
+	// FallbackChain tries the receivers in order, stopping at the first that succeeds,
+	// instead of sending the notifications to all of them.
+	FallbackChain bool `yaml:"fallback_chain,omitempty" json:"fallback_chain,omitempty"`
+
+	// MaxConcurrentNotifications is the maximum number of notifications that the receivers
+	// send at the same time. Notifications are not limited if it is 0.
+	MaxConcurrentNotifications int `yaml:"max_concurrent_notifications,omitempty" json:"max_concurrent_notifications,omitempty"`
 }
 
 type EncryptFn func(ctx context.Context, payload []byte, scope secrets.EncryptionOptions) ([]byte, error)
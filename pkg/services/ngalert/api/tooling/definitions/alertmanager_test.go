@@ -340,6 +340,28 @@ func Test_ApiAlertingConfig_Marshaling(t *testing.T) {
 			},
 			err: true,
 		},
+		{
+			desc: "failure negative max concurrent notifications",
+			input: PostableApiAlertingConfig{
+				Config: Config{
+					Route: &Route{
+						Receiver: "graf",
+					},
+				},
+				Receivers: []*PostableApiReceiver{
+					{
+						Receiver: config.Receiver{
+							Name: "graf",
+						},
+						PostableGrafanaReceivers: PostableGrafanaReceivers{
+							GrafanaManagedReceivers:    []*PostableGrafanaReceiver{{}},
+							MaxConcurrentNotifications: -1,
+						},
+					},
+				},
+			},
+			err: true,
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			encoded, err := json.Marshal(tc.input)
@@ -73,6 +73,12 @@ type API struct {
 type Alertmanager struct {
 	Registerer prometheus.Registerer
 	*metrics.Alerts
+	TeamBudgetDropped *prometheus.CounterVec
+	// NotificationAttempts, NotificationRetries and NotificationOutcomes count the attempts to
+	// send notifications, by the type of their integration.
+	NotificationAttempts *prometheus.CounterVec
+	NotificationRetries  *prometheus.CounterVec
+	NotificationOutcomes *prometheus.CounterVec
 }
 
 type State struct {
@@ -112,6 +118,30 @@ func NewAlertmanagerMetrics(r prometheus.Registerer) *Alertmanager {
 	return &Alertmanager{
 		Registerer: r,
 		Alerts:     metrics.NewAlerts("grafana", prometheus.WrapRegistererWithPrefix(fmt.Sprintf("%s_%s_", Namespace, Subsystem), r)),
+		TeamBudgetDropped: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "notifications_team_budget_dropped_total",
+			Help:      "The total number of notifications of a contact point whose alerts were deferred because the team exceeded its notification budget.",
+		}, []string{"team"}),
+		NotificationAttempts: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "notification_attempts_total",
+			Help:      "The total number of attempts to send notifications, including retries.",
+		}, []string{"integration"}),
+		NotificationRetries: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "notification_retries_total",
+			Help:      "The total number of failed attempts to send notifications that were retried.",
+		}, []string{"integration"}),
+		NotificationOutcomes: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "notification_outcomes_total",
+			Help:      "The total number of notifications that were sent or failed without being retried, by outcome.",
+		}, []string{"integration", "outcome"}),
 	}
 }
 
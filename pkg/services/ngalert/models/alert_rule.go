@@ -114,6 +114,9 @@ const (
 
 	ValuesAnnotation      = "__values__"
 	ValueStringAnnotation = "__value_string__"
+
+	// StateTransitionsAnnotation contains the JSON encoded AlertStateTransitions of an alert.
+	StateTransitionsAnnotation = "__state_transitions__"
 )
 
 const (
@@ -48,6 +48,14 @@ func (i InstanceStateType) IsValid() bool {
 		i == InstanceStateError
 }
 
+// AlertStateTransition is a change of the state of an alert instance. States are formatted
+// with their reason, e.g. "Alerting (NoData)".
+type AlertStateTransition struct {
+	PreviousState string    `json:"previousState"`
+	State         string    `json:"state"`
+	At            time.Time `json:"at"`
+}
+
 // ListAlertInstancesQuery is the query list alert Instances.
 type ListAlertInstancesQuery struct {
 	RuleOrgID   int64 `json:"-"`
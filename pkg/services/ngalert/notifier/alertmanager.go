@@ -56,6 +56,8 @@ const (
 	defaultResolveTimeout = 5 * time.Minute
 	// memoryAlertsGCInterval is the interval at which we'll remove resolved alerts from memory.
 	memoryAlertsGCInterval = 30 * time.Minute
+	// fallbackChainIntegrationType is the integration of receivers with a fallback chain.
+	fallbackChainIntegrationType = "fallback_chain"
 )
 
 // How long should we keep silences and notification entries on-disk after they've served their purpose.
@@ -119,6 +121,12 @@ type Alertmanager struct {
 	silences *silence.Silences
 
 	receivers []*notify.Receiver
+	// notifiers are the notifiers of the receivers of the current configuration. They are
+	// stopped when the configuration is replaced, so their timers don't keep sending.
+	notifiers []channels.NotificationChannel
+	// maxDeferral is the longest deferral of the notifiers, the notifications of all groups
+	// are given that much more time so that deferred notifications don't time out.
+	maxDeferral time.Duration
 
 	// muteTimes is a map where the key is the name of the mute_time_interval
 	// and the value represents all configured time_interval(s)
@@ -127,6 +135,13 @@ type Alertmanager struct {
 	stageMetrics      *notify.Metrics
 	dispatcherMetrics *dispatch.DispatcherMetrics
 
+	// teamBudget is nil when the team notification budget is disabled.
+	teamBudget *teamBudget
+	// retryBudget is shared by the Alertmanagers of all organizations. It is nil when retries are disabled.
+	retryBudget *channels.RetryBudget
+	// imageLimiter is shared by the Alertmanagers of all organizations. It is nil when image requests are not limited.
+	imageLimiter *channels.ImageLimiter
+
 	reloadConfigMtx sync.RWMutex
 	config          *apimodels.PostableUserConfig
 	configHash      [16]byte
@@ -136,7 +151,7 @@ type Alertmanager struct {
 }
 
 func newAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store AlertingStore, kvStore kvstore.KVStore,
-	peer ClusterPeer, decryptFn channels.GetDecryptedValueFn, ns notifications.Service, m *metrics.Alertmanager) (*Alertmanager, error) {
+	peer ClusterPeer, decryptFn channels.GetDecryptedValueFn, ns notifications.Service, m *metrics.Alertmanager, retryBudget *channels.RetryBudget, imageLimiter *channels.ImageLimiter) (*Alertmanager, error) {
 	am := &Alertmanager{
 		Settings:            cfg,
 		stopc:               make(chan struct{}),
@@ -151,6 +166,12 @@ func newAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store A
 		NotificationService: ns,
 		orgID:               orgID,
 		decryptFn:           decryptFn,
+		retryBudget:         retryBudget,
+		imageLimiter:        imageLimiter,
+	}
+
+	if limit := cfg.UnifiedAlerting.TeamNotificationBudget.HourlyLimit; limit > 0 {
+		am.teamBudget = newTeamBudget(limit)
 	}
 
 	am.fileStore = NewFileStore(am.orgID, kvStore, am.WorkingDirPath())
@@ -234,6 +255,7 @@ func (am *Alertmanager) StopAndWait() {
 	if am.dispatcher != nil {
 		am.dispatcher.Stop()
 	}
+	stopNotifiers(am.notifiers)
 
 	if am.inhibitor != nil {
 		am.inhibitor.Stop()
@@ -400,7 +422,7 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	}
 
 	// Finally, build the integrations map using the receiver configuration and templates.
-	integrationsMap, err := am.buildIntegrationsMap(cfg.AlertmanagerConfig.Receivers, tmpl)
+	integrationsMap, notifiers, err := am.buildIntegrationsMap(cfg.AlertmanagerConfig.Receivers, tmpl)
 	if err != nil {
 		return fmt.Errorf("failed to build integration map: %w", err)
 	}
@@ -414,6 +436,9 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	if am.dispatcher != nil {
 		am.dispatcher.Stop()
 	}
+	stopNotifiers(am.notifiers)
+	am.notifiers = notifiers
+	am.maxDeferral = maxDeferral(notifiers)
 
 	am.inhibitor = inhibit.NewInhibitor(am.alerts, cfg.AlertmanagerConfig.InhibitRules, am.marker, am.logger)
 	am.muteTimes = am.buildMuteTimesMap(cfg.AlertmanagerConfig.MuteTimeIntervals)
@@ -462,30 +487,81 @@ func (am *Alertmanager) WorkingDirPath() string {
 }
 
 // buildIntegrationsMap builds a map of name to the list of Grafana integration notifiers off of a list of receiver config.
-func (am *Alertmanager) buildIntegrationsMap(receivers []*apimodels.PostableApiReceiver, templates *template.Template) (map[string][]*notify.Integration, error) {
+// It also returns the notifiers of the integrations, to stop them once they are replaced.
+func (am *Alertmanager) buildIntegrationsMap(receivers []*apimodels.PostableApiReceiver, templates *template.Template) (map[string][]*notify.Integration, []channels.NotificationChannel, error) {
 	integrationsMap := make(map[string][]*notify.Integration, len(receivers))
+	var notifiers []channels.NotificationChannel
 	for _, receiver := range receivers {
-		integrations, err := am.buildReceiverIntegrations(receiver, templates)
+		integrations, receiverNotifiers, err := am.buildReceiverIntegrations(receiver, templates)
 		if err != nil {
-			return nil, err
+			stopNotifiers(notifiers)
+			return nil, nil, err
 		}
 		integrationsMap[receiver.Name] = integrations
+		notifiers = append(notifiers, receiverNotifiers...)
 	}
 
-	return integrationsMap, nil
+	return integrationsMap, notifiers, nil
 }
 
-// buildReceiverIntegrations builds a list of integration notifiers off of a receiver config.
-func (am *Alertmanager) buildReceiverIntegrations(receiver *apimodels.PostableApiReceiver, tmpl *template.Template) ([]*notify.Integration, error) {
+// buildReceiverIntegrations builds a list of integration notifiers off of a receiver config,
+// and returns the notifiers of the integrations. The integrations share the concurrency
+// limit of the receiver.
+func (am *Alertmanager) buildReceiverIntegrations(receiver *apimodels.PostableApiReceiver, tmpl *template.Template) ([]*notify.Integration, []channels.NotificationChannel, error) {
+	limit := channels.NewConcurrencyLimit(receiver.MaxConcurrentNotifications)
+	if receiver.FallbackChain {
+		return am.buildFallbackChainIntegration(receiver, tmpl, limit)
+	}
 	var integrations []*notify.Integration
+	var notifiers []channels.NotificationChannel
 	for i, r := range receiver.GrafanaManagedReceivers {
 		n, err := am.buildReceiverIntegration(r, tmpl)
 		if err != nil {
-			return nil, err
+			stopNotifiers(notifiers)
+			return nil, nil, err
 		}
+		n = channels.LimitConcurrency(n, limit)
 		integrations = append(integrations, notify.NewIntegration(n, n, r.Type, i))
+		notifiers = append(notifiers, n)
+	}
+	return integrations, notifiers, nil
+}
+
+// buildFallbackChainIntegration builds a single integration that tries the notifiers of the
+// receiver in order, until one of them succeeds.
+func (am *Alertmanager) buildFallbackChainIntegration(receiver *apimodels.PostableApiReceiver, tmpl *template.Template, limit *channels.ConcurrencyLimit) ([]*notify.Integration, []channels.NotificationChannel, error) {
+	notifiers := make([]channels.FallbackChainNotifier, 0, len(receiver.GrafanaManagedReceivers))
+	for _, r := range receiver.GrafanaManagedReceivers {
+		n, err := am.buildReceiverIntegration(r, tmpl)
+		if err != nil {
+			for _, built := range notifiers {
+				built.Notifier.Stop()
+			}
+			return nil, nil, err
+		}
+		notifiers = append(notifiers, channels.FallbackChainNotifier{UID: r.UID, Name: r.Name, Type: r.Type, Notifier: n})
+	}
+	cfg := &channels.NotificationChannelConfig{OrgID: am.orgID, Name: receiver.Name, Type: fallbackChainIntegrationType}
+	n := channels.LimitConcurrency(channels.NewFallbackChain(cfg, notifiers, LoggerFactory("ngalert.notifier.fallback_chain", "receiver", receiver.Name)), limit)
+	return []*notify.Integration{notify.NewIntegration(n, n, fallbackChainIntegrationType, 0)}, []channels.NotificationChannel{n}, nil
+}
+
+// maxDeferral returns the longest time any of the notifiers defers notifications.
+func maxDeferral(notifiers []channels.NotificationChannel) time.Duration {
+	var max time.Duration
+	for _, n := range notifiers {
+		if d := n.MaxDeferral(); d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// stopNotifiers stops the notifiers of a configuration that is replaced.
+func stopNotifiers(notifiers []channels.NotificationChannel) {
+	for _, n := range notifiers {
+		n.Stop()
 	}
-	return integrations, nil
 }
 
 func (am *Alertmanager) buildReceiverIntegration(r *apimodels.PostableGrafanaReceiver, tmpl *template.Template) (channels.NotificationChannel, error) {
@@ -521,6 +597,27 @@ func (am *Alertmanager) buildReceiverIntegration(r *apimodels.PostableGrafanaRec
 			Err:      err,
 		}
 	}
+	factoryConfig.RetryBudget = am.retryBudget
+	factoryConfig.ImageStore = channels.LimitImageStore(factoryConfig.ImageStore, am.imageLimiter)
+	factoryConfig.SilenceLinkKey = channels.SilenceLinkKey(am.Settings.SecretKey)
+	factoryConfig.EmailOptOut = am.Settings.UnifiedAlerting.EmailOptOut
+	factoryConfig.SeverityColors = channels.NewSeverityColors(am.Settings.UnifiedAlerting.SeverityColors)
+	factoryConfig.Runbooks = channels.RunbookSettings{
+		AllowedHosts: am.Settings.UnifiedAlerting.RunbookAllowedHosts,
+		MaxSize:      am.Settings.UnifiedAlerting.RunbookMaxSize,
+		Timeout:      am.Settings.UnifiedAlerting.RunbookTimeout,
+	}
+	if s, ok := am.Store.(channels.TeamMuteTimingsProvider); ok {
+		factoryConfig.TeamMuteTimings = s
+	}
+	if s, ok := am.Store.(channels.TemplateStore); ok {
+		factoryConfig.Templates = s
+	}
+	factoryConfig.SendMetrics = &channels.SendMetrics{
+		Attempts: am.Metrics.NotificationAttempts,
+		Retries:  am.Metrics.NotificationRetries,
+		Outcomes: am.Metrics.NotificationOutcomes,
+	}
 	receiverFactory, exists := channels.Factory(r.Type)
 	if !exists {
 		return nil, InvalidReceiverError{
@@ -688,11 +785,18 @@ func (am *Alertmanager) createReceiverStage(name string, integrations []*notify.
 		var s notify.MultiStage
 		s = append(s, notify.NewWaitStage(wait))
 		s = append(s, notify.NewDedupStage(integration, notificationLog, recv))
+		if am.teamBudget != nil {
+			s = append(s, newTeamBudgetStage(am.Settings.UnifiedAlerting.TeamNotificationBudget.TeamLabel))
+		}
 		s = append(s, notify.NewRetryStage(integration, name, am.stageMetrics))
 		s = append(s, notify.NewSetNotifiesStage(notificationLog, recv))
 
 		fs = append(fs, s)
 	}
+	if am.teamBudget != nil {
+		// The budget of a team is taken once per flush of the receiver, not per integration.
+		return notify.MultiStage{newTeamBudgetFlushStage(am.teamBudget, am.Metrics.TeamBudgetDropped), fs}
+	}
 	return fs
 }
 
@@ -717,7 +821,8 @@ func (am *Alertmanager) timeoutFunc(d time.Duration) time.Duration {
 	if d < notify.MinTimeout {
 		d = notify.MinTimeout
 	}
-	return d + am.waitFunc()
+	// Notifications of deferred notifiers wait for the deferred send.
+	return d + am.waitFunc() + am.maxDeferral
 }
 
 type nilLimits struct{}
@@ -74,7 +74,9 @@ func (moa *MultiOrgAlertmanager) GetAlertmanagerConfiguration(ctx context.Contex
 		}
 		gettableApiReceiver := definitions.GettableApiReceiver{
 			GettableGrafanaReceivers: definitions.GettableGrafanaReceivers{
-				GrafanaManagedReceivers: receivers,
+				GrafanaManagedReceivers:    receivers,
+				FallbackChain:              recv.FallbackChain,
+				MaxConcurrentNotifications: recv.MaxConcurrentNotifications,
 			},
 		}
 		gettableApiReceiver.Name = recv.Name
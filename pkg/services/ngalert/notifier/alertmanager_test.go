@@ -22,6 +22,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
 	"github.com/grafana/grafana/pkg/setting"
@@ -48,7 +49,7 @@ func setupAMTest(t *testing.T) *Alertmanager {
 	kvStore := NewFakeKVStore(t)
 	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
 	decryptFn := secretsService.GetDecryptedValue
-	am, err := newAlertmanager(context.Background(), 1, cfg, s, kvStore, &NilPeer{}, decryptFn, nil, m)
+	am, err := newAlertmanager(context.Background(), 1, cfg, s, kvStore, &NilPeer{}, decryptFn, nil, m, nil, nil)
 	require.NoError(t, err)
 	return am
 }
@@ -416,3 +417,77 @@ func TestSilenceCleanup(t *testing.T) {
 		return len(found) == 2
 	}, 6*time.Second, 100*time.Millisecond)
 }
+
+func TestBuildReceiverIntegrationsFallbackChain(t *testing.T) {
+	am := setupAMTest(t)
+	tmpl, err := am.templateFromPaths()
+	require.NoError(t, err)
+
+	receiver := &apimodels.PostableApiReceiver{
+		PostableGrafanaReceivers: apimodels.PostableGrafanaReceivers{
+			GrafanaManagedReceivers: []*apimodels.PostableGrafanaReceiver{
+				{UID: "webhook-uid", Name: "ops", Type: "webhook", Settings: apimodels.RawMessage(`{"url": "http://localhost/test"}`)},
+				{UID: "email-uid", Name: "ops", Type: "email", Settings: apimodels.RawMessage(`{"addresses": "someops@example.com"}`)},
+			},
+		},
+	}
+	receiver.Name = "ops"
+
+	integrations, _, err := am.buildReceiverIntegrations(receiver, tmpl)
+	require.NoError(t, err)
+	require.Len(t, integrations, 2)
+
+	receiver.FallbackChain = true
+	integrations, _, err = am.buildReceiverIntegrations(receiver, tmpl)
+	require.NoError(t, err)
+	require.Len(t, integrations, 1)
+	require.Equal(t, fallbackChainIntegrationType, integrations[0].Name())
+}
+
+// stopRecorder records whether the notifier was stopped.
+type stopRecorder struct {
+	channels.NotificationChannel
+	stopped bool
+}
+
+func (n *stopRecorder) Stop() {
+	n.stopped = true
+}
+
+func TestApplyConfigStopsReplacedNotifiers(t *testing.T) {
+	am := setupAMTest(t)
+
+	config := func(url string) *apimodels.PostableUserConfig {
+		t.Helper()
+		cfg, err := Load([]byte(`{"alertmanager_config": {"route": {"receiver": "ops"}, "receivers": [{"name": "ops", "grafana_managed_receiver_configs": [
+			{"uid": "webhook-uid", "name": "ops", "type": "webhook", "settings": {"url": "` + url + `", "reminderInterval": "1h"}}
+		]}]}}`))
+		require.NoError(t, err)
+		return cfg
+	}
+
+	require.NoError(t, am.applyConfig(config("http://localhost/a"), nil))
+	require.Len(t, am.notifiers, 1)
+	old := &stopRecorder{NotificationChannel: am.notifiers[0]}
+	am.notifiers[0] = old
+
+	require.NoError(t, am.applyConfig(config("http://localhost/b"), nil))
+	require.True(t, old.stopped)
+	require.Len(t, am.notifiers, 1)
+	require.NotSame(t, old, am.notifiers[0])
+}
+
+func TestApplyConfigExtendsTimeoutByDeferral(t *testing.T) {
+	am := setupAMTest(t)
+
+	cfg, err := Load([]byte(`{"alertmanager_config": {"route": {"receiver": "ops"}, "receivers": [{"name": "ops", "grafana_managed_receiver_configs": [
+		{"uid": "webhook-uid", "name": "ops", "type": "webhook", "settings": {"url": "http://localhost/a", "delay": "10m"}},
+		{"uid": "email-uid", "name": "ops", "type": "email", "settings": {"addresses": "ops@example.com"}}
+	]}]}}`))
+	require.NoError(t, err)
+	require.NoError(t, am.applyConfig(cfg, nil))
+
+	// Notifications wait for the deferred send, so they are given the delay on top of the group interval.
+	require.Equal(t, 10*time.Minute, am.maxDeferral)
+	require.Equal(t, 15*time.Minute+am.waitFunc(), am.timeoutFunc(5*time.Minute))
+}
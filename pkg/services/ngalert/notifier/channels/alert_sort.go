@@ -0,0 +1,84 @@
+package channels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// AlertSortByStartsAt sorts alerts by the time they started firing.
+	AlertSortByStartsAt = "startsAt"
+	// AlertSortBySeverity sorts alerts by their severity label, from the least severe in
+	// ascending order. Alerts without a known severity are the least severe.
+	AlertSortBySeverity = "severity"
+	// AlertSortByAlertname sorts alerts by their alertname label.
+	AlertSortByAlertname = "alertname"
+)
+
+const (
+	AlertSortAscending  = "asc"
+	AlertSortDescending = "desc"
+)
+
+// AlertSort is the order alerts are rendered in. Alerts are kept in the order they are
+// received in if By is empty. Alerts that are equal keep their order in either direction.
+type AlertSort struct {
+	By         string
+	Descending bool
+}
+
+// parseAlertSort parses the sortBy and sortOrder settings. The order is ascending if it is
+// not set.
+func parseAlertSort(by, order string) (AlertSort, error) {
+	switch by {
+	case "", AlertSortByStartsAt, AlertSortBySeverity, AlertSortByAlertname:
+	default:
+		return AlertSort{}, fmt.Errorf("invalid sort by %q, must be %q, %q or %q", by, AlertSortByStartsAt, AlertSortBySeverity, AlertSortByAlertname)
+	}
+	switch order {
+	case "", AlertSortAscending:
+		return AlertSort{By: by}, nil
+	case AlertSortDescending:
+		return AlertSort{By: by, Descending: true}, nil
+	default:
+		return AlertSort{}, fmt.Errorf("invalid sort order %q, must be %q or %q", order, AlertSortAscending, AlertSortDescending)
+	}
+}
+
+// apply returns the alerts in the sort order. The alerts are copied so that the alerts of
+// other notifiers are not reordered.
+func (s AlertSort) apply(alerts []*types.Alert) []*types.Alert {
+	if s.By == "" || len(alerts) < 2 {
+		return alerts
+	}
+	sorted := make([]*types.Alert, len(alerts))
+	copy(sorted, alerts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if s.Descending {
+			return s.less(sorted[j], sorted[i])
+		}
+		return s.less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+func (s AlertSort) less(a, b *types.Alert) bool {
+	switch s.By {
+	case AlertSortByStartsAt:
+		return a.StartsAt.Before(b.StartsAt)
+	case AlertSortBySeverity:
+		// A lower rank is more severe.
+		return alertSeverityRank(a) > alertSeverityRank(b)
+	case AlertSortByAlertname:
+		return a.Labels[model.AlertNameLabel] < b.Labels[model.AlertNameLabel]
+	}
+	return false
+}
+
+func alertSeverityRank(a *types.Alert) int {
+	return severityRankOf(strings.ToLower(string(a.Labels[SeverityLabel])))
+}
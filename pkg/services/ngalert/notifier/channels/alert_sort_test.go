@@ -0,0 +1,74 @@
+package channels
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertSort(t *testing.T) {
+	startsAt := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	newAlert := func(name, severity string, startedAgo time.Duration) *types.Alert {
+		labels := model.LabelSet{"alertname": model.LabelValue(name)}
+		if severity != "" {
+			labels[SeverityLabel] = model.LabelValue(severity)
+		}
+		return &types.Alert{Alert: model.Alert{Labels: labels, StartsAt: startsAt.Add(-startedAgo)}}
+	}
+	diskFull := newAlert("DiskFull", "warning", time.Hour)
+	highLatency := newAlert("HighLatency", "Critical", time.Minute)
+	noSeverity := newAlert("Backup", "", 10*time.Minute)
+	lowMemory := newAlert("LowMemory", "info", 30*time.Minute)
+	alerts := []*types.Alert{diskFull, highLatency, noSeverity, lowMemory}
+
+	names := func(alerts []*types.Alert) []string {
+		var names []string
+		for _, a := range alerts {
+			names = append(names, string(a.Labels[model.AlertNameLabel]))
+		}
+		return names
+	}
+
+	cases := []struct {
+		by, order string
+		expected  []string
+	}{
+		{AlertSortByStartsAt, AlertSortAscending, []string{"DiskFull", "LowMemory", "Backup", "HighLatency"}},
+		{AlertSortByStartsAt, AlertSortDescending, []string{"HighLatency", "Backup", "LowMemory", "DiskFull"}},
+		{AlertSortBySeverity, AlertSortAscending, []string{"Backup", "LowMemory", "DiskFull", "HighLatency"}},
+		{AlertSortBySeverity, AlertSortDescending, []string{"HighLatency", "DiskFull", "LowMemory", "Backup"}},
+		{AlertSortByAlertname, "", []string{"Backup", "DiskFull", "HighLatency", "LowMemory"}},
+		{AlertSortByAlertname, AlertSortDescending, []string{"LowMemory", "HighLatency", "DiskFull", "Backup"}},
+		{"", AlertSortDescending, []string{"DiskFull", "HighLatency", "Backup", "LowMemory"}},
+	}
+	for _, c := range cases {
+		t.Run(c.by+" "+c.order, func(t *testing.T) {
+			s, err := parseAlertSort(c.by, c.order)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, names(s.apply(alerts)))
+		})
+	}
+
+	t.Run("alerts are sorted in a copy", func(t *testing.T) {
+		s := AlertSort{By: AlertSortByAlertname}
+		s.apply(alerts)
+		require.Equal(t, []string{"DiskFull", "HighLatency", "Backup", "LowMemory"}, names(alerts))
+	})
+
+	t.Run("equal alerts keep their order", func(t *testing.T) {
+		critical := newAlert("Other", "critical", 0)
+		s := AlertSort{By: AlertSortBySeverity, Descending: true}
+		require.Equal(t, []string{"HighLatency", "Other"}, names(s.apply([]*types.Alert{highLatency, critical})))
+		require.Equal(t, []string{"Other", "HighLatency"}, names(s.apply([]*types.Alert{critical, highLatency})))
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := parseAlertSort("value", "")
+		require.EqualError(t, err, `invalid sort by "value", must be "startsAt", "severity" or "alertname"`)
+		_, err = parseAlertSort(AlertSortByStartsAt, "newest")
+		require.EqualError(t, err, `invalid sort order "newest", must be "asc" or "desc"`)
+	})
+}
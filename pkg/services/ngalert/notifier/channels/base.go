@@ -1,17 +1,68 @@
 package channels
 
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// ErrSizeEstimateUnsupported is returned by notifiers that cannot estimate the size of
+// their notifications.
+var ErrSizeEstimateUnsupported = errors.New("the notifier does not support estimating the size of notifications")
+
 // Base is the base implementation of a notifier. It contains the common fields across all notifier types.
 type Base struct {
 	Name                  string
 	Type                  string
 	UID                   string
 	DisableResolveMessage bool
+
+	mtx         sync.Mutex
+	lastError   error
+	lastErrorAt time.Time
 }
 
 func (n *Base) GetDisableResolveMessage() bool {
 	return n.DisableResolveMessage
 }
 
+// EstimateSize returns ErrSizeEstimateUnsupported, notifiers that can estimate the size of
+// their notifications override it.
+func (n *Base) EstimateSize(ctx context.Context, alerts ...*types.Alert) (int, error) {
+	return 0, ErrSizeEstimateUnsupported
+}
+
+// Stop does nothing, notifiers with timers override it.
+func (n *Base) Stop() {}
+
+// MaxDeferral returns 0, notifiers that defer notifications override it.
+func (n *Base) MaxDeferral() time.Duration {
+	return 0
+}
+
+// LastError returns the error of the last notification and when it failed. It returns a
+// zero time and nil if the last notification succeeded or none was sent yet.
+func (n *Base) LastError() (time.Time, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.lastErrorAt, n.lastError
+}
+
+// setLastError records the result of a notification, a nil error resets the last error.
+func (n *Base) setLastError(err error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.lastError = err
+	if err == nil {
+		n.lastErrorAt = time.Time{}
+	} else {
+		n.lastErrorAt = timeNow()
+	}
+}
+
 func NewBase(cfg *NotificationChannelConfig) *Base {
 	return &Base{
 		UID:                   cfg.UID,
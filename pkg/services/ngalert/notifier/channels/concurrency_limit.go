@@ -0,0 +1,58 @@
+package channels
+
+import (
+	"context"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// ConcurrencyLimit bounds the notifications that the notifiers sharing it send at the same
+// time, e.g. the integrations of a contact point. The notifications of different alert
+// groups are sent in parallel up to the limit, and the ones over the limit wait for a
+// notification to finish, so that a slow contact point is not flooded with requests.
+//
+// A nil ConcurrencyLimit does not limit notifications.
+type ConcurrencyLimit struct {
+	slots chan struct{}
+}
+
+// NewConcurrencyLimit returns a limit of max notifications at the same time. It returns nil,
+// i.e. no limit, if max is not positive.
+func NewConcurrencyLimit(max int) *ConcurrencyLimit {
+	if max <= 0 {
+		return nil
+	}
+	return &ConcurrencyLimit{slots: make(chan struct{}, max)}
+}
+
+// LimitConcurrency returns a notifier that sends the notifications of n within the limit.
+// It returns n if limit is nil.
+func LimitConcurrency(n NotificationChannel, limit *ConcurrencyLimit) NotificationChannel {
+	if limit == nil {
+		return n
+	}
+	return &concurrencyLimitNotifier{NotificationChannel: n, limit: limit}
+}
+
+// concurrencyLimitNotifier waits for a slot of the limit before each notification, and frees
+// it once the notification is sent or failed.
+type concurrencyLimitNotifier struct {
+	NotificationChannel
+	limit *ConcurrencyLimit
+}
+
+// Notify returns the error of the context, and asks to be retried, if the context is done
+// before a slot is free. Notifiers that defer notifications do not take a slot, as they wait
+// for the deferred send and then send the deferred notifications one at a time.
+func (n *concurrencyLimitNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	if n.MaxDeferral() > 0 {
+		return n.NotificationChannel.Notify(ctx, alerts...)
+	}
+	select {
+	case n.limit.slots <- struct{}{}:
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+	defer func() { <-n.limit.slots }()
+	return n.NotificationChannel.Notify(ctx, alerts...)
+}
@@ -0,0 +1,189 @@
+package channels
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingNotifier blocks each notification until it is released, and records how many
+// notifications were sent at the same time.
+type blockingNotifier struct {
+	*Base
+	started chan struct{}
+	release chan struct{}
+
+	// deferral is returned by MaxDeferral.
+	deferral time.Duration
+
+	mtx       sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (n *blockingNotifier) MaxDeferral() time.Duration {
+	return n.deferral
+}
+
+func newBlockingNotifier() *blockingNotifier {
+	return &blockingNotifier{
+		Base:    NewBase(&NotificationChannelConfig{Name: "slow", Type: "webhook"}),
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (n *blockingNotifier) Notify(ctx context.Context, _ ...*types.Alert) (bool, error) {
+	n.mtx.Lock()
+	n.active++
+	if n.active > n.maxActive {
+		n.maxActive = n.active
+	}
+	n.mtx.Unlock()
+	defer func() {
+		n.mtx.Lock()
+		n.active--
+		n.mtx.Unlock()
+	}()
+
+	n.started <- struct{}{}
+	<-n.release
+	return false, nil
+}
+
+func (n *blockingNotifier) SendResolved() bool {
+	return true
+}
+
+func (n *blockingNotifier) max() int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.maxActive
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	// waitStarted waits for the expected number of notifications to start, and fails if more
+	// notifications start.
+	waitStarted := func(t *testing.T, n *blockingNotifier, expected int) {
+		t.Helper()
+		for i := 0; i < expected; i++ {
+			select {
+			case <-n.started:
+			case <-time.After(time.Second):
+				t.Fatalf("expected %d notifications to start, got %d", expected, i)
+			}
+		}
+		select {
+		case <-n.started:
+			t.Fatalf("expected only %d notifications to start", expected)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	t.Run("notifications are sent in parallel up to the limit", func(t *testing.T) {
+		inner := newBlockingNotifier()
+		n := LimitConcurrency(inner, NewConcurrencyLimit(2))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := n.Notify(context.Background())
+				require.NoError(t, err)
+			}()
+		}
+
+		// Two notifications are sent at the same time, and the others wait for them.
+		waitStarted(t, inner, 2)
+		inner.release <- struct{}{}
+		waitStarted(t, inner, 1)
+		inner.release <- struct{}{}
+		waitStarted(t, inner, 1)
+		inner.release <- struct{}{}
+		inner.release <- struct{}{}
+		wg.Wait()
+
+		require.Equal(t, 2, inner.max())
+	})
+
+	t.Run("notifications over a limit of one are sent one at a time", func(t *testing.T) {
+		limit := NewConcurrencyLimit(1)
+		first, second := newBlockingNotifier(), newBlockingNotifier()
+		n1, n2 := LimitConcurrency(first, limit), LimitConcurrency(second, limit)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := n1.Notify(context.Background())
+			require.NoError(t, err)
+		}()
+		waitStarted(t, first, 1)
+		go func() {
+			defer wg.Done()
+			_, err := n2.Notify(context.Background())
+			require.NoError(t, err)
+		}()
+
+		// The notifiers share the limit, so the second one waits for the first.
+		waitStarted(t, second, 0)
+		first.release <- struct{}{}
+		waitStarted(t, second, 1)
+		second.release <- struct{}{}
+		wg.Wait()
+	})
+
+	t.Run("waiting for the limit is stopped when the context is done", func(t *testing.T) {
+		inner := newBlockingNotifier()
+		n := LimitConcurrency(inner, NewConcurrencyLimit(1))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := n.Notify(context.Background())
+			require.NoError(t, err)
+		}()
+		waitStarted(t, inner, 1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		retry, err := n.Notify(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.True(t, retry)
+
+		inner.release <- struct{}{}
+		<-done
+	})
+
+	t.Run("notifiers that defer notifications do not take a slot while they wait", func(t *testing.T) {
+		inner := newBlockingNotifier()
+		inner.deferral = time.Hour
+		n := LimitConcurrency(inner, NewConcurrencyLimit(1))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := n.Notify(context.Background())
+				require.NoError(t, err)
+			}()
+		}
+
+		waitStarted(t, inner, 2)
+		inner.release <- struct{}{}
+		inner.release <- struct{}{}
+		wg.Wait()
+	})
+
+	t.Run("notifications are not limited without a limit", func(t *testing.T) {
+		inner := newBlockingNotifier()
+		require.Nil(t, NewConcurrencyLimit(0))
+		require.Same(t, inner, LimitConcurrency(inner, NewConcurrencyLimit(0)))
+	})
+}
@@ -0,0 +1,271 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+type deferredCtxKey struct{}
+
+// isDeferred returns true if the notification is sent by deferredSends, or otherwise must be
+// sent right away.
+func isDeferred(ctx context.Context) bool {
+	v, _ := ctx.Value(deferredCtxKey{}).(bool)
+	return v
+}
+
+// WithoutDeferral returns a context whose notifications are sent right away, even if the
+// notifier defers notifications, e.g. for test notifications.
+func WithoutDeferral(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deferredCtxKey{}, true)
+}
+
+// parseDeferral parses the delay and scheduleAt settings. Both are durations, and at most one
+// of them can be set. Notifications are not deferred if neither is set.
+func parseDeferral(delay, scheduleAt string) (time.Duration, time.Duration, error) {
+	d, err := parseNonNegativeDuration(delay, "delay")
+	if err != nil {
+		return 0, 0, err
+	}
+	s, err := parseNonNegativeDuration(scheduleAt, "schedule")
+	if err != nil {
+		return 0, 0, err
+	}
+	if d > 0 && s > 0 {
+		return 0, 0, errors.New("delay and scheduleAt cannot be used together")
+	}
+	if s > 0 && s < time.Minute {
+		return 0, 0, fmt.Errorf("invalid schedule %s, must be at least 1m", s)
+	}
+	return d, s, nil
+}
+
+// errDeferredSendsStopped is the error of the notifications that were deferred by a notifier
+// that was stopped before they were sent, they are sent by the notifier that replaced it once
+// retried.
+var errDeferredSendsStopped = errors.New("the notifier was stopped before the deferred notification was sent")
+
+// deferredSends defers the notifications of a contact point and sends them all at once,
+// either a delay after the first deferred notification or at the next multiple of the
+// schedule, e.g. at the top of the hour for a schedule of 1h. Deferred notifications of the
+// same group are coalesced: only the last one is sent, as it has the latest state of the
+// alerts of the group.
+//
+// Each notification waits for the deferred notification of its group to be sent and gets
+// its result, so that it is only marked as sent once it is, and is retried otherwise.
+//
+// A nil deferredSends does not defer notifications.
+type deferredSends struct {
+	delay    time.Duration
+	schedule time.Duration
+	log      Logger
+	send     func(ctx context.Context, alerts ...*types.Alert) (bool, error)
+
+	mtx     sync.Mutex
+	due     time.Time
+	timer   *time.Timer
+	order   []string
+	pending map[string]*deferredNotification
+	stopped bool
+}
+
+// deferredNotification is the last notification deferred for a group, and the notifications
+// of the group waiting for it to be sent.
+type deferredNotification struct {
+	key         string
+	groupLabels model.LabelSet
+	receiver    string
+	alerts      []*types.Alert
+	waiters     []chan deferredResult
+}
+
+type deferredResult struct {
+	retry bool
+	err   error
+}
+
+func newDeferredSends(delay, schedule time.Duration, l Logger, send func(ctx context.Context, alerts ...*types.Alert) (bool, error)) *deferredSends {
+	if delay <= 0 && schedule <= 0 {
+		return nil
+	}
+	return &deferredSends{
+		delay:    delay,
+		schedule: schedule,
+		log:      l,
+		send:     send,
+		pending:  map[string]*deferredNotification{},
+	}
+}
+
+// defers returns true if the notification in ctx is deferred. It returns false if it must be
+// sent right away, either because deferral is disabled or because ctx is a deferred
+// notification being sent or a reminder.
+func (d *deferredSends) defers(ctx context.Context) bool {
+	return d != nil && !isDeferred(ctx) && !isReminder(ctx)
+}
+
+// maxWait returns the longest time a notification waits to be sent.
+func (d *deferredSends) maxWait() time.Duration {
+	if d == nil {
+		return 0
+	}
+	if d.schedule > 0 {
+		return d.schedule
+	}
+	return d.delay
+}
+
+// add defers the notification in ctx and waits for it to be sent. It returns the result of
+// sending the notification of its group, and whether it should be retried if it failed.
+//
+// If ctx is done before it is sent, the notification is no longer deferred, unless later
+// notifications of its group wait for it, and should be retried.
+func (d *deferredSends) add(ctx context.Context, alerts []*types.Alert) (bool, error) {
+	// Notifications without a group are coalesced together.
+	var key string
+	if k, err := notify.ExtractGroupKey(ctx); err == nil {
+		key = k.String()
+	}
+	groupLabels, _ := notify.GroupLabels(ctx)
+	receiver, _ := notify.ReceiverName(ctx)
+	result := make(chan deferredResult, 1)
+
+	d.mtx.Lock()
+	if d.stopped {
+		d.mtx.Unlock()
+		return true, errDeferredSendsStopped
+	}
+	if len(d.pending) == 0 {
+		now := timeNow()
+		d.due = d.nextSend(now)
+		d.timer = time.AfterFunc(d.due.Sub(now), d.flush)
+	}
+	n, ok := d.pending[key]
+	if !ok {
+		n = &deferredNotification{key: key}
+		d.pending[key] = n
+		d.order = append(d.order, key)
+	}
+	n.groupLabels, n.receiver, n.alerts = groupLabels, receiver, alerts
+	n.waiters = append(n.waiters, result)
+	d.mtx.Unlock()
+
+	select {
+	case r := <-result:
+		return r.retry, r.err
+	case <-ctx.Done():
+		if d.remove(n, result) {
+			return true, ctx.Err()
+		}
+		// The notification is already being sent.
+		r := <-result
+		return r.retry, r.err
+	}
+}
+
+// remove stops the waiter from waiting for the notification, and removes the notification if
+// no other waiter is left. It returns false if the notification is no longer pending.
+func (d *deferredSends) remove(n *deferredNotification, waiter chan deferredResult) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.pending[n.key] != n {
+		return false
+	}
+	for i, w := range n.waiters {
+		if w == waiter {
+			n.waiters = append(n.waiters[:i], n.waiters[i+1:]...)
+			break
+		}
+	}
+	if len(n.waiters) > 0 {
+		return true
+	}
+	delete(d.pending, n.key)
+	for i, key := range d.order {
+		if key == n.key {
+			d.order = append(d.order[:i], d.order[i+1:]...)
+			break
+		}
+	}
+	if len(d.pending) == 0 {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	return true
+}
+
+// nextSend returns when the notifications deferred at now are sent.
+func (d *deferredSends) nextSend(now time.Time) time.Time {
+	if d.schedule > 0 {
+		return now.Truncate(d.schedule).Add(d.schedule)
+	}
+	return now.Add(d.delay)
+}
+
+// flush sends the deferred notifications if they are due.
+func (d *deferredSends) flush() {
+	d.mtx.Lock()
+	if len(d.pending) == 0 {
+		d.mtx.Unlock()
+		return
+	}
+	d.timer.Stop()
+	if now := timeNow(); now.Before(d.due) {
+		d.timer = time.AfterFunc(d.due.Sub(now), d.flush)
+		d.mtx.Unlock()
+		return
+	}
+	notifications := make([]*deferredNotification, 0, len(d.order))
+	for _, key := range d.order {
+		notifications = append(notifications, d.pending[key])
+	}
+	d.order = nil
+	d.pending = map[string]*deferredNotification{}
+	d.timer = nil
+	d.mtx.Unlock()
+
+	for _, n := range notifications {
+		ctx := context.WithValue(context.Background(), deferredCtxKey{}, true)
+		if n.key != "" {
+			ctx = notify.WithGroupKey(ctx, n.key)
+		}
+		ctx = notify.WithGroupLabels(ctx, n.groupLabels)
+		ctx = notify.WithReceiverName(ctx, n.receiver)
+		retry, err := d.send(ctx, n.alerts...)
+		if err != nil {
+			d.log.Error("failed to send deferred notification", "group", n.key, "error", err)
+		}
+		for _, w := range n.waiters {
+			w <- deferredResult{retry: retry, err: err}
+		}
+	}
+}
+
+// stop stops the timer of the deferred notifications. The notifications that were not sent
+// fail and should be retried, and so do later notifications.
+func (d *deferredSends) stop() {
+	if d == nil {
+		return
+	}
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.stopped = true
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	for _, n := range d.pending {
+		for _, w := range n.waiters {
+			w <- deferredResult{retry: true, err: errDeferredSendsStopped}
+		}
+	}
+	d.order = nil
+	d.pending = map[string]*deferredNotification{}
+}
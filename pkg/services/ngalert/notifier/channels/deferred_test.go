@@ -0,0 +1,198 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferredSends(t *testing.T) {
+	now := time.Date(2022, 12, 1, 10, 20, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	groupCtx := func(groupKey string) context.Context {
+		ctx := notify.WithGroupKey(context.Background(), groupKey)
+		return notify.WithGroupLabels(ctx, model.LabelSet{"alertname": model.LabelValue(groupKey)})
+	}
+	alertA1 := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "A", "instance": "1"}}}
+	alertA2 := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "A", "instance": "2"}}}
+	alertB := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "B"}}}
+
+	type sentNotification struct {
+		ctx    context.Context
+		alerts []*types.Alert
+	}
+	var sent []sentNotification
+	var sendErr error
+	send := func(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+		sent = append(sent, sentNotification{ctx: ctx, alerts: alerts})
+		return true, sendErr
+	}
+
+	// addAsync defers the notification in the background, as it waits to be sent.
+	addAsync := func(ctx context.Context, d *deferredSends, alerts ...*types.Alert) <-chan deferredResult {
+		done := make(chan deferredResult, 1)
+		go func() {
+			retry, err := d.add(ctx, alerts)
+			done <- deferredResult{retry: retry, err: err}
+		}()
+		return done
+	}
+	// waitWaiting waits for n notifications to wait for their deferred send.
+	waitWaiting := func(t *testing.T, d *deferredSends, n int) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			d.mtx.Lock()
+			defer d.mtx.Unlock()
+			var waiting int
+			for _, p := range d.pending {
+				waiting += len(p.waiters)
+			}
+			return waiting == n
+		}, time.Second, time.Millisecond)
+	}
+
+	t.Run("notifications are sent at the next multiple of the schedule", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		d := newDeferredSends(0, time.Hour, &FakeLogger{}, send)
+
+		first := addAsync(groupCtx("A"), d, alertA1)
+		waitWaiting(t, d, 1)
+		second := addAsync(groupCtx("B"), d, alertB)
+		waitWaiting(t, d, 2)
+		third := addAsync(groupCtx("A"), d, alertA1, alertA2)
+		waitWaiting(t, d, 3)
+
+		mockTimeNow(now.Add(39 * time.Minute))
+		d.flush()
+		require.Empty(t, sent)
+
+		mockTimeNow(now.Add(40 * time.Minute))
+		d.flush()
+		require.Len(t, sent, 2)
+		// The notifications of a group are coalesced into the last one, and all of them get
+		// its result.
+		require.Equal(t, []*types.Alert{alertA1, alertA2}, sent[0].alerts)
+		require.Equal(t, []*types.Alert{alertB}, sent[1].alerts)
+		for _, done := range []<-chan deferredResult{first, second, third} {
+			require.Equal(t, deferredResult{retry: true}, <-done)
+		}
+		require.True(t, isDeferred(sent[0].ctx))
+		key, err := notify.ExtractGroupKey(sent[0].ctx)
+		require.NoError(t, err)
+		require.Equal(t, "A", key.String())
+
+		// Sent notifications are not sent again.
+		d.flush()
+		require.Len(t, sent, 2)
+	})
+
+	t.Run("notifications are sent a delay after the first one", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		d := newDeferredSends(10*time.Minute, 0, &FakeLogger{}, send)
+
+		first := addAsync(groupCtx("A"), d, alertA1)
+		waitWaiting(t, d, 1)
+		mockTimeNow(now.Add(5 * time.Minute))
+		second := addAsync(groupCtx("B"), d, alertB)
+		waitWaiting(t, d, 2)
+
+		mockTimeNow(now.Add(10*time.Minute - time.Second))
+		d.flush()
+		require.Empty(t, sent)
+
+		mockTimeNow(now.Add(10 * time.Minute))
+		d.flush()
+		require.Len(t, sent, 2)
+		require.NoError(t, (<-first).err)
+		require.NoError(t, (<-second).err)
+	})
+
+	t.Run("failed send is reported to the notifications", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent, sendErr = nil, errors.New("connection refused")
+		defer func() { sendErr = nil }()
+		d := newDeferredSends(10*time.Minute, 0, &FakeLogger{}, send)
+
+		done := addAsync(groupCtx("A"), d, alertA1)
+		waitWaiting(t, d, 1)
+		mockTimeNow(now.Add(10 * time.Minute))
+		d.flush()
+		require.Equal(t, deferredResult{retry: true, err: sendErr}, <-done)
+	})
+
+	t.Run("notification is no longer deferred once its context is done", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		d := newDeferredSends(10*time.Minute, 0, &FakeLogger{}, send)
+
+		ctx, cancel := context.WithCancel(groupCtx("A"))
+		done := addAsync(ctx, d, alertA1)
+		waitWaiting(t, d, 1)
+		cancel()
+		require.Equal(t, deferredResult{retry: true, err: context.Canceled}, <-done)
+		require.Empty(t, d.pending)
+		require.Nil(t, d.timer)
+
+		mockTimeNow(now.Add(10 * time.Minute))
+		d.flush()
+		require.Empty(t, sent)
+	})
+
+	t.Run("stopped notifications fail and are retried", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		d := newDeferredSends(10*time.Minute, 0, &FakeLogger{}, send)
+
+		done := addAsync(groupCtx("A"), d, alertA1)
+		waitWaiting(t, d, 1)
+		d.stop()
+		require.Equal(t, deferredResult{retry: true, err: errDeferredSendsStopped}, <-done)
+		require.Nil(t, d.timer)
+
+		retry, err := d.add(groupCtx("B"), []*types.Alert{alertB})
+		require.ErrorIs(t, err, errDeferredSendsStopped)
+		require.True(t, retry)
+		require.Empty(t, sent)
+	})
+
+	t.Run("deferred notifications, reminders and test notifications are not deferred", func(t *testing.T) {
+		d := newDeferredSends(10*time.Minute, 0, &FakeLogger{}, send)
+		require.True(t, d.defers(groupCtx("A")))
+		require.False(t, d.defers(context.WithValue(groupCtx("A"), deferredCtxKey{}, true)))
+		require.False(t, d.defers(context.WithValue(groupCtx("A"), reminderCtxKey{}, true)))
+		require.False(t, d.defers(WithoutDeferral(context.Background())))
+		require.Equal(t, 10*time.Minute, d.maxWait())
+	})
+
+	t.Run("notifications are not deferred without a delay or schedule", func(t *testing.T) {
+		d := newDeferredSends(0, 0, &FakeLogger{}, send)
+		require.Nil(t, d)
+		require.False(t, d.defers(groupCtx("A")))
+		require.Zero(t, d.maxWait())
+	})
+}
+
+func TestParseDeferral(t *testing.T) {
+	delay, scheduleAt, err := parseDeferral("10m", "")
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, delay)
+	require.Zero(t, scheduleAt)
+
+	_, _, err = parseDeferral("10m", "1h")
+	require.EqualError(t, err, "delay and scheduleAt cannot be used together")
+
+	_, _, err = parseDeferral("", "30s")
+	require.EqualError(t, err, "invalid schedule 30s, must be at least 1m")
+
+	_, _, err = parseDeferral("-1m", "")
+	require.EqualError(t, err, "delay should not be negative")
+}
@@ -9,8 +9,6 @@ import (
 	"io"
 	"mime/multipart"
 	"path/filepath"
-	"strconv"
-	"strings"
 
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
@@ -27,6 +25,7 @@ type DiscordNotifier struct {
 	images   ImageStore
 	tmpl     *template.Template
 	settings discordSettings
+	colors   SeverityColors
 }
 
 type discordSettings struct {
@@ -74,6 +73,7 @@ func newDiscordNotifier(fc FactoryConfig) (*DiscordNotifier, error) {
 		ns:     fc.NotificationService,
 		images: fc.ImageStore,
 		tmpl:   fc.Template,
+		colors: fc.SeverityColors,
 		settings: discordSettings{
 			Title:              settings.Get("title").MustString(DefaultMessageTitleEmbed),
 			Content:            settings.Get("message").MustString(DefaultMessageEmbed),
@@ -85,8 +85,6 @@ func newDiscordNotifier(fc FactoryConfig) (*DiscordNotifier, error) {
 }
 
 func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	alerts := types.Alerts(as...)
-
 	bodyJSON := simplejson.New()
 
 	if !d.settings.UseDiscordUsername {
@@ -128,7 +126,7 @@ func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	linkEmbed.Set("footer", footer)
 	linkEmbed.Set("type", "rich")
 
-	color, _ := strconv.ParseInt(strings.TrimLeft(getAlertStatusColor(alerts.Status()), "#"), 16, 0)
+	color := hexColorValue(d.colors.Color(as...))
 	linkEmbed.Set("color", color)
 
 	ruleURL := joinUrlPath(d.tmpl.ExternalURL.String(), "/alerting/list", d.log)
@@ -138,7 +136,6 @@ func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 
 	attachments := d.constructAttachments(ctx, as, DiscordMaxEmbeds-1)
 	for _, a := range attachments {
-		color, _ := strconv.ParseInt(strings.TrimLeft(getAlertStatusColor(alerts.Status()), "#"), 16, 0)
 		embed := map[string]interface{}{
 			"image": map[string]interface{}{
 				"url": a.url,
@@ -2,39 +2,218 @@ package channels
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/mail"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/util"
 )
 
+const (
+	EmailImportanceLow    = "low"
+	EmailImportanceNormal = "normal"
+	EmailImportanceHigh   = "high"
+)
+
+const (
+	EmailTransferEncodingQuotedPrintable = "quoted-printable"
+	EmailTransferEncodingBase64          = "base64"
+)
+
+const (
+	EmailCharsetUTF8      = "UTF-8"
+	EmailCharsetISO8859_1 = "ISO-8859-1"
+)
+
+// EmailRawJSONAttachment is the name of the file the alerts are attached as with AttachRawJSON.
+const EmailRawJSONAttachment = "alerts.json"
+
+// emailImportanceHeaders are the headers that mark the importance of an email. Importance
+// is understood by most clients, X-Priority by clients that don't support it.
+var emailImportanceHeaders = map[string]map[string]string{
+	EmailImportanceLow:    {"Importance": "Low", "X-Priority": "5 (Lowest)"},
+	EmailImportanceNormal: {"Importance": "Normal", "X-Priority": "3 (Normal)"},
+	EmailImportanceHigh:   {"Importance": "High", "X-Priority": "1 (Highest)"},
+}
+
+// emailLabelImportance maps the common values of severity labels to importances.
+var emailLabelImportance = map[string]string{
+	EmailImportanceLow:    EmailImportanceLow,
+	"info":                EmailImportanceLow,
+	EmailImportanceNormal: EmailImportanceNormal,
+	"medium":              EmailImportanceNormal,
+	"warning":             EmailImportanceNormal,
+	EmailImportanceHigh:   EmailImportanceHigh,
+	"error":               EmailImportanceHigh,
+	"critical":            EmailImportanceHigh,
+}
+
 // EmailNotifier is responsible for sending
 // alert notifications over email.
 type EmailNotifier struct {
 	*Base
-	Addresses   []string
-	SingleEmail bool
-	Message     string
-	Subject     string
-	log         Logger
-	ns          EmailSender
-	images      ImageStore
-	tmpl        *template.Template
+	Addresses []string
+	// CC are the carbon copy recipients of all emails, CCRules add recipients to emails
+	// about the groups they match.
+	CC           []string
+	CCRules      []EmailCCRule
+	SingleEmail  bool
+	CopyToSender bool
+	// GroupByDomain sends a single email per domain of the recipients, instead of a single
+	// email to all recipients, if SingleEmail is set.
+	GroupByDomain bool
+	// SplitByDashboard sends an email per dashboard of the alerts, by their dashboard UID
+	// annotation. Alerts without a dashboard are sent in an email of their own.
+	SplitByDashboard bool
+	// StableMessageID sets the Message-Id of the email to one derived from the deduplication
+	// key, so re-sends of a notification have the same Message-Id.
+	StableMessageID bool
+	// AttachRawJSON attaches the alerts of the email, serialized to JSON, as alerts.json.
+	AttachRawJSON bool
+	// IncludeRunbook includes an excerpt of the runbook of each alert, if it can be fetched,
+	// in addition to the link to it.
+	IncludeRunbook bool
+	Message        string
+	Subject        string
+	// ResolvedMessage and ResolvedSubject are used instead of Message and Subject
+	// for resolved notifications, if set.
+	ResolvedMessage string
+	ResolvedSubject string
+	// TemplateName is the name of a template stored in the database, e.g. edited in the UI,
+	// that is used instead of Message. Message is used if the template does not exist.
+	TemplateName string
+	// Importance sets the importance headers of the email, if set.
+	Importance string
+	// Headers are custom headers of the email. Names and values are templates.
+	Headers map[string]string
+	// ImportanceLabel is the name of a label, such as severity, whose value is used as the
+	// importance of the email. Importance is used when no alert has a known value.
+	ImportanceLabel string
+	// EnvironmentLabel is the name of a label, such as env, whose value prefixes the subject
+	// in brackets and uppercased, e.g. [PROD], when it is common to all alerts.
+	EnvironmentLabel string
+	// TransferEncoding is the Content-Transfer-Encoding of the body, quoted-printable or
+	// base64. The default of the mailer, quoted-printable, is used if it is not set.
+	TransferEncoding string
+	// Charset is the charset the body is encoded in, UTF-8 or ISO-8859-1.
+	Charset string
+	// SMIMECertFile and SMIMEKeyFile are the PEM files of the certificate and private key
+	// emails are signed with using S/MIME. Emails are not signed if they are not set.
+	SMIMECertFile string
+	SMIMEKeyFile  string
+	// ExternalURLOverride replaces the Grafana external URL in links, if set.
+	ExternalURLOverride *url.URL
+	// RequireImages fails the notification, so it is retried, instead of sending it
+	// without images when none of the images of the alerts could be retrieved.
+	RequireImages bool
+	// ImagesOnFiringOnly sends the notifications of resolved groups without images.
+	ImagesOnFiringOnly bool
+	// MinAlerts skips notifications of groups with fewer firing alerts, if set.
+	MinAlerts int
+	// Sort is the order the alerts are rendered in.
+	Sort AlertSort
+	// TextDirection is the direction of the text of the HTML email: ltr, rtl or auto to
+	// detect it from the message and the annotations of the alerts.
+	TextDirection string
+	// Timezone is the time zone timestamps are rendered in. It is UTC if it is nil.
+	Timezone *time.Location
+	// SignSilenceLinks signs the silence links of the alerts with silenceLinkKey, so that
+	// they expire after SilenceLinkTTL and cannot be forged.
+	SignSilenceLinks bool
+	SilenceLinkTTL   time.Duration
+	silenceLinkKey   []byte
+	// ExcludeLabels are removed from the labels of the alerts in the message.
+	ExcludeLabels LabelPatterns
+	// RedactPatterns match secrets that are redacted from the label and annotation values
+	// of the alerts in the message.
+	RedactPatterns RedactPatterns
+	// MaxLabelValueLength truncates longer label values in the message, if set.
+	MaxLabelValueLength int
+	orgID               int64
+	log                 Logger
+	ns                  EmailSender
+	images              ImageStore
+	tmpl                *template.Template
+	// batch is nil when batching is disabled.
+	batch *emailBatcher
+	// retries is nil when failed emails are not retried.
+	retries *RetryBudget
+	// reminders is nil when reminders are disabled.
+	reminders *reminders
+	// deferred is nil when notifications are sent right away.
+	deferred *deferredSends
+	// recipients resolves the addresses each email is sent to.
+	recipients RecipientResolver
+	// history records each attempt to send an email.
+	history HistorySink
+	// optOut are the addresses removed from the recipients of all emails.
+	optOut emailOptOut
+	// runbooks fetches the runbooks included in emails, if IncludeRunbook is set.
+	runbooks *runbookFetcher
+	// templates is nil when there is no template store.
+	templates *storedTemplates
+	// metrics is nil when the attempts to send emails are not counted.
+	metrics *SendMetrics
 }
 
 type EmailConfig struct {
 	*NotificationChannelConfig
-	SingleEmail bool
-	Addresses   []string
-	Message     string
-	Subject     string
+	SingleEmail         bool
+	CopyToSender        bool
+	GroupByDomain       bool
+	SplitByDashboard    bool
+	StableMessageID     bool
+	AttachRawJSON       bool
+	IncludeRunbook      bool
+	Addresses           []string
+	CC                  []string
+	CCRules             []EmailCCRule
+	Message             string
+	Subject             string
+	ResolvedMessage     string
+	ResolvedSubject     string
+	TemplateName        string
+	Importance          string
+	Headers             map[string]string
+	ImportanceLabel     string
+	EnvironmentLabel    string
+	TransferEncoding    string
+	Charset             string
+	SMIMECertFile       string
+	SMIMEKeyFile        string
+	ExternalURLOverride *url.URL
+	RequireImages       bool
+	ImagesOnFiringOnly  bool
+	MaxLabelValueLength int
+	MinAlerts           int
+	Sort                AlertSort
+	TextDirection       string
+	Timezone            *time.Location
+	SignSilenceLinks    bool
+	SilenceLinkTTL      time.Duration
+	ExcludeLabels       LabelPatterns
+	RedactPatterns      RedactPatterns
+	BatchWindow         time.Duration
+	BatchMaxCount       int
+	ReminderInterval    time.Duration
+	Delay               time.Duration
+	ScheduleAt          time.Duration
 }
 
 func EmailFactory(fc FactoryConfig) (NotificationChannel, error) {
@@ -45,7 +224,21 @@ func EmailFactory(fc FactoryConfig) (NotificationChannel, error) {
 			Cfg:    *fc.Config,
 		}
 	}
-	return NewEmailNotifier(cfg, fc.Logger, fc.NotificationService, fc.ImageStore, fc.Template), nil
+	if cfg.SignSilenceLinks && len(fc.SilenceLinkKey) == 0 {
+		return nil, receiverInitError{
+			Reason: "silence links cannot be signed without a secret key",
+			Cfg:    *fc.Config,
+		}
+	}
+	en := NewEmailNotifier(cfg, fc.Logger, fc.NotificationService, fc.ImageStore, fc.Template, nil)
+	en.silenceLinkKey = fc.SilenceLinkKey
+	en.optOut = newEmailOptOut(fc.EmailOptOut)
+	en.runbooks = newRunbookFetcher(fc.Runbooks)
+	en.templates = newStoredTemplates(fc.Templates, fc.Config.OrgID, fc.Logger)
+	en.metrics = fc.SendMetrics
+	en.retries = fc.RetryBudget
+	en.history = historyOrNoop(fc.History)
+	return en, nil
 }
 
 func NewEmailConfig(config *NotificationChannelConfig) (*EmailConfig, error) {
@@ -58,41 +251,386 @@ func NewEmailConfig(config *NotificationChannelConfig) (*EmailConfig, error) {
 		return nil, errors.New("could not find addresses in settings")
 	}
 	// split addresses with a few different ways
-	addresses := util.SplitEmails(addressesString)
+	addresses, err := parseEmailAddresses(util.SplitEmails(addressesString))
+	if err != nil {
+		return nil, err
+	}
+	externalURLOverride, err := parseExternalURLOverride(settings.Get("externalURLOverride").MustString())
+	if err != nil {
+		return nil, err
+	}
+	var batchWindow time.Duration
+	if s := settings.Get("batchWindow").MustString(); s != "" {
+		batchWindow, err = time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch window: %w", err)
+		}
+		if batchWindow < 0 {
+			return nil, errors.New("batch window should not be negative")
+		}
+	}
+	batchMaxCount := DefaultEmailBatchMaxCount
+	// The value is a string when set from the UI.
+	if v := settings.Get("batchMaxCount").Interface(); v != nil && v != "" {
+		batchMaxCount, err = strconv.Atoi(fmt.Sprint(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch max count: %w", err)
+		}
+	}
+	if batchMaxCount < 1 {
+		return nil, errors.New("batch max count should be greater than 0")
+	}
+	maxLabelValueLength, err := parseNonNegativeInt(settings.Get("maxLabelValueLength").Interface(), "max label value length")
+	if err != nil {
+		return nil, err
+	}
+	minAlerts, err := parseNonNegativeInt(settings.Get("minAlerts").Interface(), "min alerts")
+	if err != nil {
+		return nil, err
+	}
+	ccRules, err := parseEmailCCRules(settings.Get("ccRules"))
+	if err != nil {
+		return nil, err
+	}
+	reminderInterval, err := parseReminderInterval(settings.Get("reminderInterval").MustString())
+	if err != nil {
+		return nil, err
+	}
+	delay, scheduleAt, err := parseDeferral(settings.Get("delay").MustString(), settings.Get("scheduleAt").MustString())
+	if err != nil {
+		return nil, err
+	}
+	alertSort, err := parseAlertSort(settings.Get("sortBy").MustString(), settings.Get("sortOrder").MustString())
+	if err != nil {
+		return nil, err
+	}
+	textDirection, err := parseEmailTextDirection(settings.Get("textDirection").MustString())
+	if err != nil {
+		return nil, err
+	}
+	importance := settings.Get("importance").MustString()
+	if _, ok := emailImportanceHeaders[importance]; importance != "" && !ok {
+		return nil, fmt.Errorf("invalid importance %q, must be %q, %q or %q", importance, EmailImportanceLow, EmailImportanceNormal, EmailImportanceHigh)
+	}
+	headers, err := parseEmailHeaders(settings.Get("headers"))
+	if err != nil {
+		return nil, err
+	}
+	timezone, err := parseTimezone(settings.Get("timezone").MustString())
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		ExcludeLabels  LabelPatterns  `json:"excludeLabels"`
+		RedactPatterns RedactPatterns `json:"redactPatterns"`
+	}
+	if err := config.unmarshalSettings(&raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	silenceLinkTTL, err := parseNonNegativeDuration(settings.Get("silenceLinkTTL").MustString(), "silence link TTL")
+	if err != nil {
+		return nil, err
+	}
+	if silenceLinkTTL == 0 {
+		silenceLinkTTL = DefaultSilenceLinkTTL
+	}
+	transferEncoding := settings.Get("transferEncoding").MustString()
+	switch transferEncoding {
+	case "", EmailTransferEncodingQuotedPrintable, EmailTransferEncodingBase64:
+	default:
+		return nil, fmt.Errorf("invalid transfer encoding %q, must be %q or %q", transferEncoding, EmailTransferEncodingQuotedPrintable, EmailTransferEncodingBase64)
+	}
+	charset, err := parseEmailCharset(settings.Get("charset").MustString())
+	if err != nil {
+		return nil, err
+	}
+	smimeCertFile := settings.Get("smimeCertFile").MustString()
+	smimeKeyFile := settings.Get("smimeKeyFile").MustString()
+	if err := validateSMIMEKeyPair(smimeCertFile, smimeKeyFile); err != nil {
+		return nil, err
+	}
 	return &EmailConfig{
 		NotificationChannelConfig: config,
 		SingleEmail:               settings.Get("singleEmail").MustBool(false),
+		CopyToSender:              settings.Get("copyToSender").MustBool(false),
+		GroupByDomain:             settings.Get("groupByDomain").MustBool(false),
+		SplitByDashboard:          settings.Get("splitByDashboard").MustBool(false),
+		StableMessageID:           settings.Get("stableMessageId").MustBool(false),
+		AttachRawJSON:             settings.Get("attachRawJSON").MustBool(false),
+		IncludeRunbook:            settings.Get("includeRunbook").MustBool(false),
 		Message:                   settings.Get("message").MustString(),
 		Subject:                   settings.Get("subject").MustString(DefaultMessageTitleEmbed),
+		ResolvedMessage:           settings.Get("resolvedMessage").MustString(),
+		TemplateName:              settings.Get("templateName").MustString(),
+		ResolvedSubject:           settings.Get("resolvedSubject").MustString(),
+		Importance:                importance,
+		Headers:                   headers,
+		ImportanceLabel:           settings.Get("importanceLabel").MustString(),
+		EnvironmentLabel:          settings.Get("environmentLabel").MustString(),
+		TransferEncoding:          transferEncoding,
+		Charset:                   charset,
+		SMIMECertFile:             smimeCertFile,
+		SMIMEKeyFile:              smimeKeyFile,
 		Addresses:                 addresses,
+		CC:                        util.SplitEmails(settings.Get("cc").MustString()),
+		CCRules:                   ccRules,
+		ExternalURLOverride:       externalURLOverride,
+		RequireImages:             settings.Get("requireImages").MustBool(false),
+		ImagesOnFiringOnly:        settings.Get("imagesOnFiringOnly").MustBool(false),
+		MaxLabelValueLength:       maxLabelValueLength,
+		MinAlerts:                 minAlerts,
+		Sort:                      alertSort,
+		TextDirection:             textDirection,
+		Timezone:                  timezone,
+		SignSilenceLinks:          settings.Get("signSilenceLinks").MustBool(false),
+		SilenceLinkTTL:            silenceLinkTTL,
+		ExcludeLabels:             raw.ExcludeLabels,
+		RedactPatterns:            redactPatternsWithDefaults(settings.Get("redactSecrets").MustBool(false), raw.RedactPatterns),
+		BatchWindow:               batchWindow,
+		BatchMaxCount:             batchMaxCount,
+		ReminderInterval:          reminderInterval,
+		Delay:                     delay,
+		ScheduleAt:                scheduleAt,
 	}, nil
 }
 
+// parseEmailCharset returns the charset of the body in its canonical case, or an error if
+// the charset is not supported. The charset is UTF-8 if it is empty.
+func parseEmailCharset(s string) (string, error) {
+	for _, charset := range []string{EmailCharsetUTF8, EmailCharsetISO8859_1} {
+		if s == "" || strings.EqualFold(s, charset) {
+			return charset, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported charset %q, must be %q or %q", s, EmailCharsetUTF8, EmailCharsetISO8859_1)
+}
+
+// parseEmailAddresses validates the addresses, either plain email addresses or in the
+// Name <email> form, and returns them as they are written in the headers of emails. Display
+// names are quoted, and encoded if they are not ASCII.
+func parseEmailAddresses(addresses []string) ([]string, error) {
+	parsed := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		addr, err := mail.ParseAddress(strings.TrimSpace(address))
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address %q: %w", address, err)
+		}
+		if addr.Name == "" {
+			parsed = append(parsed, addr.Address)
+		} else {
+			parsed = append(parsed, addr.String())
+		}
+	}
+	return parsed, nil
+}
+
+// validateSMIMEKeyPair returns an error if only one of the S/MIME certificate and key files
+// is set, or if they cannot be loaded, so that the contact point fails when it is saved
+// rather than when it sends emails.
+func validateSMIMEKeyPair(certFile, keyFile string) error {
+	if certFile == "" && keyFile == "" {
+		return nil
+	}
+	if certFile == "" || keyFile == "" {
+		return errors.New("both the S/MIME certificate file and key file are required to sign emails")
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return fmt.Errorf("failed to load the S/MIME certificate and key: %w", err)
+	}
+	return nil
+}
+
 // NewEmailNotifier is the constructor function
-// for the EmailNotifier.
-func NewEmailNotifier(config *EmailConfig, l Logger, ns EmailSender, images ImageStore, t *template.Template) *EmailNotifier {
-	return &EmailNotifier{
-		Base:        NewBase(config.NotificationChannelConfig),
-		Addresses:   config.Addresses,
-		SingleEmail: config.SingleEmail,
-		Message:     config.Message,
-		Subject:     config.Subject,
-		log:         l,
-		ns:          ns,
-		images:      images,
-		tmpl:        t,
+// for the EmailNotifier. If recipients is nil, emails are sent to the addresses of the config.
+func NewEmailNotifier(config *EmailConfig, l Logger, ns EmailSender, images ImageStore, t *template.Template, recipients RecipientResolver) *EmailNotifier {
+	if recipients == nil {
+		recipients = StaticRecipientResolver(config.Addresses)
+	}
+	en := &EmailNotifier{
+		Base:                NewBase(config.NotificationChannelConfig),
+		Addresses:           config.Addresses,
+		CC:                  config.CC,
+		CCRules:             config.CCRules,
+		SingleEmail:         config.SingleEmail,
+		CopyToSender:        config.CopyToSender,
+		GroupByDomain:       config.GroupByDomain,
+		SplitByDashboard:    config.SplitByDashboard,
+		StableMessageID:     config.StableMessageID,
+		AttachRawJSON:       config.AttachRawJSON,
+		IncludeRunbook:      config.IncludeRunbook,
+		Message:             config.Message,
+		Subject:             config.Subject,
+		ResolvedMessage:     config.ResolvedMessage,
+		TemplateName:        config.TemplateName,
+		ResolvedSubject:     config.ResolvedSubject,
+		Importance:          config.Importance,
+		Headers:             config.Headers,
+		ImportanceLabel:     config.ImportanceLabel,
+		EnvironmentLabel:    config.EnvironmentLabel,
+		TransferEncoding:    config.TransferEncoding,
+		Charset:             config.Charset,
+		SMIMECertFile:       config.SMIMECertFile,
+		SMIMEKeyFile:        config.SMIMEKeyFile,
+		ExternalURLOverride: config.ExternalURLOverride,
+		RequireImages:       config.RequireImages,
+		ImagesOnFiringOnly:  config.ImagesOnFiringOnly,
+		MaxLabelValueLength: config.MaxLabelValueLength,
+		MinAlerts:           config.MinAlerts,
+		Sort:                config.Sort,
+		TextDirection:       config.TextDirection,
+		Timezone:            config.Timezone,
+		SignSilenceLinks:    config.SignSilenceLinks,
+		SilenceLinkTTL:      config.SilenceLinkTTL,
+		ExcludeLabels:       config.ExcludeLabels,
+		RedactPatterns:      config.RedactPatterns,
+		orgID:               config.OrgID,
+		log:                 l,
+		ns:                  ns,
+		images:              images,
+		tmpl:                t,
+		recipients:          recipients,
+		history:             NoopHistorySink{},
+	}
+	if config.BatchWindow > 0 {
+		en.batch = newEmailBatcher(config.BatchWindow, config.BatchMaxCount, l, en.sendBatch)
+	}
+	if config.ReminderInterval > 0 {
+		en.reminders = newReminders(config.ReminderInterval, l, en.Notify)
 	}
+	en.deferred = newDeferredSends(config.Delay, config.ScheduleAt, l, en.Notify)
+	return en
 }
 
 // Notify sends the alert notification.
 func (en *EmailNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	if en.deferred.defers(ctx) {
+		return en.deferred.add(ctx, alerts)
+	}
+	if belowMinAlerts(en.MinAlerts, alerts) {
+		en.log.Debug("skipping email notification, too few firing alerts", "alerts", len(alerts), "minAlerts", en.MinAlerts)
+		reportSkipped(ctx)
+		return true, nil
+	}
+	alerts = en.Sort.apply(alerts)
+
+	groups := [][]*types.Alert{alerts}
+	if en.SplitByDashboard {
+		groups = groupAlertsByDashboard(alerts)
+	}
+	// The emails of the other groups are still sent if one of them fails.
+	var retry bool
+	var firstErr error
+	for _, group := range groups {
+		if r, err := en.notify(ctx, group); err != nil && firstErr == nil {
+			retry, firstErr = r, err
+		}
+	}
+	if firstErr != nil {
+		return retry, firstErr
+	}
+
+	en.reminders.update(ctx, alerts)
+	return true, nil
+}
+
+// groupAlertsByDashboard groups the alerts by their dashboard UID annotation, in the order
+// the dashboards first appear in. The alerts without a dashboard are the last group.
+func groupAlertsByDashboard(alerts []*types.Alert) [][]*types.Alert {
+	var groups [][]*types.Alert
+	var noDashboard []*types.Alert
+	index := make(map[model.LabelValue]int)
+	for _, a := range alerts {
+		uid := a.Annotations[ngmodels.DashboardUIDAnnotation]
+		if uid == "" {
+			noDashboard = append(noDashboard, a)
+			continue
+		}
+		i, ok := index[uid]
+		if !ok {
+			i = len(groups)
+			index[uid] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], a)
+	}
+	if len(noDashboard) > 0 {
+		groups = append(groups, noDashboard)
+	}
+	return groups
+}
+
+// notify sends an email about the alerts.
+func (en *EmailNotifier) notify(ctx context.Context, alerts []*types.Alert) (retry bool, err error) {
+	var to []string
+	defer func() {
+		en.history.Record(ctx, newNotificationRecord(en.Base, alerts, to, err))
+	}()
+
+	to, err = en.recipients.ResolveRecipients(ctx, alerts)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve recipients: %w", err)
+	}
+	if len(to) == 0 {
+		return false, errors.New("no recipients to send the email to")
+	}
+	to = en.removeOptedOut(to)
+	if len(to) == 0 {
+		en.log.Info("skipping email notification, all recipients opted out of alert emails")
+		reportSkipped(ctx)
+		return true, nil
+	}
+	reportRecipients(ctx, to)
+
 	var tmplErr error
-	tmpl, data := TmplText(ctx, en.tmpl, alerts, en.log, &tmplErr)
+	t := withExternalURL(en.tmpl, en.ExternalURLOverride)
+	tmpl, data := TmplText(ctx, t, alerts, en.log, &tmplErr)
+	excludeLabels(data, en.ExcludeLabels)
+	redactValues(data, en.RedactPatterns)
+	truncateLabelValues(data, en.MaxLabelValueLength)
+	inTimezone(data, en.Timezone)
+	if en.SignSilenceLinks {
+		en.signSilenceLinks(data)
+	}
+	if en.IncludeRunbook && en.runbooks != nil {
+		en.addRunbookExcerpts(ctx, data)
+	}
+	render := tmplWithFallback(tmpl, &tmplErr, en.log)
 
-	subject := tmpl(en.Subject)
-	alertPageURL := en.tmpl.ExternalURL.String()
-	ruleURL := en.tmpl.ExternalURL.String()
-	u, err := url.Parse(en.tmpl.ExternalURL.String())
+	subjectTmpl, messageTmpl := en.Subject, en.templates.messageTemplate(ctx, en.TemplateName, en.Message)
+	if data.Status == string(model.AlertResolved) {
+		if en.ResolvedSubject != "" {
+			subjectTmpl = en.ResolvedSubject
+		}
+		if en.ResolvedMessage != "" {
+			messageTmpl = en.ResolvedMessage
+		}
+	}
+
+	headers := renderEmailHeaders(emailImportanceHeaders[en.importance(alerts)], en.Headers, func(s string) string {
+		v, _ := render(s, "")
+		return v
+	}, en.log)
+	if groupKey, keyErr := notify.ExtractGroupKey(ctx); keyErr == nil {
+		var dashboardUID string
+		if en.SplitByDashboard {
+			dashboardUID = data.CommonAnnotations[ngmodels.DashboardUIDAnnotation]
+		}
+		headers = withDedupHeaders(headers, emailDedupKey(groupKey.String(), data.Status, dashboardUID), t.ExternalURL.Hostname(), en.StableMessageID)
+	}
+	subject, subjectFallback := render(subjectTmpl, DefaultMessageTitleEmbed)
+	message, messageFallback := render(messageTmpl, "")
+	if subjectFallback || messageFallback {
+		message = strings.TrimSpace(TemplateErrorNotice + "\n\n" + message)
+	}
+	// The environment prefix follows the reminder prefix, e.g. [REMINDER] [PROD] subject.
+	subject = en.environmentPrefix(data) + subject
+	if isReminder(ctx) {
+		subject = ReminderTitlePrefix + subject
+		message = reminderMessage(data)
+	}
+	alertPageURL := t.ExternalURL.String()
+	ruleURL := t.ExternalURL.String()
+	u, err := url.Parse(t.ExternalURL.String())
 	if err == nil {
 		basePath := u.Path
 		u.Path = path.Join(basePath, "/alerting/list")
@@ -100,32 +638,55 @@ func (en *EmailNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bo
 		u.RawQuery = "alertState=firing&view=state"
 		alertPageURL = u.String()
 	} else {
-		en.log.Debug("failed to parse external URL", "url", en.tmpl.ExternalURL.String(), "error", err.Error())
+		en.log.Debug("failed to parse external URL", "url", t.ExternalURL.String(), "error", err.Error())
 	}
 
-	// Extend alerts data with images, if available.
+	// Extend alerts data with images, if available. Resolved groups are sent without images
+	// with imagesOnFiringOnly.
 	var embeddedFiles []string
-	_ = withStoredImages(ctx, en.log, en.images,
-		func(index int, image Image) error {
-			if len(image.URL) != 0 {
-				data.Alerts[index].ImageURL = image.URL
-			} else if len(image.Path) != 0 {
-				_, err := os.Stat(image.Path)
-				if err == nil {
-					data.Alerts[index].EmbeddedImage = filepath.Base(image.Path)
-					embeddedFiles = append(embeddedFiles, image.Path)
-				} else {
-					en.log.Warn("failed to get image file for email attachment", "file", image.Path, "error", err)
+	if !en.ImagesOnFiringOnly || data.Status != string(model.AlertResolved) {
+		images := en.images
+		var required *requiredImageStore
+		if en.RequireImages {
+			required = &requiredImageStore{ImageStore: en.images}
+			images = required
+		}
+		_ = withStoredImages(ctx, en.log, images,
+			func(index int, image Image) error {
+				if len(image.URL) != 0 {
+					data.Alerts[index].ImageURL = image.URL
+				} else if len(image.Path) != 0 {
+					_, err := os.Stat(image.Path)
+					if err == nil {
+						data.Alerts[index].EmbeddedImage = filepath.Base(image.Path)
+						embeddedFiles = append(embeddedFiles, image.Path)
+					} else {
+						en.log.Warn("failed to get image file for email attachment", "file", image.Path, "error", err)
+					}
 				}
-			}
-			return nil
-		}, alerts...)
+				return nil
+			}, alerts...)
+		if err := required.err(); err != nil {
+			return true, err
+		}
+	}
+	gallery := newEmailGallery(data.Alerts)
+	embeddedFiles = gallery.embeddedFiles(embeddedFiles)
+
+	var attachedFiles []*SendEmailAttachFile
+	if en.AttachRawJSON {
+		raw, err := json.MarshalIndent(alerts, "", "  ")
+		if err != nil {
+			return false, fmt.Errorf("failed to serialize the alerts: %w", err)
+		}
+		attachedFiles = append(attachedFiles, &SendEmailAttachFile{Name: EmailRawJSONAttachment, Content: raw})
+	}
 
 	cmd := &SendEmailSettings{
 		Subject: subject,
 		Data: map[string]interface{}{
 			"Title":             subject,
-			"Message":           tmpl(en.Message),
+			"Message":           message,
 			"Status":            data.Status,
 			"Alerts":            data.Alerts,
 			"GroupLabels":       data.GroupLabels,
@@ -134,24 +695,191 @@ func (en *EmailNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bo
 			"ExternalURL":       data.ExternalURL,
 			"RuleUrl":           ruleURL,
 			"AlertPageUrl":      alertPageURL,
+			"TextDirection":     en.textDirection(message, data),
+			"ImageGallery":      gallery,
 		},
-		EmbeddedFiles: embeddedFiles,
-		To:            en.Addresses,
-		SingleEmail:   en.SingleEmail,
-		Template:      "ng_alert_notification",
+		EmbeddedFiles:    embeddedFiles,
+		AttachedFiles:    attachedFiles,
+		To:               to,
+		Cc:               en.removeOptedOut(ccRecipients(en.CC, en.CCRules, alerts)),
+		SingleEmail:      en.SingleEmail,
+		CopyToSender:     en.CopyToSender,
+		OptOut:           en.optOut.addresses(),
+		Headers:          headers,
+		Template:         "ng_alert_notification",
+		OrgID:            en.orgID,
+		TransferEncoding: en.TransferEncoding,
+		Charset:          en.Charset,
+		SMIMECertFile:    en.SMIMECertFile,
+		SMIMEKeyFile:     en.SMIMEKeyFile,
 	}
 
-	if tmplErr != nil {
-		en.log.Warn("failed to template email message", "error", tmplErr.Error())
+	if en.batch != nil {
+		// The notification waits for its batch, so that it is only marked as sent once it is.
+		if retry, err := en.batch.add(ctx, cmd); err != nil {
+			return retry, err
+		}
+		return true, nil
 	}
 
-	if err := en.ns.SendEmail(ctx, cmd); err != nil {
-		return false, err
+	if res, err := en.sendEmail(ctx, cmd); err != nil {
+		retry := en.allowRetry(res)
+		en.metrics.result(en.Type, err, retry)
+		return retry, err
 	}
-
+	en.metrics.result(en.Type, nil, false)
 	return true, nil
 }
 
+// RenderPreview renders the subject, message and custom headers of the email for the sample
+// data, without sending it or accessing any store. It returns a TemplatePreviewError if one
+// of the templates fails to execute.
+func (en *EmailNotifier) RenderPreview(data *ExtendedData) (TemplatePreview, error) {
+	data = previewData(data, en.ExcludeLabels, en.RedactPatterns, en.MaxLabelValueLength, en.Timezone)
+	r := &previewRenderer{tmpl: en.tmpl, data: data}
+
+	subjectTmpl, messageTmpl := en.Subject, en.Message
+	subjectField, messageField := "subject", "message"
+	if data.Status == string(model.AlertResolved) {
+		if en.ResolvedSubject != "" {
+			subjectTmpl, subjectField = en.ResolvedSubject, "resolved subject"
+		}
+		if en.ResolvedMessage != "" {
+			messageTmpl, messageField = en.ResolvedMessage, "resolved message"
+		}
+	}
+	preview := TemplatePreview{
+		Title:   en.environmentPrefix(data) + r.render(subjectField, subjectTmpl),
+		Message: r.render(messageField, messageTmpl),
+	}
+	preview.Headers = renderEmailHeaders(nil, en.Headers, func(s string) string {
+		return r.render("headers", s)
+	}, en.log)
+	if r.err != nil {
+		return TemplatePreview{}, r.err
+	}
+	return preview, nil
+}
+
+// sendEmail sends the email, split into an email per domain of the recipients if
+// GroupByDomain and SingleEmail are set. The result contains the outcome for the recipients
+// of all emails.
+func (en *EmailNotifier) sendEmail(ctx context.Context, cmd *SendEmailSettings) (EmailSendResult, error) {
+	en.metrics.attempt(en.Type)
+	if !en.GroupByDomain || !cmd.SingleEmail {
+		return en.ns.SendEmail(ctx, cmd)
+	}
+	var res EmailSendResult
+	for _, email := range splitEmailByDomain(cmd) {
+		r, err := en.ns.SendEmail(ctx, email)
+		if err != nil && len(r.Failed()) == 0 {
+			r = NewEmailSendResult(email.To, err)
+		}
+		res.Recipients = append(res.Recipients, r.Recipients...)
+	}
+	return res, res.Err()
+}
+
+// sendBatch sends a batch of emails. It returns true if the batch failed and can be retried
+// within the retry budget.
+func (en *EmailNotifier) sendBatch(ctx context.Context, cmd *SendEmailSettings) (bool, error) {
+	if res, err := en.sendEmail(ctx, cmd); err != nil {
+		retry := en.allowRetry(res)
+		en.metrics.result(en.Type, err, retry)
+		return retry, err
+	}
+	en.metrics.result(en.Type, nil, false)
+	return false, nil
+}
+
+// allowRetry returns true if an email that failed can be retried within the retry budget.
+// Emails that were delivered to some of their recipients are not retried, as retrying would
+// send them again to these recipients.
+func (en *EmailNotifier) allowRetry(res EmailSendResult) bool {
+	if len(res.Delivered()) > 0 {
+		return false
+	}
+	return en.retries.Allow()
+}
+
+// removeOptedOut returns the recipients without the addresses that opted out of alert
+// emails, and logs the removed recipients.
+func (en *EmailNotifier) removeOptedOut(recipients []string) []string {
+	kept, removed := en.optOut.remove(recipients)
+	if len(removed) > 0 {
+		en.log.Info("removed recipients that opted out of alert emails", "recipients", strings.Join(removed, ", "))
+	}
+	return kept
+}
+
+// signSilenceLinks signs the silence links of the alerts. Links that cannot be signed are
+// removed rather than sent unsigned.
+func (en *EmailNotifier) signSilenceLinks(data *ExtendedData) {
+	expiresAt := timeNow().Add(en.SilenceLinkTTL)
+	for i := range data.Alerts {
+		if data.Alerts[i].SilenceURL == "" {
+			continue
+		}
+		signed, err := signSilenceURL(data.Alerts[i].SilenceURL, en.silenceLinkKey, expiresAt)
+		if err != nil {
+			en.log.Warn("failed to sign silence link", "error", err)
+		}
+		data.Alerts[i].SilenceURL = signed
+	}
+}
+
+// environmentPrefix returns the prefix of the subject with the common value of the
+// EnvironmentLabel, or an empty string if it is not set or the alerts have no common value.
+func (en *EmailNotifier) environmentPrefix(data *ExtendedData) string {
+	if en.EnvironmentLabel == "" {
+		return ""
+	}
+	env := data.CommonLabels[en.EnvironmentLabel]
+	if env == "" {
+		return ""
+	}
+	return "[" + strings.ToUpper(env) + "] "
+}
+
+// importance returns the importance of the email. If ImportanceLabel is set, it is the
+// highest importance among the values of the label in the alerts.
+func (en *EmailNotifier) importance(alerts []*types.Alert) string {
+	if en.ImportanceLabel == "" {
+		return en.Importance
+	}
+	var importance string
+	for _, a := range alerts {
+		v := strings.ToLower(string(a.Labels[model.LabelName(en.ImportanceLabel)]))
+		switch emailLabelImportance[v] {
+		case EmailImportanceHigh:
+			return EmailImportanceHigh
+		case EmailImportanceNormal:
+			importance = EmailImportanceNormal
+		case EmailImportanceLow:
+			if importance == "" {
+				importance = EmailImportanceLow
+			}
+		}
+	}
+	if importance == "" {
+		return en.Importance
+	}
+	return importance
+}
+
 func (en *EmailNotifier) SendResolved() bool {
 	return !en.GetDisableResolveMessage()
 }
+
+// Stop cancels the pending reminders, deferred notifications and the current batch of the
+// notifier.
+func (en *EmailNotifier) Stop() {
+	en.reminders.stop()
+	en.deferred.stop()
+	en.batch.stop()
+}
+
+// MaxDeferral returns the longest time a notification waits for its deferred send.
+func (en *EmailNotifier) MaxDeferral() time.Duration {
+	return en.deferred.maxWait()
+}
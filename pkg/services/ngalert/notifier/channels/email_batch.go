@@ -0,0 +1,344 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/common/model"
+)
+
+// DefaultEmailBatchMaxCount is the default number of notifications after which a batch is sent
+// even if its window has not expired yet.
+const DefaultEmailBatchMaxCount = 10
+
+// emailBatchDeadlineMargin is how long before the deadline of a notification waiting for its
+// batch the batch is sent, so that the notification does not time out before it is sent.
+const emailBatchDeadlineMargin = 5 * time.Second
+
+// errEmailBatchStopped is the error of the emails of a batch that was stopped before it was
+// sent, they are sent by the notifier that replaced it once retried.
+var errEmailBatchStopped = errors.New("the email batch was stopped before it was sent")
+
+// emailBatcher buffers the emails of a contact point for a window and coalesces them into a
+// single email that lists the alerts of all notifications. A batch is sent when its window
+// expires or when it contains the maximum number of notifications, whichever happens first,
+// and before the deadline of the notifications in it.
+//
+// Each notification waits for its batch to be sent and gets the result of the email it was
+// coalesced into, so that it is only marked as sent once it is, and is retried otherwise.
+type emailBatcher struct {
+	window   time.Duration
+	maxCount int
+	log      Logger
+	send     func(ctx context.Context, cmd *SendEmailSettings) (bool, error)
+
+	mtx     sync.Mutex
+	started time.Time
+	pending []*emailBatchEntry
+	timer   *time.Timer
+	// flushAt is when the timer sends the current batch, in the time of the timer.
+	flushAt time.Time
+}
+
+// emailBatchEntry is an email of a batch and the result of sending it.
+type emailBatchEntry struct {
+	cmd    *SendEmailSettings
+	result chan emailBatchResult
+}
+
+type emailBatchResult struct {
+	retry bool
+	err   error
+}
+
+func newEmailBatcher(window time.Duration, maxCount int, l Logger, send func(ctx context.Context, cmd *SendEmailSettings) (bool, error)) *emailBatcher {
+	return &emailBatcher{
+		window:   window,
+		maxCount: maxCount,
+		log:      l,
+		send:     send,
+	}
+}
+
+// add adds the email to the current batch and waits for the batch to be sent. The batch is
+// sent right away if this email fills it up or its window has expired. It returns the result
+// of sending the email, and whether it should be retried if it failed.
+//
+// If ctx is done before the batch is sent, the email is removed from the batch and should be
+// retried.
+func (b *emailBatcher) add(ctx context.Context, cmd *SendEmailSettings) (bool, error) {
+	e := &emailBatchEntry{cmd: cmd, result: make(chan emailBatchResult, 1)}
+	b.mtx.Lock()
+	if len(b.pending) == 0 {
+		b.started = timeNow()
+		b.schedule(b.window)
+	}
+	b.pending = append(b.pending, e)
+	if len(b.pending) >= b.maxCount || timeNow().Sub(b.started) >= b.window {
+		pending := b.take()
+		b.mtx.Unlock()
+		b.flush(ctx, pending)
+		r := <-e.result
+		return r.retry, r.err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if at := deadline.Add(-emailBatchDeadlineMargin); at.Before(b.flushAt) {
+			b.schedule(time.Until(at))
+		}
+	}
+	b.mtx.Unlock()
+
+	select {
+	case r := <-e.result:
+		return r.retry, r.err
+	case <-ctx.Done():
+		if b.remove(e) {
+			return true, ctx.Err()
+		}
+		// The batch is being sent, the result is not far off.
+		r := <-e.result
+		return r.retry, r.err
+	}
+}
+
+// schedule sends the current batch after d. It must be called with the lock held.
+func (b *emailBatcher) schedule(d time.Duration) {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.flushAt = time.Now().Add(d)
+	b.timer = time.AfterFunc(d, b.flushOnTimer)
+}
+
+func (b *emailBatcher) flushOnTimer() {
+	b.mtx.Lock()
+	pending := b.take()
+	b.mtx.Unlock()
+
+	b.flush(context.Background(), pending)
+}
+
+// flush sends the emails, coalesced into a single email per group of emails with the same
+// recipients and headers, and sends the result of each group to its emails.
+func (b *emailBatcher) flush(ctx context.Context, pending []*emailBatchEntry) {
+	for _, group := range groupEmailsByRecipients(pending) {
+		cmds := make([]*SendEmailSettings, 0, len(group))
+		for _, e := range group {
+			cmds = append(cmds, e.cmd)
+		}
+		retry, err := b.send(ctx, coalesceEmails(cmds))
+		if err != nil {
+			b.log.Warn("failed to send batched email", "emails", len(group), "retry", retry, "error", err)
+		}
+		for _, e := range group {
+			e.result <- emailBatchResult{retry: retry, err: err}
+		}
+	}
+}
+
+// remove removes the email from the current batch. It returns false if the email is not in
+// the batch, i.e. it is being sent.
+func (b *emailBatcher) remove(e *emailBatchEntry) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	for i, pending := range b.pending {
+		if pending == e {
+			b.pending = append(b.pending[:i:i], b.pending[i+1:]...)
+			if len(b.pending) == 0 {
+				b.take()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// stop fails the emails of the current batch, so that the notifications waiting for them are
+// retried, e.g. once the notifier is replaced by a new configuration.
+func (b *emailBatcher) stop() {
+	if b == nil {
+		return
+	}
+	b.mtx.Lock()
+	pending := b.take()
+	b.mtx.Unlock()
+	for _, e := range pending {
+		e.result <- emailBatchResult{retry: true, err: errEmailBatchStopped}
+	}
+}
+
+// take empties the current batch and returns its emails. It must be called with the lock held.
+func (b *emailBatcher) take() []*emailBatchEntry {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	pending := b.pending
+	b.pending = nil
+	return pending
+}
+
+// groupEmailsByRecipients groups the emails that have the same recipients and headers and are
+// signed with the same S/MIME certificate, in the order of their first email.
+func groupEmailsByRecipients(entries []*emailBatchEntry) [][]*emailBatchEntry {
+	var groups [][]*emailBatchEntry
+	index := make(map[string]int)
+	for _, e := range entries {
+		key := emailRecipientsKey(e.cmd)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], e)
+	}
+	return groups
+}
+
+// emailRecipientsKey returns the key of the emails that can be coalesced. The custom headers
+// are part of the key, as the coalesced email has the headers of its first email, except for
+// the headers of a single notification and the importance, which is the highest of the emails.
+func emailRecipientsKey(cmd *SendEmailSettings) string {
+	to := append([]string(nil), cmd.To...)
+	sort.Strings(to)
+	cc := append([]string(nil), cmd.Cc...)
+	sort.Strings(cc)
+	headers := make([]string, 0, len(cmd.Headers))
+	for k, v := range cmd.Headers {
+		if !emailBatchIgnoredHeader(k) {
+			headers = append(headers, k+": "+v)
+		}
+	}
+	sort.Strings(headers)
+	return strings.Join([]string{
+		strings.Join(to, ","),
+		strings.Join(cc, ","),
+		strconv.FormatBool(cmd.CopyToSender),
+		strconv.FormatBool(cmd.SingleEmail),
+		cmd.SMIMECertFile,
+		cmd.SMIMEKeyFile,
+		strings.Join(headers, "\n"),
+	}, "\n")
+}
+
+// emailBatchIgnoredHeader returns true if the header is not kept as is in a coalesced email:
+// the deduplication headers of a single notification, and the importance headers.
+func emailBatchIgnoredHeader(name string) bool {
+	switch name {
+	case EmailDedupHeader, "Message-Id", "Importance", "X-Priority":
+		return true
+	}
+	return false
+}
+
+// coalesceEmails merges emails with the same recipients and headers into a single email that contains the
+// alerts of all of them. The subject is taken from the first email, and the email has the
+// highest importance of the emails.
+func coalesceEmails(cmds []*SendEmailSettings) *SendEmailSettings {
+	if len(cmds) == 1 {
+		return cmds[0]
+	}
+
+	first := cmds[0]
+	status := string(model.AlertResolved)
+	var alerts ExtendedAlerts
+	var messages []string
+	var embeddedFiles []string
+	var attachedFiles []*SendEmailAttachFile
+	var rawAlerts []json.RawMessage
+	seenFiles := make(map[string]bool)
+	importance := ""
+	for _, cmd := range cmds {
+		if cmd.Data["Status"] == string(model.AlertFiring) {
+			status = string(model.AlertFiring)
+		}
+		if a, ok := cmd.Data["Alerts"].(ExtendedAlerts); ok {
+			alerts = append(alerts, a...)
+		}
+		if m, ok := cmd.Data["Message"].(string); ok && m != "" {
+			messages = append(messages, m)
+		}
+		for _, f := range cmd.EmbeddedFiles {
+			if !seenFiles[f] {
+				seenFiles[f] = true
+				embeddedFiles = append(embeddedFiles, f)
+			}
+		}
+		for _, f := range cmd.AttachedFiles {
+			// The alerts of all emails are attached as a single file.
+			var raw []json.RawMessage
+			if f.Name == EmailRawJSONAttachment && json.Unmarshal(f.Content, &raw) == nil {
+				rawAlerts = append(rawAlerts, raw...)
+				continue
+			}
+			attachedFiles = append(attachedFiles, f)
+		}
+		importance = higherEmailImportance(importance, emailHeadersImportance(cmd.Headers))
+	}
+	if rawAlerts != nil {
+		if raw, err := json.MarshalIndent(rawAlerts, "", "  "); err == nil {
+			attachedFiles = append(attachedFiles, &SendEmailAttachFile{Name: EmailRawJSONAttachment, Content: raw})
+		}
+	}
+
+	subject := fmt.Sprintf("%s (and %d more)", first.Subject, len(cmds)-1)
+	data := make(map[string]interface{}, len(first.Data))
+	for k, v := range first.Data {
+		data[k] = v
+	}
+	data["Title"] = subject
+	data["Message"] = strings.Join(messages, "\n\n")
+	data["Status"] = status
+	data["Alerts"] = alerts
+	data["GroupLabels"] = template.KV{}
+	data["CommonLabels"] = template.KV{}
+	data["CommonAnnotations"] = template.KV{}
+
+	// The digest is not about a single group, so it has no deduplication headers.
+	headers := make(map[string]string, len(first.Headers))
+	for k, v := range first.Headers {
+		if !emailBatchIgnoredHeader(k) {
+			headers[k] = v
+		}
+	}
+	for k, v := range emailImportanceHeaders[importance] {
+		headers[k] = v
+	}
+
+	coalesced := *first
+	coalesced.Subject = subject
+	coalesced.Headers = headers
+	coalesced.Data = data
+	coalesced.EmbeddedFiles = embeddedFiles
+	coalesced.AttachedFiles = attachedFiles
+	return &coalesced
+}
+
+// emailHeadersImportance returns the importance the headers mark the email with, or an empty
+// string if they don't.
+func emailHeadersImportance(headers map[string]string) string {
+	for importance, h := range emailImportanceHeaders {
+		if headers["Importance"] == h["Importance"] {
+			return importance
+		}
+	}
+	return ""
+}
+
+// higherEmailImportance returns the higher of the importances.
+func higherEmailImportance(a, b string) string {
+	rank := map[string]int{EmailImportanceLow: 1, EmailImportanceNormal: 2, EmailImportanceHigh: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,66 @@
+package channels
+
+import (
+	"fmt"
+	"unicode"
+)
+
+const (
+	EmailTextDirectionLTR  = "ltr"
+	EmailTextDirectionRTL  = "rtl"
+	EmailTextDirectionAuto = "auto"
+)
+
+// rtlScripts are the scripts that are written from right to left.
+var rtlScripts = []*unicode.RangeTable{
+	unicode.Arabic,
+	unicode.Hebrew,
+	unicode.Syriac,
+	unicode.Thaana,
+	unicode.Nko,
+}
+
+// parseEmailTextDirection parses the textDirection setting. The direction is detected from
+// the content of each email if it is not set.
+func parseEmailTextDirection(s string) (string, error) {
+	switch s {
+	case "":
+		return EmailTextDirectionAuto, nil
+	case EmailTextDirectionLTR, EmailTextDirectionRTL, EmailTextDirectionAuto:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid text direction %q, must be %q, %q or %q", s, EmailTextDirectionLTR, EmailTextDirectionRTL, EmailTextDirectionAuto)
+	}
+}
+
+// textDirection returns the direction of the text of the email. With EmailTextDirectionAuto,
+// it is right to left if most letters of the message and the annotations of the alerts are
+// in a right to left script.
+func (en *EmailNotifier) textDirection(message string, data *ExtendedData) string {
+	if en.TextDirection != "" && en.TextDirection != EmailTextDirectionAuto {
+		return en.TextDirection
+	}
+	var rtl, ltr int
+	count := func(s string) {
+		for _, r := range s {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			if unicode.In(r, rtlScripts...) {
+				rtl++
+			} else {
+				ltr++
+			}
+		}
+	}
+	count(message)
+	for _, alert := range data.Alerts {
+		for _, v := range alert.Annotations {
+			count(v)
+		}
+	}
+	if rtl > ltr {
+		return EmailTextDirectionRTL
+	}
+	return EmailTextDirectionLTR
+}
@@ -0,0 +1,68 @@
+package channels
+
+import (
+	"path/filepath"
+)
+
+// emailGalleryMaxImages is the maximum number of images shown in the gallery of an email.
+const emailGalleryMaxImages = 6
+
+// emailGallery shows the images of the alerts of an email in a grid, instead of after each
+// alert, when the alerts have more than one image.
+type emailGallery struct {
+	Images []emailGalleryImage
+	// More is the number of images of the alerts that are not shown.
+	More int
+}
+
+// emailGalleryImage is either an image with a URL or an image embedded in the email.
+type emailGalleryImage struct {
+	URL           string
+	EmbeddedImage string
+	// Alt is the name of the alert of the image.
+	Alt string
+}
+
+// newEmailGallery returns the gallery of the images of the alerts, or nil if they have
+// fewer than two images.
+func newEmailGallery(alerts ExtendedAlerts) *emailGallery {
+	var images []emailGalleryImage
+	for _, a := range alerts {
+		if a.ImageURL == "" && a.EmbeddedImage == "" {
+			continue
+		}
+		images = append(images, emailGalleryImage{
+			URL:           a.ImageURL,
+			EmbeddedImage: a.EmbeddedImage,
+			Alt:           a.Labels["alertname"],
+		})
+	}
+	if len(images) < 2 {
+		return nil
+	}
+	if len(images) > emailGalleryMaxImages {
+		return &emailGallery{Images: images[:emailGalleryMaxImages], More: len(images) - emailGalleryMaxImages}
+	}
+	return &emailGallery{Images: images}
+}
+
+// embeddedFiles returns the files of the embedded images that are shown in the gallery, as
+// the images of the alerts are not shown after each alert.
+func (g *emailGallery) embeddedFiles(files []string) []string {
+	if g == nil {
+		return files
+	}
+	shown := make(map[string]bool, len(g.Images))
+	for _, img := range g.Images {
+		if img.EmbeddedImage != "" {
+			shown[img.EmbeddedImage] = true
+		}
+	}
+	var kept []string
+	for _, f := range files {
+		if shown[filepath.Base(f)] {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,127 @@
+package channels
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// emailReservedHeaders are set by the mailer and cannot be overridden by custom headers.
+var emailReservedHeaders = map[string]struct{}{
+	"From":                      {},
+	"To":                        {},
+	"Cc":                        {},
+	"Bcc":                       {},
+	"Subject":                   {},
+	"Reply-To":                  {},
+	"Date":                      {},
+	"Message-Id":                {},
+	"Mime-Version":              {},
+	"Content-Type":              {},
+	"Content-Transfer-Encoding": {},
+}
+
+// EmailDedupHeader is the header with the deduplication key of the email, the same for all
+// emails about a group with the same status, so that receivers can correlate re-sends.
+const EmailDedupHeader = "X-Grafana-Dedup"
+
+// emailDedupKey returns the deduplication key of the emails about the group with the status.
+// The dashboard UID tells apart the emails of a group split by dashboard.
+func emailDedupKey(groupKey, status, dashboardUID string) string {
+	h := sha256.New()
+	for _, s := range []string{groupKey, status, dashboardUID} {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// withDedupHeaders returns a copy of the headers with the deduplication key, and a Message-Id
+// derived from it if messageID is set. The host is the domain of the Message-Id.
+func withDedupHeaders(headers map[string]string, key string, host string, messageID bool) map[string]string {
+	result := make(map[string]string, len(headers)+2)
+	for k, v := range headers {
+		result[k] = v
+	}
+	result[EmailDedupHeader] = key
+	if messageID {
+		if host == "" {
+			host = "grafana"
+		}
+		result["Message-Id"] = "<" + key + "@" + host + ">"
+	}
+	return result
+}
+
+// parseEmailHeaders parses the custom headers of the email. Header names that are templates
+// are validated once they are rendered.
+func parseEmailHeaders(settings *simplejson.Json) (map[string]string, error) {
+	raw, err := settings.Map()
+	if err != nil {
+		if settings.Interface() == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid headers: %w", err)
+	}
+	headers := make(map[string]string, len(raw))
+	for name, v := range raw {
+		value, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value of header %q, must be a string", name)
+		}
+		if !strings.Contains(name, "{{") {
+			if err := validateEmailHeaderName(name); err != nil {
+				return nil, err
+			}
+		}
+		headers[name] = value
+	}
+	return headers, nil
+}
+
+// validateEmailHeaderName returns an error if the name is not a valid header field name
+// (RFC 5322) or is a header set by the mailer.
+func validateEmailHeaderName(name string) error {
+	if name == "" {
+		return fmt.Errorf("invalid header name %q", name)
+	}
+	for _, c := range name {
+		if c < '!' || c > '~' || c == ':' {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	if _, ok := emailReservedHeaders[textproto.CanonicalMIMEHeaderKey(name)]; ok {
+		return fmt.Errorf("header %q cannot be set", name)
+	}
+	return nil
+}
+
+// renderEmailHeaders returns the headers with the custom headers rendered and added to them.
+// Headers whose name renders invalid, or whose value renders empty, are not added.
+func renderEmailHeaders(headers map[string]string, custom map[string]string, render func(string) string, l Logger) map[string]string {
+	if len(custom) == 0 {
+		return headers
+	}
+	result := make(map[string]string, len(headers)+len(custom))
+	for k, v := range headers {
+		result[k] = v
+	}
+	for name, value := range custom {
+		name = strings.TrimSpace(render(name))
+		if err := validateEmailHeaderName(name); err != nil {
+			l.Warn("failed to render email header", "error", err)
+			continue
+		}
+		// Line breaks would end the header, they are replaced to not inject other headers.
+		value = strings.TrimSpace(strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(render(value)))
+		if value == "" {
+			continue
+		}
+		result[name] = value
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package channels
+
+import (
+	"net/mail"
+	"sort"
+	"strings"
+)
+
+// emailOptOut are the addresses that must not receive alert emails, in lower case.
+// A nil emailOptOut removes no recipients.
+type emailOptOut map[string]struct{}
+
+func newEmailOptOut(addresses []string) emailOptOut {
+	if len(addresses) == 0 {
+		return nil
+	}
+	optOut := make(emailOptOut, len(addresses))
+	for _, a := range addresses {
+		optOut[strings.ToLower(emailAddress(a))] = struct{}{}
+	}
+	return optOut
+}
+
+// remove returns the recipients without the addresses that opted out, and the removed
+// recipients. Recipients are matched by their address, regardless of their display name.
+func (o emailOptOut) remove(recipients []string) (kept []string, removed []string) {
+	if len(o) == 0 {
+		return recipients, nil
+	}
+	kept = make([]string, 0, len(recipients))
+	for _, r := range recipients {
+		if _, ok := o[strings.ToLower(emailAddress(r))]; ok {
+			removed = append(removed, r)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept, removed
+}
+
+// addresses returns the addresses that opted out, sorted.
+func (o emailOptOut) addresses() []string {
+	if len(o) == 0 {
+		return nil
+	}
+	addresses := make([]string, 0, len(o))
+	for a := range o {
+		addresses = append(addresses, a)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// emailAddress returns the address of the recipient without its display name, or the
+// recipient itself if it cannot be parsed.
+func emailAddress(recipient string) string {
+	addr, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return strings.TrimSpace(recipient)
+	}
+	return addr.Address
+}
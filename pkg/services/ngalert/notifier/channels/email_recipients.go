@@ -0,0 +1,171 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// RecipientResolver resolves the addresses an email is sent to, e.g. from the labels of the
+// alerts and an external directory.
+type RecipientResolver interface {
+	// ResolveRecipients returns the addresses to send the email about the alerts to.
+	ResolveRecipients(ctx context.Context, alerts []*types.Alert) ([]string, error)
+}
+
+// StaticRecipientResolver sends all emails to the same addresses. It is the default resolver
+// and uses the addresses of the contact point.
+type StaticRecipientResolver []string
+
+func (r StaticRecipientResolver) ResolveRecipients(_ context.Context, _ []*types.Alert) ([]string, error) {
+	return r, nil
+}
+
+// MultiRecipientResolver sends emails to the addresses of all its resolvers, in order and
+// without duplicates. Combined with a StaticRecipientResolver it supplements the addresses of
+// the contact point instead of overriding them.
+type MultiRecipientResolver []RecipientResolver
+
+func (r MultiRecipientResolver) ResolveRecipients(ctx context.Context, alerts []*types.Alert) ([]string, error) {
+	var addresses []string
+	seen := map[string]struct{}{}
+	for _, resolver := range r {
+		resolved, err := resolver.ResolveRecipients(ctx, alerts)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range resolved {
+			if _, ok := seen[address]; ok {
+				continue
+			}
+			seen[address] = struct{}{}
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses, nil
+}
+
+// EmailCCRule copies emails about groups with an alert that matches all of its matchers to
+// its addresses.
+type EmailCCRule struct {
+	Matchers  labels.Matchers
+	Addresses []string
+}
+
+// matches returns true if any of the alerts matches all matchers of the rule.
+func (r EmailCCRule) matches(alerts []*types.Alert) bool {
+	for _, alert := range alerts {
+		if r.Matchers.Matches(alert.Labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEmailCCRules parses the ccRules setting, a list of objects with the matchers, e.g.
+// severity=critical, and the addresses of each rule.
+func parseEmailCCRules(settings *simplejson.Json) ([]EmailCCRule, error) {
+	var raw []struct {
+		Matchers  []string `json:"matchers"`
+		Addresses string   `json:"addresses"`
+	}
+	b, err := settings.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("invalid CC rules: %w", err)
+	}
+
+	rules := make([]EmailCCRule, 0, len(raw))
+	for i, r := range raw {
+		rule := EmailCCRule{Addresses: util.SplitEmails(r.Addresses)}
+		if len(rule.Addresses) == 0 {
+			return nil, fmt.Errorf("CC rule %d has no addresses", i)
+		}
+		for _, s := range r.Matchers {
+			m, err := labels.ParseMatcher(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid matcher %q in CC rule %d: %w", s, i, err)
+			}
+			rule.Matchers = append(rule.Matchers, m)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ccRecipients returns the static carbon copy addresses and those of the rules matching the
+// alerts, in order and without duplicates.
+func ccRecipients(static []string, rules []EmailCCRule, alerts []*types.Alert) []string {
+	var addresses []string
+	seen := map[string]struct{}{}
+	add := func(address string) {
+		if _, ok := seen[address]; ok {
+			return
+		}
+		seen[address] = struct{}{}
+		addresses = append(addresses, address)
+	}
+	for _, address := range static {
+		add(address)
+	}
+	for _, rule := range rules {
+		if !rule.matches(alerts) {
+			continue
+		}
+		for _, address := range rule.Addresses {
+			add(address)
+		}
+	}
+	return addresses
+}
+
+// splitEmailByDomain returns a copy of the email for each domain of its recipients, in the
+// order the domains first appear, each sent to the recipients of the domain. Carbon copy
+// recipients go with the email of their domain, or with the first email if none of the
+// recipients has their domain. Only the first email is copied to the sender.
+func splitEmailByDomain(cmd *SendEmailSettings) []*SendEmailSettings {
+	var emails []*SendEmailSettings
+	byDomain := map[string]*SendEmailSettings{}
+	for _, address := range cmd.To {
+		domain := emailDomain(address)
+		email, ok := byDomain[domain]
+		if !ok {
+			c := *cmd
+			c.To, c.Cc = nil, nil
+			c.CopyToSender = cmd.CopyToSender && len(emails) == 0
+			email = &c
+			byDomain[domain] = email
+			emails = append(emails, email)
+		}
+		email.To = append(email.To, address)
+	}
+	if len(emails) == 0 {
+		return []*SendEmailSettings{cmd}
+	}
+	for _, address := range cmd.Cc {
+		email, ok := byDomain[emailDomain(address)]
+		if !ok {
+			email = emails[0]
+		}
+		email.Cc = append(email.Cc, address)
+	}
+	return emails
+}
+
+// emailDomain returns the lowercased domain of the address, or an empty string if it has none.
+func emailDomain(address string) string {
+	i := strings.LastIndex(address, "@")
+	if i < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimRight(address[i+1:], ">"))
+}
@@ -0,0 +1,205 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// RunbookURLAnnotation is the annotation with the URL of the runbook of an alert.
+	RunbookURLAnnotation = "runbook_url"
+	// runbookExcerptLength is the maximum number of characters of runbook excerpts.
+	runbookExcerptLength = 500
+)
+
+// RunbookSettings limit the runbooks whose content is included in emails.
+type RunbookSettings struct {
+	// AllowedHosts are the hosts runbooks can be fetched from. No runbook is fetched if it
+	// is empty.
+	AllowedHosts []string
+	// MaxSize is the number of bytes of a runbook that are read, and Timeout bounds the time
+	// to fetch it.
+	MaxSize int64
+	Timeout time.Duration
+}
+
+// runbookFetcher fetches runbooks from the allowed hosts, and extracts excerpts of their text.
+type runbookFetcher struct {
+	settings RunbookSettings
+	client   *http.Client
+}
+
+func newRunbookFetcher(settings RunbookSettings) *runbookFetcher {
+	f := &runbookFetcher{settings: settings}
+	f.client = &http.Client{
+		Timeout: settings.Timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		// Redirects are only followed to the allowed hosts.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if !f.allowed(req.URL) {
+				return fmt.Errorf("redirect to %s, which is not an allowed runbook host", req.URL.Host)
+			}
+			return nil
+		},
+	}
+	return f
+}
+
+// allowed returns true if runbooks can be fetched from u.
+func (f *runbookFetcher) allowed(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	for _, host := range f.settings.AllowedHosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// excerpt fetches the runbook at rawURL and returns an excerpt of its text, without markup.
+func (f *runbookFetcher) excerpt(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid runbook URL: %w", err)
+	}
+	if !f.allowed(u) {
+		return "", fmt.Errorf("%s is not an allowed runbook host", u.Host)
+	}
+	if f.settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.settings.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html, text/plain")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return "", fmt.Errorf("invalid content type: %w", err)
+	}
+	if !strings.HasPrefix(mediaType, "text/") {
+		return "", fmt.Errorf("unsupported content type %s", mediaType)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.settings.MaxSize))
+	if err != nil {
+		return "", err
+	}
+	text := string(body)
+	if mediaType == "text/html" {
+		text = htmlText(text)
+	}
+	return runbookExcerpt(text), nil
+}
+
+// htmlText returns the text of the HTML document, without the content of scripts and styles.
+func htmlText(s string) string {
+	var b strings.Builder
+	var skip int
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			// The document is either complete or truncated by the size limit.
+			return b.String()
+		case html.StartTagToken:
+			if isNonTextTag(z) {
+				skip++
+			}
+		case html.EndTagToken:
+			if isNonTextTag(z) && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(z.Text())
+				b.WriteByte(' ')
+			}
+		}
+	}
+}
+
+func isNonTextTag(z *html.Tokenizer) bool {
+	name, _ := z.TagName()
+	switch string(name) {
+	case "script", "style", "noscript", "template", "head":
+		return true
+	}
+	return false
+}
+
+// runbookExcerpt returns the first runbookExcerptLength characters of the text, with its
+// whitespace collapsed and without control and invalid characters.
+func runbookExcerpt(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= runbookExcerptLength {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:runbookExcerptLength-1])) + "…"
+}
+
+// addRunbookExcerpts sets the runbook excerpt of the alerts with a runbook. Emails still link
+// to the runbooks that cannot be fetched. Each runbook is fetched once.
+func (en *EmailNotifier) addRunbookExcerpts(ctx context.Context, data *ExtendedData) {
+	excerpts := make(map[string]string)
+	for i, alert := range data.Alerts {
+		u := alert.Annotations[RunbookURLAnnotation]
+		if u == "" {
+			continue
+		}
+		excerpt, ok := excerpts[u]
+		if !ok {
+			var err error
+			excerpt, err = en.runbooks.excerpt(ctx, u)
+			if err != nil {
+				en.log.Warn("failed to fetch runbook, linking to it instead", "url", u, "error", err)
+			}
+			excerpt = en.RedactPatterns.Redact(excerpt)
+			excerpts[u] = excerpt
+		}
+		data.Alerts[i].RunbookExcerpt = excerpt
+	}
+}
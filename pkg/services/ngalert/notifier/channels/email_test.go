@@ -2,15 +2,33 @@ package channels
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/notifications"
 )
 
@@ -47,7 +65,7 @@ func TestEmailNotifier(t *testing.T) {
 			Settings: json.RawMessage(jsonData),
 		})
 		require.NoError(t, err)
-		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl)
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
 
 		alerts := []*types.Alert{
 			{
@@ -95,14 +113,1764 @@ func TestEmailNotifier(t *testing.T) {
 				"ExternalURL":       "http://localhost/base",
 				"RuleUrl":           "http://localhost/base/alerting/list",
 				"AlertPageUrl":      "http://localhost/base/alerting/list?alertState=firing&view=state",
+				"TextDirection":     "ltr",
+				"ImageGallery":      (*emailGallery)(nil),
 			},
 		}, expected)
 	})
+
+	t.Run("with external URL override links use the override", func(t *testing.T) {
+		jsonData := `{
+			"addresses": "someops@example.com",
+			"externalURLOverride": "https://grafana.example.com/public"
+		}`
+
+		emailSender := mockNotificationService()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(jsonData),
+		})
+		require.NoError(t, err)
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		alerts := []*types.Alert{
+			{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": "AlwaysFiring", "severity": "warning"},
+					Annotations: model.LabelSet{"__dashboardUid__": "abc", "__panelId__": "5"},
+				},
+			},
+		}
+
+		ok, err := emailNotifier.Notify(context.Background(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		data := emailSender.EmailSync.Data
+		require.Equal(t, "https://grafana.example.com/public", data["ExternalURL"])
+		require.Equal(t, "https://grafana.example.com/public/alerting/list", data["RuleUrl"])
+		extendedAlerts := data["Alerts"].(ExtendedAlerts)
+		require.Len(t, extendedAlerts, 1)
+		require.Equal(t, "https://grafana.example.com/public/d/abc", extendedAlerts[0].DashboardURL)
+		require.Equal(t, "https://grafana.example.com/public/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DAlwaysFiring&matcher=severity%3Dwarning", extendedAlerts[0].SilenceURL)
+		// The shared template must not be modified.
+		require.Equal(t, "http://localhost/base", tmpl.ExternalURL.String())
+	})
+
+	t.Run("partial delivery failure reports the failed recipients", func(t *testing.T) {
+		errInvalid := errors.New("invalid address")
+		emailSender := mockNotificationService()
+		emailSender.EmailErrors = map[string]error{"unknown@example.com": errInvalid}
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com;unknown@example.com"}`),
+		})
+		require.NoError(t, err)
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+		emailNotifier.retries = NewRetryBudget(1, time.Hour)
+
+		ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+			Alert: model.Alert{Labels: model.LabelSet{"alertname": "AlwaysFiring"}},
+		})
+		// The email is not retried although the retry budget allows it, so that the
+		// recipients that got it don't get it again.
+		require.False(t, ok)
+		var deliveryErr *EmailDeliveryError
+		require.ErrorAs(t, err, &deliveryErr)
+		require.Equal(t, []EmailRecipientResult{
+			{Address: "someops@example.com"},
+			{Address: "unknown@example.com", Error: errInvalid},
+		}, deliveryErr.Result.Recipients)
+	})
+
+	t.Run("resolved templates are used for resolved groups only", func(t *testing.T) {
+		jsonData := `{
+			"addresses": "someops@example.com",
+			"subject": "Firing subject",
+			"message": "Firing message",
+			"resolvedSubject": "Resolved {{ len .Alerts.Resolved }}",
+			"resolvedMessage": "All good again"
+		}`
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(jsonData),
+		})
+		require.NoError(t, err)
+
+		cases := []struct {
+			name       string
+			endsAt     time.Time
+			expSubject string
+			expMessage string
+		}{
+			{
+				name:       "firing group",
+				expSubject: "Firing subject",
+				expMessage: "Firing message",
+			},
+			{
+				name:       "resolved group",
+				endsAt:     time.Now().Add(-time.Minute),
+				expSubject: "Resolved 1",
+				expMessage: "All good again",
+			},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				emailSender := mockNotificationService()
+				emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+				ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "AlwaysFiring"},
+						EndsAt: c.endsAt,
+					},
+				})
+				require.NoError(t, err)
+				require.True(t, ok)
+				require.Equal(t, c.expSubject, emailSender.EmailSync.Subject)
+				require.Equal(t, c.expMessage, emailSender.EmailSync.Data["Message"])
+			})
+		}
+	})
+
+	t.Run("resolved group falls back to the firing templates", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "subject": "Subject {{ .Status }}", "message": "Message"}`),
+		})
+		require.NoError(t, err)
+		emailSender := mockNotificationService()
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		_, err = emailNotifier.Notify(context.Background(), &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "AlwaysFiring"},
+				EndsAt: time.Now().Add(-time.Minute),
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Subject resolved", emailSender.EmailSync.Subject)
+		require.Equal(t, "Message", emailSender.EmailSync.Data["Message"])
+	})
+
+	t.Run("invalid external URL override should return error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "externalURLOverride": "not a url"}`),
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("importance is derived from the importance label", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "importance": "low", "importanceLabel": "severity"}`),
+		})
+		require.NoError(t, err)
+
+		cases := []struct {
+			name          string
+			severities    []model.LabelValue
+			expImportance string
+		}{
+			{
+				name:          "highest severity wins",
+				severities:    []model.LabelValue{"info", "Critical", "warning"},
+				expImportance: "High",
+			},
+			{
+				name:          "warning is normal",
+				severities:    []model.LabelValue{"info", "warning"},
+				expImportance: "Normal",
+			},
+			{
+				name:          "unknown severity uses the importance",
+				severities:    []model.LabelValue{"unknown", ""},
+				expImportance: "Low",
+			},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				emailSender := mockNotificationService()
+				emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+				var alerts []*types.Alert
+				for i, severity := range c.severities {
+					alerts = append(alerts, &types.Alert{
+						Alert: model.Alert{
+							Labels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert%d", i)), "severity": severity},
+						},
+					})
+				}
+				ok, err := emailNotifier.Notify(context.Background(), alerts...)
+				require.NoError(t, err)
+				require.True(t, ok)
+				require.Equal(t, c.expImportance, emailSender.EmailSync.Headers["Importance"])
+			})
+		}
+	})
+
+	t.Run("invalid importance should return error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "importance": "urgent"}`),
+		})
+		require.EqualError(t, err, `invalid importance "urgent", must be "low", "normal" or "high"`)
+	})
+
+	t.Run("broken templates fall back to the default template", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "subject": "{{ template \"missing\" . }}", "message": "{{ .Missing.Field }}"}`),
+		})
+		require.NoError(t, err)
+		emailSender := mockNotificationService()
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "AlwaysFiring", "severity": "warning"},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "[FIRING:1]  (AlwaysFiring warning)", emailSender.EmailSync.Subject)
+		require.Equal(t, TemplateErrorNotice, emailSender.EmailSync.Data["Message"])
+	})
+}
+
+// teamRecipientResolver resolves the recipients from the team label of the alerts.
+type teamRecipientResolver struct {
+	addresses map[model.LabelValue][]string
+	err       error
+}
+
+func (r teamRecipientResolver) ResolveRecipients(_ context.Context, alerts []*types.Alert) ([]string, error) {
+	var addresses []string
+	for _, a := range alerts {
+		addresses = append(addresses, r.addresses[a.Labels["team"]]...)
+	}
+	return addresses, r.err
+}
+
+func TestEmailNotifierRecipientResolver(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "someops@example.com"}`),
+	})
+	require.NoError(t, err)
+
+	teams := teamRecipientResolver{addresses: map[model.LabelValue][]string{
+		"db":  {"dba@example.com"},
+		"web": {"web@example.com", "someops@example.com"},
+	}}
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "db"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2", "team": "web"}}},
+	}
+
+	cases := []struct {
+		name     string
+		resolver RecipientResolver
+		expTo    []string
+		expErr   string
+	}{
+		{
+			name:  "addresses of the config are used by default",
+			expTo: []string{"someops@example.com"},
+		},
+		{
+			name:     "resolver overrides the addresses of the config",
+			resolver: teams,
+			expTo:    []string{"dba@example.com", "web@example.com", "someops@example.com"},
+		},
+		{
+			name:     "resolver supplements the addresses of the config",
+			resolver: MultiRecipientResolver{StaticRecipientResolver(cfg.Addresses), teams},
+			expTo:    []string{"someops@example.com", "dba@example.com", "web@example.com"},
+		},
+		{
+			name:     "resolver without recipients fails",
+			resolver: teamRecipientResolver{},
+			expErr:   "no recipients to send the email to",
+		},
+		{
+			name:     "resolver error fails",
+			resolver: teamRecipientResolver{err: errors.New("directory unavailable")},
+			expErr:   "failed to resolve recipients: directory unavailable",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			emailSender := mockNotificationService()
+			emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, c.resolver)
+
+			ok, err := emailNotifier.Notify(context.Background(), alerts...)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				require.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, c.expTo, emailSender.EmailSync.To)
+		})
+	}
+}
+
+func TestEmailNotifierCCRules(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name: "ops",
+		Type: "email",
+		Settings: json.RawMessage(`{
+			"addresses": "someops@example.com",
+			"cc": "team@example.com",
+			"ccRules": [
+				{"matchers": ["severity=critical"], "addresses": "escalation@example.com;team@example.com"},
+				{"matchers": ["severity=~warning|critical", "team=db"], "addresses": "dba@example.com"}
+			]
+		}`),
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		alerts []*types.Alert
+		expCC  []string
+	}{
+		{
+			name: "critical group adds the escalation CC",
+			alerts: []*types.Alert{
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "severity": "warning"}}},
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2", "severity": "critical"}}},
+			},
+			expCC: []string{"team@example.com", "escalation@example.com"},
+		},
+		{
+			name: "warning group only has the static CC",
+			alerts: []*types.Alert{
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "severity": "warning"}}},
+			},
+			expCC: []string{"team@example.com"},
+		},
+		{
+			name: "all matchers of a rule must match the same alert",
+			alerts: []*types.Alert{
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "severity": "warning", "team": "db"}}},
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2", "team": "web"}}},
+			},
+			expCC: []string{"team@example.com", "dba@example.com"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			emailSender := mockNotificationService()
+			emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+			ok, err := emailNotifier.Notify(context.Background(), c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, []string{"someops@example.com"}, emailSender.EmailSync.To)
+			require.Equal(t, c.expCC, emailSender.EmailSync.Cc)
+		})
+	}
+
+	t.Run("invalid CC rules should return error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "ccRules": [{"matchers": ["severity"], "addresses": "escalation@example.com"}]}`),
+		})
+		require.ErrorContains(t, err, `invalid matcher "severity" in CC rule 0`)
+
+		_, err = NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "ccRules": [{"matchers": ["severity=critical"]}]}`),
+		})
+		require.EqualError(t, err, "CC rule 0 has no addresses")
+	})
+}
+
+func TestEmailNotifierGroupByDomain(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name: "ops",
+		Type: "email",
+		Settings: json.RawMessage(`{
+			"addresses": "a@example.com;b@partner.org;c@Example.com",
+			"cc": "lead@partner.org;manager@corp.net",
+			"singleEmail": true,
+			"copyToSender": true,
+			"groupByDomain": true
+		}`),
+	})
+	require.NoError(t, err)
+	require.True(t, cfg.GroupByDomain)
+
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	t.Run("sends an email per domain of the recipients", func(t *testing.T) {
+		emailSender := mockNotificationService()
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		ok, err := emailNotifier.Notify(context.Background(), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Len(t, emailSender.Emails, 2)
+		require.Equal(t, []string{"a@example.com", "c@Example.com"}, emailSender.Emails[0].To)
+		require.Equal(t, []string{"b@partner.org"}, emailSender.Emails[1].To)
+		// CC recipients without a domain of the recipients get the first email.
+		require.Equal(t, []string{"manager@corp.net"}, emailSender.Emails[0].Cc)
+		require.Equal(t, []string{"lead@partner.org"}, emailSender.Emails[1].Cc)
+		require.True(t, emailSender.Emails[0].CopyToSender)
+		require.False(t, emailSender.Emails[1].CopyToSender)
+		require.Equal(t, emailSender.Emails[0].Subject, emailSender.Emails[1].Subject)
+	})
+
+	t.Run("reports the recipients of all failed emails", func(t *testing.T) {
+		emailSender := mockNotificationService()
+		emailSender.EmailErrors = map[string]error{"b@partner.org": errors.New("mailbox unavailable")}
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		_, err := emailNotifier.Notify(context.Background(), alert)
+		require.EqualError(t, err, "failed to send email to 1 of 3 recipients: b@partner.org: mailbox unavailable")
+		require.Len(t, emailSender.Emails, 2)
+	})
+
+	t.Run("recipients are not grouped without single email", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "a@example.com;b@partner.org", "groupByDomain": true}`),
+		})
+		require.NoError(t, err)
+		emailSender := mockNotificationService()
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		ok, err := emailNotifier.Notify(context.Background(), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, emailSender.Emails, 1)
+		require.Equal(t, []string{"a@example.com", "b@partner.org"}, emailSender.Emails[0].To)
+	})
+}
+
+func TestEmailNotifierSplitByDashboard(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "ops@example.com", "singleEmail": true, "splitByDashboard": true}`),
+	})
+	require.NoError(t, err)
+	require.True(t, cfg.SplitByDashboard)
+
+	newAlert := func(name, dashboardUID string) *types.Alert {
+		a := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name)}, Annotations: model.LabelSet{}}}
+		if dashboardUID != "" {
+			a.Annotations["__dashboardUid__"] = model.LabelValue(dashboardUID)
+		}
+		return a
+	}
+	alertNames := func(email SendEmailSettings) []string {
+		var names []string
+		for _, a := range email.Data["Alerts"].(ExtendedAlerts) {
+			names = append(names, a.Labels["alertname"])
+		}
+		return names
+	}
+
+	t.Run("sends an email per dashboard", func(t *testing.T) {
+		emailSender := mockNotificationService()
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		ok, err := emailNotifier.Notify(context.Background(), newAlert("alert1", "abc"), newAlert("alert2", "def"), newAlert("alert3", "abc"))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Len(t, emailSender.Emails, 2)
+		require.Equal(t, []string{"alert1", "alert3"}, alertNames(emailSender.Emails[0]))
+		require.Equal(t, []string{"alert2"}, alertNames(emailSender.Emails[1]))
+		for _, email := range emailSender.Emails {
+			require.Equal(t, []string{"ops@example.com"}, email.To)
+		}
+	})
+
+	t.Run("alerts without a dashboard are sent in an email of their own", func(t *testing.T) {
+		emailSender := mockNotificationService()
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		ok, err := emailNotifier.Notify(context.Background(), newAlert("alert1", ""), newAlert("alert2", "abc"), newAlert("alert3", ""))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Len(t, emailSender.Emails, 2)
+		require.Equal(t, []string{"alert2"}, alertNames(emailSender.Emails[0]))
+		require.Equal(t, []string{"alert1", "alert3"}, alertNames(emailSender.Emails[1]))
+	})
+
+	t.Run("the emails of the other dashboards are sent if one fails", func(t *testing.T) {
+		emailSender := &failingEmailSender{notificationServiceMock: mockNotificationService(), fail: 1}
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		_, err := emailNotifier.Notify(context.Background(), newAlert("alert1", "abc"), newAlert("alert2", "def"))
+		require.Error(t, err)
+		require.Len(t, emailSender.Emails, 2)
+	})
+
+	t.Run("alerts are not split without the option", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "ops@example.com"}`),
+		})
+		require.NoError(t, err)
+		emailSender := mockNotificationService()
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		_, err = emailNotifier.Notify(context.Background(), newAlert("alert1", "abc"), newAlert("alert2", "def"), newAlert("alert3", ""))
+		require.NoError(t, err)
+		require.Len(t, emailSender.Emails, 1)
+		require.Equal(t, []string{"alert1", "alert2", "alert3"}, alertNames(emailSender.Emails[0]))
+	})
+}
+
+// failingEmailSender fails the email with the index fail, and sends the others.
+type failingEmailSender struct {
+	*notificationServiceMock
+	fail int
+}
+
+func (s *failingEmailSender) SendEmail(ctx context.Context, cmd *SendEmailSettings) (EmailSendResult, error) {
+	if len(s.Emails) == s.fail {
+		s.ShouldError = errors.New("mailbox unavailable")
+	} else {
+		s.ShouldError = nil
+	}
+	return s.notificationServiceMock.SendEmail(ctx, cmd)
+}
+
+func TestEmailNotifierDedupHeaders(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://grafana.example.com:3000/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(t *testing.T, settings string) (*EmailNotifier, *notificationServiceMock) {
+		t.Helper()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(settings),
+		})
+		require.NoError(t, err)
+		emailSender := mockNotificationService()
+		return NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil), emailSender
+	}
+	groupCtx := func(groupKey string) context.Context {
+		ctx := notify.WithGroupKey(context.Background(), groupKey)
+		return notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	}
+	firing := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+	resolved := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert1"},
+		StartsAt: time.Now().Add(-time.Hour),
+		EndsAt:   time.Now().Add(-time.Minute),
+	}}
+
+	t.Run("the dedup header is stable for the same group and status", func(t *testing.T) {
+		emailNotifier, emailSender := newNotifier(t, `{"addresses": "ops@example.com"}`)
+
+		for _, a := range []*types.Alert{firing, firing, resolved} {
+			_, err := emailNotifier.Notify(groupCtx("{}:{alertname=\"alert1\"}"), a)
+			require.NoError(t, err)
+		}
+		_, err := emailNotifier.Notify(groupCtx("{}:{alertname=\"alert2\"}"), firing)
+		require.NoError(t, err)
+
+		require.Len(t, emailSender.Emails, 4)
+		key := emailSender.Emails[0].Headers[EmailDedupHeader]
+		require.Len(t, key, 64)
+		require.Equal(t, key, emailSender.Emails[1].Headers[EmailDedupHeader])
+		require.NotEqual(t, key, emailSender.Emails[2].Headers[EmailDedupHeader], "a resolved notification should have another key")
+		require.NotEqual(t, key, emailSender.Emails[3].Headers[EmailDedupHeader], "another group should have another key")
+		// The Message-Id is only set if it should be stable.
+		require.NotContains(t, emailSender.Emails[0].Headers, "Message-Id")
+	})
+
+	t.Run("the message id is derived from the dedup key", func(t *testing.T) {
+		emailNotifier, emailSender := newNotifier(t, `{"addresses": "ops@example.com", "stableMessageId": true}`)
+
+		for i := 0; i < 2; i++ {
+			_, err := emailNotifier.Notify(groupCtx("{}:{alertname=\"alert1\"}"), firing)
+			require.NoError(t, err)
+		}
+
+		require.Len(t, emailSender.Emails, 2)
+		key := emailSender.Emails[0].Headers[EmailDedupHeader]
+		require.Equal(t, "<"+key+"@grafana.example.com>", emailSender.Emails[0].Headers["Message-Id"])
+		require.Equal(t, emailSender.Emails[0].Headers["Message-Id"], emailSender.Emails[1].Headers["Message-Id"])
+	})
+
+	t.Run("emails without a group have no dedup header", func(t *testing.T) {
+		emailNotifier, emailSender := newNotifier(t, `{"addresses": "ops@example.com", "stableMessageId": true}`)
+
+		_, err := emailNotifier.Notify(context.Background(), firing)
+		require.NoError(t, err)
+		require.Empty(t, emailSender.EmailSync.Headers)
+	})
+}
+
+func TestEmailNotifierAttachRawJSON(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(t *testing.T, settings string) (*EmailNotifier, *notificationServiceMock) {
+		t.Helper()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(settings),
+		})
+		require.NoError(t, err)
+		emailSender := mockNotificationService()
+		return NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil), emailSender
+	}
+	startsAt := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+	alerts := []*types.Alert{
+		{Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "alert1", "severity": "critical"},
+			Annotations:  model.LabelSet{"summary": "disk full"},
+			StartsAt:     startsAt,
+			GeneratorURL: "http://localhost/alerting/1",
+		}},
+		{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert2"},
+			StartsAt: startsAt,
+			EndsAt:   startsAt.Add(time.Hour),
+		}},
+	}
+
+	t.Run("the alerts are attached as JSON", func(t *testing.T) {
+		emailNotifier, emailSender := newNotifier(t, `{"addresses": "ops@example.com", "attachRawJSON": true}`)
+
+		_, err := emailNotifier.Notify(context.Background(), alerts...)
+		require.NoError(t, err)
+
+		require.Len(t, emailSender.EmailSync.AttachedFiles, 1)
+		file := emailSender.EmailSync.AttachedFiles[0]
+		require.Equal(t, EmailRawJSONAttachment, file.Name)
+		var attached []*types.Alert
+		require.NoError(t, json.Unmarshal(file.Content, &attached))
+		require.Equal(t, alerts, attached)
+	})
+
+	t.Run("nothing is attached by default", func(t *testing.T) {
+		emailNotifier, emailSender := newNotifier(t, `{"addresses": "ops@example.com"}`)
+
+		_, err := emailNotifier.Notify(context.Background(), alerts...)
+		require.NoError(t, err)
+		require.Empty(t, emailSender.EmailSync.AttachedFiles)
+	})
+}
+
+func TestEmailNotifierIncludeRunbook(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/runbook":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<html><head><title>Disk full</title><style>p { color: red; }</style></head>
+<body><h1>Disk full</h1><script>alert("x")</script><p>Delete the <b>old</b> logs.</p></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	newNotifier := func(t *testing.T, allowedHosts ...string) (*EmailNotifier, *notificationServiceMock) {
+		t.Helper()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "ops@example.com", "includeRunbook": true}`),
+		})
+		require.NoError(t, err)
+		emailSender := mockNotificationService()
+		en := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+		en.runbooks = newRunbookFetcher(RunbookSettings{AllowedHosts: allowedHosts, MaxSize: 1024, Timeout: time.Second})
+		return en, emailSender
+	}
+	newAlert := func(runbookURL string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{RunbookURLAnnotation: model.LabelValue(runbookURL)},
+		}}
+	}
+	sentAlert := func(t *testing.T, emailSender *notificationServiceMock) ExtendedAlert {
+		t.Helper()
+		alerts := emailSender.EmailSync.Data["Alerts"].(ExtendedAlerts)
+		require.Len(t, alerts, 1)
+		return alerts[0]
+	}
+
+	t.Run("an excerpt of the text of the runbook is included", func(t *testing.T) {
+		emailNotifier, emailSender := newNotifier(t, serverURL.Host)
+
+		_, err := emailNotifier.Notify(context.Background(), newAlert(server.URL+"/runbook"))
+		require.NoError(t, err)
+
+		alert := sentAlert(t, emailSender)
+		require.Equal(t, "Disk full Delete the old logs.", alert.RunbookExcerpt)
+		require.Equal(t, server.URL+"/runbook", alert.Annotations[RunbookURLAnnotation])
+	})
+
+	t.Run("emails only link to runbooks that cannot be fetched", func(t *testing.T) {
+		emailNotifier, emailSender := newNotifier(t, serverURL.Host)
+
+		_, err := emailNotifier.Notify(context.Background(), newAlert(server.URL+"/missing"))
+		require.NoError(t, err)
+
+		alert := sentAlert(t, emailSender)
+		require.Empty(t, alert.RunbookExcerpt)
+		require.Equal(t, server.URL+"/missing", alert.Annotations[RunbookURLAnnotation])
+	})
+
+	t.Run("runbooks are not fetched from hosts that are not allowed", func(t *testing.T) {
+		emailNotifier, emailSender := newNotifier(t, "wiki.example.com")
+
+		_, err := emailNotifier.Notify(context.Background(), newAlert(server.URL+"/runbook"))
+		require.NoError(t, err)
+		require.Empty(t, sentAlert(t, emailSender).RunbookExcerpt)
+	})
+}
+
+func TestRunbookExcerpt(t *testing.T) {
+	require.Equal(t, "a b c", runbookExcerpt(" a\n\tb \x00c "))
+
+	long := runbookExcerpt(strings.Repeat("é", runbookExcerptLength+1))
+	require.Equal(t, runbookExcerptLength, len([]rune(long)))
+	require.True(t, strings.HasSuffix(long, "…"))
+}
+
+func TestEmailNotifierSort(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "ops@example.com", "sortBy": "startsAt", "sortOrder": "desc"}`),
+	})
+	require.NoError(t, err)
+	emailSender := mockNotificationService()
+	emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+	startsAt := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "oldest"}, StartsAt: startsAt}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "newest"}, StartsAt: startsAt.Add(time.Hour)}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "middle"}, StartsAt: startsAt.Add(time.Minute)}},
+	}
+	_, err = emailNotifier.Notify(context.Background(), alerts...)
+	require.NoError(t, err)
+
+	var names []string
+	for _, a := range emailSender.EmailSync.Data["Alerts"].(ExtendedAlerts) {
+		names = append(names, a.Labels["alertname"])
+	}
+	require.Equal(t, []string{"newest", "middle", "oldest"}, names)
+
+	_, err = NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "ops@example.com", "sortBy": "value"}`),
+	})
+	require.EqualError(t, err, `invalid sort by "value", must be "startsAt", "severity" or "alertname"`)
+}
+
+func TestEmailNotifierDisplayNames(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	t.Run("display names are kept in the recipients", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "Payments On-Call <payments@example.com>; ops@example.com;Zoë <zoe@example.com>", "singleEmail": true}`),
+		})
+		require.NoError(t, err)
+		expected := []string{`"Payments On-Call" <payments@example.com>`, "ops@example.com", "=?utf-8?q?Zo=C3=AB?= <zoe@example.com>"}
+		require.Equal(t, expected, cfg.Addresses)
+
+		emailSender := mockNotificationService()
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+		ok, err := emailNotifier.Notify(context.Background(), &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, emailSender.Emails, 1)
+		require.Equal(t, expected, emailSender.Emails[0].To)
+	})
+
+	t.Run("invalid addresses return an error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "ops@example.com;Payments <payments>"}`),
+		})
+		require.ErrorContains(t, err, `invalid email address "Payments <payments>"`)
+	})
+}
+
+func TestEmailNotifierSMIME(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	certTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "alerts@example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, certTmpl, certTmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newConfig := func(settings map[string]interface{}) (*EmailConfig, error) {
+		settings["addresses"] = "someops@example.com"
+		b, err := json.Marshal(settings)
+		require.NoError(t, err)
+		return NewEmailConfig(&NotificationChannelConfig{Name: "ops", Type: "email", Settings: b})
+	}
+
+	t.Run("the certificate and key files are passed to the email", func(t *testing.T) {
+		cfg, err := newConfig(map[string]interface{}{"smimeCertFile": certFile, "smimeKeyFile": keyFile})
+		require.NoError(t, err)
+		emailSender := mockNotificationService()
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+		ok, err := emailNotifier.Notify(context.Background(), &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, emailSender.Emails, 1)
+		require.Equal(t, certFile, emailSender.Emails[0].SMIMECertFile)
+		require.Equal(t, keyFile, emailSender.Emails[0].SMIMEKeyFile)
+	})
+
+	t.Run("the key file is required with the certificate file", func(t *testing.T) {
+		_, err := newConfig(map[string]interface{}{"smimeCertFile": certFile})
+		require.EqualError(t, err, "both the S/MIME certificate file and key file are required to sign emails")
+	})
+
+	t.Run("the certificate and key must be loadable", func(t *testing.T) {
+		_, err := newConfig(map[string]interface{}{"smimeCertFile": filepath.Join(dir, "missing.pem"), "smimeKeyFile": keyFile})
+		require.ErrorContains(t, err, "failed to load the S/MIME certificate and key")
+	})
+}
+
+func TestEmailNotifierRequireImages(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{
+		{Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{models.ImageTokenAnnotation: "test-image-1"},
+		}},
+		{Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert2"},
+			Annotations: model.LabelSet{models.ImageTokenAnnotation: "test-image-2"},
+		}},
+	}
+
+	cases := []struct {
+		name     string
+		settings string
+		images   ImageStore
+		alerts   []*types.Alert
+		expErr   error
+	}{
+		{
+			name:     "notification is sent without images by default",
+			settings: `{"addresses": "someops@example.com"}`,
+			images:   &UnavailableImageStore{},
+			alerts:   alerts,
+		},
+		{
+			name:     "notification fails when images are required and unavailable",
+			settings: `{"addresses": "someops@example.com", "requireImages": true}`,
+			images:   &UnavailableImageStore{},
+			alerts:   alerts,
+			expErr:   ErrRequiredImagesUnavailable,
+		},
+		{
+			name:     "notification is sent when some required images are available",
+			settings: `{"addresses": "someops@example.com", "requireImages": true}`,
+			images:   newFakeImageStore(1),
+			alerts:   alerts,
+		},
+		{
+			name:     "notification is sent when required images are not expected",
+			settings: `{"addresses": "someops@example.com", "requireImages": true}`,
+			images:   &UnavailableImageStore{},
+			alerts:   []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := NewEmailConfig(&NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "email",
+				Settings: json.RawMessage(c.settings),
+			})
+			require.NoError(t, err)
+
+			emailSender := mockNotificationService()
+			emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, c.images, tmpl, nil)
+
+			ok, err := emailNotifier.Notify(context.Background(), c.alerts...)
+			if c.expErr != nil {
+				require.ErrorIs(t, err, c.expErr)
+				require.True(t, ok)
+				require.Empty(t, emailSender.EmailSync.To)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, []string{"someops@example.com"}, emailSender.EmailSync.To)
+		})
+	}
+}
+
+func TestEmailNotifierImagesOnFiringOnly(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "alert1"},
+		Annotations: model.LabelSet{models.ImageTokenAnnotation: "test-image-1"},
+	}}
+	resolved := &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "alert1"},
+		Annotations: model.LabelSet{models.ImageTokenAnnotation: "test-image-1"},
+		StartsAt:    time.Now().Add(-time.Hour),
+		EndsAt:      time.Now().Add(-time.Minute),
+	}}
+
+	cases := []struct {
+		name     string
+		settings string
+		alert    *types.Alert
+		expImage string
+	}{
+		{
+			name:     "firing notifications include images",
+			settings: `{"addresses": "someops@example.com", "imagesOnFiringOnly": true}`,
+			alert:    firing,
+			expImage: "https://www.example.com/test-image-1.jpg",
+		},
+		{
+			name:     "resolved notifications do not include images",
+			settings: `{"addresses": "someops@example.com", "imagesOnFiringOnly": true, "requireImages": true}`,
+			alert:    resolved,
+		},
+		{
+			name:     "resolved notifications include images by default",
+			settings: `{"addresses": "someops@example.com"}`,
+			alert:    resolved,
+			expImage: "https://www.example.com/test-image-1.jpg",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := NewEmailConfig(&NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "email",
+				Settings: json.RawMessage(c.settings),
+			})
+			require.NoError(t, err)
+
+			emailSender := mockNotificationService()
+			emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, newFakeImageStore(1), tmpl, nil)
+
+			ok, err := emailNotifier.Notify(context.Background(), c.alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			alerts := emailSender.EmailSync.Data["Alerts"].(ExtendedAlerts)
+			require.Len(t, alerts, 1)
+			require.Equal(t, c.expImage, alerts[0].ImageURL)
+		})
+	}
+}
+
+func TestEmailNotifierMaxLabelValueLength(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "someops@example.com", "maxLabelValueLength": "100"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 100, cfg.MaxLabelValueLength)
+
+	emailSender := mockNotificationService()
+	emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+	query := strings.Repeat("x", 5000)
+	ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "query": model.LabelValue(query)}},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	expected := strings.Repeat("x", 99) + "…"
+	alerts := emailSender.EmailSync.Data["Alerts"].(ExtendedAlerts)
+	require.Equal(t, template.KV{"alertname": "alert1", "query": expected}, alerts[0].Labels)
+	require.Equal(t, expected, emailSender.EmailSync.Data["CommonLabels"].(template.KV)["query"])
+
+	t.Run("invalid length should return error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "maxLabelValueLength": -1}`),
+		})
+		require.EqualError(t, err, "max label value length should not be negative")
+	})
+}
+
+func TestEmailNotifierExcludeLabels(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "someops@example.com", "excludeLabels": ["replica", "internal_*"]}`),
+	})
+	require.NoError(t, err)
+
+	emailSender := mockNotificationService()
+	emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+	labels := model.LabelSet{"alertname": "alert1", "replica": "a", "internal_id": "42", "team": "ops"}
+	ok, err := emailNotifier.Notify(context.Background(), &types.Alert{Alert: model.Alert{Labels: labels}})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	alerts := emailSender.EmailSync.Data["Alerts"].(ExtendedAlerts)
+	require.Equal(t, template.KV{"alertname": "alert1", "team": "ops"}, alerts[0].Labels)
+	require.Equal(t, labels.Fingerprint().String(), alerts[0].Fingerprint)
+	require.Equal(t, template.KV{"alertname": "alert1", "team": "ops"}, emailSender.EmailSync.Data["CommonLabels"].(template.KV))
+	require.Equal(t, "[FIRING:1]  (alert1 ops)", emailSender.EmailSync.Subject)
+}
+
+func TestEmailNotifierRedactPatterns(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "someops@example.com", "redactSecrets": true, "redactPatterns": ["\\bsk-[a-z0-9]{8}\\b"], "message": "{{ range .Alerts }}{{ .Annotations.description }}{{ end }}"}`),
+	})
+	require.NoError(t, err)
+
+	emailSender := mockNotificationService()
+	emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+	ok, err := emailNotifier.Notify(context.Background(), &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "alert1", "dsn": "postgres://db?password=hunter2"},
+		Annotations: model.LabelSet{"description": "Scrape failed with token=abc123 for key sk-a1b2c3d4 on host-1"},
+	}})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Equal(t, "Scrape failed with token=[REDACTED] for key [REDACTED] on host-1", emailSender.EmailSync.Data["Message"])
+	alerts := emailSender.EmailSync.Data["Alerts"].(ExtendedAlerts)
+	require.Equal(t, "postgres://db?password=[REDACTED]", alerts[0].Labels["dsn"])
+
+	t.Run("invalid redact pattern should return error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "redactPatterns": ["(unclosed"]}`),
+		})
+		require.ErrorContains(t, err, `invalid redact pattern "(unclosed"`)
+	})
+}
+
+func TestEmailNotifierMinAlerts(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "someops@example.com", "minAlerts": "5"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 5, cfg.MinAlerts)
+
+	newAlerts := func(n int, endsAt time.Time) []*types.Alert {
+		alerts := make([]*types.Alert, 0, n)
+		for i := 0; i < n; i++ {
+			alerts = append(alerts, &types.Alert{
+				Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert%d", i))}, EndsAt: endsAt},
+			})
+		}
+		return alerts
+	}
+
+	cases := []struct {
+		name    string
+		alerts  []*types.Alert
+		expSent bool
+	}{
+		{
+			name:    "group with too few firing alerts is skipped",
+			alerts:  newAlerts(2, time.Time{}),
+			expSent: false,
+		},
+		{
+			name:    "group with enough firing alerts is sent",
+			alerts:  newAlerts(5, time.Time{}),
+			expSent: true,
+		},
+		{
+			name:    "resolved group is sent",
+			alerts:  newAlerts(2, time.Now().Add(-time.Minute)),
+			expSent: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			emailSender := mockNotificationService()
+			emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+			ok, err := emailNotifier.Notify(context.Background(), c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, c.expSent, emailSender.EmailSync.Data != nil)
+		})
+	}
+
+	t.Run("invalid min alerts should return error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "minAlerts": -1}`),
+		})
+		require.EqualError(t, err, "min alerts should not be negative")
+	})
+}
+
+func TestEmailNotifierSignSilenceLinks(t *testing.T) {
+	now := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	key := []byte("secret")
+	emailSender := mockNotificationService()
+	n, err := EmailFactory(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "signSilenceLinks": true, "silenceLinkTTL": "1h"}`),
+		},
+		NotificationService: emailSender,
+		ImageStore:          &UnavailableImageStore{},
+		Template:            tmpl,
+		Logger:              &FakeLogger{},
+		SilenceLinkKey:      key,
+	})
+	require.NoError(t, err)
+
+	ok, err := n.Notify(context.Background(), &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "ops"}},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	alerts := emailSender.EmailSync.Data["Alerts"].(ExtendedAlerts)
+	u, err := url.Parse(alerts[0].SilenceURL)
+	require.NoError(t, err)
+	q := u.Query()
+	require.Equal(t, []string{"alertname=alert1", "team=ops"}, q["matcher"])
+	require.Equal(t, "1667307600", q.Get(SilenceLinkExpiresParam))
+	require.NotEmpty(t, q.Get(SilenceLinkSignatureParam))
+
+	verify := func(matchers []string, now time.Time) error {
+		return VerifySilenceLink(key, matchers, q.Get(SilenceLinkExpiresParam), q.Get(SilenceLinkSignatureParam), now)
+	}
+	require.NoError(t, verify([]string{"team=ops", "alertname=alert1"}, now.Add(time.Hour)))
+	require.ErrorIs(t, verify(q["matcher"], now.Add(time.Hour+time.Second)), ErrSilenceLinkExpired)
+	require.ErrorIs(t, verify([]string{"alertname=alert1"}, now), ErrSilenceLinkInvalid)
+	require.ErrorIs(t, VerifySilenceLink([]byte("other"), q["matcher"], q.Get(SilenceLinkExpiresParam), q.Get(SilenceLinkSignatureParam), now), ErrSilenceLinkInvalid)
+	require.ErrorIs(t, VerifySilenceLink(key, q["matcher"], "1767225600", q.Get(SilenceLinkSignatureParam), now), ErrSilenceLinkInvalid)
+
+	t.Run("key is derived from the secret key for silence links only", func(t *testing.T) {
+		derived := SilenceLinkKey("secret")
+		require.Len(t, derived, sha256.Size)
+		require.NotEqual(t, []byte("secret"), derived)
+		require.Equal(t, derived, SilenceLinkKey("secret"))
+		require.NotEqual(t, derived, SilenceLinkKey("other"))
+	})
+
+	t.Run("signing without a secret key should return error", func(t *testing.T) {
+		_, err := EmailFactory(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "email",
+				Settings: json.RawMessage(`{"addresses": "someops@example.com", "signSilenceLinks": true}`),
+			},
+			Template: tmpl,
+			Logger:   &FakeLogger{},
+		})
+		require.ErrorContains(t, err, "silence links cannot be signed without a secret key")
+	})
+}
+
+func TestEmailNotifierEnvironmentLabel(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "someops@example.com", "subject": "{{ .CommonLabels.alertname }}", "environmentLabel": "env"}`),
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		reminder   bool
+		alerts     []*types.Alert
+		expSubject string
+	}{
+		{
+			name: "common environment prefixes the subject",
+			alerts: []*types.Alert{
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "env": "prod"}}},
+			},
+			expSubject: "[PROD] alert1",
+		},
+		{
+			name: "no prefix without a common environment",
+			alerts: []*types.Alert{
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "env": "prod"}}},
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "env": "staging"}}},
+			},
+			expSubject: "alert1",
+		},
+		{
+			name:     "environment prefix follows the reminder prefix",
+			reminder: true,
+			alerts: []*types.Alert{
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "env": "staging"}}},
+			},
+			expSubject: "[REMINDER] [STAGING] alert1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			emailSender := mockNotificationService()
+			emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+			ctx := context.Background()
+			if c.reminder {
+				ctx = context.WithValue(ctx, reminderCtxKey{}, true)
+			}
+			ok, err := emailNotifier.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, c.expSubject, emailSender.EmailSync.Subject)
+		})
+	}
+}
+
+func TestEmailNotifierTransferEncoding(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "someops@example.com", "transferEncoding": "base64"}`),
+	})
+	require.NoError(t, err)
+
+	emailSender := mockNotificationService()
+	emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+	ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, EmailTransferEncodingBase64, emailSender.EmailSync.TransferEncoding)
+
+	t.Run("invalid transfer encoding should return error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "transferEncoding": "7bit"}`),
+		})
+		require.EqualError(t, err, `invalid transfer encoding "7bit", must be "quoted-printable" or "base64"`)
+	})
+}
+
+func TestEmailNotifierHeaders(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name: "ops",
+		Type: "email",
+		Settings: json.RawMessage(`{
+			"addresses": "someops@example.com",
+			"importance": "high",
+			"headers": {
+				"X-Ticket-Queue": "{{ .CommonLabels.team }}-alerts",
+				"X-Team-{{ .CommonLabels.team }}": "true",
+				"X-Runbook": "{{ .CommonAnnotations.runbook }}"
+			}
+		}`),
+	})
+	require.NoError(t, err)
+
+	emailSender := mockNotificationService()
+	emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+	ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "billing"}},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, map[string]string{
+		"Importance":     "High",
+		"X-Priority":     "1 (Highest)",
+		"X-Ticket-Queue": "billing-alerts",
+		"X-Team-billing": "true",
+	}, emailSender.EmailSync.Headers)
+	require.Len(t, emailImportanceHeaders[EmailImportanceHigh], 2, "the importance headers should not be modified")
+
+	t.Run("invalid headers should return error", func(t *testing.T) {
+		cases := []struct {
+			headers string
+			expErr  string
+		}{
+			{headers: `{"X Ticket": "a"}`, expErr: `invalid header name "X Ticket"`},
+			{headers: `{"X-Ticket:": "a"}`, expErr: `invalid header name "X-Ticket:"`},
+			{headers: `{"from": "a"}`, expErr: `header "from" cannot be set`},
+			{headers: `{"X-Ticket": 1}`, expErr: `invalid value of header "X-Ticket", must be a string`},
+			{headers: `"X-Ticket"`, expErr: `invalid headers: type assertion to map[string]interface{} failed`},
+		}
+		for _, c := range cases {
+			_, err := NewEmailConfig(&NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "email",
+				Settings: json.RawMessage(`{"addresses": "someops@example.com", "headers": ` + c.headers + `}`),
+			})
+			require.EqualError(t, err, c.expErr)
+		}
+	})
+
+	t.Run("line breaks in rendered values are replaced", func(t *testing.T) {
+		headers := renderEmailHeaders(nil, map[string]string{"X-Ticket-Queue": "a\r\nBcc: b"}, func(s string) string { return s }, &FakeLogger{})
+		require.Equal(t, map[string]string{"X-Ticket-Queue": "a Bcc: b"}, headers)
+	})
+}
+
+func TestEmailNotifierCharset(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "someops@example.com", "charset": "iso-8859-1"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, EmailCharsetISO8859_1, cfg.Charset)
+
+	emailSender := mockNotificationService()
+	emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+	ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, EmailCharsetISO8859_1, emailSender.EmailSync.Charset)
+
+	t.Run("charset defaults to UTF-8", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com"}`),
+		})
+		require.NoError(t, err)
+		require.Equal(t, EmailCharsetUTF8, cfg.Charset)
+	})
+
+	t.Run("unsupported charset should return error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "charset": "Shift_JIS"}`),
+		})
+		require.EqualError(t, err, `unsupported charset "Shift_JIS", must be "UTF-8" or "ISO-8859-1"`)
+	})
+}
+
+func TestEmailNotifierBatching(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	newNotifier := func(t *testing.T, settings string) (*EmailNotifier, *notificationServiceMock) {
+		t.Helper()
+		emailSender := mockNotificationService()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(settings),
+		})
+		require.NoError(t, err)
+		return NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil), emailSender
+	}
+
+	alertA := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "A"}}}
+	alertB := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "B"}}}
+
+	alertNames := func(data map[string]interface{}) []string {
+		var names []string
+		for _, a := range data["Alerts"].(ExtendedAlerts) {
+			names = append(names, a.Labels["alertname"])
+		}
+		return names
+	}
+
+	// notifyAsync sends the notification in the background, as it waits for its batch.
+	notifyAsync := func(ctx context.Context, en *EmailNotifier, alerts ...*types.Alert) <-chan emailBatchResult {
+		done := make(chan emailBatchResult, 1)
+		go func() {
+			retry, err := en.Notify(ctx, alerts...)
+			done <- emailBatchResult{retry: retry, err: err}
+		}()
+		return done
+	}
+	// waitPending waits for the current batch of the notifier to have n emails.
+	waitPending := func(t *testing.T, en *EmailNotifier, n int) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			en.batch.mtx.Lock()
+			defer en.batch.mtx.Unlock()
+			return len(en.batch.pending) == n
+		}, time.Second, time.Millisecond)
+	}
+
+	t.Run("notifications within the window are coalesced into one email", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		en, emailSender := newNotifier(t, `{"addresses": "someops@example.com", "batchWindow": "1m"}`)
+
+		first := notifyAsync(context.Background(), en, alertA)
+		waitPending(t, en, 1)
+		require.Empty(t, emailSender.EmailSync.To, "email should be buffered until the window expires")
+
+		mockTimeNow(now.Add(time.Minute))
+		ok, err := en.Notify(context.Background(), alertB)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, emailBatchResult{retry: true}, <-first)
+
+		require.Equal(t, []string{"someops@example.com"}, emailSender.EmailSync.To)
+		require.Equal(t, "[FIRING:1]  (A) (and 1 more)", emailSender.EmailSync.Subject)
+		require.Equal(t, []string{"A", "B"}, alertNames(emailSender.EmailSync.Data))
+	})
+
+	t.Run("batch is sent once it reaches the max count", func(t *testing.T) {
+		en, emailSender := newNotifier(t, `{"addresses": "someops@example.com", "batchWindow": "1h", "batchMaxCount": "2"}`)
+
+		first := notifyAsync(context.Background(), en, alertA)
+		waitPending(t, en, 1)
+		require.Empty(t, emailSender.EmailSync.To)
+
+		_, err := en.Notify(context.Background(), alertB)
+		require.NoError(t, err)
+		require.NoError(t, (<-first).err)
+		require.Equal(t, []string{"A", "B"}, alertNames(emailSender.EmailSync.Data))
+	})
+
+	t.Run("batch is sent before the deadline of its notifications", func(t *testing.T) {
+		en, emailSender := newNotifier(t, `{"addresses": "someops@example.com", "batchWindow": "1h"}`)
+
+		ctx, cancel := context.WithTimeout(context.Background(), emailBatchDeadlineMargin+50*time.Millisecond)
+		defer cancel()
+		ok, err := en.Notify(ctx, alertA)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"A"}, alertNames(emailSender.EmailSync.Data))
+	})
+
+	t.Run("notifications are coalesced with the notifications with the same recipients only", func(t *testing.T) {
+		emailSender := mockNotificationService()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "batchWindow": "1h", "batchMaxCount": "3", "importanceLabel": "severity", "attachRawJSON": true}`),
+		})
+		require.NoError(t, err)
+		teams := teamRecipientResolver{addresses: map[model.LabelValue][]string{
+			"db":  {"dba@example.com"},
+			"web": {"web@example.com"},
+		}}
+		en := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, teams)
+
+		dbLow := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "A", "team": "db", "severity": "info"}}}
+		web := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "B", "team": "web"}}}
+		dbHigh := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "C", "team": "db", "severity": "critical"}}}
+		first := notifyAsync(context.Background(), en, dbLow)
+		waitPending(t, en, 1)
+		second := notifyAsync(context.Background(), en, web)
+		waitPending(t, en, 2)
+		_, err = en.Notify(context.Background(), dbHigh)
+		require.NoError(t, err)
+		require.NoError(t, (<-first).err)
+		require.NoError(t, (<-second).err)
+
+		require.Len(t, emailSender.Emails, 2)
+		db, webEmail := emailSender.Emails[0], emailSender.Emails[1]
+		require.Equal(t, []string{"dba@example.com"}, db.To)
+		require.Equal(t, []string{"A", "C"}, alertNames(db.Data))
+		require.Equal(t, "High", db.Headers["Importance"])
+		require.Equal(t, []string{"web@example.com"}, webEmail.To)
+		require.Equal(t, []string{"B"}, alertNames(webEmail.Data))
+
+		// The alerts of the coalesced emails are attached as a single file.
+		require.Len(t, db.AttachedFiles, 1)
+		require.Equal(t, EmailRawJSONAttachment, db.AttachedFiles[0].Name)
+		var attached []*types.Alert
+		require.NoError(t, json.Unmarshal(db.AttachedFiles[0].Content, &attached))
+		require.Len(t, attached, 2)
+	})
+
+	t.Run("notifications with different custom headers are not coalesced", func(t *testing.T) {
+		en, emailSender := newNotifier(t, `{"addresses": "someops@example.com", "batchWindow": "1h", "batchMaxCount": "2", "headers": {"X-Ticket-Queue": "{{ .CommonLabels.queue }}"}}`)
+
+		first := notifyAsync(context.Background(), en, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "A", "queue": "db"}}})
+		waitPending(t, en, 1)
+		_, err := en.Notify(context.Background(), &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "B", "queue": "web"}}})
+		require.NoError(t, err)
+		require.NoError(t, (<-first).err)
+
+		require.Len(t, emailSender.Emails, 2)
+		require.Equal(t, "db", emailSender.Emails[0].Headers["X-Ticket-Queue"])
+		require.Equal(t, []string{"A"}, alertNames(emailSender.Emails[0].Data))
+		require.Equal(t, "web", emailSender.Emails[1].Headers["X-Ticket-Queue"])
+		require.Equal(t, []string{"B"}, alertNames(emailSender.Emails[1].Data))
+	})
+
+	t.Run("failed batch is reported to its notifications to be retried", func(t *testing.T) {
+		en, emailSender := newNotifier(t, `{"addresses": "someops@example.com", "batchWindow": "1m", "batchMaxCount": "2"}`)
+		en.retries = NewRetryBudget(1, time.Hour)
+		emailSender.ShouldError = errors.New("smtp unavailable")
+
+		first := notifyAsync(context.Background(), en, alertA)
+		waitPending(t, en, 1)
+		ok, err := en.Notify(context.Background(), alertB)
+		require.Error(t, err)
+		require.True(t, ok)
+		r := <-first
+		require.Error(t, r.err)
+		require.True(t, r.retry)
+		require.Len(t, emailSender.Emails, 1)
+	})
+
+	t.Run("failed batch is not retried without retry budget", func(t *testing.T) {
+		en, emailSender := newNotifier(t, `{"addresses": "someops@example.com", "batchWindow": "1m", "batchMaxCount": "2"}`)
+		emailSender.ShouldError = errors.New("smtp unavailable")
+
+		first := notifyAsync(context.Background(), en, alertA)
+		waitPending(t, en, 1)
+		ok, err := en.Notify(context.Background(), alertB)
+		require.Error(t, err)
+		require.False(t, ok)
+		require.False(t, (<-first).retry)
+	})
+
+	t.Run("notification that is cancelled is removed from its batch", func(t *testing.T) {
+		en, emailSender := newNotifier(t, `{"addresses": "someops@example.com", "batchWindow": "1h"}`)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		first := notifyAsync(ctx, en, alertA)
+		waitPending(t, en, 1)
+		cancel()
+		r := <-first
+		require.ErrorIs(t, r.err, context.Canceled)
+		require.True(t, r.retry)
+		waitPending(t, en, 0)
+		require.Empty(t, emailSender.Emails)
+	})
+
+	t.Run("notifications of a stopped notifier are retried", func(t *testing.T) {
+		en, emailSender := newNotifier(t, `{"addresses": "someops@example.com", "batchWindow": "1h"}`)
+
+		first := notifyAsync(context.Background(), en, alertA)
+		waitPending(t, en, 1)
+		en.Stop()
+		r := <-first
+		require.ErrorIs(t, r.err, errEmailBatchStopped)
+		require.True(t, r.retry)
+		require.Empty(t, emailSender.Emails)
+	})
+
+	t.Run("invalid batch window should return error", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "batchWindow": "soon"}`),
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestEmailNotifierScheduleAt(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	now := time.Date(2022, 12, 1, 10, 20, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	cfg, err := NewEmailConfig(&NotificationChannelConfig{
+		Name:     "ops",
+		Type:     "email",
+		Settings: json.RawMessage(`{"addresses": "someops@example.com", "scheduleAt": "1h"}`),
+	})
+	require.NoError(t, err)
+	emailSender := mockNotificationService()
+	en := NewEmailNotifier(cfg, &FakeLogger{}, emailSender, &UnavailableImageStore{}, tmpl, nil)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "A"})
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "A"}}}
+	done := make(chan deferredResult, 1)
+	go func() {
+		retry, err := en.Notify(ctx, alert)
+		done <- deferredResult{retry: retry, err: err}
+	}()
+	require.Eventually(t, func() bool {
+		en.deferred.mtx.Lock()
+		defer en.deferred.mtx.Unlock()
+		return len(en.deferred.pending) == 1
+	}, time.Second, time.Millisecond)
+	require.Empty(t, emailSender.Emails, "email should be deferred until the top of the hour")
+
+	mockTimeNow(now.Add(39 * time.Minute))
+	en.deferred.flush()
+	require.Empty(t, emailSender.Emails)
+
+	mockTimeNow(now.Add(40 * time.Minute))
+	en.deferred.flush()
+	// The notification is only reported as sent once the email is.
+	require.Equal(t, deferredResult{retry: true}, <-done)
+	require.Len(t, emailSender.Emails, 1)
+	require.Equal(t, []string{"someops@example.com"}, emailSender.Emails[0].To)
 }
 
 func TestEmailNotifierIntegration(t *testing.T) {
 	ns := createEmailSender(t)
 
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
 	emailTmpl := templateForTests(t)
 	externalURL, err := url.Parse("http://localhost/base")
 	require.NoError(t, err)
@@ -198,6 +1966,23 @@ func TestEmailNotifierIntegration(t *testing.T) {
 				"<a href=\"http://localhost/base/d/abc?viewPanel=5",
 			},
 		},
+		{
+			name: "firing alert includes for how long it has been firing",
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "AlwaysFiring", "severity": "warning"},
+						Annotations: model.LabelSet{"runbook_url": "http://fix.me"},
+						StartsAt:    now.Add(-12 * time.Minute),
+					},
+				},
+			},
+			messageTmpl: "",
+			expSubject:  "[FIRING:1]  (AlwaysFiring warning)",
+			expSnippets: []string{
+				"Firing for <strong>12m</strong>",
+			},
+		},
 		{
 			name: "message containing HTML gets HTMLencoded",
 			alerts: []*types.Alert{
@@ -234,6 +2019,24 @@ func TestEmailNotifierIntegration(t *testing.T) {
 			expSubject:  "This notification is firing!",
 			expSnippets: []string{},
 		},
+		{
+			name: "annotations with URL values render as buttons",
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "AlwaysFiring", "severity": "warning"},
+						Annotations: model.LabelSet{"runbook_url": "https://runbooks.example.com/always-firing", "owner": "team-a"},
+					},
+				},
+			},
+			expSubject: "[FIRING:1]  (AlwaysFiring warning)",
+			expSnippets: []string{
+				`<a class="annotation-button" href="https://runbooks.example.com/always-firing"`,
+				`>runbook_url</a>`,
+				"<strong>owner</strong>",
+				"team-a",
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -262,6 +2065,111 @@ func TestEmailNotifierIntegration(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("copy to sender adds the from address to bcc", func(t *testing.T) {
+		bytes, err := json.Marshal(map[string]interface{}{
+			"addresses":    "someops@example.com;somedev@example.com",
+			"singleEmail":  true,
+			"copyToSender": true,
+		})
+		require.NoError(t, err)
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: bytes,
+		})
+		require.NoError(t, err)
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, ns, &UnavailableImageStore{}, emailTmpl, nil)
+
+		ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "AlwaysFiring", "severity": "warning"},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		sentMsg := getSingleSentMessage(t, ns)
+		require.Equal(t, []string{"someops@example.com", "somedev@example.com"}, sentMsg.To)
+		require.Equal(t, []string{"from@address.com"}, sentMsg.Bcc)
+	})
+
+	t.Run("high importance sets the importance headers", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "importance": "high"}`),
+		})
+		require.NoError(t, err)
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, ns, &UnavailableImageStore{}, emailTmpl, nil)
+
+		ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "AlwaysFiring"},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		sentMsg := getSingleSentMessage(t, ns)
+		require.Equal(t, map[string]string{"Importance": "High", "X-Priority": "1 (Highest)"}, sentMsg.Headers)
+	})
+
+	t.Run("rtl text direction sets the dir attribute", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "textDirection": "rtl"}`),
+		})
+		require.NoError(t, err)
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, ns, &UnavailableImageStore{}, emailTmpl, nil)
+
+		ok, err := emailNotifier.Notify(context.Background(), &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "AlwaysFiring"},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		sentMsg := getSingleSentMessage(t, ns)
+		require.Contains(t, sentMsg.Body["text/html"], `dir="rtl"`)
+	})
+
+	t.Run("multiple images are shown in the gallery", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com"}`),
+		})
+		require.NoError(t, err)
+		emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, ns, newFakeImageStore(8), emailTmpl, nil)
+
+		var alerts []*types.Alert
+		for i := 1; i <= 8; i++ {
+			alerts = append(alerts, &types.Alert{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("HighCPU%d", i))},
+					Annotations: model.LabelSet{models.ImageTokenAnnotation: model.LabelValue(fmt.Sprintf("test-image-%d", i))},
+				},
+			})
+		}
+		ok, err := emailNotifier.Notify(context.Background(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		html := getSingleSentMessage(t, ns).Body["text/html"]
+		require.Contains(t, html, `class="image-gallery"`)
+		for i := 1; i <= emailGalleryMaxImages; i++ {
+			img := fmt.Sprintf(`<img alt="HighCPU%d" height="auto" src="https://www.example.com/test-image-%d.jpg"`, i, i)
+			// The images are only shown in the gallery, not after each alert.
+			require.Equal(t, 1, strings.Count(html, fmt.Sprintf(`src="https://www.example.com/test-image-%d.jpg"`, i)))
+			require.Contains(t, html, img)
+		}
+		require.NotContains(t, html, "test-image-7.jpg")
+		require.NotContains(t, html, "test-image-8.jpg")
+		require.Contains(t, html, "and 2 more images")
+	})
 }
 
 func createSut(t *testing.T, messageTmpl string, subjectTmpl string, emailTmpl *template.Template, ns *emailSender) *EmailNotifier {
@@ -286,7 +2194,7 @@ func createSut(t *testing.T, messageTmpl string, subjectTmpl string, emailTmpl *
 		Settings: bytes,
 	})
 	require.NoError(t, err)
-	emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, ns, &UnavailableImageStore{}, emailTmpl)
+	emailNotifier := NewEmailNotifier(cfg, &FakeLogger{}, ns, &UnavailableImageStore{}, emailTmpl, nil)
 
 	return emailNotifier
 }
@@ -300,3 +2208,131 @@ func getSingleSentMessage(t *testing.T, ns *emailSender) *notifications.Message
 	mailer.Sent = []*notifications.Message{}
 	return sent
 }
+
+func TestEmailNotifierOptOut(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(t *testing.T, settings string, optOut ...string) (NotificationChannel, *notificationServiceMock) {
+		t.Helper()
+		ns := mockNotificationService()
+		n, err := EmailFactory(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "email",
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: ns,
+			ImageStore:          &UnavailableImageStore{},
+			Template:            tmpl,
+			Logger:              &FakeLogger{},
+			EmailOptOut:         optOut,
+		})
+		require.NoError(t, err)
+		return n, ns
+	}
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	t.Run("opted-out recipients are removed", func(t *testing.T) {
+		n, ns := newNotifier(t,
+			`{"addresses": "ops@example.com;Dev <DEV@example.com>", "cc": "lead@example.com;audit@example.com", "singleEmail": true}`,
+			"dev@example.com", "Audit <audit@example.com>")
+
+		ok, err := n.Notify(context.Background(), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, ns.Emails, 1)
+		require.Equal(t, []string{"ops@example.com"}, ns.Emails[0].To)
+		require.Equal(t, []string{"lead@example.com"}, ns.Emails[0].Cc)
+		// The blind carbon copies are resolved when the email is sent, so the opt-out list
+		// is sent along with the email.
+		require.Equal(t, []string{"audit@example.com", "dev@example.com"}, ns.Emails[0].OptOut)
+	})
+
+	t.Run("the email is not sent when all recipients opted out", func(t *testing.T) {
+		n, ns := newNotifier(t, `{"addresses": "ops@example.com;dev@example.com", "cc": "lead@example.com"}`,
+			"ops@example.com", "dev@example.com")
+
+		ok, err := n.Notify(context.Background(), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Empty(t, ns.Emails)
+		require.Empty(t, ns.EmailSync)
+	})
+
+	t.Run("no recipients are removed without opt-out list", func(t *testing.T) {
+		n, ns := newNotifier(t, `{"addresses": "ops@example.com;dev@example.com", "singleEmail": true}`)
+
+		_, err := n.Notify(context.Background(), alert)
+		require.NoError(t, err)
+		require.Len(t, ns.Emails, 1)
+		require.Equal(t, []string{"ops@example.com", "dev@example.com"}, ns.Emails[0].To)
+	})
+}
+
+func TestEmailNotifierTextDirection(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name        string
+		settings    string
+		annotations model.LabelSet
+		expected    string
+	}{
+		{
+			name:        "auto detects right to left annotations",
+			settings:    `{"addresses": "someops@example.com"}`,
+			annotations: model.LabelSet{"summary": "وحدة المعالجة المركزية مرتفعة على الخادم"},
+			expected:    EmailTextDirectionRTL,
+		},
+		{
+			name:        "auto detects left to right annotations",
+			settings:    `{"addresses": "someops@example.com", "textDirection": "auto"}`,
+			annotations: model.LabelSet{"summary": "CPU usage is high on the server"},
+			expected:    EmailTextDirectionLTR,
+		},
+		{
+			name:        "configured direction overrides the content",
+			settings:    `{"addresses": "someops@example.com", "textDirection": "ltr"}`,
+			annotations: model.LabelSet{"summary": "وحدة المعالجة المركزية مرتفعة على الخادم"},
+			expected:    EmailTextDirectionLTR,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := NewEmailConfig(&NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "email",
+				Settings: json.RawMessage(c.settings),
+			})
+			require.NoError(t, err)
+			ns := mockNotificationService()
+			en := NewEmailNotifier(cfg, &FakeLogger{}, ns, &UnavailableImageStore{}, tmpl, nil)
+
+			ok, err := en.Notify(context.Background(), &types.Alert{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": "AlwaysFiring"},
+					Annotations: c.annotations,
+				},
+			})
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, c.expected, ns.EmailSync.Data["TextDirection"])
+		})
+	}
+
+	t.Run("invalid direction", func(t *testing.T) {
+		_, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com", "textDirection": "up"}`),
+		})
+		require.EqualError(t, err, `invalid text direction "up", must be "ltr", "rtl" or "auto"`)
+	})
+}
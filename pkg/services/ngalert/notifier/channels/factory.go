@@ -1,10 +1,12 @@
 package channels
 
 import (
+	"context"
 	"errors"
 	"strings"
 
 	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
 )
 
 type FactoryConfig struct {
@@ -15,6 +17,31 @@ type FactoryConfig struct {
 	// Used to retrieve image URLs for messages, or data for uploads.
 	Template *template.Template
 	Logger   Logger
+	// RetryBudget caps the retries of failed notifications. Retries are disabled if it is nil.
+	RetryBudget *RetryBudget
+	// History records the notifications that are sent. Notifications are not recorded if it is nil.
+	History HistorySink
+	// TeamMuteTimings mutes the alerts of teams inside their mute timings. Alerts are not
+	// muted by their team if it is nil.
+	TeamMuteTimings TeamMuteTimingsProvider
+	// SilenceLinkKey signs silence links, if notifiers are configured to sign them.
+	SilenceLinkKey []byte
+	// OnSent is called, without blocking the notifier, after each notification that was
+	// sent successfully. It is not called if it is nil.
+	OnSent OnSentFunc
+	// EmailOptOut are addresses that are removed from the recipients of all emails.
+	EmailOptOut []string
+	// SeverityColors are the colors of chat messages by severity. The default colors are used
+	// if it is nil.
+	SeverityColors SeverityColors
+	// Runbooks limit the runbooks that can be included in emails. No runbook is fetched if
+	// it has no allowed hosts.
+	Runbooks RunbookSettings
+	// Templates are the templates stored in the database that notifiers can use by name.
+	// Stored templates are not used if it is nil.
+	Templates TemplateStore
+	// SendMetrics count the attempts to send notifications. They are not counted if it is nil.
+	SendMetrics *SendMetrics
 }
 
 func NewFactoryConfig(config *NotificationChannelConfig, notificationService NotificationSender,
@@ -43,10 +70,13 @@ func NewFactoryConfig(config *NotificationChannelConfig, notificationService Not
 
 var receiverFactories = map[string]func(FactoryConfig) (NotificationChannel, error){
 	"prometheus-alertmanager": AlertmanagerFactory,
+	"amqp":                    AMQPFactory,
 	"dingding":                DingDingFactory,
 	"discord":                 DiscordFactory,
 	"email":                   EmailFactory,
 	"googlechat":              GoogleChatFactory,
+	"grpc":                    GRPCFactory,
+	"jira":                    JiraFactory,
 	"kafka":                   KafkaFactory,
 	"line":                    LineFactory,
 	"opsgenie":                OpsgenieFactory,
@@ -63,8 +93,51 @@ var receiverFactories = map[string]func(FactoryConfig) (NotificationChannel, err
 	"webex":                   WebexFactory,
 }
 
+// Factory returns the factory of the receiver type. The notifiers it builds record the
+// result of each notification, see NotificationChannel.LastError, drop the alerts of
+// muted teams, see FactoryConfig.TeamMuteTimings, and call FactoryConfig.OnSent.
 func Factory(receiverType string) (func(FactoryConfig) (NotificationChannel, error), bool) {
 	receiverType = strings.ToLower(receiverType)
 	factory, exists := receiverFactories[receiverType]
-	return factory, exists
+	if !exists {
+		return nil, false
+	}
+	return func(fc FactoryConfig) (NotificationChannel, error) {
+		n, err := factory(fc)
+		if err != nil {
+			return nil, err
+		}
+		n = &lastErrorNotifier{NotificationChannel: n}
+		if fc.OnSent != nil {
+			n = &onSentNotifier{
+				NotificationChannel: n,
+				name:                fc.Config.Name,
+				channelType:         fc.Config.Type,
+				onSent:              fc.OnSent,
+				log:                 fc.Logger,
+			}
+		}
+		if fc.TeamMuteTimings != nil {
+			n = &teamMuteNotifier{
+				NotificationChannel: n,
+				orgID:               fc.Config.OrgID,
+				muteTimings:         fc.TeamMuteTimings,
+				log:                 fc.Logger,
+			}
+		}
+		return n, nil
+	}, true
+}
+
+// lastErrorNotifier records the result of each notification of the notifier.
+type lastErrorNotifier struct {
+	NotificationChannel
+}
+
+func (n *lastErrorNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	retry, err := n.NotificationChannel.Notify(ctx, alerts...)
+	if r, ok := n.NotificationChannel.(interface{ setLastError(error) }); ok {
+		r.setLastError(err)
+	}
+	return retry, err
 }
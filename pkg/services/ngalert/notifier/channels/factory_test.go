@@ -0,0 +1,73 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactoryLastError(t *testing.T) {
+	now := time.Now()
+	defer mockTimeNow(now)()
+
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	factory, ok := Factory("webhook")
+	require.True(t, ok)
+
+	webhookSender := mockNotificationService()
+	n, err := factory(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(`{"url": "http://localhost/test"}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	at, lastErr := n.LastError()
+	require.True(t, at.IsZero())
+	require.NoError(t, lastErr)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	t.Run("last error is recorded on failure", func(t *testing.T) {
+		webhookSender.ShouldError = errors.New("connection refused")
+		_, err := n.Notify(ctx, alert)
+		require.Error(t, err)
+
+		at, lastErr := n.LastError()
+		require.Equal(t, now, at)
+		require.EqualError(t, lastErr, "connection refused")
+	})
+
+	t.Run("last error is cleared on success", func(t *testing.T) {
+		webhookSender.ShouldError = nil
+		_, err := n.Notify(ctx, alert)
+		require.NoError(t, err)
+
+		at, lastErr := n.LastError()
+		require.True(t, at.IsZero())
+		require.NoError(t, lastErr)
+	})
+}
@@ -0,0 +1,148 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// FallbackChainNotifier is a notifier of a fallback chain.
+type FallbackChainNotifier struct {
+	UID      string
+	Name     string
+	Type     string
+	Notifier NotificationChannel
+}
+
+// FallbackAttempt is the result of a notifier of a fallback chain for the last notification.
+type FallbackAttempt struct {
+	UID  string
+	Name string
+	Type string
+	// Attempted is false if the notifier was not tried because a notifier before it
+	// succeeded, or because it does not send resolved notifications.
+	Attempted bool
+	// Err is the error of the notifier if it was tried and failed.
+	Err error
+}
+
+// FallbackChainError is returned when all of the notifiers of a fallback chain that were
+// tried failed.
+type FallbackChainError struct {
+	Attempts []FallbackAttempt
+}
+
+func (e *FallbackChainError) Error() string {
+	var msgs []string
+	for _, a := range e.Attempts {
+		if a.Err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s (%s): %s", a.Name, a.Type, a.Err))
+		}
+	}
+	return fmt.Sprintf("all %d notifiers of the fallback chain failed: %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// FallbackChain tries its notifiers in order and stops at the first one that succeeds,
+// e.g. to send an email only if a webhook fails. It fails if all of the notifiers fail.
+type FallbackChain struct {
+	*Base
+	log       Logger
+	notifiers []FallbackChainNotifier
+
+	attemptsMtx sync.Mutex
+	attempts    []FallbackAttempt
+}
+
+func NewFallbackChain(config *NotificationChannelConfig, notifiers []FallbackChainNotifier, l Logger) *FallbackChain {
+	return &FallbackChain{
+		Base:      NewBase(config),
+		log:       l,
+		notifiers: notifiers,
+	}
+}
+
+// Notify sends the alerts with the notifiers in order until one of them succeeds. The
+// notification is retried if it failed and any of the notifiers asked to be retried.
+func (fc *FallbackChain) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	attempts := make([]FallbackAttempt, len(fc.notifiers))
+	var retry, failed, sent bool
+	for i, n := range fc.notifiers {
+		attempts[i] = FallbackAttempt{UID: n.UID, Name: n.Name, Type: n.Type}
+		if sent {
+			continue
+		}
+		alerts := as
+		if !n.Notifier.SendResolved() {
+			alerts = firingAlerts(as, timeNow())
+		}
+		if len(alerts) == 0 {
+			continue
+		}
+
+		attempts[i].Attempted = true
+		r, err := n.Notifier.Notify(ctx, alerts...)
+		if err != nil {
+			fc.log.Warn("notifier of the fallback chain failed, trying the next one", "notifier", n.Name, "type", n.Type, "error", err)
+			attempts[i].Err = err
+			retry = retry || r
+			failed = true
+			continue
+		}
+		sent = true
+	}
+	fc.setAttempts(attempts)
+
+	if sent || !failed {
+		fc.setLastError(nil)
+		return true, nil
+	}
+	err := &FallbackChainError{Attempts: attempts}
+	fc.setLastError(err)
+	return retry, err
+}
+
+// SendResolved returns true if any of the notifiers sends resolved notifications.
+func (fc *FallbackChain) SendResolved() bool {
+	for _, n := range fc.notifiers {
+		if n.Notifier.SendResolved() {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop stops the notifiers of the chain.
+func (fc *FallbackChain) Stop() {
+	for _, n := range fc.notifiers {
+		n.Notifier.Stop()
+	}
+}
+
+// MaxDeferral returns the longest deferral of the notifiers of the chain.
+func (fc *FallbackChain) MaxDeferral() time.Duration {
+	var max time.Duration
+	for _, n := range fc.notifiers {
+		if d := n.Notifier.MaxDeferral(); d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// LastAttempts returns the result of each notifier of the chain for the last notification,
+// in the order of the chain. It returns nil if no notification was sent yet.
+func (fc *FallbackChain) LastAttempts() []FallbackAttempt {
+	fc.attemptsMtx.Lock()
+	defer fc.attemptsMtx.Unlock()
+	return append([]FallbackAttempt(nil), fc.attempts...)
+}
+
+func (fc *FallbackChain) setAttempts(attempts []FallbackAttempt) {
+	fc.attemptsMtx.Lock()
+	defer fc.attemptsMtx.Unlock()
+	fc.attempts = attempts
+}
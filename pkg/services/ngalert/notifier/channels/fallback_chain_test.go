@@ -0,0 +1,111 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackChain(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newChain := func(t *testing.T, ns *notificationServiceMock) *FallbackChain {
+		t.Helper()
+		wn, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: json.RawMessage(`{"url": "http://localhost/test"}`),
+			},
+			NotificationService: ns,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+		})
+		require.NoError(t, err)
+		wn.httpRetry = httpRetry{Attempts: 1}
+
+		emailCfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "email_testing",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com"}`),
+		})
+		require.NoError(t, err)
+		en := NewEmailNotifier(emailCfg, &FakeLogger{}, ns, &UnavailableImageStore{}, tmpl, nil)
+
+		return NewFallbackChain(&NotificationChannelConfig{Name: "ops", Type: "fallback_chain"}, []FallbackChainNotifier{
+			{UID: "webhook-uid", Name: "webhook_testing", Type: "webhook", Notifier: wn},
+			{UID: "email-uid", Name: "email_testing", Type: "email", Notifier: en},
+		}, &FakeLogger{})
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	t.Run("webhook success skips email", func(t *testing.T) {
+		ns := mockNotificationService()
+		chain := newChain(t, ns)
+
+		ok, err := chain.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, ns.Webhooks, 1)
+		require.Empty(t, ns.Emails)
+
+		require.Equal(t, []FallbackAttempt{
+			{UID: "webhook-uid", Name: "webhook_testing", Type: "webhook", Attempted: true},
+			{UID: "email-uid", Name: "email_testing", Type: "email"},
+		}, chain.LastAttempts())
+	})
+
+	t.Run("webhook failure triggers email", func(t *testing.T) {
+		ns := mockNotificationService()
+		webhookErr := errors.New("connection refused")
+		ns.WebhookErrors = map[string]error{"http://localhost/test": webhookErr}
+		chain := newChain(t, ns)
+
+		ok, err := chain.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, ns.Webhooks, 1)
+		require.Len(t, ns.Emails, 1)
+		require.Equal(t, []string{"someops@example.com"}, ns.EmailSync.To)
+
+		attempts := chain.LastAttempts()
+		require.Len(t, attempts, 2)
+		require.True(t, attempts[0].Attempted)
+		require.ErrorIs(t, attempts[0].Err, webhookErr)
+		require.Equal(t, FallbackAttempt{UID: "email-uid", Name: "email_testing", Type: "email", Attempted: true}, attempts[1])
+
+		_, lastErr := chain.LastError()
+		require.NoError(t, lastErr)
+	})
+
+	t.Run("fails when all notifiers fail", func(t *testing.T) {
+		ns := mockNotificationService()
+		ns.ShouldError = errors.New("connection refused")
+		chain := newChain(t, ns)
+
+		_, err := chain.Notify(ctx, alert)
+		var chainErr *FallbackChainError
+		require.ErrorAs(t, err, &chainErr)
+		require.EqualError(t, err, "all 2 notifiers of the fallback chain failed: webhook_testing (webhook): connection refused; email_testing (email): failed to send email to 1 of 1 recipients: someops@example.com: connection refused")
+
+		_, lastErr := chain.LastError()
+		require.Equal(t, err, lastErr)
+	})
+}
@@ -0,0 +1,245 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// DefaultGRPCMethod is the full name of the client streaming method notifications are sent to
+// unless configured otherwise. It receives google.protobuf.Struct messages and responds
+// with google.protobuf.Empty.
+const DefaultGRPCMethod = "/grafana.alerting.v1.NotificationSink/Send"
+
+// GRPCNotifier is responsible for sending
+// alert notifications to a gRPC sink.
+type GRPCNotifier struct {
+	*Base
+	log      Logger
+	images   ImageStore
+	tmpl     *template.Template
+	orgID    int64
+	settings grpcSettings
+
+	connMtx sync.Mutex
+	conn    *grpc.ClientConn
+}
+
+type grpcSettings struct {
+	Endpoint      string
+	Method        string
+	Insecure      bool
+	TLSSkipVerify bool
+	// Authorization is sent as the value of the authorization metadata, if set.
+	Authorization string
+
+	Title   string
+	Message string
+}
+
+// GRPCMessage defines the message sent to gRPC sinks. It is sent as a google.protobuf.Struct.
+type GRPCMessage struct {
+	*ExtendedData
+
+	// The protocol version.
+	Version  string `json:"version"`
+	GroupKey string `json:"groupKey"`
+	OrgID    int64  `json:"orgId"`
+	Title    string `json:"title"`
+	State    string `json:"state"`
+	Message  string `json:"message"`
+}
+
+func buildGRPCSettings(fc FactoryConfig) (grpcSettings, error) {
+	settings := grpcSettings{}
+	rawSettings := struct {
+		Endpoint      string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+		Method        string `json:"method,omitempty" yaml:"method,omitempty"`
+		Insecure      bool   `json:"insecure,omitempty" yaml:"insecure,omitempty"`
+		TLSSkipVerify bool   `json:"tlsSkipVerify,omitempty" yaml:"tlsSkipVerify,omitempty"`
+		Authorization string `json:"authorization,omitempty" yaml:"authorization,omitempty"`
+		Title         string `json:"title,omitempty" yaml:"title,omitempty"`
+		Message       string `json:"message,omitempty" yaml:"message,omitempty"`
+	}{}
+
+	err := fc.Config.unmarshalSettings(&rawSettings)
+	if err != nil {
+		return settings, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	if rawSettings.Endpoint == "" {
+		return settings, errors.New("required field 'endpoint' is not specified")
+	}
+	settings.Endpoint = rawSettings.Endpoint
+	settings.Method = rawSettings.Method
+	if settings.Method == "" {
+		settings.Method = DefaultGRPCMethod
+	}
+	settings.Insecure = rawSettings.Insecure
+	settings.TLSSkipVerify = rawSettings.TLSSkipVerify
+	settings.Authorization = fc.DecryptFunc(context.Background(), fc.Config.SecureSettings, "authorization", rawSettings.Authorization)
+	settings.Title = rawSettings.Title
+	if settings.Title == "" {
+		settings.Title = DefaultMessageTitleEmbed
+	}
+	settings.Message = rawSettings.Message
+	if settings.Message == "" {
+		settings.Message = DefaultMessageEmbed
+	}
+	return settings, nil
+}
+
+func GRPCFactory(fc FactoryConfig) (NotificationChannel, error) {
+	notifier, err := newGRPCNotifier(fc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return notifier, nil
+}
+
+// newGRPCNotifier is the constructor function for the gRPC notifier.
+func newGRPCNotifier(fc FactoryConfig) (*GRPCNotifier, error) {
+	settings, err := buildGRPCSettings(fc)
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCNotifier{
+		Base:     NewBase(fc.Config),
+		log:      fc.Logger,
+		images:   fc.ImageStore,
+		tmpl:     fc.Template,
+		orgID:    fc.Config.OrgID,
+		settings: settings,
+	}, nil
+}
+
+// Notify sends the alert notification.
+func (gn *GRPCNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, gn.tmpl, as, gn.log, &tmplErr)
+
+	_ = withStoredImages(ctx, gn.log, gn.images,
+		func(index int, image Image) error {
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
+			}
+			return nil
+		},
+		as...)
+
+	msg := &GRPCMessage{
+		Version:      "1",
+		ExtendedData: data,
+		GroupKey:     groupKey.String(),
+		OrgID:        gn.orgID,
+		Title:        tmpl(gn.settings.Title),
+		Message:      tmpl(gn.settings.Message),
+	}
+	if types.Alerts(as...).Status() == model.AlertFiring {
+		msg.State = string(models.AlertStateAlerting)
+	} else {
+		msg.State = string(models.AlertStateOK)
+	}
+
+	if tmplErr != nil {
+		gn.log.Warn("failed to template gRPC message", "error", tmplErr.Error())
+	}
+
+	payload, err := toStruct(msg)
+	if err != nil {
+		return false, fmt.Errorf("failed to build gRPC message: %w", err)
+	}
+
+	if err := gn.send(ctx, payload); err != nil {
+		return false, fmt.Errorf("failed to send notification to gRPC endpoint %s: %w", gn.settings.Endpoint, err)
+	}
+
+	return true, nil
+}
+
+func (gn *GRPCNotifier) send(ctx context.Context, payload *structpb.Struct) error {
+	conn, err := gn.clientConn()
+	if err != nil {
+		return err
+	}
+
+	if gn.settings.Authorization != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", gn.settings.Authorization)
+	}
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true}, gn.settings.Method)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(payload); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	return stream.RecvMsg(&emptypb.Empty{})
+}
+
+// clientConn returns the connection to the endpoint. The connection is created on first
+// use and reused for subsequent notifications.
+func (gn *GRPCNotifier) clientConn() (*grpc.ClientConn, error) {
+	gn.connMtx.Lock()
+	defer gn.connMtx.Unlock()
+
+	if gn.conn != nil {
+		return gn.conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if !gn.settings.Insecure {
+		creds = credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: gn.settings.TLSSkipVerify,
+		})
+	}
+	conn, err := grpc.Dial(gn.settings.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	gn.conn = conn
+	return conn, nil
+}
+
+// toStruct converts v to a google.protobuf.Struct using its JSON representation.
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(m)
+}
+
+func (gn *GRPCNotifier) SendResolved() bool {
+	return !gn.GetDisableResolveMessage()
+}
@@ -0,0 +1,162 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type grpcTestSink struct {
+	method   string
+	metadata metadata.MD
+	messages []*structpb.Struct
+}
+
+func newGRPCTestServer(t *testing.T, sink *grpcTestSink) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		sink.method, _ = grpc.MethodFromServerStream(stream)
+		sink.metadata, _ = metadata.FromIncomingContext(stream.Context())
+		msg := &structpb.Struct{}
+		if err := stream.RecvMsg(msg); err != nil {
+			return err
+		}
+		sink.messages = append(sink.messages, msg)
+		return stream.SendMsg(&emptypb.Empty{})
+	}))
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+func TestGRPCNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(t *testing.T, settings string, secureSettings map[string][]byte) (*GRPCNotifier, error) {
+		t.Helper()
+		return newGRPCNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				OrgID:          1,
+				Name:           "grpc_testing",
+				Type:           "grpc",
+				Settings:       json.RawMessage(settings),
+				SecureSettings: secureSettings,
+			},
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				if v, ok := sjd[key]; ok {
+					return string(v)
+				}
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+		})
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	ctx = notify.WithReceiverName(ctx, "my_receiver")
+
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+				Annotations: model.LabelSet{"ann1": "annv1", "__dashboardUid__": "abcd", "__panelId__": "efgh"},
+			},
+		},
+	}
+
+	t.Run("sends the notification to the sink", func(t *testing.T) {
+		sink := &grpcTestSink{}
+		endpoint := newGRPCTestServer(t, sink)
+
+		gn, err := newNotifier(t, `{"endpoint": "`+endpoint+`", "insecure": true, "message": "Custom message"}`,
+			map[string][]byte{"authorization": []byte("Bearer secret")})
+		require.NoError(t, err)
+
+		ok, err := gn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Equal(t, DefaultGRPCMethod, sink.method)
+		require.Equal(t, []string{"Bearer secret"}, sink.metadata.Get("authorization"))
+		require.Len(t, sink.messages, 1)
+
+		msg := sink.messages[0].AsMap()
+		require.Equal(t, "1", msg["version"])
+		require.Equal(t, "alertname", msg["groupKey"])
+		require.Equal(t, float64(1), msg["orgId"])
+		require.Equal(t, "[FIRING:1]  (val1)", msg["title"])
+		require.Equal(t, "Custom message", msg["message"])
+		require.Equal(t, "alerting", msg["state"])
+		require.Equal(t, "my_receiver", msg["receiver"])
+		msgAlerts := msg["alerts"].([]interface{})
+		require.Len(t, msgAlerts, 1)
+		alert := msgAlerts[0].(map[string]interface{})
+		require.Equal(t, map[string]interface{}{"alertname": "alert1", "lbl1": "val1"}, alert["labels"])
+		require.Equal(t, "http://localhost/d/abcd", alert["dashboardURL"])
+	})
+
+	t.Run("reuses the connection", func(t *testing.T) {
+		sink := &grpcTestSink{}
+		endpoint := newGRPCTestServer(t, sink)
+
+		gn, err := newNotifier(t, `{"endpoint": "`+endpoint+`", "insecure": true, "method": "/my.Sink/Push"}`, nil)
+		require.NoError(t, err)
+
+		_, err = gn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		conn := gn.conn
+		_, err = gn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+
+		require.Same(t, conn, gn.conn)
+		require.Equal(t, "/my.Sink/Push", sink.method)
+		require.Len(t, sink.messages, 2)
+	})
+
+	t.Run("unreachable endpoint returns an error", func(t *testing.T) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		endpoint := lis.Addr().String()
+		require.NoError(t, lis.Close())
+
+		gn, err := newNotifier(t, `{"endpoint": "`+endpoint+`", "insecure": true}`, nil)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		ok, err := gn.Notify(ctx, alerts...)
+		require.False(t, ok)
+		require.ErrorContains(t, err, "failed to send notification to gRPC endpoint "+endpoint)
+	})
+
+	t.Run("missing endpoint returns an error", func(t *testing.T) {
+		_, err := newNotifier(t, `{}`, nil)
+		require.EqualError(t, err, "required field 'endpoint' is not specified")
+	})
+}
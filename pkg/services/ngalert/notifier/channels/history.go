@@ -0,0 +1,59 @@
+package channels
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// NotificationRecord is an attempt of a contact point to send a notification.
+type NotificationRecord struct {
+	// Channel is the name of the contact point and ChannelType its type, e.g. email.
+	Channel     string
+	ChannelType string
+	// Status is the status of the notified alerts, either firing or resolved.
+	Status string
+	// Recipients are the addresses, or the URL, the notification was sent to.
+	Recipients []string
+	Timestamp  time.Time
+	Success    bool
+	// Error is the reason the notification failed, if it did.
+	Error string
+}
+
+// HistorySink records the notifications sent by contact points, e.g. for audit.
+type HistorySink interface {
+	// Record records an attempt to send a notification. It must not block the notifier.
+	Record(ctx context.Context, record NotificationRecord)
+}
+
+// NoopHistorySink discards all records. It is the sink of notifiers without one.
+type NoopHistorySink struct{}
+
+func (NoopHistorySink) Record(_ context.Context, _ NotificationRecord) {}
+
+// historyOrNoop returns the sink, or a NoopHistorySink if it is nil.
+func historyOrNoop(sink HistorySink) HistorySink {
+	if sink == nil {
+		return NoopHistorySink{}
+	}
+	return sink
+}
+
+// newNotificationRecord returns the record of an attempt of the contact point to notify the
+// recipients about the alerts. A nil err means the attempt succeeded.
+func newNotificationRecord(base *Base, alerts []*types.Alert, recipients []string, err error) NotificationRecord {
+	record := NotificationRecord{
+		Channel:     base.Name,
+		ChannelType: base.Type,
+		Status:      string(types.Alerts(alerts...).Status()),
+		Recipients:  recipients,
+		Timestamp:   timeNow(),
+		Success:     err == nil,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
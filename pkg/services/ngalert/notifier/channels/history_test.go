@@ -0,0 +1,130 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHistorySink struct {
+	records []NotificationRecord
+}
+
+func (f *fakeHistorySink) Record(_ context.Context, record NotificationRecord) {
+	f.records = append(f.records, record)
+}
+
+func TestNotifierHistory(t *testing.T) {
+	now := time.Now()
+	defer mockTimeNow(now)()
+
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: now,
+			EndsAt:   now.Add(time.Hour),
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: now.Add(-time.Hour),
+			EndsAt:   now.Add(-time.Minute),
+		},
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+
+	newFactoryConfig := func(typ, settings string, ns NotificationSender, sink HistorySink) FactoryConfig {
+		return FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     typ + "_testing",
+				Type:     typ,
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: ns,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+			History:    sink,
+		}
+	}
+
+	t.Run("email notifier records each notification", func(t *testing.T) {
+		sink := &fakeHistorySink{}
+		sender := mockNotificationService()
+		n, err := EmailFactory(newFactoryConfig("email", `{"addresses": "someops@example.com;somedev@example.com"}`, sender, sink))
+		require.NoError(t, err)
+
+		_, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+		sender.ShouldError = errors.New("smtp unavailable")
+		_, err = n.Notify(ctx, resolved)
+		require.Error(t, err)
+
+		require.Equal(t, []NotificationRecord{
+			{
+				Channel:     "email_testing",
+				ChannelType: "email",
+				Status:      "firing",
+				Recipients:  []string{"someops@example.com", "somedev@example.com"},
+				Timestamp:   now,
+				Success:     true,
+			},
+			{
+				Channel:     "email_testing",
+				ChannelType: "email",
+				Status:      "resolved",
+				Recipients:  []string{"someops@example.com", "somedev@example.com"},
+				Timestamp:   now,
+				Error:       "failed to send email to 2 of 2 recipients: someops@example.com: smtp unavailable; somedev@example.com: smtp unavailable",
+			},
+		}, sink.records)
+	})
+
+	t.Run("webhook notifier records each notification", func(t *testing.T) {
+		sink := &fakeHistorySink{}
+		sender := mockNotificationService()
+		n, err := WebHookFactory(newFactoryConfig("webhook", `{"url": "http://localhost/test"}`, sender, sink))
+		require.NoError(t, err)
+
+		_, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+
+		require.Equal(t, []NotificationRecord{
+			{
+				Channel:     "webhook_testing",
+				ChannelType: "webhook",
+				Status:      "firing",
+				Recipients:  []string{"http://localhost/test"},
+				Timestamp:   now,
+				Success:     true,
+			},
+		}, sink.records)
+	})
+
+	t.Run("notifiers without a sink don't record notifications", func(t *testing.T) {
+		n, err := EmailFactory(newFactoryConfig("email", `{"addresses": "someops@example.com"}`, mockNotificationService(), nil))
+		require.NoError(t, err)
+
+		_, err = n.Notify(ctx, firing)
+		require.NoError(t, err)
+	})
+}
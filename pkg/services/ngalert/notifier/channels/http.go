@@ -0,0 +1,138 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// httpRetry configures how sendHTTP retries failed requests.
+type httpRetry struct {
+	// Attempts is the maximum number of attempts, including the first one.
+	Attempts int
+	// InitialBackoff is the wait before the first retry. It is doubled after every retry,
+	// up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Budget is charged for every retry, the request is not retried once it is exhausted.
+	// Requests are not retried if it is nil.
+	Budget *RetryBudget
+	// Pacer paces the retries of requests sharing it, if set.
+	Pacer *retryPacer
+	// Metrics count the attempts and retries of the requests as the Integration, if set.
+	Metrics     *SendMetrics
+	Integration string
+}
+
+var defaultHTTPRetry = httpRetry{
+	Attempts:       3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// sendHTTP sends the request, retrying failed attempts with exponential backoff. The timeout
+// of the request bounds all attempts together, and every retry is charged to the retry budget. The proxy and TLS are handled by the sender,
+// so all notifiers that use it behave the same. The error of the last attempt is returned.
+func sendHTTP(ctx context.Context, ns WebhookSender, cmd *SendWebhookSettings, retry httpRetry, l Logger) error {
+	if cmd.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cmd.Timeout)
+		defer cancel()
+	}
+
+	backoff := retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		retry.Metrics.attempt(retry.Integration)
+		err := ns.SendWebhook(ctx, cmd)
+		if err == nil || attempt >= retry.Attempts || !retryableHTTPError(err) || !retry.Budget.Allow() {
+			return err
+		}
+		retry.Metrics.retry(retry.Integration)
+		wait := retry.Pacer.wait(cmd.Url, backoff)
+		l.Debug("retrying failed request", "url", cmd.Url, "attempt", attempt, "backoff", wait, "error", err)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		if backoff *= 2; retry.MaxBackoff > 0 && backoff > retry.MaxBackoff {
+			backoff = retry.MaxBackoff
+		}
+	}
+}
+
+// retryableHTTPError returns false for errors that would fail again on retry. Client errors
+// are not retried, except for request timeouts and rate limits.
+func retryableHTTPError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, notifications.ErrWebhookTargetNotAllowed) {
+		return false
+	}
+	var statusErr *notifications.WebhookStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode/100 == 4 {
+		return statusErr.StatusCode == http.StatusRequestTimeout || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// retryPacer paces the retries of the requests of a notifier, e.g. to the URLs of a webhook
+// that share a backend. Retries of the same URL are at least urlInterval apart, and retries
+// of all URLs together at least sharedInterval apart.
+type retryPacer struct {
+	urlInterval    time.Duration
+	sharedInterval time.Duration
+
+	mtx sync.Mutex
+	// lastRetry is the time of the last retry of each URL, and lastShared the time of the
+	// last retry of any URL. Both can be in the future for retries that are waiting.
+	lastRetry  map[string]time.Time
+	lastShared time.Time
+}
+
+func newRetryPacer(urlInterval, sharedInterval time.Duration) *retryPacer {
+	return &retryPacer{
+		urlInterval:    urlInterval,
+		sharedInterval: sharedInterval,
+		lastRetry:      make(map[string]time.Time),
+	}
+}
+
+// wait reserves the time of the next retry of the URL, no earlier than the backoff, and
+// returns how long to wait for it. It returns the backoff if the pacer is nil.
+func (p *retryPacer) wait(url string, backoff time.Duration) time.Duration {
+	if p == nil {
+		return backoff
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := timeNow()
+	at := now.Add(backoff)
+	if earliest := now.Add(p.urlInterval); at.Before(earliest) {
+		at = earliest
+	}
+	if last, ok := p.lastRetry[url]; ok && at.Before(last.Add(p.urlInterval)) {
+		at = last.Add(p.urlInterval)
+	}
+	if p.sharedInterval > 0 && at.Before(p.lastShared.Add(p.sharedInterval)) {
+		at = p.lastShared.Add(p.sharedInterval)
+	}
+
+	// URLs whose last retry is too long ago to pace the next one are forgotten.
+	for u, last := range p.lastRetry {
+		if !now.Before(last.Add(p.urlInterval)) {
+			delete(p.lastRetry, u)
+		}
+	}
+	p.lastRetry[url] = at
+	if at.After(p.lastShared) {
+		p.lastShared = at
+	}
+	return at.Sub(now)
+}
@@ -0,0 +1,153 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// flakyWebhookSender fails the first len(errs) webhooks with the given errors.
+type flakyWebhookSender struct {
+	errs  []error
+	calls int
+}
+
+func (s *flakyWebhookSender) SendWebhook(ctx context.Context, cmd *SendWebhookSettings) error {
+	s.calls++
+	if s.calls <= len(s.errs) {
+		return s.errs[s.calls-1]
+	}
+	return nil
+}
+
+func TestSendHTTP(t *testing.T) {
+	unavailable := &notifications.WebhookStatusError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+	badRequest := &notifications.WebhookStatusError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"}
+	tooManyRequests := &notifications.WebhookStatusError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}
+
+	cases := []struct {
+		name     string
+		errs     []error
+		budget   int64
+		expCalls int
+		expErr   error
+	}{
+		{
+			name:     "success is not retried",
+			expCalls: 1,
+		},
+		{
+			name:     "failure is retried until it succeeds",
+			errs:     []error{unavailable, unavailable},
+			expCalls: 3,
+		},
+		{
+			name:     "last error is returned after all attempts",
+			errs:     []error{unavailable, unavailable, unavailable},
+			expCalls: 3,
+			expErr:   unavailable,
+		},
+		{
+			name:     "blocked target is not retried",
+			errs:     []error{fmt.Errorf("%w: localhost is not in webhook_allowed_hosts", notifications.ErrWebhookTargetNotAllowed)},
+			expCalls: 1,
+			expErr:   notifications.ErrWebhookTargetNotAllowed,
+		},
+		{
+			name:     "client error is not retried",
+			errs:     []error{badRequest},
+			expCalls: 1,
+			expErr:   badRequest,
+		},
+		{
+			name:     "rate limit is retried",
+			errs:     []error{tooManyRequests},
+			expCalls: 2,
+		},
+		{
+			name:     "retries stop when the budget is exhausted",
+			errs:     []error{unavailable, unavailable, unavailable},
+			budget:   1,
+			expCalls: 2,
+			expErr:   unavailable,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			budget := c.budget
+			if budget == 0 {
+				budget = 10
+			}
+			retry := httpRetry{Attempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, Budget: NewRetryBudget(budget, time.Hour)}
+			sender := &flakyWebhookSender{errs: c.errs}
+			err := sendHTTP(context.Background(), sender, &SendWebhookSettings{Url: "http://localhost/test"}, retry, &FakeLogger{})
+			if c.expErr != nil {
+				require.ErrorIs(t, err, c.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, c.expCalls, sender.calls)
+		})
+	}
+
+	t.Run("timeout bounds all attempts", func(t *testing.T) {
+		sender := &flakyWebhookSender{errs: []error{unavailable, unavailable, unavailable}}
+		start := time.Now()
+		err := sendHTTP(context.Background(), sender, &SendWebhookSettings{
+			Url:     "http://localhost/test",
+			Timeout: 100 * time.Millisecond,
+		}, httpRetry{Attempts: 3, InitialBackoff: time.Minute, Budget: NewRetryBudget(10, time.Hour)}, &FakeLogger{})
+		require.ErrorIs(t, err, unavailable)
+		require.Equal(t, 1, sender.calls)
+		require.Less(t, time.Since(start), time.Minute)
+	})
+}
+
+func TestRetryPacer(t *testing.T) {
+	now := time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC)
+	t.Cleanup(mockTimeNow(now))
+	const primary, secondary = "http://primary/test", "http://secondary/test"
+
+	t.Run("retries of the same URL are at least the URL interval apart", func(t *testing.T) {
+		p := newRetryPacer(10*time.Second, 0)
+		require.Equal(t, 10*time.Second, p.wait(primary, time.Second))
+		require.Equal(t, 20*time.Second, p.wait(primary, time.Second))
+		// A longer backoff is kept.
+		require.Equal(t, time.Minute, p.wait(primary, time.Minute))
+		// Other URLs are not paced by the retries of the first one.
+		require.Equal(t, 10*time.Second, p.wait(secondary, time.Second))
+	})
+
+	t.Run("retries of all URLs are at least the shared interval apart", func(t *testing.T) {
+		p := newRetryPacer(0, 5*time.Second)
+		require.Equal(t, time.Second, p.wait(primary, time.Second))
+		require.Equal(t, 6*time.Second, p.wait(secondary, time.Second))
+		require.Equal(t, 11*time.Second, p.wait(primary, time.Second))
+		require.Equal(t, 16*time.Second, p.wait(secondary, 2*time.Second))
+	})
+
+	t.Run("both intervals pace retries of two URLs", func(t *testing.T) {
+		p := newRetryPacer(10*time.Second, 4*time.Second)
+		require.Equal(t, 10*time.Second, p.wait(primary, time.Second))
+		require.Equal(t, 14*time.Second, p.wait(secondary, time.Second))
+		require.Equal(t, 20*time.Second, p.wait(primary, time.Second))
+		require.Equal(t, 24*time.Second, p.wait(secondary, time.Second))
+
+		// Once the reserved retries have passed, the next ones are only paced by the URL interval.
+		mockTimeNow(now.Add(time.Minute))
+		require.Equal(t, 10*time.Second, p.wait(primary, time.Second))
+		require.Equal(t, 14*time.Second, p.wait(secondary, time.Second))
+		require.Len(t, p.lastRetry, 2)
+	})
+
+	t.Run("nil pacer does not change the backoff", func(t *testing.T) {
+		var p *retryPacer
+		require.Equal(t, time.Second, p.wait(primary, time.Second))
+	})
+}
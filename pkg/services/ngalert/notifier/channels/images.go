@@ -24,3 +24,43 @@ func (i Image) HasURL() bool {
 type ImageStore interface {
 	GetImage(ctx context.Context, token string) (*Image, error)
 }
+
+// ImageLimiter caps how many images are requested at the same time. It is shared by all
+// contact points so that a burst of notifications queues instead of overwhelming the image
+// store and the renderer. It is safe to use concurrently.
+//
+// A nil ImageLimiter does not limit requests.
+type ImageLimiter struct {
+	sem chan struct{}
+}
+
+// NewImageLimiter returns a limiter that allows limit image requests at the same time.
+func NewImageLimiter(limit int64) *ImageLimiter {
+	return &ImageLimiter{sem: make(chan struct{}, limit)}
+}
+
+// LimitImageStore returns an ImageStore that waits for the limiter before requesting
+// images from store. It returns store if the limiter is nil.
+func LimitImageStore(store ImageStore, limiter *ImageLimiter) ImageStore {
+	if limiter == nil {
+		return store
+	}
+	return &limitedImageStore{store: store, limiter: limiter}
+}
+
+type limitedImageStore struct {
+	store   ImageStore
+	limiter *ImageLimiter
+}
+
+// GetImage waits until fewer images than the limit are being requested, or until the
+// context is done, and then requests the image from the store.
+func (s *limitedImageStore) GetImage(ctx context.Context, token string) (*Image, error) {
+	select {
+	case s.limiter.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-s.limiter.sem }()
+	return s.store.GetImage(ctx, token)
+}
@@ -0,0 +1,74 @@
+package channels
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// slowImageStore is an image store that counts how many images are requested at the same time.
+type slowImageStore struct {
+	ImageStore
+	delay         time.Duration
+	inFlight      int64
+	maxInFlight   int64
+	totalRequests int64
+}
+
+func (s *slowImageStore) GetImage(ctx context.Context, token string) (*Image, error) {
+	n := atomic.AddInt64(&s.inFlight, 1)
+	defer atomic.AddInt64(&s.inFlight, -1)
+	atomic.AddInt64(&s.totalRequests, 1)
+	for {
+		prev := atomic.LoadInt64(&s.maxInFlight)
+		if n <= prev || atomic.CompareAndSwapInt64(&s.maxInFlight, prev, n) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	return s.ImageStore.GetImage(ctx, token)
+}
+
+func TestLimitImageStore(t *testing.T) {
+	t.Run("no more than the limit of images are requested at the same time during a burst", func(t *testing.T) {
+		store := &slowImageStore{ImageStore: newFakeImageStore(1), delay: 10 * time.Millisecond}
+		limiter := NewImageLimiter(3)
+		// Contact points have their own store, but share the limiter.
+		stores := []ImageStore{LimitImageStore(store, limiter), LimitImageStore(store, limiter)}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 30; i++ {
+			wg.Add(1)
+			go func(s ImageStore) {
+				defer wg.Done()
+				img, err := s.GetImage(context.Background(), "test-image-1")
+				require.NoError(t, err)
+				require.Equal(t, "test-image-1", img.Token)
+			}(stores[i%len(stores)])
+		}
+		wg.Wait()
+
+		require.Equal(t, int64(30), store.totalRequests)
+		require.Equal(t, int64(3), store.maxInFlight)
+	})
+
+	t.Run("waiting requests fail when the context is done", func(t *testing.T) {
+		limiter := NewImageLimiter(1)
+		limiter.sem <- struct{}{}
+		s := LimitImageStore(newFakeImageStore(1), limiter)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := s.GetImage(ctx, "test-image-1")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("nil limiter does not limit requests", func(t *testing.T) {
+		store := newFakeImageStore(1)
+		require.Equal(t, store, LimitImageStore(store, nil))
+	})
+}
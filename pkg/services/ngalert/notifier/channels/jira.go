@@ -0,0 +1,274 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// DefaultJiraIssueType is the type of the issues that are created if it is not set.
+	DefaultJiraIssueType = "Bug"
+	// JiraDedupLabelPrefix prefixes the hash of the group key in the label that identifies
+	// the issue of an alert group.
+	JiraDedupLabelPrefix = "grafana-"
+	// https://support.atlassian.com/jira-software-cloud/docs/what-is-an-issue/ - summaries
+	// are limited to 255 characters.
+	jiraMaxSummaryLenRunes = 255
+)
+
+// JiraNotifier is responsible for creating Jira issues for alert groups. A group that
+// already has an open issue updates it instead of creating another one.
+type JiraNotifier struct {
+	*Base
+	log      Logger
+	ns       WebhookSender
+	tmpl     *template.Template
+	settings jiraSettings
+}
+
+type jiraSettings struct {
+	URL       string
+	Project   string
+	IssueType string
+	// User and APIToken authenticate with HTTP Basic Authentication, e.g. the email and an
+	// API token of Jira Cloud. APIToken is sent as a bearer token, e.g. a personal access
+	// token of Jira Data Center, if User is not set.
+	User        string
+	APIToken    string
+	Summary     string
+	Description string
+}
+
+type jiraIssueFields struct {
+	Project     *jiraProject   `json:"project,omitempty"`
+	IssueType   *jiraIssueType `json:"issuetype,omitempty"`
+	Summary     string         `json:"summary"`
+	Description string         `json:"description"`
+	Labels      []string       `json:"labels"`
+}
+
+type jiraProject struct {
+	Key string `json:"key"`
+}
+
+type jiraIssueType struct {
+	Name string `json:"name"`
+}
+
+type jiraIssue struct {
+	Key    string           `json:"key,omitempty"`
+	Fields *jiraIssueFields `json:"fields,omitempty"`
+}
+
+type jiraSearch struct {
+	JQL        string   `json:"jql"`
+	Fields     []string `json:"fields"`
+	MaxResults int      `json:"maxResults"`
+}
+
+type jiraSearchResult struct {
+	Issues []jiraIssue `json:"issues"`
+}
+
+func JiraFactory(fc FactoryConfig) (NotificationChannel, error) {
+	notifier, err := newJiraNotifier(fc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return notifier, nil
+}
+
+func buildJiraSettings(fc FactoryConfig) (jiraSettings, error) {
+	var raw struct {
+		URL         string `json:"url,omitempty" yaml:"url,omitempty"`
+		Project     string `json:"project,omitempty" yaml:"project,omitempty"`
+		IssueType   string `json:"issueType,omitempty" yaml:"issueType,omitempty"`
+		User        string `json:"user,omitempty" yaml:"user,omitempty"`
+		APIToken    string `json:"apiToken,omitempty" yaml:"apiToken,omitempty"`
+		Summary     string `json:"summary,omitempty" yaml:"summary,omitempty"`
+		Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	}
+	if err := fc.Config.unmarshalSettings(&raw); err != nil {
+		return jiraSettings{}, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+
+	settings := jiraSettings{
+		URL:         strings.TrimRight(raw.URL, "/"),
+		Project:     raw.Project,
+		IssueType:   raw.IssueType,
+		User:        raw.User,
+		APIToken:    fc.DecryptFunc(context.Background(), fc.Config.SecureSettings, "apiToken", raw.APIToken),
+		Summary:     raw.Summary,
+		Description: raw.Description,
+	}
+	if settings.URL == "" {
+		return settings, errors.New("could not find url property in settings")
+	}
+	if settings.Project == "" {
+		return settings, errors.New("could not find project property in settings")
+	}
+	if settings.APIToken == "" {
+		return settings, errors.New("could not find api token property in settings")
+	}
+	if settings.IssueType == "" {
+		settings.IssueType = DefaultJiraIssueType
+	}
+	if strings.TrimSpace(settings.Summary) == "" {
+		settings.Summary = DefaultMessageTitleEmbed
+	}
+	if strings.TrimSpace(settings.Description) == "" {
+		settings.Description = DefaultMessageEmbed
+	}
+	return settings, nil
+}
+
+// newJiraNotifier is the constructor function for the Jira notifier.
+func newJiraNotifier(fc FactoryConfig) (*JiraNotifier, error) {
+	settings, err := buildJiraSettings(fc)
+	if err != nil {
+		return nil, err
+	}
+	return &JiraNotifier{
+		Base:     NewBase(fc.Config),
+		log:      fc.Logger,
+		ns:       fc.NotificationService,
+		tmpl:     fc.Template,
+		settings: settings,
+	}, nil
+}
+
+// Notify creates an issue for the alert group, or updates the open issue of the group.
+func (jn *JiraNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	key, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	dedupLabel := JiraDedupLabelPrefix + key.Hash()
+
+	issueKey, err := jn.findIssue(ctx, dedupLabel)
+	if err != nil {
+		return false, fmt.Errorf("search Jira issue: %w", err)
+	}
+	if issueKey == "" && types.Alerts(as...).Status() == model.AlertResolved {
+		jn.log.Debug("no Jira issue to update for resolved alerts", "group", key)
+		return true, nil
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, jn.tmpl, as, jn.log, &tmplErr)
+	summary, truncated := TruncateInRunes(tmpl(jn.settings.Summary), jiraMaxSummaryLenRunes)
+	if truncated {
+		jn.log.Warn("Truncated summary", "group", key, "max_runes", jiraMaxSummaryLenRunes)
+	}
+	fields := &jiraIssueFields{
+		Summary:     summary,
+		Description: tmpl(jn.settings.Description),
+		Labels:      jiraLabels(data.CommonLabels, dedupLabel),
+	}
+	if tmplErr != nil {
+		jn.log.Warn("failed to template Jira issue", "error", tmplErr.Error())
+	}
+
+	if issueKey != "" {
+		body, err := json.Marshal(jiraIssue{Fields: fields})
+		if err != nil {
+			return false, err
+		}
+		if err := jn.send(ctx, http.MethodPut, jn.settings.URL+"/rest/api/2/issue/"+issueKey, body, nil); err != nil {
+			return false, fmt.Errorf("update Jira issue %s: %w", issueKey, err)
+		}
+		jn.log.Debug("updated Jira issue", "issue", issueKey, "group", key)
+		return true, nil
+	}
+
+	fields.Project = &jiraProject{Key: jn.settings.Project}
+	fields.IssueType = &jiraIssueType{Name: jn.settings.IssueType}
+	body, err := json.Marshal(jiraIssue{Fields: fields})
+	if err != nil {
+		return false, err
+	}
+	var created jiraIssue
+	if err := jn.send(ctx, http.MethodPost, jn.settings.URL+"/rest/api/2/issue", body, &created); err != nil {
+		return false, fmt.Errorf("create Jira issue: %w", err)
+	}
+	jn.log.Debug("created Jira issue", "issue", created.Key, "group", key)
+	return true, nil
+}
+
+// findIssue returns the key of the most recent open issue with the label, or an empty
+// string if there is none.
+func (jn *JiraNotifier) findIssue(ctx context.Context, label string) (string, error) {
+	body, err := json.Marshal(jiraSearch{
+		JQL:        fmt.Sprintf("project = %q AND labels = %q AND statusCategory != Done ORDER BY created DESC", jn.settings.Project, label),
+		Fields:     []string{"key"},
+		MaxResults: 1,
+	})
+	if err != nil {
+		return "", err
+	}
+	var result jiraSearchResult
+	if err := jn.send(ctx, http.MethodPost, jn.settings.URL+"/rest/api/2/search", body, &result); err != nil {
+		return "", err
+	}
+	if len(result.Issues) == 0 {
+		return "", nil
+	}
+	return result.Issues[0].Key, nil
+}
+
+// send sends the request to the Jira API, and decodes a successful response into result
+// if it is not nil.
+func (jn *JiraNotifier) send(ctx context.Context, method, url string, body []byte, result interface{}) error {
+	cmd := &SendWebhookSettings{
+		Url:        url,
+		Body:       string(body),
+		HttpMethod: method,
+		HttpHeader: map[string]string{
+			"Content-Type": "application/json",
+			"Accept":       "application/json",
+		},
+	}
+	if jn.settings.User != "" {
+		cmd.User = jn.settings.User
+		cmd.Password = jn.settings.APIToken
+	} else {
+		cmd.HttpHeader["Authorization"] = "Bearer " + jn.settings.APIToken
+	}
+	if result != nil {
+		cmd.Validation = func(b []byte, statusCode int) error {
+			// Unsuccessful responses are reported by the sender.
+			if statusCode/100 != 2 {
+				return nil
+			}
+			return json.Unmarshal(b, result)
+		}
+	}
+	return jn.ns.SendWebhook(ctx, cmd)
+}
+
+// jiraLabels returns the labels of the issue: the dedup label followed by the common labels
+// of the alerts as name=value. Jira labels cannot contain spaces, they are replaced by
+// underscores.
+func jiraLabels(common template.KV, dedupLabel string) []string {
+	labels := []string{dedupLabel}
+	for _, p := range common.SortedPairs() {
+		labels = append(labels, strings.ReplaceAll(p.Name+"="+p.Value, " ", "_"))
+	}
+	return labels
+}
+
+func (jn *JiraNotifier) SendResolved() bool {
+	return !jn.GetDisableResolveMessage()
+}
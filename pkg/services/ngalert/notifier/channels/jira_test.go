@@ -0,0 +1,265 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJira is a mock of the Jira REST API that keeps the issues it creates.
+type fakeJira struct {
+	t      *testing.T
+	mtx    sync.Mutex
+	issues map[string]*jiraIssueFields
+	done   map[string]bool
+	auth   []string
+}
+
+func newFakeJira(t *testing.T) (*fakeJira, *httptest.Server) {
+	f := &fakeJira{t: t, issues: map[string]*jiraIssueFields{}, done: map[string]bool{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+
+	b, err := io.ReadAll(r.Body)
+	require.NoError(f.t, err)
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/search":
+		var search jiraSearch
+		require.NoError(f.t, json.Unmarshal(b, &search))
+		result := jiraSearchResult{Issues: []jiraIssue{}}
+		for key, fields := range f.issues {
+			if f.done[key] {
+				continue
+			}
+			for _, l := range fields.Labels {
+				if strings.Contains(search.JQL, fmt.Sprintf("labels = %q", l)) {
+					result.Issues = append(result.Issues, jiraIssue{Key: key})
+				}
+			}
+		}
+		require.NoError(f.t, json.NewEncoder(w).Encode(result))
+	case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+		var issue jiraIssue
+		require.NoError(f.t, json.Unmarshal(b, &issue))
+		key := fmt.Sprintf("%s-%d", issue.Fields.Project.Key, len(f.issues)+1)
+		f.issues[key] = issue.Fields
+		w.WriteHeader(http.StatusCreated)
+		require.NoError(f.t, json.NewEncoder(w).Encode(jiraIssue{Key: key}))
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/rest/api/2/issue/"):
+		key := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+		existing, ok := f.issues[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var issue jiraIssue
+		require.NoError(f.t, json.Unmarshal(b, &issue))
+		require.Nil(f.t, issue.Fields.Project, "the project of an issue cannot be updated")
+		issue.Fields.Project, issue.Fields.IssueType = existing.Project, existing.IssueType
+		f.issues[key] = issue.Fields
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestJiraNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	jira, server := newFakeJira(t)
+	pn, err := newJiraNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name: "jira_testing",
+			Type: "jira",
+			Settings: json.RawMessage(fmt.Sprintf(`{
+				"url": %q,
+				"project": "OPS",
+				"user": "ops@example.com",
+				"apiToken": "token",
+				"summary": "{{ .CommonLabels.alertname }} is {{ .Status }}"
+			}`, server.URL+"/")),
+		},
+		NotificationService: createWebhookSender(t),
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		Template: tmpl,
+		Logger:   &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	notifyGroup := func(groupKey string, alerts ...*types.Alert) {
+		t.Helper()
+		ctx := notify.WithGroupKey(context.Background(), groupKey)
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+		ok, err := pn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	firing := &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "site reliability"}},
+	}
+
+	t.Run("creates an issue for a new alert group", func(t *testing.T) {
+		notifyGroup("group1", firing)
+
+		require.Len(t, jira.issues, 1)
+		issue := jira.issues["OPS-1"]
+		require.Equal(t, "OPS", issue.Project.Key)
+		require.Equal(t, DefaultJiraIssueType, issue.IssueType.Name)
+		require.Equal(t, "alert1 is firing", issue.Summary)
+		require.Contains(t, issue.Description, "alertname = alert1")
+		require.Equal(t, []string{
+			JiraDedupLabelPrefix + notify.Key("group1").Hash(),
+			"alertname=alert1",
+			"team=site_reliability",
+		}, issue.Labels)
+		require.Equal(t, "Basic b3BzQGV4YW1wbGUuY29tOnRva2Vu", jira.auth[len(jira.auth)-1])
+	})
+
+	t.Run("updates the open issue of a still firing alert group", func(t *testing.T) {
+		notifyGroup("group1", firing, &types.Alert{
+			Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "billing"}},
+		})
+
+		require.Len(t, jira.issues, 1)
+		issue := jira.issues["OPS-1"]
+		require.Equal(t, "OPS", issue.Project.Key)
+		require.Equal(t, "alert1 is firing", issue.Summary)
+		require.Contains(t, issue.Description, "team = billing")
+		require.Equal(t, []string{JiraDedupLabelPrefix + notify.Key("group1").Hash(), "alertname=alert1"}, issue.Labels)
+	})
+
+	t.Run("updates the open issue when the alert group is resolved", func(t *testing.T) {
+		notifyGroup("group1", &types.Alert{
+			Alert: model.Alert{Labels: firing.Labels, EndsAt: time.Now().Add(-time.Minute)},
+		})
+
+		require.Len(t, jira.issues, 1)
+		require.Equal(t, "alert1 is resolved", jira.issues["OPS-1"].Summary)
+	})
+
+	t.Run("creates another issue once the open issue is done", func(t *testing.T) {
+		jira.done["OPS-1"] = true
+		notifyGroup("group1", firing)
+
+		require.Len(t, jira.issues, 2)
+		require.Equal(t, "alert1 is firing", jira.issues["OPS-2"].Summary)
+	})
+
+	t.Run("does not create an issue for a resolved alert group without one", func(t *testing.T) {
+		notifyGroup("group2", &types.Alert{
+			Alert: model.Alert{Labels: firing.Labels, EndsAt: time.Now().Add(-time.Minute)},
+		})
+
+		require.Len(t, jira.issues, 2)
+	})
+}
+
+func TestJiraNotifierSettings(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	cases := []struct {
+		name        string
+		settings    string
+		expSettings jiraSettings
+		expErr      string
+	}{
+		{
+			name:     "defaults",
+			settings: `{"url": "https://example.atlassian.net/", "project": "OPS", "apiToken": "token"}`,
+			expSettings: jiraSettings{
+				URL:         "https://example.atlassian.net",
+				Project:     "OPS",
+				IssueType:   DefaultJiraIssueType,
+				APIToken:    "token",
+				Summary:     DefaultMessageTitleEmbed,
+				Description: DefaultMessageEmbed,
+			},
+		},
+		{
+			name:     "missing url",
+			settings: `{"project": "OPS", "apiToken": "token"}`,
+			expErr:   "could not find url property in settings",
+		},
+		{
+			name:     "missing project",
+			settings: `{"url": "https://example.atlassian.net", "apiToken": "token"}`,
+			expErr:   "could not find project property in settings",
+		},
+		{
+			name:     "missing api token",
+			settings: `{"url": "https://example.atlassian.net", "project": "OPS"}`,
+			expErr:   "could not find api token property in settings",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settings, err := buildJiraSettings(FactoryConfig{
+				Config: &NotificationChannelConfig{
+					Name:     "jira_testing",
+					Type:     "jira",
+					Settings: json.RawMessage(c.settings),
+				},
+				DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+					return fallback
+				},
+				Template: tmpl,
+				Logger:   &FakeLogger{},
+			})
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expSettings, settings)
+		})
+	}
+
+	t.Run("api token is a bearer token without a user", func(t *testing.T) {
+		webhookSender := mockNotificationService()
+		pn, err := newJiraNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "jira_testing",
+				Type:     "jira",
+				Settings: json.RawMessage(`{"url": "https://example.atlassian.net", "project": "OPS", "apiToken": "token"}`),
+			},
+			NotificationService: webhookSender,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			Template: tmpl,
+			Logger:   &FakeLogger{},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, pn.send(context.Background(), http.MethodPost, "https://example.atlassian.net/rest/api/2/search", nil, nil))
+		require.Equal(t, "Bearer token", webhookSender.Webhook.HttpHeader["Authorization"])
+		require.Empty(t, webhookSender.Webhook.User)
+	})
+}
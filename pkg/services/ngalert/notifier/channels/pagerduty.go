@@ -34,6 +34,9 @@ var (
 	PagerdutyEventAPIURL = "https://events.pagerduty.com/v2/enqueue"
 )
 
+// severityRank orders the known severities, the highest first.
+var severityRank = []string{defaultSeverity, "error", "warning", "info"}
+
 // PagerdutyNotifier is responsible for sending
 // alert notifications to pagerduty
 type PagerdutyNotifier struct {
@@ -56,6 +59,11 @@ type pagerdutySettings struct {
 	Source        string `json:"source,omitempty" yaml:"source,omitempty"`
 	Client        string `json:"client,omitempty" yaml:"client,omitempty"`
 	ClientURL     string `json:"client_url,omitempty" yaml:"client_url,omitempty"`
+	// SeverityLabel is the label the severity is read from. The highest known severity of
+	// the alerts is used, or Severity if none of them has a known severity.
+	SeverityLabel string `json:"severityLabel,omitempty" yaml:"severityLabel,omitempty"`
+	// Details are templated custom details added to, or replacing, the default ones.
+	Details map[string]string `json:"details,omitempty" yaml:"details,omitempty"`
 }
 
 func buildPagerdutySettings(fc FactoryConfig) (*pagerdutySettings, error) {
@@ -76,6 +84,9 @@ func buildPagerdutySettings(fc FactoryConfig) (*pagerdutySettings, error) {
 		"num_firing":   `{{ .Alerts.Firing | len }}`,
 		"num_resolved": `{{ .Alerts.Resolved | len }}`,
 	}
+	for k, v := range settings.Details {
+		settings.customDetails[k] = v
+	}
 
 	if settings.Severity == "" {
 		settings.Severity = defaultSeverity
@@ -186,14 +197,17 @@ func (pn *PagerdutyNotifier) buildPagerdutyMessage(ctx context.Context, alerts m
 
 	details := make(map[string]string, len(pn.settings.customDetails))
 	for k, v := range pn.settings.customDetails {
-		detail, err := pn.tmpl.ExecuteTextString(v, data)
+		detail, err := executeTextString(pn.tmpl, v, data)
 		if err != nil {
 			return nil, "", fmt.Errorf("%q: failed to template %q: %w", k, v, err)
 		}
 		details[k] = detail
 	}
 
-	severity := strings.ToLower(tmpl(pn.settings.Severity))
+	severity := severityFromLabel(as, pn.settings.SeverityLabel)
+	if severity == "" {
+		severity = strings.ToLower(tmpl(pn.settings.Severity))
+	}
 	if _, ok := knownSeverity[severity]; !ok {
 		pn.log.Warn("Severity is not in the list of known values - using default severity", "actualSeverity", severity, "defaultSeverity", defaultSeverity)
 		severity = defaultSeverity
@@ -243,6 +257,29 @@ func (pn *PagerdutyNotifier) buildPagerdutyMessage(ctx context.Context, alerts m
 	return msg, eventType, nil
 }
 
+// severityFromLabel returns the highest known severity in the label of the alerts. Only
+// firing alerts are taken into account, unless all of them are resolved. It returns an
+// empty string if the label is not set or none of the alerts has a known severity.
+func severityFromLabel(as []*types.Alert, label string) string {
+	if label == "" {
+		return ""
+	}
+	resolved := types.Alerts(as...).Status() == model.AlertResolved
+	found := make(map[string]struct{}, len(severityRank))
+	for _, a := range as {
+		if !resolved && a.Resolved() {
+			continue
+		}
+		found[strings.ToLower(string(a.Labels[model.LabelName(label)]))] = struct{}{}
+	}
+	for _, severity := range severityRank {
+		if _, ok := found[severity]; ok {
+			return severity
+		}
+	}
+	return ""
+}
+
 func (pn *PagerdutyNotifier) SendResolved() bool {
 	return !pn.GetDisableResolveMessage()
 }
@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -316,3 +317,70 @@ func TestPagerdutyNotifier(t *testing.T) {
 		})
 	}
 }
+
+func TestPagerdutyNotifierTriggerAndResolve(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := mockNotificationService()
+	pn, err := newPagerdutyNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name: "pageduty_testing",
+			Type: "pagerduty",
+			Settings: json.RawMessage(`{
+				"integrationKey": "abcdefgh0123456789",
+				"severity": "info",
+				"severityLabel": "priority",
+				"details": {"team": "{{ .CommonLabels.team }}", "num_firing": "{{ len .Alerts.Firing }} alerts"}
+			}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		Template: tmpl,
+		Logger:   &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "{}:{alertname=\"alert1\"}")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	notifyEvent := func(alerts ...*types.Alert) pagerDutyMessage {
+		t.Helper()
+		ok, err := pn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		var msg pagerDutyMessage
+		require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+		return msg
+	}
+	resolved := time.Now().Add(-time.Minute)
+	alert := func(priority string, endsAt time.Time) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert1", "team": "sre", "priority": model.LabelValue(priority)},
+			EndsAt: endsAt,
+		}}
+	}
+
+	trigger := notifyEvent(alert("warning", time.Time{}), alert("Critical", resolved), alert("error", time.Time{}))
+	require.Equal(t, pagerDutyEventTrigger, trigger.EventAction)
+	require.Equal(t, "abcdefgh0123456789", trigger.RoutingKey)
+	// The severity is the highest one of the firing alerts.
+	require.Equal(t, "error", trigger.Payload.Severity)
+	require.Equal(t, "sre", trigger.Payload.CustomDetails["team"])
+	require.Equal(t, "2 alerts", trigger.Payload.CustomDetails["num_firing"])
+	require.Equal(t, "1", trigger.Payload.CustomDetails["num_resolved"])
+
+	resolve := notifyEvent(alert("warning", resolved), alert("unknown", resolved))
+	require.Equal(t, pagerDutyEventResolve, resolve.EventAction)
+	require.Equal(t, "warning", resolve.Payload.Severity)
+	// The dedup key of the group is stable, so the event resolves the incident of the trigger.
+	require.Equal(t, trigger.DedupKey, resolve.DedupKey)
+	require.Equal(t, notify.Key("{}:{alertname=\"alert1\"}").Hash(), resolve.DedupKey)
+
+	unknown := notifyEvent(alert("P1", time.Time{}))
+	require.Equal(t, "info", unknown.Payload.Severity)
+}
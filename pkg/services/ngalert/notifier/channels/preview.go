@@ -0,0 +1,65 @@
+package channels
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+)
+
+// TemplatePreview is the output of the templates of a notifier for sample data.
+type TemplatePreview struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	// Headers are the headers of emails, with the custom headers rendered.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// TemplatePreviewError is returned when a template of a notifier fails to execute for the
+// sample data of a preview.
+type TemplatePreviewError struct {
+	// Field is the setting of the notifier with the template, e.g. subject.
+	Field string
+	Err   error
+}
+
+func (e TemplatePreviewError) Error() string {
+	return fmt.Sprintf("failed to render the %s template: %s", e.Field, e.Err)
+}
+
+func (e TemplatePreviewError) Unwrap() error {
+	return e.Err
+}
+
+// previewData returns a copy of the sample data with the labels excluded, the secrets
+// redacted, the label values truncated and the timestamps in the time zone, like the data
+// of notifications.
+func previewData(data *ExtendedData, exclude LabelPatterns, redact RedactPatterns, maxLabelValueLength int, loc *time.Location) *ExtendedData {
+	d := *data
+	d.Alerts = append(ExtendedAlerts(nil), data.Alerts...)
+	excludeLabels(&d, exclude)
+	redactValues(&d, redact)
+	truncateLabelValues(&d, maxLabelValueLength)
+	inTimezone(&d, loc)
+	return &d
+}
+
+// previewRenderer executes the templates of a preview. Unlike notifications, templates that
+// fail to execute do not fall back to the default templates: the first error is kept so it
+// can be shown to the user, and the templates after it are not executed.
+type previewRenderer struct {
+	tmpl *template.Template
+	data *ExtendedData
+	err  error
+}
+
+func (r *previewRenderer) render(field, text string) string {
+	if r.err != nil {
+		return ""
+	}
+	s, err := executeTextString(r.tmpl, text, r.data)
+	if err != nil {
+		r.err = TemplatePreviewError{Field: field, Err: err}
+	}
+	return s
+}
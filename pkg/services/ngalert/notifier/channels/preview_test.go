@@ -0,0 +1,115 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/require"
+)
+
+func previewSampleData() *ExtendedData {
+	startsAt := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+	return &ExtendedData{
+		Receiver: "ops",
+		Status:   "firing",
+		Alerts: ExtendedAlerts{{
+			Status:   "firing",
+			Labels:   template.KV{"alertname": "HighCPU", "instance": "host-1"},
+			StartsAt: startsAt,
+		}},
+		GroupLabels:  template.KV{"alertname": "HighCPU"},
+		CommonLabels: template.KV{"alertname": "HighCPU", "instance": "host-1"},
+		ExternalURL:  "http://localhost",
+	}
+}
+
+func TestEmailNotifierRenderPreview(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	newNotifier := func(t *testing.T, settings string) *EmailNotifier {
+		t.Helper()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(settings),
+		})
+		require.NoError(t, err)
+		// The preview must not send emails nor get images, so there are no services to use.
+		return NewEmailNotifier(cfg, &FakeLogger{}, nil, nil, tmpl, nil)
+	}
+
+	t.Run("valid templates", func(t *testing.T) {
+		en := newNotifier(t, `{
+			"addresses": "someops@example.com",
+			"subject": "{{ .CommonLabels.alertname }} is {{ .Status }}",
+			"message": "{{ range .Alerts }}{{ .Labels.instance }} since {{ localTime .StartsAt \"15:04\" }}{{ end }}",
+			"timezone": "Europe/Paris",
+			"headers": {"X-Alert": "{{ .CommonLabels.alertname }}"}
+		}`)
+
+		preview, err := en.RenderPreview(previewSampleData())
+		require.NoError(t, err)
+		require.Equal(t, TemplatePreview{
+			Title:   "HighCPU is firing",
+			Message: "host-1 since 13:00",
+			Headers: map[string]string{"X-Alert": "HighCPU"},
+		}, preview)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		en := newNotifier(t, `{"addresses": "someops@example.com", "subject": "{{ .CommonLabels.alertname "}`)
+
+		_, err := en.RenderPreview(previewSampleData())
+		var previewErr TemplatePreviewError
+		require.ErrorAs(t, err, &previewErr)
+		require.Equal(t, "subject", previewErr.Field)
+		require.ErrorContains(t, err, "failed to render the subject template: template: :1: unclosed action")
+	})
+}
+
+func TestWebhookNotifierRenderPreview(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	newNotifier := func(t *testing.T, settings string) *WebhookNotifier {
+		t.Helper()
+		wn, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: json.RawMessage(settings),
+			},
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			Template: tmpl,
+			Logger:   &FakeLogger{},
+		})
+		require.NoError(t, err)
+		return wn
+	}
+
+	t.Run("valid templates", func(t *testing.T) {
+		wn := newNotifier(t, `{"url": "http://localhost/test", "title": "{{ .CommonLabels.alertname }}", "message": "{{ len .Alerts.Firing }} firing"}`)
+
+		preview, err := wn.RenderPreview(previewSampleData())
+		require.NoError(t, err)
+		require.Equal(t, TemplatePreview{Title: "HighCPU", Message: "1 firing"}, preview)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		wn := newNotifier(t, `{"url": "http://localhost/test", "message": "{{ range .Alerts }}{{ .Labels.instance }}"}`)
+
+		_, err := wn.RenderPreview(previewSampleData())
+		require.ErrorContains(t, err, "failed to render the message template: template: :1: unexpected EOF")
+	})
+
+	t.Run("execution error", func(t *testing.T) {
+		wn := newNotifier(t, `{"url": "http://localhost/test", "title": "{{ template \"missing\" . }}"}`)
+
+		_, err := wn.RenderPreview(previewSampleData())
+		require.ErrorContains(t, err, `failed to render the title template: template: :1:12: executing "" at <{{template "missing" .}}>: template "missing" not defined`)
+	})
+}
@@ -0,0 +1,138 @@
+package channels
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRecentlyResolvedWindow is how long resolved alerts are included in the resolved
+	// notifications of their group if the window is not set.
+	defaultRecentlyResolvedWindow = time.Hour
+	// recentlyResolvedMaxGroups and recentlyResolvedMaxAlerts bound the resolved alerts that
+	// are kept. The groups whose last alert resolved the longest ago, and the oldest resolved
+	// alerts of a group, are dropped first.
+	recentlyResolvedMaxGroups = 1000
+	recentlyResolvedMaxAlerts = 100
+)
+
+// recentlyResolved keeps the alerts that were resolved in the notifications of each group
+// for the window, so that the later resolved notifications of the group can include them
+// for context.
+//
+// A nil recentlyResolved keeps no alerts.
+type recentlyResolved struct {
+	window time.Duration
+
+	mtx    sync.Mutex
+	groups map[string][]resolvedAlert
+}
+
+// resolvedAlert is an alert and the time of the notification it was resolved in.
+type resolvedAlert struct {
+	alert ExtendedAlert
+	at    time.Time
+}
+
+func newRecentlyResolved(window time.Duration) *recentlyResolved {
+	if window == 0 {
+		window = defaultRecentlyResolvedWindow
+	}
+	return &recentlyResolved{
+		window: window,
+		groups: make(map[string][]resolvedAlert),
+	}
+}
+
+// add keeps the resolved alerts of a notification of the group. An alert that is already
+// kept is replaced, so the window starts again.
+func (r *recentlyResolved) add(groupKey string, alerts ExtendedAlerts) {
+	if r == nil {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := timeNow()
+	r.prune(now)
+	resolved := alerts.Resolved()
+	if len(resolved) == 0 {
+		return
+	}
+
+	replaced := make(map[string]bool, len(resolved))
+	for _, a := range resolved {
+		replaced[a.Fingerprint] = true
+	}
+	kept := make([]resolvedAlert, 0, len(r.groups[groupKey])+len(resolved))
+	for _, e := range r.groups[groupKey] {
+		if !replaced[e.alert.Fingerprint] {
+			kept = append(kept, e)
+		}
+	}
+	for _, a := range resolved {
+		kept = append(kept, resolvedAlert{alert: a, at: now})
+	}
+	if len(kept) > recentlyResolvedMaxAlerts {
+		kept = kept[len(kept)-recentlyResolvedMaxAlerts:]
+	}
+
+	if _, ok := r.groups[groupKey]; !ok && len(r.groups) >= recentlyResolvedMaxGroups {
+		r.evictOldestGroup()
+	}
+	r.groups[groupKey] = kept
+}
+
+// recent returns the alerts of the group that were resolved within the window in previous
+// notifications, oldest first, except the alerts of the current notification.
+func (r *recentlyResolved) recent(groupKey string, current ExtendedAlerts) ExtendedAlerts {
+	if r == nil {
+		return nil
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.prune(timeNow())
+	exclude := make(map[string]bool, len(current))
+	for _, a := range current {
+		exclude[a.Fingerprint] = true
+	}
+	var recent ExtendedAlerts
+	for _, e := range r.groups[groupKey] {
+		if !exclude[e.alert.Fingerprint] {
+			recent = append(recent, e.alert)
+		}
+	}
+	return recent
+}
+
+// prune drops the alerts that were resolved before the window, and the groups without any
+// alert left. It must be called with the lock held.
+func (r *recentlyResolved) prune(now time.Time) {
+	for key, entries := range r.groups {
+		// The alerts of a group are ordered by the time they were resolved.
+		i := 0
+		for i < len(entries) && now.Sub(entries[i].at) >= r.window {
+			i++
+		}
+		if i == len(entries) {
+			delete(r.groups, key)
+		} else if i > 0 {
+			r.groups[key] = entries[i:]
+		}
+	}
+}
+
+// evictOldestGroup drops the group whose last alert was resolved the longest ago. It must be
+// called with the lock held.
+func (r *recentlyResolved) evictOldestGroup() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entries := range r.groups {
+		last := entries[len(entries)-1].at
+		if oldestKey == "" || last.Before(oldest) {
+			oldestKey, oldest = key, last
+		}
+	}
+	delete(r.groups, oldestKey)
+}
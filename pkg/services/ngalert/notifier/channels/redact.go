@@ -0,0 +1,113 @@
+package channels
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+)
+
+// RedactedValue replaces the secrets found in label and annotation values.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactPatterns match common secrets, such as credentials set as key=value,
+// bearer tokens, JSON web tokens, and AWS, GitHub, Slack and Grafana tokens.
+var DefaultRedactPatterns = RedactPatterns{
+	regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key)["']?\s*[:=]\s*["']?([^\s"',;&]+)`),
+	regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]+=*)`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bglsa_[A-Za-z0-9]{32}_[0-9a-fA-F]{8}\b`),
+}
+
+// RedactPatterns are regular expressions matching secrets in label and annotation values.
+// If a pattern has a capturing group, only the first group is redacted, e.g. the value
+// of password=(\S+), otherwise the whole match is.
+type RedactPatterns []*regexp.Regexp
+
+// UnmarshalJSON accepts a list of regular expressions, or a string with one regular
+// expression per line when set from the UI.
+func (p *RedactPatterns) UnmarshalJSON(b []byte) error {
+	var exprs []string
+	if err := json.Unmarshal(b, &exprs); err != nil {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		exprs = strings.Split(s, "\n")
+	}
+	*p = nil
+	for _, expr := range exprs {
+		if expr = strings.TrimSpace(expr); expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", expr, err)
+		}
+		*p = append(*p, re)
+	}
+	return nil
+}
+
+// Redact replaces the substrings of s matching the patterns with RedactedValue.
+func (p RedactPatterns) Redact(s string) string {
+	for _, re := range p {
+		matches := re.FindAllStringSubmatchIndex(s, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if len(m) > 2 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(RedactedValue)
+			last = end
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// redactValues redacts the secrets in the label and annotation values of the data. The
+// label sets are replaced rather than modified, like in excludeLabels.
+func redactValues(data *ExtendedData, patterns RedactPatterns) {
+	if len(patterns) == 0 {
+		return
+	}
+	redact := func(kv template.KV) template.KV {
+		if kv == nil {
+			return nil
+		}
+		result := make(template.KV, len(kv))
+		for name, value := range kv {
+			result[name] = patterns.Redact(value)
+		}
+		return result
+	}
+	data.GroupLabels = redact(data.GroupLabels)
+	data.CommonLabels = redact(data.CommonLabels)
+	data.CommonAnnotations = redact(data.CommonAnnotations)
+	for i := range data.Alerts {
+		data.Alerts[i].Labels = redact(data.Alerts[i].Labels)
+		data.Alerts[i].Annotations = redact(data.Alerts[i].Annotations)
+	}
+}
+
+// redactPatternsWithDefaults returns the patterns of the notifier, with the default patterns if
+// redactSecrets is set.
+func redactPatternsWithDefaults(redactSecrets bool, patterns RedactPatterns) RedactPatterns {
+	if !redactSecrets {
+		return patterns
+	}
+	return append(append(RedactPatterns(nil), DefaultRedactPatterns...), patterns...)
+}
@@ -0,0 +1,181 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// ReminderTitlePrefix is prepended to the title of reminders.
+const ReminderTitlePrefix = "[REMINDER] "
+
+type reminderCtxKey struct{}
+
+// isReminder returns true if the notification is a reminder for a group that is still firing.
+func isReminder(ctx context.Context) bool {
+	v, _ := ctx.Value(reminderCtxKey{}).(bool)
+	return v
+}
+
+// reminderMessage is the compact message of a reminder.
+func reminderMessage(data *ExtendedData) string {
+	return fmt.Sprintf("%d alert(s) still firing.", len(data.Alerts.Firing()))
+}
+
+// parseReminderInterval parses the reminderInterval setting. An empty interval disables reminders.
+func parseReminderInterval(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid reminder interval: %w", err)
+	}
+	if interval < 0 {
+		return 0, errors.New("reminder interval should not be negative")
+	}
+	return interval, nil
+}
+
+// reminders re-sends a notification for the groups of a contact point that are still firing
+// once the interval has passed since their last notification. Each group has at most one
+// pending reminder, and a new notification for the group postpones it, so reminders never
+// stack up.
+//
+// A nil reminders never sends reminders.
+type reminders struct {
+	interval time.Duration
+	log      Logger
+	send     func(ctx context.Context, alerts ...*types.Alert) (bool, error)
+
+	mtx    sync.Mutex
+	groups map[string]*reminderGroup
+	// stopped is true once the notifier is replaced, no reminders are scheduled after.
+	stopped bool
+}
+
+// reminderGroup is the state of a firing group.
+type reminderGroup struct {
+	key         notify.Key
+	groupLabels model.LabelSet
+	receiver    string
+	alerts      []*types.Alert
+	due         time.Time
+	timer       *time.Timer
+}
+
+func newReminders(interval time.Duration, l Logger, send func(ctx context.Context, alerts ...*types.Alert) (bool, error)) *reminders {
+	return &reminders{
+		interval: interval,
+		log:      l,
+		send:     send,
+		groups:   map[string]*reminderGroup{},
+	}
+}
+
+// update records a notification that was sent for the group in ctx. If the group is firing,
+// a reminder is scheduled for it, otherwise its pending reminder is cancelled.
+func (r *reminders) update(ctx context.Context, alerts []*types.Alert) {
+	if r == nil || isReminder(ctx) {
+		return
+	}
+	key, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.stopped {
+		return
+	}
+	if g, ok := r.groups[key.String()]; ok {
+		g.timer.Stop()
+		delete(r.groups, key.String())
+	}
+
+	firing := firingAlerts(alerts, timeNow())
+	if len(firing) == 0 {
+		return
+	}
+	groupLabels, _ := notify.GroupLabels(ctx)
+	receiver, _ := notify.ReceiverName(ctx)
+	g := &reminderGroup{
+		key:         key,
+		groupLabels: groupLabels,
+		receiver:    receiver,
+		alerts:      firing,
+		due:         timeNow().Add(r.interval),
+	}
+	g.timer = time.AfterFunc(r.interval, func() { r.remind(g) })
+	r.groups[key.String()] = g
+}
+
+// stop cancels the pending reminders, and no reminders are scheduled after it.
+func (r *reminders) stop() {
+	if r == nil {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.stopped = true
+	for key, g := range r.groups {
+		g.timer.Stop()
+		delete(r.groups, key)
+	}
+}
+
+// remind sends the reminder of the group if it is due and the group is still firing. It is
+// rescheduled if it is called before the reminder is due.
+func (r *reminders) remind(g *reminderGroup) {
+	key := g.key.String()
+	r.mtx.Lock()
+	if r.stopped || r.groups[key] != g {
+		// The group was resolved or notified again, and has a new timer if still firing.
+		r.mtx.Unlock()
+		return
+	}
+	if now := timeNow(); now.Before(g.due) {
+		g.timer.Stop()
+		g.timer = time.AfterFunc(g.due.Sub(now), func() { r.remind(g) })
+		r.mtx.Unlock()
+		return
+	}
+	g.alerts = firingAlerts(g.alerts, timeNow())
+	if len(g.alerts) == 0 {
+		delete(r.groups, key)
+		r.mtx.Unlock()
+		return
+	}
+	g.due = timeNow().Add(r.interval)
+	g.timer.Stop()
+	g.timer = time.AfterFunc(r.interval, func() { r.remind(g) })
+	alerts := g.alerts
+
+	ctx := notify.WithGroupKey(context.Background(), g.key.String())
+	ctx = notify.WithGroupLabels(ctx, g.groupLabels)
+	ctx = notify.WithReceiverName(ctx, g.receiver)
+	ctx = context.WithValue(ctx, reminderCtxKey{}, true)
+	r.mtx.Unlock()
+
+	if _, err := r.send(ctx, alerts...); err != nil {
+		r.log.Error("failed to send reminder", "group", key, "error", err)
+	}
+}
+
+func firingAlerts(alerts []*types.Alert, now time.Time) []*types.Alert {
+	var firing []*types.Alert
+	for _, a := range alerts {
+		if !a.ResolvedAt(now) {
+			firing = append(firing, a)
+		}
+	}
+	return firing
+}
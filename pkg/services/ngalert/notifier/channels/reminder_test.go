@@ -0,0 +1,192 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// remindGroup calls the reminder of the group as its timer does.
+func remindGroup(r *reminders, key string) {
+	r.mtx.Lock()
+	g, ok := r.groups[key]
+	r.mtx.Unlock()
+	if ok {
+		r.remind(g)
+	}
+}
+
+func TestReminders(t *testing.T) {
+	now := time.Now()
+	defer mockTimeNow(now)()
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert1"},
+			EndsAt: now.Add(24 * time.Hour),
+		},
+	}
+
+	var sent []context.Context
+	r := newReminders(time.Hour, &FakeLogger{}, func(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+		sent = append(sent, ctx)
+		return true, nil
+	})
+
+	t.Run("reminder is sent after the interval but not before", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		r.update(ctx, []*types.Alert{firing})
+
+		mockTimeNow(now.Add(59 * time.Minute))
+		remindGroup(r, "alertname")
+		require.Empty(t, sent)
+
+		mockTimeNow(now.Add(time.Hour))
+		remindGroup(r, "alertname")
+		require.Len(t, sent, 1)
+		require.True(t, isReminder(sent[0]))
+		key, err := notify.ExtractGroupKey(sent[0])
+		require.NoError(t, err)
+		require.Equal(t, "alertname", key.String())
+
+		// Reminders don't stack, the next one is due an interval later.
+		remindGroup(r, "alertname")
+		require.Len(t, sent, 1)
+		mockTimeNow(now.Add(2 * time.Hour))
+		remindGroup(r, "alertname")
+		require.Len(t, sent, 2)
+	})
+
+	t.Run("a new notification postpones the reminder", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		r.update(ctx, []*types.Alert{firing})
+
+		mockTimeNow(now.Add(30 * time.Minute))
+		r.update(ctx, []*types.Alert{firing})
+
+		mockTimeNow(now.Add(time.Hour))
+		remindGroup(r, "alertname")
+		require.Empty(t, sent)
+
+		mockTimeNow(now.Add(90 * time.Minute))
+		remindGroup(r, "alertname")
+		require.Len(t, sent, 1)
+	})
+
+	t.Run("no reminder is sent for resolved groups", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		r.update(ctx, []*types.Alert{firing})
+		r.update(ctx, []*types.Alert{{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert1"},
+				EndsAt: now.Add(-time.Minute),
+			},
+		}})
+
+		mockTimeNow(now.Add(time.Hour))
+		remindGroup(r, "alertname")
+		require.Empty(t, sent)
+	})
+
+	t.Run("no reminder is sent once the alerts resolved", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		r.update(ctx, []*types.Alert{firing})
+
+		mockTimeNow(now.Add(48 * time.Hour))
+		remindGroup(r, "alertname")
+		require.Empty(t, sent)
+	})
+
+	t.Run("a reminder that is not due yet is rescheduled", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		r.update(ctx, []*types.Alert{firing})
+		timer := r.groups["alertname"].timer
+
+		mockTimeNow(now.Add(59 * time.Minute))
+		remindGroup(r, "alertname")
+		require.Empty(t, sent)
+		require.NotSame(t, timer, r.groups["alertname"].timer)
+		require.False(t, timer.Stop(), "the previous timer should be stopped")
+	})
+
+	t.Run("no reminders are sent once stopped", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		sent = nil
+		r := newReminders(time.Hour, &FakeLogger{}, func(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+			sent = append(sent, ctx)
+			return true, nil
+		})
+		r.update(ctx, []*types.Alert{firing})
+		g := r.groups["alertname"]
+		r.stop()
+		require.Empty(t, r.groups)
+
+		mockTimeNow(now.Add(time.Hour))
+		r.remind(g)
+		r.update(ctx, []*types.Alert{firing})
+		require.Empty(t, sent)
+		require.Empty(t, r.groups)
+	})
+}
+
+func TestWebhookNotifierReminder(t *testing.T) {
+	now := time.Now()
+	defer mockTimeNow(now)()
+
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := mockNotificationService()
+	pn, err := buildWebhookNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(`{"url": "http://localhost/test", "reminderInterval": "4h"}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	ok, err := pn.Notify(ctx, &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: now,
+			EndsAt:   now.Add(24 * time.Hour),
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	webhookSender.Webhook = SendWebhookSettings{}
+	mockTimeNow(now.Add(4 * time.Hour))
+	remindGroup(pn.reminders, "alertname")
+
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+	require.Equal(t, "[REMINDER] [FIRING:1] alert1 ", msg.Title)
+	require.Equal(t, "1 alert(s) still firing.", msg.Message)
+}
@@ -0,0 +1,54 @@
+package channels
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RetryBudget is a token bucket that caps how many failed notifications are retried per
+// interval. It is shared by all contact points so that retries of many contact points can't
+// overwhelm a shared downstream. It is safe to use concurrently.
+//
+// A nil RetryBudget never allows retries.
+type RetryBudget struct {
+	limit    float64
+	interval time.Duration
+
+	mtx    sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRetryBudget returns a budget that allows limit retries per interval. The budget is
+// replenished continuously, and starts full.
+func NewRetryBudget(limit int64, interval time.Duration) *RetryBudget {
+	return &RetryBudget{
+		limit:    float64(limit),
+		interval: interval,
+		tokens:   float64(limit),
+		last:     timeNow(),
+	}
+}
+
+// Allow consumes one retry from the budget. It returns false if the budget is exhausted,
+// in which case the notification should fail without being retried.
+func (b *RetryBudget) Allow() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := timeNow()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.limit, b.tokens+b.limit*float64(elapsed)/float64(b.interval))
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,103 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Now()
+	defer mockTimeNow(now)()
+
+	t.Run("nil budget never allows retries", func(t *testing.T) {
+		var b *RetryBudget
+		require.False(t, b.Allow())
+	})
+
+	t.Run("budget is replenished over the interval", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		b := NewRetryBudget(2, time.Minute)
+		require.True(t, b.Allow())
+		require.True(t, b.Allow())
+		require.False(t, b.Allow())
+
+		mockTimeNow(now.Add(30 * time.Second))
+		require.True(t, b.Allow())
+		require.False(t, b.Allow())
+
+		// The budget never exceeds its limit.
+		mockTimeNow(now.Add(time.Hour))
+		require.True(t, b.Allow())
+		require.True(t, b.Allow())
+		require.False(t, b.Allow())
+	})
+
+	t.Run("retries of all contact points are capped by the shared budget", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		tmpl := templateForTests(t)
+		externalURL, err := url.Parse("http://localhost")
+		require.NoError(t, err)
+		tmpl.ExternalURL = externalURL
+		budget := NewRetryBudget(5, time.Minute)
+
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert1"},
+			},
+		}
+
+		retries := 0
+		for i := 0; i < 10; i++ {
+			sender := mockNotificationService()
+			sender.ShouldError = errors.New("downstream unavailable")
+			fc := FactoryConfig{
+				Config: &NotificationChannelConfig{
+					Name:     fmt.Sprintf("webhook_%d", i),
+					Type:     "webhook",
+					Settings: json.RawMessage(`{"url": "http://localhost/test"}`),
+				},
+				NotificationService: sender,
+				DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+					return fallback
+				},
+				ImageStore:  &UnavailableImageStore{},
+				Template:    tmpl,
+				Logger:      &FakeLogger{},
+				RetryBudget: budget,
+			}
+			webhook, err := buildWebhookNotifier(fc)
+			require.NoError(t, err)
+			// Only the retries of the notification use the budget.
+			webhook.httpRetry = httpRetry{Attempts: 1}
+
+			fc.Config = &NotificationChannelConfig{
+				Name:     fmt.Sprintf("email_%d", i),
+				Type:     "email",
+				Settings: json.RawMessage(`{"addresses": "someops@example.com"}`),
+			}
+			email, err := EmailFactory(fc)
+			require.NoError(t, err)
+
+			for _, n := range []NotificationChannel{webhook, email} {
+				retry, err := n.Notify(ctx, alert)
+				require.Error(t, err)
+				if retry {
+					retries++
+				}
+			}
+		}
+		require.Equal(t, 5, retries)
+	})
+}
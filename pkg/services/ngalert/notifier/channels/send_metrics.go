@@ -0,0 +1,57 @@
+package channels
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of the notifications counted by SendMetrics.
+const (
+	SendOutcomeSuccess = "success"
+	SendOutcomeFailure = "failure"
+)
+
+// SendMetrics count the attempts to send notifications by the type of their notifier. The
+// counters are labeled by integration, and Outcomes by outcome too.
+type SendMetrics struct {
+	// Attempts counts every attempt to send a notification, including retries. Each request
+	// to the URLs of a webhook is an attempt.
+	Attempts *prometheus.CounterVec
+	// Retries counts the failed attempts that are retried, either by the notifier or by the
+	// Alertmanager.
+	Retries *prometheus.CounterVec
+	// Outcomes counts the notifications that were sent, and the ones that failed without
+	// being retried.
+	Outcomes *prometheus.CounterVec
+}
+
+// attempt counts an attempt to send a notification. It does nothing if m is nil.
+func (m *SendMetrics) attempt(integration string) {
+	if m == nil {
+		return
+	}
+	m.Attempts.WithLabelValues(integration).Inc()
+}
+
+// retry counts a failed attempt that is retried. It does nothing if m is nil.
+func (m *SendMetrics) retry(integration string) {
+	if m == nil {
+		return
+	}
+	m.Retries.WithLabelValues(integration).Inc()
+}
+
+// result counts the result of a notification: a success if err is nil, a retry if it failed
+// and is retried, or a failure otherwise. It does nothing if m is nil.
+func (m *SendMetrics) result(integration string, err error, retried bool) {
+	if m == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		m.Outcomes.WithLabelValues(integration, SendOutcomeSuccess).Inc()
+	case retried:
+		m.retry(integration)
+	default:
+		m.Outcomes.WithLabelValues(integration, SendOutcomeFailure).Inc()
+	}
+}
@@ -0,0 +1,96 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSendMetrics() *SendMetrics {
+	return &SendMetrics{
+		Attempts: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_attempts_total"}, []string{"integration"}),
+		Retries:  prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_retries_total"}, []string{"integration"}),
+		Outcomes: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_outcomes_total"}, []string{"integration", "outcome"}),
+	}
+}
+
+func TestSendMetrics(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighCPU"}}}
+
+	t.Run("webhook retries are counted until the flaky endpoint succeeds", func(t *testing.T) {
+		m := newTestSendMetrics()
+		wn, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "webhook",
+				Settings: json.RawMessage(`{"url": "http://localhost/test"}`),
+			},
+			NotificationService: mockNotificationService(),
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore:  &UnavailableImageStore{},
+			Template:    tmpl,
+			Logger:      &FakeLogger{},
+			SendMetrics: m,
+			RetryBudget: NewRetryBudget(10, time.Minute),
+		})
+		require.NoError(t, err)
+		unavailable := errors.New("webhook response status 503 Service Unavailable")
+		sender := &flakyWebhookSender{errs: []error{unavailable, unavailable}}
+		wn.ns = sender
+		wn.httpRetry.InitialBackoff, wn.httpRetry.MaxBackoff = time.Millisecond, time.Millisecond
+
+		ok, err := wn.Notify(notify.WithGroupKey(context.Background(), "alertname"), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Equal(t, 3, sender.calls)
+		require.Equal(t, 3.0, testutil.ToFloat64(m.Attempts.WithLabelValues("webhook")))
+		require.Equal(t, 2.0, testutil.ToFloat64(m.Retries.WithLabelValues("webhook")))
+		require.Equal(t, 1.0, testutil.ToFloat64(m.Outcomes.WithLabelValues("webhook", SendOutcomeSuccess)))
+		require.Equal(t, 0.0, testutil.ToFloat64(m.Outcomes.WithLabelValues("webhook", SendOutcomeFailure)))
+	})
+
+	t.Run("failed emails are counted as retries while the retry budget allows them", func(t *testing.T) {
+		m := newTestSendMetrics()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "ops@example.com"}`),
+		})
+		require.NoError(t, err)
+		ns := mockNotificationService()
+		ns.ShouldError = errors.New("connection refused")
+		en := NewEmailNotifier(cfg, &FakeLogger{}, ns, &UnavailableImageStore{}, tmpl, nil)
+		en.metrics = m
+		en.retries = NewRetryBudget(1, time.Hour)
+
+		retry, err := en.Notify(context.Background(), alert)
+		require.Error(t, err)
+		require.True(t, retry)
+		retry, err = en.Notify(context.Background(), alert)
+		require.Error(t, err)
+		require.False(t, retry)
+
+		require.Equal(t, 2.0, testutil.ToFloat64(m.Attempts.WithLabelValues("email")))
+		require.Equal(t, 1.0, testutil.ToFloat64(m.Retries.WithLabelValues("email")))
+		require.Equal(t, 1.0, testutil.ToFloat64(m.Outcomes.WithLabelValues("email", SendOutcomeFailure)))
+		require.Equal(t, 0.0, testutil.ToFloat64(m.Outcomes.WithLabelValues("email", SendOutcomeSuccess)))
+	})
+}
@@ -1,6 +1,11 @@
 package channels
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type SendWebhookSettings struct {
 	Url         string
@@ -10,7 +15,17 @@ type SendWebhookSettings struct {
 	HttpMethod  string
 	HttpHeader  map[string]string
 	ContentType string
-	Validation  func(body []byte, statusCode int) error
+	// Timeout bounds the whole request, ConnectTimeout resolving the host and connecting
+	// to it. Defaults are used if they are not set.
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+	// ConnectionPool is the key of the connections reused by the webhooks with the same pool,
+	// kept alive with MaxIdleConns and IdleConnTimeout. The connections shared by all
+	// webhooks are used if they are not set.
+	ConnectionPool  string
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+	Validation      func(body []byte, statusCode int) error
 }
 
 // SendEmailSettings is the command for sending emails
@@ -24,6 +39,24 @@ type SendEmailSettings struct {
 	ReplyTo       []string
 	EmbeddedFiles []string
 	AttachedFiles []*SendEmailAttachFile
+	// Cc are the carbon copy recipients of the email.
+	Cc []string
+	// CopyToSender sends a blind carbon copy of the email to its From address.
+	CopyToSender bool
+	// OptOut are the addresses removed from the blind carbon copies of the email, e.g. the
+	// copy to the sender or to the archive address.
+	OptOut []string
+	// Headers are additional headers of the email.
+	Headers map[string]string
+	// TransferEncoding is the Content-Transfer-Encoding of the body, if set.
+	TransferEncoding string
+	// Charset is the charset of the body, if set.
+	Charset string
+	// SMIMECertFile and SMIMEKeyFile sign the email with S/MIME, if set.
+	SMIMECertFile string
+	SMIMEKeyFile  string
+	// OrgID is the org the email is sent for.
+	OrgID int64
 }
 
 // SendEmailAttachFile is a definition of the attached files without path
@@ -36,8 +69,75 @@ type WebhookSender interface {
 	SendWebhook(ctx context.Context, cmd *SendWebhookSettings) error
 }
 
+// EmailRecipientResult is the outcome of delivering an email to a single recipient.
+type EmailRecipientResult struct {
+	Address string
+	Error   error
+}
+
+// EmailSendResult contains the delivery outcome for every recipient of an email.
+type EmailSendResult struct {
+	Recipients []EmailRecipientResult
+}
+
+// NewEmailSendResult returns a result with the same outcome for all recipients,
+// e.g. when a single email was sent to all of them.
+func NewEmailSendResult(recipients []string, err error) EmailSendResult {
+	res := EmailSendResult{Recipients: make([]EmailRecipientResult, 0, len(recipients))}
+	for _, r := range recipients {
+		res.Recipients = append(res.Recipients, EmailRecipientResult{Address: r, Error: err})
+	}
+	return res
+}
+
+// Failed returns the results of the recipients the email could not be delivered to.
+func (r EmailSendResult) Failed() []EmailRecipientResult {
+	var failed []EmailRecipientResult
+	for _, rec := range r.Recipients {
+		if rec.Error != nil {
+			failed = append(failed, rec)
+		}
+	}
+	return failed
+}
+
+// Delivered returns the results of the recipients the email was delivered to.
+func (r EmailSendResult) Delivered() []EmailRecipientResult {
+	var delivered []EmailRecipientResult
+	for _, rec := range r.Recipients {
+		if rec.Error == nil {
+			delivered = append(delivered, rec)
+		}
+	}
+	return delivered
+}
+
+// Err returns an *EmailDeliveryError if the email could not be delivered to at least one recipient.
+func (r EmailSendResult) Err() error {
+	if len(r.Failed()) == 0 {
+		return nil
+	}
+	return &EmailDeliveryError{Result: r}
+}
+
+// EmailDeliveryError is returned when an email could not be delivered to some or all of its recipients.
+type EmailDeliveryError struct {
+	Result EmailSendResult
+}
+
+func (e *EmailDeliveryError) Error() string {
+	failed := e.Result.Failed()
+	msgs := make([]string, 0, len(failed))
+	for _, f := range failed {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", f.Address, f.Error))
+	}
+	return fmt.Sprintf("failed to send email to %d of %d recipients: %s", len(failed), len(e.Result.Recipients), strings.Join(msgs, "; "))
+}
+
 type EmailSender interface {
-	SendEmail(ctx context.Context, cmd *SendEmailSettings) error
+	// SendEmail sends the email and returns the outcome for each recipient. The error is
+	// an *EmailDeliveryError if the email could not be delivered to at least one recipient.
+	SendEmail(ctx context.Context, cmd *SendEmailSettings) (EmailSendResult, error)
 }
 
 type NotificationSender interface {
@@ -0,0 +1,97 @@
+package channels
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// SentResult describes a notification that was sent successfully.
+type SentResult struct {
+	// Channel is the name of the contact point and ChannelType its type, e.g. email.
+	Channel     string
+	ChannelType string
+	// Recipients are the addresses, or the URL, the notification was sent to. It is empty
+	// for contact points that do not report their recipients.
+	Recipients []string
+	GroupKey   string
+}
+
+// OnSentFunc is called after a notification was sent successfully, e.g. to trigger
+// follow-up automation.
+type OnSentFunc func(ctx context.Context, result SentResult)
+
+type sendReportKey struct{}
+
+// sendReport is filled in by notifiers during a notification, for the OnSentFunc.
+type sendReport struct {
+	recipients []string
+	// skipped is true if the notifier did not send anything, e.g. because there were
+	// too few alerts.
+	skipped bool
+}
+
+// reportRecipients reports the recipients of the notification to the OnSentFunc, if any.
+// Notifiers that send several messages per notification report the recipients of each.
+func reportRecipients(ctx context.Context, recipients []string) {
+	r, ok := ctx.Value(sendReportKey{}).(*sendReport)
+	if !ok {
+		return
+	}
+next:
+	for _, recipient := range recipients {
+		for _, reported := range r.recipients {
+			if recipient == reported {
+				continue next
+			}
+		}
+		r.recipients = append(r.recipients, recipient)
+	}
+}
+
+// reportSkipped reports that the notification was not sent, so that the OnSentFunc is not
+// called even though the notification succeeded.
+func reportSkipped(ctx context.Context) {
+	if r, ok := ctx.Value(sendReportKey{}).(*sendReport); ok {
+		r.skipped = true
+	}
+}
+
+// onSentNotifier calls onSent after each notification of the notifier that succeeded.
+type onSentNotifier struct {
+	NotificationChannel
+	name        string
+	channelType string
+	onSent      OnSentFunc
+	log         Logger
+}
+
+func (n *onSentNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	report := &sendReport{}
+	retry, err := n.NotificationChannel.Notify(context.WithValue(ctx, sendReportKey{}, report), alerts...)
+	if err != nil || report.skipped {
+		return retry, err
+	}
+
+	result := SentResult{
+		Channel:     n.name,
+		ChannelType: n.channelType,
+		Recipients:  report.recipients,
+	}
+	if groupKey, err := notify.ExtractGroupKey(ctx); err == nil {
+		result.GroupKey = groupKey.String()
+	}
+	// The hook must not delay or fail the notification. It gets a background context as
+	// the context of the notification can be done before the hook returns.
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				n.log.Error("notification sent hook panic", "error", r, "stack", string(debug.Stack()))
+			}
+		}()
+		n.onSent(context.Background(), result)
+	}()
+	return retry, err
+}
@@ -0,0 +1,153 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactoryOnSent(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(t *testing.T, receiverType, settings string, ns *notificationServiceMock, onSent OnSentFunc) NotificationChannel {
+		t.Helper()
+		factory, ok := Factory(receiverType)
+		require.True(t, ok)
+		n, err := factory(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     receiverType + "_testing",
+				Type:     receiverType,
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: ns,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+			OnSent:     onSent,
+		})
+		require.NoError(t, err)
+		return n
+	}
+
+	// results waits for the expected number of results of the hook, and fails if there are more.
+	results := func(t *testing.T, sent <-chan SentResult, expected int) []SentResult {
+		t.Helper()
+		var received []SentResult
+		for len(received) < expected {
+			select {
+			case r := <-sent:
+				received = append(received, r)
+			case <-time.After(time.Second):
+				t.Fatalf("expected %d results of the hook, got %d", expected, len(received))
+			}
+		}
+		select {
+		case r := <-sent:
+			t.Fatalf("unexpected result of the hook: %v", r)
+		case <-time.After(50 * time.Millisecond):
+		}
+		return received
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "{}:{alertname=\"alert1\"}")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	t.Run("the hook is called once per successful notification", func(t *testing.T) {
+		sent := make(chan SentResult, 10)
+		ns := mockNotificationService()
+		n := newNotifier(t, "webhook", `{"url": "http://localhost/test"}`, ns, func(_ context.Context, r SentResult) {
+			sent <- r
+		})
+
+		_, err := n.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.Equal(t, []SentResult{{
+			Channel:     "webhook_testing",
+			ChannelType: "webhook",
+			Recipients:  []string{"http://localhost/test"},
+			GroupKey:    "{}:{alertname=\"alert1\"}",
+		}}, results(t, sent, 1))
+
+		ns.ShouldError = errors.New("connection refused")
+		_, err = n.Notify(ctx, alert)
+		require.Error(t, err)
+		require.Empty(t, results(t, sent, 0))
+	})
+
+	t.Run("the hook gets the recipients of emails", func(t *testing.T) {
+		sent := make(chan SentResult, 10)
+		ns := mockNotificationService()
+		n := newNotifier(t, "email", `{"addresses": "ops@example.com;dev@example.com", "singleEmail": true}`, ns, func(_ context.Context, r SentResult) {
+			sent <- r
+		})
+
+		_, err := n.Notify(ctx, alert)
+		require.NoError(t, err)
+		received := results(t, sent, 1)
+		require.Len(t, received, 1)
+		require.Equal(t, "email", received[0].ChannelType)
+		require.Equal(t, []string{"ops@example.com", "dev@example.com"}, received[0].Recipients)
+	})
+
+	t.Run("the hook gets the rendered URLs of webhooks", func(t *testing.T) {
+		sent := make(chan SentResult, 10)
+		ns := mockNotificationService()
+		n := newNotifier(t, "webhook", `{"url": "http://localhost/{{ .CommonLabels.alertname }}", "urls": ["http://localhost/backup"]}`, ns, func(_ context.Context, r SentResult) {
+			sent <- r
+		})
+
+		_, err := n.Notify(ctx, alert)
+		require.NoError(t, err)
+		received := results(t, sent, 1)
+		require.Len(t, received, 1)
+		require.Equal(t, []string{"http://localhost/alert1", "http://localhost/backup"}, received[0].Recipients)
+	})
+
+	t.Run("the hook is not called when the notification is skipped", func(t *testing.T) {
+		sent := make(chan SentResult, 10)
+		n := newNotifier(t, "webhook", `{"url": "http://localhost/test", "minAlerts": 2}`, mockNotificationService(), func(_ context.Context, r SentResult) {
+			sent <- r
+		})
+
+		_, err := n.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.Empty(t, results(t, sent, 0))
+	})
+
+	t.Run("the hook does not block or fail the notification", func(t *testing.T) {
+		release := make(chan struct{})
+		panicked := make(chan struct{})
+		n := newNotifier(t, "webhook", `{"url": "http://localhost/test"}`, mockNotificationService(), func(_ context.Context, r SentResult) {
+			defer close(panicked)
+			<-release
+			panic("hook failed")
+		})
+
+		ok, err := n.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// The notification returned while the hook is blocked, and its panic is recovered.
+		close(release)
+		select {
+		case <-panicked:
+		case <-time.After(time.Second):
+			t.Fatal("the hook was not called")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package channels
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// SeverityLabel is the label whose value selects the color of chat messages about alerts.
+const SeverityLabel = "severity"
+
+const (
+	ColorSeverityWarning = "#FF9830"
+	ColorSeverityInfo    = "#5794F2"
+)
+
+// DefaultSeverityColors are the colors of chat messages about firing alerts, by the value
+// of their severity label.
+var DefaultSeverityColors = SeverityColors{
+	"critical": ColorAlertFiring,
+	"high":     ColorAlertFiring,
+	"error":    ColorAlertFiring,
+	"warning":  ColorSeverityWarning,
+	"medium":   ColorSeverityWarning,
+	"low":      ColorSeverityInfo,
+	"info":     ColorSeverityInfo,
+}
+
+// chatSeverityRank orders the known severities from the most severe. Other severities are less
+// severe than all of them.
+var chatSeverityRank = []string{"critical", "high", "error", "warning", "medium", "low", "info"}
+
+// SeverityColors maps the values of the severity label, in lower case, to hex colors. It is
+// shared by the chat notifiers so that a severity has the same color in all of them. A nil
+// SeverityColors uses DefaultSeverityColors.
+type SeverityColors map[string]string
+
+// NewSeverityColors returns the default colors, overridden by the configured ones.
+// Severities are not case-sensitive.
+func NewSeverityColors(configured map[string]string) SeverityColors {
+	colors := make(SeverityColors, len(DefaultSeverityColors)+len(configured))
+	for severity, color := range DefaultSeverityColors {
+		colors[severity] = color
+	}
+	for severity, color := range configured {
+		colors[strings.ToLower(severity)] = color
+	}
+	return colors
+}
+
+// Color returns the color of a message about the alerts. It is ColorAlertResolved if all
+// alerts are resolved, otherwise the color of the most severe firing alert, or
+// ColorAlertFiring if no firing alert has a severity with a color.
+func (c SeverityColors) Color(alerts ...*types.Alert) string {
+	if types.Alerts(alerts...).Status() == model.AlertResolved {
+		return ColorAlertResolved
+	}
+	if c == nil {
+		c = DefaultSeverityColors
+	}
+	color, rank := ColorAlertFiring, -1
+	for _, a := range alerts {
+		if a.Resolved() {
+			continue
+		}
+		severity := strings.ToLower(string(a.Labels[SeverityLabel]))
+		v, ok := c[severity]
+		if !ok {
+			continue
+		}
+		r := severityRankOf(severity)
+		if rank == -1 || r < rank {
+			color, rank = v, r
+		}
+	}
+	return color
+}
+
+func severityRankOf(severity string) int {
+	for i, s := range chatSeverityRank {
+		if s == severity {
+			return i
+		}
+	}
+	return len(chatSeverityRank)
+}
+
+// hexColorValue returns the hex color as a number, or 0 if it is not a valid hex color.
+func hexColorValue(hex string) int64 {
+	v, _ := strconv.ParseInt(strings.TrimPrefix(hex, "#"), 16, 0)
+	return v
+}
+
+// adaptiveCardColor returns the text color of adaptive cards closest to the hex color, as
+// adaptive cards only support a few named colors.
+func adaptiveCardColor(hex string) string {
+	v := hexColorValue(hex)
+	r, g, b := v>>16&0xff, v>>8&0xff, v&0xff
+	high, low := r, r
+	for _, c := range []int64{g, b} {
+		if c > high {
+			high = c
+		}
+		if c < low {
+			low = c
+		}
+	}
+	switch {
+	case high-low < 32:
+		return TextColorDefault
+	case b > r && b > g:
+		return TextColorAccent
+	case g > r:
+		return TextColorGood
+	case g > r/2:
+		return TextColorWarning
+	default:
+		return TextColorAttention
+	}
+}
@@ -0,0 +1,162 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverityColors(t *testing.T) {
+	firing := func(severity string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", SeverityLabel: model.LabelValue(severity)}}}
+	}
+	resolved := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert1", SeverityLabel: "critical"},
+		StartsAt: time.Now().Add(-time.Hour),
+		EndsAt:   time.Now().Add(-time.Minute),
+	}}
+	colors := NewSeverityColors(map[string]string{"Critical": "#8F3BB8", "p3": "#FADE2A"})
+
+	tests := []struct {
+		name     string
+		colors   SeverityColors
+		alerts   []*types.Alert
+		expected string
+	}{{
+		name:     "the default colors are used without configured colors",
+		alerts:   []*types.Alert{firing("warning")},
+		expected: ColorSeverityWarning,
+	}, {
+		name:     "configured colors override the defaults",
+		colors:   colors,
+		alerts:   []*types.Alert{firing("CRITICAL")},
+		expected: "#8F3BB8",
+	}, {
+		name:     "the color of the most severe alert is used",
+		colors:   colors,
+		alerts:   []*types.Alert{firing("info"), firing("p3"), firing("warning")},
+		expected: ColorSeverityWarning,
+	}, {
+		name:     "alerts without a severity with a color are firing",
+		colors:   colors,
+		alerts:   []*types.Alert{firing(""), firing("unknown")},
+		expected: ColorAlertFiring,
+	}, {
+		name:     "resolved alerts are ignored while others are firing",
+		colors:   colors,
+		alerts:   []*types.Alert{resolved, firing("info")},
+		expected: ColorSeverityInfo,
+	}, {
+		name:     "resolved alerts have the resolved color",
+		colors:   colors,
+		alerts:   []*types.Alert{resolved},
+		expected: ColorAlertResolved,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, test.colors.Color(test.alerts...))
+		})
+	}
+}
+
+func TestAdaptiveCardColor(t *testing.T) {
+	for color, expected := range map[string]string{
+		ColorAlertFiring:     TextColorAttention,
+		ColorAlertResolved:   TextColorGood,
+		ColorSeverityWarning: TextColorWarning,
+		ColorSeverityInfo:    TextColorAccent,
+		"#FADE2A":            TextColorWarning,
+		"#808080":            TextColorDefault,
+		"invalid":            TextColorDefault,
+	} {
+		require.Equal(t, expected, adaptiveCardColor(color), color)
+	}
+}
+
+func TestSeverityColorsChatNotifiers(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	colors := NewSeverityColors(map[string]string{"warning": "#FADE2A"})
+	newFactoryConfig := func(notifierType, settings string, ns *notificationServiceMock) FactoryConfig {
+		return FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:           notifierType + "_testing",
+				Type:           notifierType,
+				Settings:       json.RawMessage(settings),
+				SecureSettings: map[string][]byte{},
+			},
+			NotificationService: ns,
+			ImageStore:          &UnavailableImageStore{},
+			DecryptFunc: func(_ context.Context, _ map[string][]byte, _ string, fallback string) string {
+				return fallback
+			},
+			Template:       tmpl,
+			Logger:         &FakeLogger{},
+			SeverityColors: colors,
+		}
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+	for _, severity := range []string{"critical", "warning", "info"} {
+		t.Run(severity, func(t *testing.T) {
+			alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", SeverityLabel: model.LabelValue(severity)}}}
+			expected := colors.Color(alert)
+
+			slack, err := buildSlackNotifier(newFactoryConfig("slack", `{"url": "https://hooks.slack.com/services/1"}`, mockNotificationService()))
+			require.NoError(t, err)
+			recorder := &slackRequestRecorder{}
+			slack.sendFn = recorder.fn
+			_, err = slack.Notify(ctx, alert)
+			require.NoError(t, err)
+			require.Len(t, recorder.requests, 1)
+			b, err := io.ReadAll(recorder.requests[0].Body)
+			require.NoError(t, err)
+			var slackMsg slackMessage
+			require.NoError(t, json.Unmarshal(b, &slackMsg))
+			require.Equal(t, expected, slackMsg.Attachments[0].Color)
+
+			discordSender := mockNotificationService()
+			discord, err := newDiscordNotifier(newFactoryConfig("discord", `{"url": "http://localhost"}`, discordSender))
+			require.NoError(t, err)
+			_, err = discord.Notify(ctx, alert)
+			require.NoError(t, err)
+			var discordMsg struct {
+				Embeds []struct {
+					Color int64 `json:"color"`
+				} `json:"embeds"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(discordSender.Webhook.Body), &discordMsg))
+			require.Equal(t, hexColorValue(expected), discordMsg.Embeds[0].Color)
+
+			teamsSender := mockNotificationService()
+			teams, err := NewTeamsNotifier(newFactoryConfig("teams", `{"url": "http://localhost"}`, teamsSender))
+			require.NoError(t, err)
+			_, err = teams.Notify(ctx, alert)
+			require.NoError(t, err)
+			var teamsMsg struct {
+				Attachments []struct {
+					Content struct {
+						Body []struct {
+							Color string `json:"color"`
+						} `json:"body"`
+					} `json:"content"`
+				} `json:"attachments"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(teamsSender.Webhook.Body), &teamsMsg))
+			require.Equal(t, adaptiveCardColor(expected), teamsMsg.Attachments[0].Content.Body[0].Color)
+		})
+	}
+}
@@ -56,6 +56,14 @@ type sendFunc func(ctx context.Context, req *http.Request, logger Logger) (strin
 // https://api.slack.com/reference/messaging/attachments#legacy_fields - 1024, no units given, assuming runes or characters.
 const slackMaxTitleLenRunes = 1024
 
+// Limits of Block Kit, see https://api.slack.com/reference/block-kit/blocks.
+const (
+	slackMaxHeaderLenRunes  = 150
+	slackMaxSectionLenRunes = 3000
+	slackMaxFieldLenRunes   = 2000
+	slackMaxFields          = 10
+)
+
 // SlackNotifier is responsible for sending
 // alert notification to Slack.
 type SlackNotifier struct {
@@ -66,6 +74,7 @@ type SlackNotifier struct {
 	webhookSender WebhookSender
 	sendFn        sendFunc
 	settings      slackSettings
+	colors        SeverityColors
 }
 
 type slackSettings struct {
@@ -81,6 +90,7 @@ type slackSettings struct {
 	MentionChannel string                `json:"mentionChannel,omitempty" yaml:"mentionChannel,omitempty"`
 	MentionUsers   CommaSeparatedStrings `json:"mentionUsers,omitempty" yaml:"mentionUsers,omitempty"`
 	MentionGroups  CommaSeparatedStrings `json:"mentionGroups,omitempty" yaml:"mentionGroups,omitempty"`
+	UseBlocks      bool                  `json:"useBlocks,omitempty" yaml:"useBlocks,omitempty"`
 }
 
 // isIncomingWebhook returns true if the settings are for an incoming webhook.
@@ -162,6 +172,7 @@ func buildSlackNotifier(factoryConfig FactoryConfig) (*SlackNotifier, error) {
 		sendFn:        sendSlackRequest,
 		log:           factoryConfig.Logger,
 		tmpl:          factoryConfig.Template,
+		colors:        factoryConfig.SeverityColors,
 	}, nil
 }
 
@@ -172,7 +183,7 @@ type slackMessage struct {
 	Username    string                   `json:"username,omitempty"`
 	IconEmoji   string                   `json:"icon_emoji,omitempty"`
 	IconURL     string                   `json:"icon_url,omitempty"`
-	Attachments []attachment             `json:"attachments"`
+	Attachments []attachment             `json:"attachments,omitempty"`
 	Blocks      []map[string]interface{} `json:"blocks,omitempty"`
 	ThreadTs    string                   `json:"thread_ts,omitempty"`
 }
@@ -347,7 +358,7 @@ func handleSlackJSONResponse(resp *http.Response, logger Logger) (string, error)
 
 func (sn *SlackNotifier) createSlackMessage(ctx context.Context, alerts []*types.Alert) (*slackMessage, error) {
 	var tmplErr error
-	tmpl, _ := TmplText(ctx, sn.tmpl, alerts, sn.log, &tmplErr)
+	tmpl, data := TmplText(ctx, sn.tmpl, alerts, sn.log, &tmplErr)
 
 	ruleURL := joinUrlPath(sn.tmpl.ExternalURL.String(), "/alerting/list", sn.log)
 
@@ -365,11 +376,11 @@ func (sn *SlackNotifier) createSlackMessage(ctx context.Context, alerts []*types
 		Username:  tmpl(sn.settings.Username),
 		IconEmoji: tmpl(sn.settings.IconEmoji),
 		IconURL:   tmpl(sn.settings.IconURL),
-		// TODO: We should use the Block Kit API instead:
+		// Attachments are replaced with Block Kit blocks if useBlocks is enabled:
 		// https://api.slack.com/messaging/composing/layouts#when-to-use-attachments
 		Attachments: []attachment{
 			{
-				Color:      getAlertStatusColor(types.Alerts(alerts...).Status()),
+				Color:      sn.colors.Color(alerts...),
 				Title:      title,
 				Fallback:   title,
 				Footer:     "Grafana v" + setting.BuildVersion,
@@ -429,9 +440,96 @@ func (sn *SlackNotifier) createSlackMessage(ctx context.Context, alerts []*types
 		req.Attachments[0].Pretext = mentionsBuilder.String()
 	}
 
+	if sn.settings.UseBlocks {
+		req.Blocks = slackBlocks(req.Attachments[0], data, sn.tmpl.ExternalURL.String(), sn.log)
+		// The text is shown in notifications of messages with blocks.
+		req.Text = title
+		req.Attachments = nil
+	}
+
 	return req, nil
 }
 
+// slackBlocks renders the attachment of a notification as Block Kit blocks: a header with
+// the title, a section with the mentions and text, a section with the status and common
+// labels as fields, the image, and buttons to the alert rules, dashboard and silence.
+func slackBlocks(a attachment, data *ExtendedData, externalURL string, l Logger) []map[string]interface{} {
+	mrkdwn := func(text string, maxRunes int) map[string]interface{} {
+		text, _ = TruncateInRunes(text, maxRunes)
+		return map[string]interface{}{"type": "mrkdwn", "text": text}
+	}
+	plainText := func(text string, maxRunes int) map[string]interface{} {
+		text, _ = TruncateInRunes(text, maxRunes)
+		return map[string]interface{}{"type": "plain_text", "text": text}
+	}
+	button := func(text, url string) map[string]interface{} {
+		return map[string]interface{}{"type": "button", "text": plainText(text, 75), "url": url}
+	}
+
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": plainText(a.Title, slackMaxHeaderLenRunes)},
+	}
+
+	text := a.Text
+	if a.Pretext != "" {
+		text = a.Pretext + "\n" + text
+	}
+	if text != "" {
+		blocks = append(blocks, map[string]interface{}{"type": "section", "text": mrkdwn(text, slackMaxSectionLenRunes)})
+	}
+
+	fields := []map[string]interface{}{
+		mrkdwn(fmt.Sprintf("*Status*\n%s", strings.Title(data.Status)), slackMaxFieldLenRunes), //nolint:staticcheck
+	}
+	for _, pair := range data.CommonLabels.SortedPairs() {
+		if len(fields) == slackMaxFields {
+			break
+		}
+		fields = append(fields, mrkdwn(fmt.Sprintf("*%s*\n%s", pair.Name, pair.Value), slackMaxFieldLenRunes))
+	}
+	blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+
+	if a.ImageURL != "" {
+		blocks = append(blocks, map[string]interface{}{"type": "image", "image_url": a.ImageURL, "alt_text": a.Fallback})
+	}
+
+	buttons := []map[string]interface{}{button("View alert rules", a.TitleLink)}
+	for _, alert := range data.Alerts {
+		if alert.DashboardURL != "" {
+			buttons = append(buttons, button("View dashboard", alert.DashboardURL))
+			break
+		}
+	}
+	if silenceURL := groupSilenceURL(externalURL, data.GroupLabels, l); silenceURL != "" {
+		buttons = append(buttons, button("Silence", silenceURL))
+	}
+	blocks = append(blocks, map[string]interface{}{"type": "actions", "elements": buttons})
+
+	return append(blocks, map[string]interface{}{
+		"type":     "context",
+		"elements": []map[string]interface{}{mrkdwn(a.Footer, slackMaxFieldLenRunes)},
+	})
+}
+
+// groupSilenceURL returns the URL to silence the group with the labels. It returns an empty
+// string if the group has no labels.
+func groupSilenceURL(externalURL string, groupLabels template.KV, l Logger) string {
+	if len(groupLabels) == 0 {
+		return ""
+	}
+	u, err := url.Parse(joinUrlPath(externalURL, "/alerting/silence/new", l))
+	if err != nil {
+		return ""
+	}
+	query := make(url.Values)
+	query.Add("alertmanager", "grafana")
+	for _, pair := range groupLabels.SortedPairs() {
+		query.Add("matcher", pair.Name+"="+pair.Value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
 func (sn *SlackNotifier) sendSlackMessage(ctx context.Context, m *slackMessage) (string, error) {
 	b, err := json.Marshal(m)
 	if err != nil {
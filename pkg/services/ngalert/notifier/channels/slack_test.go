@@ -164,6 +164,57 @@ func TestSlackIncomingWebhook(t *testing.T) {
 	}
 }
 
+func TestSlackIncomingWebhookBlocks(t *testing.T) {
+	notifier, recorder, err := setupSlackForTests(t, `{
+		"recipient": "#test",
+		"url": "https://example.com/hooks/xxxx",
+		"mentionChannel": "here",
+		"useBlocks": true
+	}`)
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+
+	ok, err := notifier.Notify(ctx, &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"ann1": "annv1", "__dashboardUid__": "abcd", "__panelId__": "efgh", "__alertImageToken__": "image-with-url"},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, recorder.requests, 1)
+
+	b, err := io.ReadAll(recorder.requests[0].Body)
+	require.NoError(t, err)
+	message := slackMessage{}
+	require.NoError(t, json.Unmarshal(b, &message))
+
+	// The title is the text of the notification, there are no attachments.
+	assert.Equal(t, "[FIRING:1] alert1 (val1)", message.Text)
+	assert.Empty(t, message.Attachments)
+
+	blocks, err := json.Marshal(message.Blocks)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"type": "header", "text": {"type": "plain_text", "text": "[FIRING:1] alert1 (val1)"}},
+		{"type": "section", "text": {"type": "mrkdwn", "text": "<!here|here>\n**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\nDashboard: http://localhost/d/abcd\nPanel: http://localhost/d/abcd?viewPanel=efgh\n"}},
+		{"type": "section", "fields": [
+			{"type": "mrkdwn", "text": "*Status*\nFiring"},
+			{"type": "mrkdwn", "text": "*alertname*\nalert1"},
+			{"type": "mrkdwn", "text": "*lbl1*\nval1"}
+		]},
+		{"type": "image", "image_url": "https://www.example.com/test.png", "alt_text": "[FIRING:1] alert1 (val1)"},
+		{"type": "actions", "elements": [
+			{"type": "button", "text": {"type": "plain_text", "text": "View alert rules"}, "url": "http://localhost/alerting/list"},
+			{"type": "button", "text": {"type": "plain_text", "text": "View dashboard"}, "url": "http://localhost/d/abcd"},
+			{"type": "button", "text": {"type": "plain_text", "text": "Silence"}, "url": "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1"}
+		]},
+		{"type": "context", "elements": [{"type": "mrkdwn", "text": "Grafana v`+setting.BuildVersion+`"}]}
+	]`, string(blocks))
+}
+
 func TestSlackPostMessage(t *testing.T) {
 	tests := []struct {
 		name            string
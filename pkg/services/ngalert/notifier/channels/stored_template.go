@@ -0,0 +1,83 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// storedTemplateCacheTTL is how long stored templates are cached, so that templates edited in
+// the UI are used by the notifiers shortly after without fetching them for each notification.
+const storedTemplateCacheTTL = time.Minute
+
+// TemplateStore returns the content of the notification template of an org with the given
+// name, as edited in the UI. It returns false if the org has no template with the name.
+type TemplateStore interface {
+	GetTemplate(ctx context.Context, orgID int64, name string) (string, bool, error)
+}
+
+type storedTemplate struct {
+	content string
+	found   bool
+	fetched time.Time
+}
+
+// storedTemplates caches the stored templates of the notifiers of an org.
+type storedTemplates struct {
+	store TemplateStore
+	orgID int64
+	log   Logger
+
+	mtx       sync.Mutex
+	templates map[string]storedTemplate
+}
+
+func newStoredTemplates(store TemplateStore, orgID int64, l Logger) *storedTemplates {
+	if store == nil {
+		return nil
+	}
+	return &storedTemplates{
+		store:     store,
+		orgID:     orgID,
+		log:       l,
+		templates: make(map[string]storedTemplate),
+	}
+}
+
+// messageTemplate returns a message template that executes the stored template with the
+// name, or fallback if the template does not exist or cannot be retrieved. Stored templates
+// define a template with their name, see definitions.MessageTemplate.
+func (s *storedTemplates) messageTemplate(ctx context.Context, name, fallback string) string {
+	if s == nil || name == "" {
+		return fallback
+	}
+	content, ok := s.get(ctx, name)
+	if !ok {
+		return fallback
+	}
+	return fmt.Sprintf("%s{{ template %q . }}", content, name)
+}
+
+// get returns the content of the stored template with the name. A template that cannot be
+// retrieved is not cached, and the previously cached content is used if there is any.
+func (s *storedTemplates) get(ctx context.Context, name string) (string, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	cached, ok := s.templates[name]
+	now := timeNow()
+	if ok && now.Sub(cached.fetched) < storedTemplateCacheTTL {
+		return cached.content, cached.found
+	}
+	content, found, err := s.store.GetTemplate(ctx, s.orgID, name)
+	if err != nil {
+		s.log.Warn("failed to get the stored template", "template", name, "error", err)
+		return cached.content, cached.found
+	}
+	if !found {
+		s.log.Warn("stored template not found, using the default template", "template", name)
+	}
+	s.templates[name] = storedTemplate{content: content, found: found, fetched: now}
+	return content, found
+}
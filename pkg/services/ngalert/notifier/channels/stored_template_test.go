@@ -0,0 +1,148 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTemplateStore struct {
+	templates map[string]string
+	err       error
+	calls     int
+}
+
+func (s *fakeTemplateStore) GetTemplate(_ context.Context, _ int64, name string) (string, bool, error) {
+	s.calls++
+	if s.err != nil {
+		return "", false, s.err
+	}
+	content, ok := s.templates[name]
+	return content, ok, nil
+}
+
+func TestStoredTemplates(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	store := &fakeTemplateStore{templates: map[string]string{
+		"ops-message": `{{ define "ops-message" }}{{ len .Alerts.Firing }} firing for {{ .CommonLabels.alertname }}{{ end }}`,
+	}}
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighCPU"}}}
+
+	newEmailNotifier := func(t *testing.T, settings string) (*EmailNotifier, *notificationServiceMock) {
+		ns := mockNotificationService()
+		n, err := EmailFactory(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "email",
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: ns,
+			ImageStore:          &UnavailableImageStore{},
+			Template:            tmpl,
+			Logger:              &FakeLogger{},
+			Templates:           store,
+		})
+		require.NoError(t, err)
+		return n.(*EmailNotifier), ns
+	}
+
+	newWebhookNotifier := func(t *testing.T, settings string) (*WebhookNotifier, *notificationServiceMock) {
+		ns := mockNotificationService()
+		wn, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "ops",
+				Type:     "webhook",
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: ns,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+			Templates:  store,
+		})
+		require.NoError(t, err)
+		return wn, ns
+	}
+
+	t.Run("email renders the stored template", func(t *testing.T) {
+		en, ns := newEmailNotifier(t, `{"addresses": "ops@example.com", "message": "configured message", "templateName": "ops-message"}`)
+		_, err := en.Notify(context.Background(), alert)
+		require.NoError(t, err)
+		require.Equal(t, "1 firing for HighCPU", ns.EmailSync.Data["Message"])
+	})
+
+	t.Run("email falls back to the message if the stored template does not exist", func(t *testing.T) {
+		en, ns := newEmailNotifier(t, `{"addresses": "ops@example.com", "message": "configured message", "templateName": "missing"}`)
+		_, err := en.Notify(context.Background(), alert)
+		require.NoError(t, err)
+		require.Equal(t, "configured message", ns.EmailSync.Data["Message"])
+	})
+
+	t.Run("webhook renders the stored template", func(t *testing.T) {
+		wn, ns := newWebhookNotifier(t, `{"url": "http://localhost/test", "templateName": "ops-message"}`)
+		_, err := wn.Notify(notify.WithGroupKey(context.Background(), "alertname"), alert)
+		require.NoError(t, err)
+
+		var body WebhookMessage
+		require.NoError(t, json.Unmarshal([]byte(ns.Webhook.Body), &body))
+		require.Equal(t, "1 firing for HighCPU", body.Message)
+	})
+
+	t.Run("webhook falls back to the default message if the stored template does not exist", func(t *testing.T) {
+		wn, ns := newWebhookNotifier(t, `{"url": "http://localhost/test", "templateName": "missing"}`)
+		_, err := wn.Notify(notify.WithGroupKey(context.Background(), "alertname"), alert)
+		require.NoError(t, err)
+
+		var body WebhookMessage
+		require.NoError(t, json.Unmarshal([]byte(ns.Webhook.Body), &body))
+		require.Contains(t, body.Message, "**Firing**")
+		require.Contains(t, body.Message, "alertname = HighCPU")
+	})
+}
+
+func TestStoredTemplatesCache(t *testing.T) {
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	store := &fakeTemplateStore{templates: map[string]string{"ops-message": `{{ define "ops-message" }}v1{{ end }}`}}
+	templates := newStoredTemplates(store, 1, &FakeLogger{})
+
+	expected := `{{ define "ops-message" }}v1{{ end }}{{ template "ops-message" . }}`
+	require.Equal(t, expected, templates.messageTemplate(context.Background(), "ops-message", "fallback"))
+	require.Equal(t, "fallback", templates.messageTemplate(context.Background(), "missing", "fallback"))
+	require.Equal(t, 2, store.calls)
+
+	// Templates are cached, including the ones that do not exist.
+	store.templates["ops-message"] = `{{ define "ops-message" }}v2{{ end }}`
+	require.Equal(t, expected, templates.messageTemplate(context.Background(), "ops-message", "fallback"))
+	require.Equal(t, "fallback", templates.messageTemplate(context.Background(), "missing", "fallback"))
+	require.Equal(t, 2, store.calls)
+
+	// The cached template is used while the store fails.
+	timeNow = func() time.Time { return now.Add(storedTemplateCacheTTL) }
+	store.err = errors.New("database is locked")
+	require.Equal(t, expected, templates.messageTemplate(context.Background(), "ops-message", "fallback"))
+	require.Equal(t, 3, store.calls)
+
+	store.err = nil
+	require.Equal(t, `{{ define "ops-message" }}v2{{ end }}{{ template "ops-message" . }}`, templates.messageTemplate(context.Background(), "ops-message", "fallback"))
+
+	// No template is used without a template name or a store.
+	require.Equal(t, "fallback", templates.messageTemplate(context.Background(), "", "fallback"))
+	require.Equal(t, "fallback", newStoredTemplates(nil, 1, &FakeLogger{}).messageTemplate(context.Background(), "ops-message", "fallback"))
+}
@@ -0,0 +1,72 @@
+package channels
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// TeamLabel is the label that assigns an alert to a team, by the name of the team.
+const TeamLabel = "team"
+
+// TeamMuteTimingsProvider returns the mute timings of the team of an org with the given name.
+type TeamMuteTimingsProvider interface {
+	GetTeamMuteTimings(ctx context.Context, orgID int64, team string) ([]config.MuteTimeInterval, error)
+}
+
+// teamMuteNotifier drops the alerts of teams that are inside one of their mute timings.
+// Overlapping mute timings of a team are combined, i.e. an alert is muted if any of them
+// contains the current time.
+type teamMuteNotifier struct {
+	NotificationChannel
+	orgID       int64
+	muteTimings TeamMuteTimingsProvider
+	log         Logger
+}
+
+func (n *teamMuteNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	now := timeNow()
+	muted := map[string]bool{}
+	unmuted := make([]*types.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		team := string(a.Labels[TeamLabel])
+		if team == "" {
+			unmuted = append(unmuted, a)
+			continue
+		}
+		isMuted, ok := muted[team]
+		if !ok {
+			isMuted = n.isMuted(ctx, team, now)
+			muted[team] = isMuted
+		}
+		if !isMuted {
+			unmuted = append(unmuted, a)
+		}
+	}
+
+	if len(unmuted) == 0 {
+		n.log.Debug("skipping notification, all alerts are muted by their teams", "alerts", len(alerts))
+		return true, nil
+	}
+	return n.NotificationChannel.Notify(ctx, unmuted...)
+}
+
+// isMuted returns whether one of the mute timings of the team contains now. Alerts are not
+// muted if the mute timings can't be retrieved.
+func (n *teamMuteNotifier) isMuted(ctx context.Context, team string, now time.Time) bool {
+	muteTimings, err := n.muteTimings.GetTeamMuteTimings(ctx, n.orgID, team)
+	if err != nil {
+		n.log.Warn("failed to get the mute timings of the team", "team", team, "error", err)
+		return false
+	}
+	for _, mt := range muteTimings {
+		for _, ti := range mt.TimeIntervals {
+			if ti.ContainsTime(now.UTC()) {
+				return true
+			}
+		}
+	}
+	return false
+}
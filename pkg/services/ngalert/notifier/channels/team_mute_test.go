@@ -0,0 +1,116 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTeamMuteTimings map[string][]config.MuteTimeInterval
+
+func (f fakeTeamMuteTimings) GetTeamMuteTimings(_ context.Context, _ int64, team string) ([]config.MuteTimeInterval, error) {
+	return f[team], nil
+}
+
+func TestTeamMuteTimings(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	// The maintenance windows of ops overlap, so its alerts are muted from 9:00 to 14:00.
+	muteTimings := fakeTeamMuteTimings{
+		"ops": {
+			{Name: "morning", TimeIntervals: []timeinterval.TimeInterval{{Times: []timeinterval.TimeRange{{StartMinute: 9 * 60, EndMinute: 12 * 60}}}}},
+			{Name: "lunch", TimeIntervals: []timeinterval.TimeInterval{{Times: []timeinterval.TimeRange{{StartMinute: 11 * 60, EndMinute: 14 * 60}}}}},
+		},
+	}
+
+	factory, ok := Factory("webhook")
+	require.True(t, ok)
+	webhookSender := mockNotificationService()
+	n, err := factory(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			OrgID:    1,
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(`{"url": "http://localhost/test"}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore:      &UnavailableImageStore{},
+		Template:        tmpl,
+		Logger:          &FakeLogger{},
+		TeamMuteTimings: muteTimings,
+	})
+	require.NoError(t, err)
+
+	opsAlert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "ops_alert", TeamLabel: "ops"}}}
+	devAlert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "dev_alert", TeamLabel: "dev"}}}
+	day := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name      string
+		now       time.Time
+		alerts    []*types.Alert
+		expAlerts []string
+	}{
+		{
+			name:   "alerts of the team are muted inside its window",
+			now:    day.Add(10 * time.Hour),
+			alerts: []*types.Alert{opsAlert},
+		},
+		{
+			name:   "overlapping windows are combined",
+			now:    day.Add(13 * time.Hour),
+			alerts: []*types.Alert{opsAlert},
+		},
+		{
+			name:      "alerts of the team are delivered outside its window",
+			now:       day.Add(15 * time.Hour),
+			alerts:    []*types.Alert{opsAlert},
+			expAlerts: []string{"ops_alert"},
+		},
+		{
+			name:      "alerts of other teams are delivered",
+			now:       day.Add(10 * time.Hour),
+			alerts:    []*types.Alert{opsAlert, devAlert},
+			expAlerts: []string{"dev_alert"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer mockTimeNow(c.now)()
+			webhookSender.Webhooks = nil
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ok, err := n.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			if len(c.expAlerts) == 0 {
+				require.Empty(t, webhookSender.Webhooks)
+				return
+			}
+			require.Len(t, webhookSender.Webhooks, 1)
+			var msg WebhookMessage
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhooks[0].Body), &msg))
+			var names []string
+			for _, a := range msg.Alerts {
+				names = append(names, a.Labels["alertname"])
+			}
+			require.Equal(t, c.expAlerts, names)
+		})
+	}
+}
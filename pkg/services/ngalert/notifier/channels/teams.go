@@ -9,7 +9,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
-	"github.com/prometheus/common/model"
 )
 
 const (
@@ -17,6 +16,7 @@ const (
 	ImageSizeMedium = "medium"
 	ImageSizeLarge  = "large"
 
+	TextColorDefault   = "default"
 	TextColorDark      = "dark"
 	TextColorLight     = "light"
 	TextColorAccent    = "accent"
@@ -251,6 +251,7 @@ type TeamsNotifier struct {
 	ns       WebhookSender
 	images   ImageStore
 	settings teamsSettings
+	colors   SeverityColors
 }
 
 // NewTeamsNotifier is the constructor for Teams notifier.
@@ -266,6 +267,7 @@ func NewTeamsNotifier(fc FactoryConfig) (*TeamsNotifier, error) {
 		images:   fc.ImageStore,
 		tmpl:     fc.Template,
 		settings: settings,
+		colors:   fc.SeverityColors,
 	}, nil
 }
 
@@ -286,7 +288,7 @@ func (tn *TeamsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 
 	card := NewAdaptiveCard()
 	card.AppendItem(AdaptiveCardTextBlockItem{
-		Color:  getTeamsTextColor(types.Alerts(as...)),
+		Color:  adaptiveCardColor(tn.colors.Color(as...)),
 		Text:   tmpl(tn.settings.Title),
 		Size:   TextSizeLarge,
 		Weight: TextWeightBolder,
@@ -368,11 +370,3 @@ func validateResponse(b []byte, statusCode int) error {
 func (tn *TeamsNotifier) SendResolved() bool {
 	return !tn.GetDisableResolveMessage()
 }
-
-// getTeamsTextColor returns the text color for the message title.
-func getTeamsTextColor(alerts model.Alerts) string {
-	if getAlertStatusColor(alerts.Status()) == ColorAlertFiring {
-		return TextColorAttention
-	}
-	return TextColorGood
-}
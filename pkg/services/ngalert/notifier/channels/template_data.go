@@ -3,6 +3,7 @@ package channels
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/url"
 	"path"
 	"sort"
@@ -32,6 +33,17 @@ type ExtendedAlert struct {
 	ValueString   string             `json:"valueString"` // TODO: Remove in Grafana 10
 	ImageURL      string             `json:"imageURL,omitempty"`
 	EmbeddedImage string             `json:"embeddedImage,omitempty"`
+	// FiringDuration is how long a firing alert has been firing for, e.g. "12m".
+	// It is empty for resolved alerts and alerts without a start time.
+	FiringDuration string `json:"firingDuration,omitempty"`
+	// PreviousStatus is the state of the alert before its last transition, e.g. "Pending".
+	// Transitions are its most recent transitions, oldest first. Both are empty for alerts
+	// without a prior state.
+	PreviousStatus string                          `json:"previousStatus,omitempty"`
+	Transitions    []ngmodels.AlertStateTransition `json:"transitions,omitempty"`
+	// RunbookExcerpt is the beginning of the text of the runbook of the alert, in emails that
+	// include runbooks. It is empty if the runbook could not be fetched.
+	RunbookExcerpt string `json:"runbookExcerpt,omitempty"`
 }
 
 type ExtendedAlerts []ExtendedAlert
@@ -68,6 +80,7 @@ func extendAlert(alert template.Alert, externalURL string, logger Logger) *Exten
 		GeneratorURL: alert.GeneratorURL,
 		Fingerprint:  alert.Fingerprint,
 	}
+	extended.FiringDuration = firingDuration(alert)
 
 	// fill in some grafana-specific urls
 	if len(externalURL) == 0 {
@@ -117,6 +130,13 @@ func extendAlert(alert template.Alert, externalURL string, logger Logger) *Exten
 		}
 		// TODO: Remove in Grafana 10
 		extended.ValueString = alert.Annotations[ngmodels.ValueStringAnnotation]
+		if s, ok := alert.Annotations[ngmodels.StateTransitionsAnnotation]; ok {
+			if err := json.Unmarshal([]byte(s), &extended.Transitions); err != nil {
+				logger.Warn("failed to unmarshal state transitions annotation", "error", err)
+			} else if len(extended.Transitions) > 0 {
+				extended.PreviousStatus = extended.Transitions[len(extended.Transitions)-1].PreviousState
+			}
+		}
 	}
 
 	matchers := make([]string, 0)
@@ -141,6 +161,68 @@ func extendAlert(alert template.Alert, externalURL string, logger Logger) *Exten
 	return extended
 }
 
+// firingDuration returns for how long the alert has been firing, rounded down to the second.
+func firingDuration(alert template.Alert) string {
+	if alert.Status != string(model.AlertFiring) || alert.StartsAt.IsZero() {
+		return ""
+	}
+	d := timeNow().Sub(alert.StartsAt).Truncate(time.Second)
+	if d < 0 {
+		d = 0
+	}
+	return model.Duration(d).String()
+}
+
+// parseExternalURLOverride parses the URL that replaces the Grafana external URL in
+// links generated by a single channel. It returns nil if the override is empty.
+func parseExternalURLOverride(s string) (*url.URL, error) {
+	if s == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid external URL override: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid external URL override %q: must be an absolute http or https URL", s)
+	}
+	return u, nil
+}
+
+// withExternalURL returns a copy of the template that generates links using the given
+// external URL. It returns the template as is if the URL is nil.
+func withExternalURL(tmpl *template.Template, externalURL *url.URL) *template.Template {
+	if externalURL == nil {
+		return tmpl
+	}
+	t := *tmpl
+	t.ExternalURL = externalURL
+	return &t
+}
+
+// inTimezone converts the timestamps of the alerts to the time zone, or UTC if it is nil,
+// so that templates render them in it.
+func inTimezone(data *ExtendedData, loc *time.Location) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	for i := range data.Alerts {
+		a := &data.Alerts[i]
+		a.StartsAt = a.StartsAt.In(loc)
+		a.EndsAt = a.EndsAt.In(loc)
+		if len(a.Transitions) == 0 {
+			continue
+		}
+		// The transitions are shared with the state of the alert.
+		transitions := make([]ngmodels.AlertStateTransition, len(a.Transitions))
+		for j, tr := range a.Transitions {
+			tr.At = tr.At.In(loc)
+			transitions[j] = tr
+		}
+		a.Transitions = transitions
+	}
+}
+
 func setOrgIdQueryParam(url *url.URL, orgId string) string {
 	q := url.Query()
 	q.Set("orgId", orgId)
@@ -170,6 +252,98 @@ func ExtendData(data *template.Data, logger Logger) *ExtendedData {
 	return extended
 }
 
+// truncateLabelValues truncates label values longer than maxRunes with an ellipsis, keeping
+// the names intact. The label sets are replaced rather than modified, so copies of data made
+// before keep the original values. It does nothing if maxRunes is 0.
+func truncateLabelValues(data *ExtendedData, maxRunes int) {
+	if maxRunes <= 0 {
+		return
+	}
+	truncate := func(kv template.KV) template.KV {
+		if kv == nil {
+			return nil
+		}
+		truncated := make(template.KV, len(kv))
+		for name, value := range kv {
+			truncated[name], _ = TruncateInRunes(value, maxRunes)
+		}
+		return truncated
+	}
+	data.GroupLabels = truncate(data.GroupLabels)
+	data.CommonLabels = truncate(data.CommonLabels)
+	for i := range data.Alerts {
+		data.Alerts[i].Labels = truncate(data.Alerts[i].Labels)
+	}
+}
+
+// LabelPatterns are the names of labels to exclude from notifications. A name ending with *
+// matches all labels with the prefix, e.g. __replica* matches __replica__.
+type LabelPatterns []string
+
+// UnmarshalJSON accepts a list of names, or a string with names separated by commas or
+// line breaks when set from the UI.
+func (p *LabelPatterns) UnmarshalJSON(b []byte) error {
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		names = strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' })
+	}
+	*p = nil
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if i := strings.Index(name, "*"); i >= 0 && i != len(name)-1 {
+			return fmt.Errorf("invalid label pattern %q, the wildcard is only supported at the end", name)
+		}
+		*p = append(*p, name)
+	}
+	return nil
+}
+
+// Matches returns true if one of the patterns matches the label name.
+func (p LabelPatterns) Matches(name string) bool {
+	for _, pattern := range p {
+		if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeLabels removes the labels matching the patterns from the data. The label sets are
+// replaced rather than modified, like in truncateLabelValues, and the fingerprints of the
+// alerts are kept as they are of the original labels.
+func excludeLabels(data *ExtendedData, patterns LabelPatterns) {
+	if len(patterns) == 0 {
+		return
+	}
+	exclude := func(kv template.KV) template.KV {
+		if kv == nil {
+			return nil
+		}
+		result := make(template.KV, len(kv))
+		for name, value := range kv {
+			if !patterns.Matches(name) {
+				result[name] = value
+			}
+		}
+		return result
+	}
+	data.GroupLabels = exclude(data.GroupLabels)
+	data.CommonLabels = exclude(data.CommonLabels)
+	for i := range data.Alerts {
+		data.Alerts[i].Labels = exclude(data.Alerts[i].Labels)
+	}
+}
+
 func TmplText(ctx context.Context, tmpl *template.Template, alerts []*types.Alert, l Logger, tmplErr *error) (func(string) string, *ExtendedData) {
 	promTmplData := notify.GetTemplateData(ctx, tmpl, alerts, l)
 	data := ExtendData(promTmplData, l)
@@ -178,11 +352,35 @@ func TmplText(ctx context.Context, tmpl *template.Template, alerts []*types.Aler
 		if *tmplErr != nil {
 			return
 		}
-		s, *tmplErr = tmpl.ExecuteTextString(name, data)
+		s, *tmplErr = executeTextString(tmpl, name, data)
 		return s
 	}, data
 }
 
+// TemplateErrorNotice is added to messages that were rendered with the default template
+// because the configured template failed to execute.
+const TemplateErrorNotice = "[template error] The configured notification template failed to render, the default template was used instead."
+
+// tmplWithFallback wraps a function returned by TmplText. When a template fails to execute,
+// the error is logged and the fallback template is executed instead, so that a notification
+// is still sent. The returned bool reports whether the fallback template was used.
+func tmplWithFallback(tmpl func(string) string, tmplErr *error, l Logger) func(text, fallback string) (string, bool) {
+	return func(text, fallback string) (string, bool) {
+		s := tmpl(text)
+		if *tmplErr == nil {
+			return s, false
+		}
+		l.Error("failed to execute template, falling back to the default template", "error", *tmplErr)
+		*tmplErr = nil
+		s = tmpl(fallback)
+		if *tmplErr != nil {
+			l.Error("failed to execute the default template", "error", *tmplErr)
+			*tmplErr = nil
+		}
+		return s, true
+	}
+}
+
 // Firing returns the subset of alerts that are firing.
 func (as ExtendedAlerts) Firing() []ExtendedAlert {
 	res := []ExtendedAlert{}
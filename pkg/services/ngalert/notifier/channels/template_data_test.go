@@ -0,0 +1,60 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestTmplTextPreviousStatus(t *testing.T) {
+	now := time.Now()
+	transitions, err := json.Marshal([]ngmodels.AlertStateTransition{
+		{PreviousState: "Normal", State: "Pending", At: now.Add(-2 * time.Minute)},
+		{PreviousState: "Pending", State: "Alerting", At: now.Add(-time.Minute)},
+	})
+	require.NoError(t, err)
+
+	alerts := []*types.Alert{
+		{ // Transitioned from pending.
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1"},
+				Annotations: model.LabelSet{ngmodels.StateTransitionsAnnotation: model.LabelValue(transitions)},
+				StartsAt:    now.Add(-time.Minute),
+				EndsAt:      now.Add(time.Hour),
+			},
+		}, { // Without a prior state.
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "alert2"},
+				StartsAt: now.Add(-time.Minute),
+				EndsAt:   now.Add(time.Hour),
+			},
+		},
+	}
+
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	var tmplErr error
+	expand, data := TmplText(context.Background(), tmpl, alerts, &FakeLogger{}, &tmplErr)
+
+	require.Equal(t, "Pending", data.Alerts[0].PreviousStatus)
+	require.Len(t, data.Alerts[0].Transitions, 2)
+	require.Empty(t, data.Alerts[0].Annotations)
+	require.Empty(t, data.Alerts[1].PreviousStatus)
+	require.Empty(t, data.Alerts[1].Transitions)
+
+	out := expand(`{{ range .Alerts }}{{ .Labels.alertname }}: was "{{ .PreviousStatus }}", now {{ .Status }}{{ range .Transitions }} [{{ .PreviousState }} -> {{ .State }}]{{ end }}
+{{ end }}`)
+	require.NoError(t, tmplErr)
+	require.Equal(t, "alert1: was \"Pending\", now firing [Normal -> Pending] [Pending -> Alerting]\nalert2: was \"\", now firing\n", out)
+}
@@ -0,0 +1,177 @@
+package channels
+
+import "strings"
+
+// emojify replaces the emoji shortcodes of the text, e.g. :fire:, with their Unicode emoji.
+// Unknown shortcodes are left untouched. It is used in templates as {{ emojify .Message }}.
+func emojify(text string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(text, ':')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start+1:], ':')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		if emoji, ok := emojiShortcodes[text[start+1:end]]; ok {
+			b.WriteString(text[:start])
+			b.WriteString(emoji)
+			text = text[end+1:]
+			continue
+		}
+		// The closing colon of an unknown shortcode can open the next shortcode, e.g. in
+		// "10:30 :fire:".
+		b.WriteString(text[:end])
+		text = text[end:]
+	}
+	b.WriteString(text)
+	return b.String()
+}
+
+// emojiShortcodes maps the shortcodes of common emoji, as they are written in Slack and
+// GitHub, to the emoji.
+var emojiShortcodes = map[string]string{
+	"alarm_clock":                 "⏰",
+	"ambulance":                   "🚑",
+	"arrow_down":                  "⬇️",
+	"arrow_left":                  "⬅️",
+	"arrow_right":                 "➡️",
+	"arrow_up":                    "⬆️",
+	"arrows_counterclockwise":     "🔄",
+	"bangbang":                    "‼️",
+	"bar_chart":                   "📊",
+	"beer":                        "🍺",
+	"bell":                        "🔔",
+	"black_circle":                "⚫️",
+	"blue_circle":                 "🔵",
+	"blue_heart":                  "💙",
+	"bookmark":                    "🔖",
+	"boom":                        "💥",
+	"broken_heart":                "💔",
+	"bug":                         "🐛",
+	"bulb":                        "💡",
+	"calendar":                    "📆",
+	"chart_with_downwards_trend":  "📉",
+	"chart_with_upwards_trend":    "📈",
+	"checkered_flag":              "🏁",
+	"clap":                        "👏",
+	"clipboard":                   "📋",
+	"cloud":                       "☁️",
+	"coffee":                      "☕",
+	"computer":                    "💻",
+	"confused":                    "😕",
+	"construction":                "🚧",
+	"cool":                        "🆒",
+	"cry":                         "😢",
+	"desktop_computer":            "🖥️",
+	"dollar":                      "💵",
+	"earth_americas":              "🌎",
+	"email":                       "📧",
+	"envelope":                    "✉️",
+	"exclamation":                 "❗",
+	"exploding_head":              "🤯",
+	"eyes":                        "👀",
+	"fire":                        "🔥",
+	"fire_engine":                 "🚒",
+	"floppy_disk":                 "💾",
+	"gear":                        "⚙️",
+	"ghost":                       "👻",
+	"globe_with_meridians":        "🌐",
+	"green_circle":                "🟢",
+	"green_heart":                 "💚",
+	"grimacing":                   "😬",
+	"grin":                        "😁",
+	"hammer":                      "🔨",
+	"hammer_and_wrench":           "🛠️",
+	"heart":                       "❤️",
+	"heavy_check_mark":            "✔️",
+	"heavy_minus_sign":            "➖",
+	"heavy_plus_sign":             "➕",
+	"hourglass":                   "⌛️",
+	"hourglass_flowing_sand":      "⏳",
+	"information_source":          "ℹ️",
+	"joy":                         "😂",
+	"key":                         "🔑",
+	"large_blue_circle":           "🔵",
+	"link":                        "🔗",
+	"lock":                        "🔒",
+	"loudspeaker":                 "📢",
+	"mag":                         "🔍",
+	"mega":                        "📣",
+	"memo":                        "📝",
+	"money_with_wings":            "💸",
+	"moneybag":                    "💰",
+	"muscle":                      "💪",
+	"negative_squared_cross_mark": "❎",
+	"neutral_face":                "😐",
+	"new":                         "🆕",
+	"no_bell":                     "🔕",
+	"no_entry":                    "⛔️",
+	"no_entry_sign":               "🚫",
+	"ocean":                       "🌊",
+	"ok":                          "🆗",
+	"ok_hand":                     "👌",
+	"orange_circle":               "🟠",
+	"package":                     "📦",
+	"pager":                       "📟",
+	"pencil":                      "📝",
+	"phone":                       "☎️",
+	"pizza":                       "🍕",
+	"point_right":                 "👉",
+	"point_up":                    "☝️",
+	"police_car":                  "🚓",
+	"pray":                        "🙏",
+	"question":                    "❓",
+	"rage":                        "😡",
+	"raised_hands":                "🙌",
+	"recycle":                     "♻️",
+	"red_circle":                  "🔴",
+	"robot":                       "🤖",
+	"rocket":                      "🚀",
+	"rotating_light":              "🚨",
+	"scream":                      "😱",
+	"shield":                      "🛡️",
+	"skull":                       "💀",
+	"sleeping":                    "😴",
+	"slightly_smiling_face":       "🙂",
+	"smile":                       "😄",
+	"snail":                       "🐌",
+	"snowflake":                   "❄️",
+	"sob":                         "😭",
+	"sos":                         "🆘",
+	"sparkles":                    "✨",
+	"star":                        "⭐",
+	"stop_sign":                   "🛑",
+	"stopwatch":                   "⏱️",
+	"sunglasses":                  "😎",
+	"sunny":                       "☀️",
+	"sweat_smile":                 "😅",
+	"tada":                        "🎉",
+	"telephone_receiver":          "📞",
+	"thinking":                    "🤔",
+	"thinking_face":               "🤔",
+	"thumbsdown":                  "👎",
+	"thumbsup":                    "👍",
+	"triangular_flag_on_post":     "🚩",
+	"turtle":                      "🐢",
+	"umbrella":                    "☔",
+	"unlock":                      "🔓",
+	"up":                          "🆙",
+	"warning":                     "⚠️",
+	"wave":                        "👋",
+	"white_check_mark":            "✅",
+	"white_circle":                "⚪️",
+	"wink":                        "😉",
+	"worried":                     "😟",
+	"wrench":                      "🔧",
+	"x":                           "❌",
+	"yellow_circle":               "🟡",
+	"zap":                         "⚡️",
+	"zzz":                         "💤",
+	"+1":                          "👍",
+	"-1":                          "👎",
+	"100":                         "💯",
+}
@@ -0,0 +1,59 @@
+package channels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmojify(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		exp  string
+	}{
+		{
+			name: "known shortcodes are replaced",
+			text: ":fire: CPU is high :rotating_light:",
+			exp:  "🔥 CPU is high 🚨",
+		},
+		{
+			name: "unknown shortcodes are left untouched",
+			text: "CPU is :unknown_emoji: high",
+			exp:  "CPU is :unknown_emoji: high",
+		},
+		{
+			name: "adjacent shortcodes are replaced",
+			text: ":fire::fire::+1:",
+			exp:  "🔥🔥👍",
+		},
+		{
+			name: "the closing colon of an unknown shortcode can open a known one",
+			text: "at 10:30 :fire: and :nope:fire:",
+			exp:  "at 10:30 🔥 and :nope🔥",
+		},
+		{
+			name: "text without shortcodes is unchanged",
+			text: "label=value: no emoji: here",
+			exp:  "label=value: no emoji: here",
+		},
+		{
+			name: "unclosed shortcodes are left untouched",
+			text: "degraded :warning",
+			exp:  "degraded :warning",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, emojify(c.text))
+		})
+	}
+
+	t.Run("is available in templates", func(t *testing.T) {
+		tmpl := templateForTests(t)
+		s, err := tmpl.ExecuteTextString(`{{ emojify ":white_check_mark: resolved" }}`, nil)
+		require.NoError(t, err)
+		require.Equal(t, "✅ resolved", s)
+	})
+}
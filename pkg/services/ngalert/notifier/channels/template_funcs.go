@@ -0,0 +1,167 @@
+package channels
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+)
+
+const (
+	// LabelOrderAlphabetical sorts labels by name. It is the default order of topLabels.
+	LabelOrderAlphabetical = "alphabetical"
+	// LabelOrderValue sorts labels by value, and labels with the same value by name.
+	LabelOrderValue = "value"
+
+	// DefaultLocalTimeLayout is the layout of localTime if none is given.
+	DefaultLocalTimeLayout = "2006-01-02 15:04:05 MST"
+)
+
+func init() {
+	// The Alertmanager only reads template functions from its defaults when templates are
+	// created, so the functions are added there. They must not depend on the org or the
+	// channel: dashboardURL and panelURL only return a placeholder for the external URL, see
+	// executeTextString.
+	template.DefaultFuncs["topLabels"] = topLabels
+	template.DefaultFuncs["dashboardURL"] = dashboardURL
+	template.DefaultFuncs["panelURL"] = panelURL
+	template.DefaultFuncs["localTime"] = localTime
+	template.DefaultFuncs["emojify"] = emojify
+}
+
+// localTime formats the timestamp in the time zone of the notifier, e.g.
+// {{ localTime .StartsAt }} or {{ localTime .StartsAt "15:04 MST" }}. The timestamps of the
+// alerts are converted to the time zone before templates are executed, see inTimezone.
+func localTime(t time.Time, layout ...string) (string, error) {
+	if len(layout) > 1 {
+		return "", fmt.Errorf("localTime accepts a single layout, got %d", len(layout))
+	}
+	l := DefaultLocalTimeLayout
+	if len(layout) == 1 && layout[0] != "" {
+		l = layout[0]
+	}
+	return t.Format(l), nil
+}
+
+// parseTimezone parses the timezone setting of a notifier, the name of a time zone in the
+// IANA database, e.g. Europe/Paris. The default is UTC.
+func parseTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return loc, nil
+}
+
+// templateExternalURLPlaceholder is returned by dashboardURL and panelURL in place of the
+// external URL of Grafana, which template functions cannot read from the template they run in.
+// It is replaced with the external URL of the template by executeTextString.
+const templateExternalURLPlaceholder = "__grafanaExternalURL__"
+
+// executeTextString executes the text template with the data, and builds the URLs of
+// dashboardURL and panelURL from the external URL of the template, i.e. the external URL of
+// the org or of the channel if it overrides it. The URLs are relative if it is not set.
+func executeTextString(tmpl *template.Template, text string, data interface{}) (string, error) {
+	s, err := tmpl.ExecuteTextString(text, data)
+	if err != nil || !strings.Contains(s, templateExternalURLPlaceholder) {
+		return s, err
+	}
+	base := ""
+	if tmpl.ExternalURL != nil {
+		u := *tmpl.ExternalURL
+		u.RawQuery = ""
+		u.Fragment = ""
+		base = strings.TrimSuffix(u.String(), "/")
+	}
+	return strings.ReplaceAll(s, templateExternalURLPlaceholder, base), nil
+}
+
+// dashboardURL returns the URL of the dashboard with the uid, or of the alert list if the uid
+// is empty. It is used in templates as {{ dashboardURL "uid" }}.
+func dashboardURL(uid string) string {
+	return templateDashboardURL(uid, "")
+}
+
+// panelURL returns the URL of the panel of the dashboard with the uid, or of the alert list
+// if the uid is empty. It is used in templates as {{ panelURL "uid" 2 }}.
+func panelURL(uid string, panelID interface{}) string {
+	return templateDashboardURL(uid, fmt.Sprint(panelID))
+}
+
+func templateDashboardURL(uid, panelID string) string {
+	if uid == "" {
+		return templateExternalURLPlaceholder + "/alerting/list"
+	}
+	u := url.URL{Path: path.Join("/d", uid)}
+	if panelID != "" {
+		u.RawQuery = url.Values{"viewPanel": []string{panelID}}.Encode()
+	}
+	return templateExternalURLPlaceholder + u.String()
+}
+
+// LabelSummary is a truncated set of labels. It renders as a comma separated list of
+// name=value pairs followed by the number of labels that were left out, if any.
+type LabelSummary struct {
+	Pairs template.Pairs
+	// More is the number of labels that were left out.
+	More int
+}
+
+func (s LabelSummary) String() string {
+	pairs := make([]string, 0, len(s.Pairs))
+	for _, p := range s.Pairs {
+		pairs = append(pairs, p.Name+"="+p.Value)
+	}
+	res := strings.Join(pairs, ", ")
+	if s.More > 0 {
+		res = strings.TrimSpace(fmt.Sprintf("%s +%d more", res, s.More))
+	}
+	return res
+}
+
+// topLabels returns the first n labels of kv in the given order, which is alphabetical
+// by default. It is used in templates as {{ topLabels 5 .Labels }} or
+// {{ topLabels 5 .Labels "value" }}.
+func topLabels(n int, kv template.KV, order ...string) (LabelSummary, error) {
+	if len(order) > 1 {
+		return LabelSummary{}, fmt.Errorf("topLabels accepts a single order, got %d", len(order))
+	}
+	if n < 0 {
+		return LabelSummary{}, fmt.Errorf("topLabels requires a non-negative number of labels, got %d", n)
+	}
+
+	// Sorting by name first makes the sort by value stable.
+	pairs := make(template.Pairs, 0, len(kv))
+	for k, v := range kv {
+		pairs = append(pairs, template.Pair{Name: k, Value: v})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+
+	o := LabelOrderAlphabetical
+	if len(order) == 1 && order[0] != "" {
+		o = order[0]
+	}
+	switch o {
+	case LabelOrderAlphabetical:
+	case LabelOrderValue:
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return pairs[i].Value < pairs[j].Value
+		})
+	default:
+		return LabelSummary{}, fmt.Errorf("unknown label order %q, must be %q or %q", o, LabelOrderAlphabetical, LabelOrderValue)
+	}
+
+	if len(pairs) <= n {
+		return LabelSummary{Pairs: pairs}, nil
+	}
+	return LabelSummary{Pairs: pairs[:n], More: len(pairs) - n}, nil
+}
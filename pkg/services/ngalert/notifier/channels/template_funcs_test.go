@@ -0,0 +1,190 @@
+package channels
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopLabels(t *testing.T) {
+	labels := template.KV{
+		"alertname": "HighCPU",
+		"zone":      "a",
+		"instance":  "host-1",
+		"cluster":   "prod",
+		"job":       "node",
+	}
+
+	cases := []struct {
+		name   string
+		n      int
+		kv     template.KV
+		order  []string
+		exp    string
+		expErr string
+	}{
+		{
+			name: "all labels sorted alphabetically",
+			n:    10,
+			kv:   labels,
+			exp:  "alertname=HighCPU, cluster=prod, instance=host-1, job=node, zone=a",
+		},
+		{
+			name: "truncated labels",
+			n:    2,
+			kv:   labels,
+			exp:  "alertname=HighCPU, cluster=prod +3 more",
+		},
+		{
+			name: "no labels",
+			n:    0,
+			kv:   labels,
+			exp:  "+5 more",
+		},
+		{
+			name:  "sorted by value",
+			n:     3,
+			kv:    labels,
+			order: []string{LabelOrderValue},
+			exp:   "alertname=HighCPU, zone=a, instance=host-1 +2 more",
+		},
+		{
+			name:  "labels with the same value are sorted by name",
+			n:     3,
+			kv:    template.KV{"c": "x", "a": "x", "b": "x", "d": "w"},
+			order: []string{LabelOrderValue},
+			exp:   "d=w, a=x, b=x +1 more",
+		},
+		{
+			name: "empty label set",
+			n:    3,
+			kv:   template.KV{},
+			exp:  "",
+		},
+		{
+			name:   "unknown order",
+			n:      3,
+			kv:     labels,
+			order:  []string{"random"},
+			expErr: `unknown label order "random", must be "alphabetical" or "value"`,
+		},
+		{
+			name:   "negative number of labels",
+			n:      -1,
+			kv:     labels,
+			expErr: "topLabels requires a non-negative number of labels, got -1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := topLabels(c.n, c.kv, c.order...)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, s.String())
+		})
+	}
+
+	t.Run("is available in templates", func(t *testing.T) {
+		tmpl := templateForTests(t)
+		data := &ExtendedData{
+			Alerts: ExtendedAlerts{{Labels: labels}},
+		}
+
+		s, err := tmpl.ExecuteTextString(`{{ range .Alerts }}{{ topLabels 3 .Labels }}{{ end }}`, data)
+		require.NoError(t, err)
+		require.Equal(t, "alertname=HighCPU, cluster=prod, instance=host-1 +2 more", s)
+
+		// HTML templates escape the summary.
+		s, err = tmpl.ExecuteHTMLString(`{{ range .Alerts }}{{ topLabels 1 .Labels "value" }}{{ end }}`, data)
+		require.NoError(t, err)
+		require.Equal(t, "alertname=HighCPU &#43;4 more", s)
+	})
+}
+
+func TestDashboardURL(t *testing.T) {
+	externalURL, err := url.Parse("http://localhost:3000/grafana/")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		tmpl string
+		exp  string
+	}{
+		{
+			name: "dashboard",
+			tmpl: `{{ dashboardURL "abc123" }}`,
+			exp:  "http://localhost:3000/grafana/d/abc123",
+		},
+		{
+			name: "panel",
+			tmpl: `{{ panelURL "abc123" 2 }}`,
+			exp:  "http://localhost:3000/grafana/d/abc123?viewPanel=2",
+		},
+		{
+			name: "panel id as string",
+			tmpl: `{{ panelURL "abc123" "2" }}`,
+			exp:  "http://localhost:3000/grafana/d/abc123?viewPanel=2",
+		},
+		{
+			name: "empty uid returns the alert list",
+			tmpl: `{{ dashboardURL "" }}`,
+			exp:  "http://localhost:3000/grafana/alerting/list",
+		},
+		{
+			name: "panel of an empty uid returns the alert list",
+			tmpl: `{{ panelURL "" 2 }}`,
+			exp:  "http://localhost:3000/grafana/alerting/list",
+		},
+		{
+			name: "uid from a label",
+			tmpl: `{{ dashboardURL .CommonLabels.dashboard }}`,
+			exp:  "http://localhost:3000/grafana/d/from-label",
+		},
+	}
+	tmpl := templateForTests(t)
+	tmpl.ExternalURL = externalURL
+	data := &ExtendedData{CommonLabels: template.KV{"dashboard": "from-label"}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := executeTextString(tmpl, c.tmpl, data)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, s)
+		})
+	}
+
+	t.Run("URLs are built from the external URL of the channel", func(t *testing.T) {
+		override, err := url.Parse("https://grafana.example.com/public")
+		require.NoError(t, err)
+		s, err := executeTextString(withExternalURL(tmpl, override), `{{ panelURL "abc123" 2 }}`, data)
+		require.NoError(t, err)
+		require.Equal(t, "https://grafana.example.com/public/d/abc123?viewPanel=2", s)
+	})
+
+	t.Run("URLs are relative without an external URL", func(t *testing.T) {
+		s, err := executeTextString(templateForTests(t), `{{ panelURL "abc123" 2 }} {{ dashboardURL "" }}`, data)
+		require.NoError(t, err)
+		require.Equal(t, "/d/abc123?viewPanel=2 /alerting/list", s)
+	})
+}
+
+func TestLocalTime(t *testing.T) {
+	loc, err := parseTimezone("Europe/Paris")
+	require.NoError(t, err)
+	data := &ExtendedData{Alerts: ExtendedAlerts{{StartsAt: time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC)}}}
+	inTimezone(data, loc)
+
+	tmpl := templateForTests(t)
+	s, err := tmpl.ExecuteTextString(`{{ range .Alerts }}{{ localTime .StartsAt }}|{{ localTime .StartsAt "15:04" }}{{ end }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "2022-11-01 13:00:00 CET|13:00", s)
+
+	_, err = tmpl.ExecuteTextString(`{{ range .Alerts }}{{ localTime .StartsAt "15:04" "MST" }}{{ end }}`, data)
+	require.ErrorContains(t, err, "localTime accepts a single layout, got 2")
+}
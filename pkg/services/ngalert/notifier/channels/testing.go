@@ -131,15 +131,34 @@ type notificationServiceMock struct {
 	Webhook     SendWebhookSettings
 	EmailSync   SendEmailSettings
 	ShouldError error
+	// Emails are all emails sent, EmailSync is the last one.
+	Emails []SendEmailSettings
+	// EmailErrors fails the delivery of emails to the given recipients.
+	EmailErrors map[string]error
+	// Webhooks are all webhooks sent, Webhook is the last one.
+	Webhooks []SendWebhookSettings
+	// WebhookErrors fails the webhooks sent to the given URLs.
+	WebhookErrors map[string]error
 }
 
 func (ns *notificationServiceMock) SendWebhook(ctx context.Context, cmd *SendWebhookSettings) error {
 	ns.Webhook = *cmd
+	ns.Webhooks = append(ns.Webhooks, *cmd)
+	if err, ok := ns.WebhookErrors[cmd.Url]; ok {
+		return err
+	}
 	return ns.ShouldError
 }
-func (ns *notificationServiceMock) SendEmail(ctx context.Context, cmd *SendEmailSettings) error {
+func (ns *notificationServiceMock) SendEmail(ctx context.Context, cmd *SendEmailSettings) (EmailSendResult, error) {
 	ns.EmailSync = *cmd
-	return ns.ShouldError
+	ns.Emails = append(ns.Emails, *cmd)
+	res := NewEmailSendResult(cmd.To, ns.ShouldError)
+	for i, r := range res.Recipients {
+		if err, ok := ns.EmailErrors[r.Address]; ok {
+			res.Recipients[i].Error = err
+		}
+	}
+	return res, res.Err()
 }
 
 func mockNotificationService() *notificationServiceMock { return &notificationServiceMock{} }
@@ -148,7 +167,7 @@ type emailSender struct {
 	ns *notifications.NotificationService
 }
 
-func (e emailSender) SendEmail(ctx context.Context, cmd *SendEmailSettings) error {
+func (e emailSender) SendEmail(ctx context.Context, cmd *SendEmailSettings) (EmailSendResult, error) {
 	attached := make([]*models.SendEmailAttachFile, 0, len(cmd.AttachedFiles))
 	for _, file := range cmd.AttachedFiles {
 		attached = append(attached, &models.SendEmailAttachFile{
@@ -156,19 +175,59 @@ func (e emailSender) SendEmail(ctx context.Context, cmd *SendEmailSettings) erro
 			Content: file.Content,
 		})
 	}
-	return e.ns.SendEmailCommandHandlerSync(ctx, &models.SendEmailCommandSync{
+	err := e.ns.SendEmailCommandHandlerSync(ctx, &models.SendEmailCommandSync{
 		SendEmailCommand: models.SendEmailCommand{
-			To:            cmd.To,
-			SingleEmail:   cmd.SingleEmail,
-			Template:      cmd.Template,
-			Subject:       cmd.Subject,
-			Data:          cmd.Data,
-			Info:          cmd.Info,
-			ReplyTo:       cmd.ReplyTo,
-			EmbeddedFiles: cmd.EmbeddedFiles,
-			AttachedFiles: attached,
+			To:               cmd.To,
+			SingleEmail:      cmd.SingleEmail,
+			Template:         cmd.Template,
+			Subject:          cmd.Subject,
+			Data:             cmd.Data,
+			Info:             cmd.Info,
+			ReplyTo:          cmd.ReplyTo,
+			EmbeddedFiles:    cmd.EmbeddedFiles,
+			AttachedFiles:    attached,
+			Cc:               cmd.Cc,
+			CopyToSender:     cmd.CopyToSender,
+			Headers:          cmd.Headers,
+			TransferEncoding: cmd.TransferEncoding,
+			Charset:          cmd.Charset,
+			SMIMECertFile:    cmd.SMIMECertFile,
+			SMIMEKeyFile:     cmd.SMIMEKeyFile,
+			OrgID:            cmd.OrgID,
 		},
 	})
+	res := NewEmailSendResult(cmd.To, err)
+	return res, res.Err()
+}
+
+type webhookSender struct {
+	notificationServiceMock
+	ns *notifications.NotificationService
+}
+
+func (w webhookSender) SendWebhook(ctx context.Context, cmd *SendWebhookSettings) error {
+	return w.ns.SendWebhookSync(ctx, &models.SendWebhookSync{
+		Url:             cmd.Url,
+		User:            cmd.User,
+		Password:        cmd.Password,
+		Body:            cmd.Body,
+		HttpMethod:      cmd.HttpMethod,
+		HttpHeader:      cmd.HttpHeader,
+		ContentType:     cmd.ContentType,
+		Timeout:         cmd.Timeout,
+		ConnectTimeout:  cmd.ConnectTimeout,
+		ConnectionPool:  cmd.ConnectionPool,
+		MaxIdleConns:    cmd.MaxIdleConns,
+		IdleConnTimeout: cmd.IdleConnTimeout,
+		Validation:      cmd.Validation,
+	})
+}
+
+// createWebhookSender returns a sender that delivers webhooks over HTTP.
+func createWebhookSender(t *testing.T) *webhookSender {
+	t.Helper()
+
+	return &webhookSender{ns: createEmailSender(t).ns}
 }
 
 func createEmailSender(t *testing.T) *emailSender {
@@ -14,6 +14,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -44,6 +45,10 @@ var (
 	// the maximum number of images has been iterated.
 	ErrImagesDone        = errors.New("images done")
 	ErrImagesUnavailable = errors.New("alert screenshots are unavailable")
+
+	// ErrRequiredImagesUnavailable is returned by notifiers that require images when none
+	// of the images of the alerts could be retrieved.
+	ErrRequiredImagesUnavailable = errors.New("required alert screenshots are unavailable")
 )
 
 type forEachImageFunc func(index int, image Image) error
@@ -122,6 +127,34 @@ func getTokenFromAnnotations(annotations model.LabelSet) string {
 	return ""
 }
 
+// requiredImageStore counts the images that are requested from an ImageStore and the
+// ones that could not be retrieved, so notifiers that require images can refuse to send
+// notifications without them.
+//
+// A nil requiredImageStore never returns an error.
+type requiredImageStore struct {
+	ImageStore
+	requested int
+	failed    int
+}
+
+func (s *requiredImageStore) GetImage(ctx context.Context, token string) (*Image, error) {
+	s.requested++
+	img, err := s.ImageStore.GetImage(ctx, token)
+	if err != nil {
+		s.failed++
+	}
+	return img, err
+}
+
+// err returns ErrRequiredImagesUnavailable if images were requested and all of them failed.
+func (s *requiredImageStore) err() error {
+	if s == nil || s.requested == 0 || s.failed < s.requested {
+		return nil
+	}
+	return ErrRequiredImagesUnavailable
+}
+
 type UnavailableImageStore struct{}
 
 // Get returns the image with the corresponding token, or ErrImageNotFound.
@@ -151,16 +184,64 @@ func (e receiverInitError) Error() string {
 
 func (e receiverInitError) Unwrap() error { return e.Err }
 
-func getAlertStatusColor(status model.AlertStatus) string {
-	if status == model.AlertFiring {
-		return ColorAlertFiring
+// parseNonNegativeInt parses a number setting, which is a string when set from the UI. An
+// empty setting is 0.
+func parseNonNegativeInt(v interface{}, name string) (int, error) {
+	if v == nil || v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(fmt.Sprint(v))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s should not be negative", name)
+	}
+	return n, nil
+}
+
+// parseNonNegativeDuration parses a duration setting. An empty setting is 0.
+func parseNonNegativeDuration(s string, name string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s should not be negative", name)
+	}
+	return d, nil
+}
+
+// belowMinAlerts returns true if the notification about the alerts is skipped because
+// fewer than minAlerts of them are firing. Notifications of resolved groups are not skipped.
+func belowMinAlerts(minAlerts int, alerts []*types.Alert) bool {
+	firing := 0
+	for _, a := range alerts {
+		if !a.Resolved() {
+			firing++
+		}
 	}
-	return ColorAlertResolved
+	return minAlerts > 0 && firing > 0 && firing < minAlerts
 }
 
 type NotificationChannel interface {
 	notify.Notifier
 	notify.ResolvedSender
+	// LastError returns the error of the last notification and when it failed, or a zero
+	// time and nil if it succeeded. It is safe to call concurrently with Notify.
+	LastError() (time.Time, error)
+	// EstimateSize renders the notification of the alerts without sending it and returns
+	// its size in bytes. It returns ErrSizeEstimateUnsupported if the notifier cannot.
+	EstimateSize(ctx context.Context, alerts ...*types.Alert) (int, error)
+	// Stop stops the timers of the notifier, e.g. of its reminders, once it is replaced by a
+	// new configuration. It is safe to call concurrently with Notify.
+	Stop()
+	// MaxDeferral returns the longest time Notify waits for a deferred notification to be
+	// sent, or 0 if notifications are sent right away.
+	MaxDeferral() time.Duration
 }
 type NotificationChannelConfig struct {
 	OrgID                 int64             // only used internally
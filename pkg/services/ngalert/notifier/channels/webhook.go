@@ -2,13 +2,24 @@ package channels
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
+	amwebhook "github.com/prometheus/alertmanager/notify/webhook"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
@@ -16,6 +27,55 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 )
 
+const (
+	// WebhookContentTypeJSON sends the webhook message as a JSON object.
+	WebhookContentTypeJSON = "json"
+	// WebhookContentTypeForm sends the webhook message as form values.
+	WebhookContentTypeForm = "form"
+)
+
+const (
+	// WebhookPayloadSchemaGrafana sends the Grafana webhook message, with the title, message
+	// and the extended data of the alerts.
+	WebhookPayloadSchemaGrafana = "grafana"
+	// WebhookPayloadSchemaAlertmanager sends the message of the webhooks of the Prometheus
+	// Alertmanager, for receivers that expect it.
+	WebhookPayloadSchemaAlertmanager = "alertmanager"
+)
+
+const (
+	// WebhookVerbosityFull sends the alerts with all of their data.
+	WebhookVerbosityFull = "full"
+	// WebhookVerbosityCompact sends only the labels, status, fingerprint and start time of
+	// the alerts, and leaves out the title, message and data common to the alerts.
+	WebhookVerbosityCompact = "compact"
+)
+
+const (
+	// WebhookSuccessAny succeeds if the webhook is sent to at least one of the URLs.
+	WebhookSuccessAny = "any"
+	// WebhookSuccessAll succeeds only if the webhook is sent to all of the URLs.
+	WebhookSuccessAll = "all"
+)
+
+const (
+	// WebhookSignatureHeader is the HMAC-SHA256 signature of the body with the HMAC secret,
+	// in the format sha256=<hex>.
+	WebhookSignatureHeader = "X-Grafana-Signature"
+	// WebhookSecondarySignatureHeader is the signature of the body with the secondary HMAC
+	// secret, so receivers can verify webhooks with either secret while it is rotated.
+	WebhookSecondarySignatureHeader = "X-Grafana-Signature-Secondary"
+	// WebhookSignatureKeyIDHeader is the id of the HMAC secret the body is signed with.
+	WebhookSignatureKeyIDHeader = "X-Grafana-Signature-Key-Id"
+	// WebhookSignatureAlgorithmHeader is the algorithm of the signature of bodies signed
+	// with a private key rather than an HMAC secret.
+	WebhookSignatureAlgorithmHeader = "X-Grafana-Signature-Algorithm"
+)
+
+// WebhookSignatureAlgorithmEd25519 signs the body with an Ed25519 private key. The signature
+// is base64 encoded, and can be verified with the public key of the private key.
+const WebhookSignatureAlgorithmEd25519 = "ed25519"
+
 // WebhookNotifier is responsible for sending
 // alert notifications as webhooks.
 type WebhookNotifier struct {
@@ -26,10 +86,30 @@ type WebhookNotifier struct {
 	tmpl     *template.Template
 	orgID    int64
 	settings webhookSettings
+	retries  *RetryBudget
+	// reminders is nil when reminders are disabled.
+	reminders *reminders
+	// deferred is nil when notifications are sent right away.
+	deferred *deferredSends
+	// history records each attempt to send a webhook.
+	history HistorySink
+	// httpRetry configures the retries of failed requests to each URL.
+	httpRetry httpRetry
+	// metrics is nil when the attempts to send webhooks are not counted.
+	metrics *SendMetrics
+	// resolved is nil when recently resolved alerts are not included.
+	resolved *recentlyResolved
+	// templates is nil when there is no template store.
+	templates *storedTemplates
 }
 
 type webhookSettings struct {
-	URL        string
+	URL string
+	// URLs are additional URLs the webhook is sent to. SuccessPolicy decides whether the
+	// webhook must be sent to any or all of the URLs for the notification to succeed.
+	URLs          []string
+	SuccessPolicy string
+
 	HTTPMethod string
 	MaxAlerts  int
 	// Authorization Header.
@@ -38,33 +118,179 @@ type webhookSettings struct {
 	// HTTP Basic Authentication.
 	User     string
 	Password string
+	// HMACSecret signs the body of the webhook, if set. HMACSecondarySecret adds a second
+	// signature during the rotation of the secret. HMACKeyID identifies HMACSecret.
+	HMACSecret          string
+	HMACSecondarySecret string
+	HMACKeyID           string
+	// Ed25519PrivateKey signs the body of the webhook, if set, so that receivers can verify
+	// it with the public key instead of a shared secret. It cannot be used with HMACSecret.
+	Ed25519PrivateKey ed25519.PrivateKey
 
 	Title   string
 	Message string
+	// TemplateName is the name of a template stored in the database, e.g. edited in the UI,
+	// that is used instead of Message. Message is used if the template does not exist.
+	TemplateName string
+
+	// ExternalURLOverride replaces the Grafana external URL in links, if set.
+	ExternalURLOverride *url.URL
+
+	// ContentType is the encoding of the body, either json or form.
+	ContentType string
+	// PayloadSchema is the schema of the body, either grafana or alertmanager. The
+	// alertmanager schema is only sent as JSON.
+	PayloadSchema string
+	// Verbosity is the representation of the alerts in the body, either full or compact.
+	// Only the grafana schema can be compact.
+	Verbosity string
+
+	// Timeout bounds the whole request, ConnectTimeout resolving the host and connecting
+	// to it. The defaults of the notification service are used if they are not set.
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+
+	// MaxIdleConns is the number of idle connections kept alive per host, and
+	// IdleConnTimeout how long they are kept, for the connections reused by the
+	// notifications of the contact point. The connections shared by all webhooks are used
+	// if they are not set.
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+
+	// ReminderInterval is the interval reminders are sent at for groups that are still
+	// firing. Reminders are disabled if it is 0.
+	ReminderInterval time.Duration
+
+	// Delay defers notifications and sends them a delay after the first deferred one, and
+	// ScheduleAt at the next multiple of the schedule, e.g. at the top of the hour for 1h.
+	// Notifications are sent right away if neither is set.
+	Delay      time.Duration
+	ScheduleAt time.Duration
+
+	// RequireImages fails the notification, so it is retried, instead of sending it
+	// without images when none of the images of the alerts could be retrieved.
+	RequireImages bool
+	// ImagesOnFiringOnly sends the notifications of resolved groups without images.
+	ImagesOnFiringOnly bool
+
+	// MaxLabelValueLength truncates longer label values in the title and message, if set.
+	// The labels in the payload are not truncated.
+	MaxLabelValueLength int
+
+	// MinAlerts skips notifications of groups with fewer firing alerts, if set.
+	MinAlerts int
+
+	// Sort is the order of the alerts in the payload. Alerts beyond MaxAlerts are truncated
+	// after they are sorted.
+	Sort AlertSort
+
+	// MaxLabels drops the labels of each alert in the payload beyond this number, if set.
+	MaxLabels int
+
+	// Timezone is the time zone timestamps are rendered in, in the title and message.
+	Timezone *time.Location
+
+	// ExcludeLabels are removed from the labels of the alerts in the title and message.
+	// The labels in the payload are kept.
+	ExcludeLabels LabelPatterns
+
+	// RedactPatterns match secrets that are redacted from the label and annotation values
+	// of the alerts, in the title, message and payload.
+	RedactPatterns RedactPatterns
+
+	// RetryInterval is the minimum interval between retries of the same URL, and
+	// SharedRetryInterval between retries of any of the URLs. Retries are not paced if
+	// they are 0.
+	RetryInterval       time.Duration
+	SharedRetryInterval time.Duration
+
+	// RequireAck considers the webhook delivered only if the response has the status
+	// AckStatusCode and its body contains AckBodyMarker. Other responses fail the attempt,
+	// so it is retried like any other failed request.
+	RequireAck    bool
+	AckStatusCode int
+	AckBodyMarker string
+
+	// MaxBodySize fails notifications whose body is larger, in bytes, without sending them.
+	// The size of the body is not limited if it is 0.
+	MaxBodySize int
+
+	// IncludeRecentlyResolved adds the alerts of the group that were resolved in previous
+	// notifications within RecentlyResolvedWindow to resolved notifications.
+	IncludeRecentlyResolved bool
+	RecentlyResolvedWindow  time.Duration
 }
 
 func buildWebhookSettings(factoryConfig FactoryConfig) (webhookSettings, error) {
 	settings := webhookSettings{}
 	rawSettings := struct {
 		URL                      string      `json:"url,omitempty" yaml:"url,omitempty"`
+		URLs                     webhookURLs `json:"urls,omitempty" yaml:"urls,omitempty"`
+		SuccessPolicy            string      `json:"successPolicy,omitempty" yaml:"successPolicy,omitempty"`
 		HTTPMethod               string      `json:"httpMethod,omitempty" yaml:"httpMethod,omitempty"`
 		MaxAlerts                json.Number `json:"maxAlerts,omitempty" yaml:"maxAlerts,omitempty"`
 		AuthorizationScheme      string      `json:"authorization_scheme,omitempty" yaml:"authorization_scheme,omitempty"`
 		AuthorizationCredentials string      `json:"authorization_credentials,omitempty" yaml:"authorization_credentials,omitempty"`
 		User                     string      `json:"username,omitempty" yaml:"username,omitempty"`
 		Password                 string      `json:"password,omitempty" yaml:"password,omitempty"`
+		HMACSecret               string      `json:"hmacSecret,omitempty" yaml:"hmacSecret,omitempty"`
+		HMACSecondarySecret      string      `json:"hmacSecondarySecret,omitempty" yaml:"hmacSecondarySecret,omitempty"`
+		HMACKeyID                string      `json:"hmacKeyId,omitempty" yaml:"hmacKeyId,omitempty"`
+		Ed25519PrivateKey        string      `json:"ed25519PrivateKey,omitempty" yaml:"ed25519PrivateKey,omitempty"`
 		Title                    string      `json:"title,omitempty" yaml:"title,omitempty"`
 		Message                  string      `json:"message,omitempty" yaml:"message,omitempty"`
+		TemplateName             string      `json:"templateName,omitempty" yaml:"templateName,omitempty"`
+		ExternalURLOverride      string      `json:"externalURLOverride,omitempty" yaml:"externalURLOverride,omitempty"`
+		ContentType              string      `json:"contentType,omitempty" yaml:"contentType,omitempty"`
+		Timeout                  string      `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+		ConnectTimeout           string      `json:"connectTimeout,omitempty" yaml:"connectTimeout,omitempty"`
+		MaxIdleConns             json.Number `json:"maxIdleConns,omitempty" yaml:"maxIdleConns,omitempty"`
+		IdleConnTimeout          string      `json:"idleConnTimeout,omitempty" yaml:"idleConnTimeout,omitempty"`
+		ReminderInterval         string      `json:"reminderInterval,omitempty" yaml:"reminderInterval,omitempty"`
+		Delay                    string      `json:"delay,omitempty" yaml:"delay,omitempty"`
+		ScheduleAt               string      `json:"scheduleAt,omitempty" yaml:"scheduleAt,omitempty"`
+		RequireImages            bool        `json:"requireImages,omitempty" yaml:"requireImages,omitempty"`
+		ImagesOnFiringOnly       bool        `json:"imagesOnFiringOnly,omitempty" yaml:"imagesOnFiringOnly,omitempty"`
+		MaxLabelValueLength      json.Number `json:"maxLabelValueLength,omitempty" yaml:"maxLabelValueLength,omitempty"`
+		MinAlerts                json.Number `json:"minAlerts,omitempty" yaml:"minAlerts,omitempty"`
+		SortBy                   string      `json:"sortBy,omitempty" yaml:"sortBy,omitempty"`
+		SortOrder                string      `json:"sortOrder,omitempty" yaml:"sortOrder,omitempty"`
+		MaxLabels                json.Number `json:"maxLabels,omitempty" yaml:"maxLabels,omitempty"`
+		Timezone                 string      `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+		RetryInterval            string      `json:"retryInterval,omitempty" yaml:"retryInterval,omitempty"`
+		SharedRetryInterval      string      `json:"sharedRetryInterval,omitempty" yaml:"sharedRetryInterval,omitempty"`
+		RequireAck               bool        `json:"requireAck,omitempty" yaml:"requireAck,omitempty"`
+		AckStatusCode            json.Number `json:"ackStatusCode,omitempty" yaml:"ackStatusCode,omitempty"`
+		AckBodyMarker            string      `json:"ackBodyMarker,omitempty" yaml:"ackBodyMarker,omitempty"`
+		IncludeRecentlyResolved  bool        `json:"includeRecentlyResolved,omitempty" yaml:"includeRecentlyResolved,omitempty"`
+		RecentlyResolvedWindow   string      `json:"recentlyResolvedWindow,omitempty" yaml:"recentlyResolvedWindow,omitempty"`
+
+		ExcludeLabels LabelPatterns `json:"excludeLabels,omitempty" yaml:"excludeLabels,omitempty"`
+		MaxBodySize   json.Number   `json:"maxBodySize,omitempty" yaml:"maxBodySize,omitempty"`
+		PayloadSchema string        `json:"payloadSchema,omitempty" yaml:"payloadSchema,omitempty"`
+		Verbosity     string        `json:"verbosity,omitempty" yaml:"verbosity,omitempty"`
+
+		RedactSecrets  bool           `json:"redactSecrets,omitempty" yaml:"redactSecrets,omitempty"`
+		RedactPatterns RedactPatterns `json:"redactPatterns,omitempty" yaml:"redactPatterns,omitempty"`
 	}{}
 
 	err := factoryConfig.Config.unmarshalSettings(&rawSettings)
 	if err != nil {
 		return settings, fmt.Errorf("failed to unmarshal settings: %w", err)
 	}
-	if rawSettings.URL == "" {
+	if rawSettings.URL == "" && len(rawSettings.URLs) == 0 {
 		return settings, errors.New("required field 'url' is not specified")
 	}
 	settings.URL = rawSettings.URL
+	settings.URLs = rawSettings.URLs
+	switch rawSettings.SuccessPolicy {
+	case "", WebhookSuccessAny:
+		settings.SuccessPolicy = WebhookSuccessAny
+	case WebhookSuccessAll:
+		settings.SuccessPolicy = WebhookSuccessAll
+	default:
+		return settings, fmt.Errorf("invalid success policy %q, must be %q or %q", rawSettings.SuccessPolicy, WebhookSuccessAny, WebhookSuccessAll)
+	}
 
 	if rawSettings.HTTPMethod == "" {
 		rawSettings.HTTPMethod = http.MethodPost
@@ -85,6 +311,20 @@ func buildWebhookSettings(factoryConfig FactoryConfig) (webhookSettings, error)
 	if settings.User != "" && settings.Password != "" && settings.AuthorizationScheme != "" && settings.AuthorizationCredentials != "" {
 		return settings, errors.New("both HTTP Basic Authentication and Authorization Header are set, only 1 is permitted")
 	}
+	settings.HMACSecret = factoryConfig.DecryptFunc(context.Background(), factoryConfig.Config.SecureSettings, "hmacSecret", rawSettings.HMACSecret)
+	settings.HMACSecondarySecret = factoryConfig.DecryptFunc(context.Background(), factoryConfig.Config.SecureSettings, "hmacSecondarySecret", rawSettings.HMACSecondarySecret)
+	settings.HMACKeyID = rawSettings.HMACKeyID
+	if settings.HMACSecondarySecret != "" && settings.HMACSecret == "" {
+		return settings, errors.New("the secondary HMAC secret requires an HMAC secret")
+	}
+	if key := factoryConfig.DecryptFunc(context.Background(), factoryConfig.Config.SecureSettings, "ed25519PrivateKey", rawSettings.Ed25519PrivateKey); key != "" {
+		if settings.HMACSecret != "" {
+			return settings, errors.New("both an HMAC secret and an Ed25519 private key are set, only 1 is permitted")
+		}
+		if settings.Ed25519PrivateKey, err = parseEd25519PrivateKey(key); err != nil {
+			return settings, err
+		}
+	}
 	settings.Title = rawSettings.Title
 	if settings.Title == "" {
 		settings.Title = DefaultMessageTitleEmbed
@@ -93,7 +333,126 @@ func buildWebhookSettings(factoryConfig FactoryConfig) (webhookSettings, error)
 	if settings.Message == "" {
 		settings.Message = DefaultMessageEmbed
 	}
-	return settings, err
+	settings.TemplateName = rawSettings.TemplateName
+	settings.ExternalURLOverride, err = parseExternalURLOverride(rawSettings.ExternalURLOverride)
+	if err != nil {
+		return settings, err
+	}
+	switch rawSettings.ContentType {
+	case "", WebhookContentTypeJSON:
+		settings.ContentType = WebhookContentTypeJSON
+	case WebhookContentTypeForm:
+		settings.ContentType = WebhookContentTypeForm
+	default:
+		return settings, fmt.Errorf("invalid content type %q, must be %q or %q", rawSettings.ContentType, WebhookContentTypeJSON, WebhookContentTypeForm)
+	}
+	switch rawSettings.PayloadSchema {
+	case "", WebhookPayloadSchemaGrafana:
+		settings.PayloadSchema = WebhookPayloadSchemaGrafana
+	case WebhookPayloadSchemaAlertmanager:
+		settings.PayloadSchema = WebhookPayloadSchemaAlertmanager
+	default:
+		return settings, fmt.Errorf("invalid payload schema %q, must be %q or %q", rawSettings.PayloadSchema, WebhookPayloadSchemaGrafana, WebhookPayloadSchemaAlertmanager)
+	}
+	if settings.PayloadSchema == WebhookPayloadSchemaAlertmanager && settings.ContentType != WebhookContentTypeJSON {
+		return settings, fmt.Errorf("the %q payload schema requires the %q content type", WebhookPayloadSchemaAlertmanager, WebhookContentTypeJSON)
+	}
+	switch rawSettings.Verbosity {
+	case "", WebhookVerbosityFull:
+		settings.Verbosity = WebhookVerbosityFull
+	case WebhookVerbosityCompact:
+		settings.Verbosity = WebhookVerbosityCompact
+	default:
+		return settings, fmt.Errorf("invalid verbosity %q, must be %q or %q", rawSettings.Verbosity, WebhookVerbosityFull, WebhookVerbosityCompact)
+	}
+	if settings.Verbosity == WebhookVerbosityCompact {
+		if settings.PayloadSchema != WebhookPayloadSchemaGrafana {
+			return settings, fmt.Errorf("the %q verbosity requires the %q payload schema", WebhookVerbosityCompact, WebhookPayloadSchemaGrafana)
+		}
+		if settings.ContentType != WebhookContentTypeJSON {
+			return settings, fmt.Errorf("the %q verbosity requires the %q content type", WebhookVerbosityCompact, WebhookContentTypeJSON)
+		}
+	}
+	if settings.Timeout, err = parseWebhookTimeout(rawSettings.Timeout); err != nil {
+		return settings, fmt.Errorf("invalid timeout: %w", err)
+	}
+	if settings.ConnectTimeout, err = parseWebhookTimeout(rawSettings.ConnectTimeout); err != nil {
+		return settings, fmt.Errorf("invalid connect timeout: %w", err)
+	}
+	if settings.MaxIdleConns, err = parseNonNegativeInt(rawSettings.MaxIdleConns.String(), "max idle connections"); err != nil {
+		return settings, err
+	}
+	if settings.IdleConnTimeout, err = parseWebhookTimeout(rawSettings.IdleConnTimeout); err != nil {
+		return settings, fmt.Errorf("invalid idle connection timeout: %w", err)
+	}
+	if settings.ReminderInterval, err = parseReminderInterval(rawSettings.ReminderInterval); err != nil {
+		return settings, err
+	}
+	if settings.Delay, settings.ScheduleAt, err = parseDeferral(rawSettings.Delay, rawSettings.ScheduleAt); err != nil {
+		return settings, err
+	}
+	settings.RequireImages = rawSettings.RequireImages
+	settings.ImagesOnFiringOnly = rawSettings.ImagesOnFiringOnly
+	if settings.MaxLabelValueLength, err = parseNonNegativeInt(rawSettings.MaxLabelValueLength.String(), "max label value length"); err != nil {
+		return settings, err
+	}
+	if settings.MinAlerts, err = parseNonNegativeInt(rawSettings.MinAlerts.String(), "min alerts"); err != nil {
+		return settings, err
+	}
+	if settings.Sort, err = parseAlertSort(rawSettings.SortBy, rawSettings.SortOrder); err != nil {
+		return settings, err
+	}
+	if settings.MaxLabels, err = parseNonNegativeInt(rawSettings.MaxLabels.String(), "max labels"); err != nil {
+		return settings, err
+	}
+	if settings.Timezone, err = parseTimezone(rawSettings.Timezone); err != nil {
+		return settings, err
+	}
+	if settings.RetryInterval, err = parseNonNegativeDuration(rawSettings.RetryInterval, "retry interval"); err != nil {
+		return settings, err
+	}
+	if settings.SharedRetryInterval, err = parseNonNegativeDuration(rawSettings.SharedRetryInterval, "shared retry interval"); err != nil {
+		return settings, err
+	}
+	settings.ExcludeLabels = rawSettings.ExcludeLabels
+	settings.RedactPatterns = redactPatternsWithDefaults(rawSettings.RedactSecrets, rawSettings.RedactPatterns)
+	if settings.MaxBodySize, err = parseNonNegativeInt(rawSettings.MaxBodySize.String(), "max body size"); err != nil {
+		return settings, err
+	}
+	settings.IncludeRecentlyResolved = rawSettings.IncludeRecentlyResolved
+	if settings.RecentlyResolvedWindow, err = parseNonNegativeDuration(rawSettings.RecentlyResolvedWindow, "recently resolved window"); err != nil {
+		return settings, err
+	}
+	if settings.RequireAck = rawSettings.RequireAck; settings.RequireAck {
+		if settings.AckStatusCode, err = parseNonNegativeInt(rawSettings.AckStatusCode.String(), "ack status code"); err != nil {
+			return settings, err
+		}
+		if settings.AckStatusCode == 0 {
+			settings.AckStatusCode = http.StatusOK
+		}
+		if settings.AckStatusCode/100 != 2 {
+			return settings, fmt.Errorf("invalid ack status code %d, must be a 2xx status code", settings.AckStatusCode)
+		}
+		settings.AckBodyMarker = rawSettings.AckBodyMarker
+		if settings.AckBodyMarker == "" {
+			return settings, errors.New("could not find ack body marker property in settings")
+		}
+	}
+	return settings, nil
+}
+
+func parseWebhookTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, errors.New("timeout should be greater than 0")
+	}
+	return timeout, nil
 }
 
 func WebHookFactory(fc FactoryConfig) (NotificationChannel, error) {
@@ -114,15 +473,33 @@ func buildWebhookNotifier(factoryConfig FactoryConfig) (*WebhookNotifier, error)
 	if err != nil {
 		return nil, err
 	}
-	return &WebhookNotifier{
-		Base:     NewBase(factoryConfig.Config),
-		orgID:    factoryConfig.Config.OrgID,
-		log:      factoryConfig.Logger,
-		ns:       factoryConfig.NotificationService,
-		images:   factoryConfig.ImageStore,
-		tmpl:     factoryConfig.Template,
-		settings: settings,
-	}, nil
+	wn := &WebhookNotifier{
+		Base:      NewBase(factoryConfig.Config),
+		orgID:     factoryConfig.Config.OrgID,
+		log:       factoryConfig.Logger,
+		ns:        factoryConfig.NotificationService,
+		images:    factoryConfig.ImageStore,
+		tmpl:      factoryConfig.Template,
+		settings:  settings,
+		retries:   factoryConfig.RetryBudget,
+		history:   historyOrNoop(factoryConfig.History),
+		httpRetry: defaultHTTPRetry,
+		templates: newStoredTemplates(factoryConfig.Templates, factoryConfig.Config.OrgID, factoryConfig.Logger),
+		metrics:   factoryConfig.SendMetrics,
+	}
+	wn.httpRetry.Metrics, wn.httpRetry.Integration = factoryConfig.SendMetrics, factoryConfig.Config.Type
+	wn.httpRetry.Budget = factoryConfig.RetryBudget
+	if settings.RetryInterval > 0 || settings.SharedRetryInterval > 0 {
+		wn.httpRetry.Pacer = newRetryPacer(settings.RetryInterval, settings.SharedRetryInterval)
+	}
+	if settings.ReminderInterval > 0 {
+		wn.reminders = newReminders(settings.ReminderInterval, factoryConfig.Logger, wn.Notify)
+	}
+	if settings.IncludeRecentlyResolved {
+		wn.resolved = newRecentlyResolved(settings.RecentlyResolvedWindow)
+	}
+	wn.deferred = newDeferredSends(settings.Delay, settings.ScheduleAt, factoryConfig.Logger, wn.Notify)
+	return wn, nil
 }
 
 // WebhookMessage defines the JSON object send to webhook endpoints.
@@ -133,82 +510,487 @@ type WebhookMessage struct {
 	Version         string `json:"version"`
 	GroupKey        string `json:"groupKey"`
 	TruncatedAlerts int    `json:"truncatedAlerts"`
-	OrgID           int64  `json:"orgId"`
-	Title           string `json:"title"`
-	State           string `json:"state"`
-	Message         string `json:"message"`
+	// DroppedLabels is the number of labels dropped from the alerts because of maxLabels.
+	DroppedLabels int    `json:"droppedLabels,omitempty"`
+	OrgID         int64  `json:"orgId"`
+	Title         string `json:"title"`
+	State         string `json:"state"`
+	Message       string `json:"message"`
+	// RecentlyResolved are the alerts of the group that were resolved in previous
+	// notifications, in resolved notifications with includeRecentlyResolved.
+	RecentlyResolved ExtendedAlerts `json:"recentlyResolved,omitempty"`
+}
+
+// RenderPreview renders the title and message of the webhook for the sample data, without
+// sending it or accessing any store. It returns a TemplatePreviewError if one of the
+// templates fails to execute.
+func (wn *WebhookNotifier) RenderPreview(data *ExtendedData) (TemplatePreview, error) {
+	data = previewData(data, wn.settings.ExcludeLabels, wn.settings.RedactPatterns, wn.settings.MaxLabelValueLength, wn.settings.Timezone)
+	r := &previewRenderer{tmpl: wn.tmpl, data: data}
+	preview := TemplatePreview{
+		Title:   r.render("title", wn.settings.Title),
+		Message: r.render("message", wn.settings.Message),
+	}
+	if r.err != nil {
+		return TemplatePreview{}, r.err
+	}
+	return preview, nil
 }
 
 // Notify implements the Notifier interface.
-func (wn *WebhookNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+func (wn *WebhookNotifier) Notify(ctx context.Context, as ...*types.Alert) (retry bool, err error) {
+	if wn.deferred.defers(ctx) {
+		return wn.deferred.add(ctx, as)
+	}
+	if belowMinAlerts(wn.settings.MinAlerts, as) {
+		wn.log.Debug("skipping webhook notification, too few firing alerts", "alerts", len(as), "minAlerts", wn.settings.MinAlerts)
+		reportSkipped(ctx)
+		return true, nil
+	}
+
+	// The recipients are the URLs of the settings until they are rendered.
+	recipients := wn.settings.allURLs()
+	defer func() {
+		wn.history.Record(ctx, newNotificationRecord(wn.Base, as, recipients, err))
+	}()
+
+	as, numTruncated := truncateAlerts(wn.settings.MaxAlerts, wn.settings.Sort.apply(as))
+	req, retry, err := wn.render(ctx, as, numTruncated)
+	if err != nil {
+		return retry, err
+	}
+	if wn.settings.MaxBodySize > 0 && len(req.body) > wn.settings.MaxBodySize {
+		return false, fmt.Errorf("webhook body of %d bytes exceeds the max body size of %d bytes", len(req.body), wn.settings.MaxBodySize)
+	}
+	recipients = req.urls
+	reportRecipients(ctx, recipients)
+
+	results := make([]WebhookURLResult, 0, len(req.urls))
+	for _, u := range req.urls {
+		cmd := &SendWebhookSettings{
+			Url:             u,
+			User:            wn.settings.User,
+			Password:        wn.settings.Password,
+			Body:            req.body,
+			HttpMethod:      wn.settings.HTTPMethod,
+			HttpHeader:      req.headers,
+			ContentType:     req.contentType,
+			Timeout:         wn.settings.Timeout,
+			ConnectTimeout:  wn.settings.ConnectTimeout,
+			ConnectionPool:  wn.UID,
+			MaxIdleConns:    wn.settings.MaxIdleConns,
+			IdleConnTimeout: wn.settings.IdleConnTimeout,
+		}
+		if wn.settings.RequireAck {
+			cmd.Validation = wn.validateAck
+		}
+		err := sendHTTP(ctx, wn.ns, cmd, wn.httpRetry, wn.log)
+		if err != nil && len(req.urls) > 1 {
+			wn.log.Warn("failed to send webhook", "url", u, "error", err)
+		}
+		results = append(results, WebhookURLResult{URL: u, Error: err})
+	}
+
+	if err := webhookResultsErr(results, wn.settings.SuccessPolicy); err != nil {
+		retry := wn.retries.Allow()
+		wn.metrics.result(wn.Type, err, retry)
+		return retry, err
+	}
+	wn.metrics.result(wn.Type, nil, false)
+
+	wn.resolved.add(req.groupKey, req.alerts)
+	wn.reminders.update(ctx, as)
+	return true, nil
+}
+
+// EstimateSize renders the webhook of the alerts without sending it and returns the size
+// of its body, the same size the max body size is checked against.
+func (wn *WebhookNotifier) EstimateSize(ctx context.Context, as ...*types.Alert) (int, error) {
+	as, numTruncated := truncateAlerts(wn.settings.MaxAlerts, wn.settings.Sort.apply(as))
+	req, _, err := wn.render(ctx, as, numTruncated)
+	if err != nil {
+		return 0, err
+	}
+	return len(req.body), nil
+}
+
+// webhookRequest is a rendered webhook, sent to each of its URLs.
+type webhookRequest struct {
+	urls        []string
+	body        string
+	contentType string
+	headers     map[string]string
+	// groupKey and alerts are the group and the alerts of the payload.
+	groupKey string
+	alerts   ExtendedAlerts
+}
+
+// render renders the webhook of the alerts, numTruncated alerts were truncated from them.
+// If it fails, retry is whether the notification should be retried.
+func (wn *WebhookNotifier) render(ctx context.Context, as []*types.Alert, numTruncated int) (req *webhookRequest, retry bool, err error) {
 	groupKey, err := notify.ExtractGroupKey(ctx)
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 
-	as, numTruncated := truncateAlerts(wn.settings.MaxAlerts, as)
 	var tmplErr error
-	tmpl, data := TmplText(ctx, wn.tmpl, as, wn.log, &tmplErr)
-
-	// Augment our Alert data with ImageURLs if available.
-	_ = withStoredImages(ctx, wn.log, wn.images,
-		func(index int, image Image) error {
-			if len(image.URL) != 0 {
-				data.Alerts[index].ImageURL = image.URL
-			}
-			return nil
-		},
-		as...)
+	tmpl, data := TmplText(ctx, withExternalURL(wn.tmpl, wn.settings.ExternalURLOverride), as, wn.log, &tmplErr)
+	render := tmplWithFallback(tmpl, &tmplErr, wn.log)
+
+	// Augment our Alert data with ImageURLs if available. Resolved groups are sent without
+	// images with imagesOnFiringOnly.
+	if !wn.settings.ImagesOnFiringOnly || data.Status != string(model.AlertResolved) {
+		images := wn.images
+		var required *requiredImageStore
+		if wn.settings.RequireImages {
+			required = &requiredImageStore{ImageStore: wn.images}
+			images = required
+		}
+		_ = withStoredImages(ctx, wn.log, images,
+			func(index int, image Image) error {
+				if len(image.URL) != 0 {
+					data.Alerts[index].ImageURL = image.URL
+				}
+				return nil
+			},
+			as...)
+		if err := required.err(); err != nil {
+			return nil, true, err
+		}
+	}
+
+	// Secrets are redacted from the title, message and payload. Only the title and message
+	// are rendered without the excluded labels, with truncated labels and in the time zone,
+	// and only the payload has limited labels.
+	redactValues(data, wn.settings.RedactPatterns)
+	payload := *data
+	payload.Alerts = append(ExtendedAlerts(nil), data.Alerts...)
+	excludeLabels(data, wn.settings.ExcludeLabels)
+	truncateLabelValues(data, wn.settings.MaxLabelValueLength)
+	inTimezone(data, wn.settings.Timezone)
+	droppedLabels := limitLabels(&payload, wn.settings.MaxLabels)
+
+	title, titleFallback := render(wn.settings.Title, DefaultMessageTitleEmbed)
+	message, messageFallback := render(wn.templates.messageTemplate(ctx, wn.settings.TemplateName, wn.settings.Message), DefaultMessageEmbed)
+	if titleFallback || messageFallback {
+		message = TemplateErrorNotice + "\n\n" + message
+	}
+	if isReminder(ctx) {
+		title = ReminderTitlePrefix + title
+		message = reminderMessage(data)
+	}
 
 	msg := &WebhookMessage{
 		Version:         "1",
-		ExtendedData:    data,
+		ExtendedData:    &payload,
 		GroupKey:        groupKey.String(),
 		TruncatedAlerts: numTruncated,
+		DroppedLabels:   droppedLabels,
 		OrgID:           wn.orgID,
-		Title:           tmpl(wn.settings.Title),
-		Message:         tmpl(wn.settings.Message),
+		Title:           title,
+		Message:         message,
 	}
 	if types.Alerts(as...).Status() == model.AlertFiring {
 		msg.State = string(models.AlertStateAlerting)
 	} else {
 		msg.State = string(models.AlertStateOK)
+		msg.RecentlyResolved = wn.resolved.recent(msg.GroupKey, payload.Alerts)
 	}
 
-	if tmplErr != nil {
-		wn.log.Warn("failed to template webhook message", "error", tmplErr.Error())
-		tmplErr = nil
-	}
-
-	body, err := json.Marshal(msg)
+	body, contentType, err := wn.encode(msg)
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 
 	headers := make(map[string]string)
 	if wn.settings.AuthorizationScheme != "" && wn.settings.AuthorizationCredentials != "" {
 		headers["Authorization"] = fmt.Sprintf("%s %s", wn.settings.AuthorizationScheme, wn.settings.AuthorizationCredentials)
 	}
+	if wn.settings.HMACSecret != "" {
+		headers[WebhookSignatureHeader] = webhookSignature(wn.settings.HMACSecret, body)
+		if wn.settings.HMACKeyID != "" {
+			headers[WebhookSignatureKeyIDHeader] = wn.settings.HMACKeyID
+		}
+		if wn.settings.HMACSecondarySecret != "" {
+			headers[WebhookSecondarySignatureHeader] = webhookSignature(wn.settings.HMACSecondarySecret, body)
+		}
+	}
+	if wn.settings.Ed25519PrivateKey != nil {
+		headers[WebhookSignatureHeader] = base64.StdEncoding.EncodeToString(ed25519.Sign(wn.settings.Ed25519PrivateKey, []byte(body)))
+		headers[WebhookSignatureAlgorithmHeader] = WebhookSignatureAlgorithmEd25519
+	}
 
-	parsedURL := tmpl(wn.settings.URL)
+	var parsedURLs []string
+	for _, u := range wn.settings.allURLs() {
+		parsedURLs = append(parsedURLs, tmpl(u))
+	}
 	if tmplErr != nil {
-		return false, tmplErr
+		return nil, false, tmplErr
+	}
+
+	return &webhookRequest{urls: parsedURLs, body: body, contentType: contentType, headers: headers, groupKey: msg.GroupKey, alerts: payload.Alerts}, false, nil
+}
+
+// validateAck returns an error if the response does not acknowledge the webhook.
+func (wn *WebhookNotifier) validateAck(body []byte, statusCode int) error {
+	if statusCode != wn.settings.AckStatusCode {
+		return fmt.Errorf("webhook was not acknowledged: response status %d, expected %d", statusCode, wn.settings.AckStatusCode)
+	}
+	if !strings.Contains(string(body), wn.settings.AckBodyMarker) {
+		return errors.New("webhook was not acknowledged: response body does not contain the ack marker")
+	}
+	return nil
+}
+
+// webhookSignature returns the HMAC-SHA256 signature of the body with the secret.
+func webhookSignature(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseEd25519PrivateKey parses an Ed25519 private key, either PEM encoded in the PKCS #8
+// format, as generated by openssl genpkey -algorithm ed25519, or its base64 encoded seed.
+func parseEd25519PrivateKey(s string) (ed25519.PrivateKey, error) {
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Ed25519 private key: %w", err)
+		}
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("invalid Ed25519 private key: the key is not an Ed25519 key")
+		}
+		return privateKey, nil
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("invalid Ed25519 private key: must be a PEM encoded PKCS #8 key or a base64 encoded seed of 32 bytes")
 	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
 
-	cmd := &SendWebhookSettings{
-		Url:        parsedURL,
-		User:       wn.settings.User,
-		Password:   wn.settings.Password,
-		Body:       string(body),
-		HttpMethod: wn.settings.HTTPMethod,
-		HttpHeader: headers,
+// allURLs returns the URL and the additional URLs of the webhook, without duplicates.
+func (s webhookSettings) allURLs() []string {
+	urls := make([]string, 0, 1+len(s.URLs))
+	seen := map[string]struct{}{}
+	for _, u := range append([]string{s.URL}, s.URLs...) {
+		if _, ok := seen[u]; ok || u == "" {
+			continue
+		}
+		seen[u] = struct{}{}
+		urls = append(urls, u)
 	}
+	return urls
+}
 
-	if err := wn.ns.SendWebhook(ctx, cmd); err != nil {
-		return false, err
+// webhookURLs is a list of URLs, or a string with one URL per line when set from the UI.
+type webhookURLs []string
+
+func (u *webhookURLs) UnmarshalJSON(b []byte) error {
+	var urls []string
+	if err := json.Unmarshal(b, &urls); err != nil {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		urls = strings.Split(s, "\n")
 	}
+	*u = nil
+	for _, v := range urls {
+		if v = strings.TrimSpace(v); v != "" {
+			*u = append(*u, v)
+		}
+	}
+	return nil
+}
 
-	return true, nil
+// WebhookURLResult is the outcome of sending a webhook to a single URL.
+type WebhookURLResult struct {
+	URL   string
+	Error error
+}
+
+// WebhookDeliveryError is returned when a webhook with several URLs could not be sent to
+// enough of them to satisfy its success policy.
+type WebhookDeliveryError struct {
+	Results []WebhookURLResult
+}
+
+func (e *WebhookDeliveryError) Error() string {
+	var msgs []string
+	for _, r := range e.Results {
+		if r.Error != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", r.URL, r.Error))
+		}
+	}
+	return fmt.Sprintf("failed to send webhook to %d of %d URLs: %s", len(msgs), len(e.Results), strings.Join(msgs, "; "))
+}
+
+// webhookResultsErr returns the error of the webhook if the results don't satisfy the
+// success policy. The error of a single URL is returned as is, otherwise it is a
+// *WebhookDeliveryError.
+func webhookResultsErr(results []WebhookURLResult, policy string) error {
+	failed := 0
+	for _, r := range results {
+		if r.Error != nil {
+			failed++
+		}
+	}
+	if failed == 0 || (policy == WebhookSuccessAny && failed < len(results)) {
+		return nil
+	}
+	if len(results) == 1 {
+		return results[0].Error
+	}
+	return &WebhookDeliveryError{Results: results}
+}
+
+// encode returns the body of the webhook request for the message and its content type.
+func (wn *WebhookNotifier) encode(msg *WebhookMessage) (string, string, error) {
+	if wn.settings.ContentType == WebhookContentTypeForm {
+		values, err := msg.formValues()
+		if err != nil {
+			return "", "", err
+		}
+		return values.Encode(), "application/x-www-form-urlencoded", nil
+	}
+
+	var v interface{} = msg
+	if wn.settings.PayloadSchema == WebhookPayloadSchemaAlertmanager {
+		v = msg.alertmanagerMessage()
+	} else if wn.settings.Verbosity == WebhookVerbosityCompact {
+		v = msg.compactMessage()
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", "", err
+	}
+	return string(body), "application/json", nil
+}
+
+// alertmanagerMessageVersion is the protocol version of the webhooks of the Alertmanager.
+const alertmanagerMessageVersion = "4"
+
+// alertmanagerMessage returns the message in the schema of the webhooks of the Prometheus
+// Alertmanager. The fields Grafana adds to the message and to the alerts are left out.
+func (m *WebhookMessage) alertmanagerMessage() *amwebhook.Message {
+	msg := &amwebhook.Message{
+		Version:         alertmanagerMessageVersion,
+		GroupKey:        m.GroupKey,
+		TruncatedAlerts: uint64(m.TruncatedAlerts),
+	}
+	if m.ExtendedData == nil {
+		return msg
+	}
+	alerts := make(template.Alerts, 0, len(m.Alerts))
+	for _, a := range m.Alerts {
+		alerts = append(alerts, template.Alert{
+			Status:       a.Status,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint,
+		})
+	}
+	msg.Data = &template.Data{
+		Receiver:          m.Receiver,
+		Status:            m.Status,
+		Alerts:            alerts,
+		GroupLabels:       m.GroupLabels,
+		CommonLabels:      m.CommonLabels,
+		CommonAnnotations: m.CommonAnnotations,
+		ExternalURL:       m.ExternalURL,
+	}
+	return msg
+}
+
+// WebhookCompactMessage is the webhook message with the compact verbosity, for receivers
+// that only need the labels and status of the alerts.
+type WebhookCompactMessage struct {
+	Version         string                `json:"version"`
+	GroupKey        string                `json:"groupKey"`
+	TruncatedAlerts int                   `json:"truncatedAlerts"`
+	DroppedLabels   int                   `json:"droppedLabels,omitempty"`
+	OrgID           int64                 `json:"orgId"`
+	State           string                `json:"state"`
+	Status          string                `json:"status"`
+	Alerts          []WebhookCompactAlert `json:"alerts"`
+}
+
+// WebhookCompactAlert is an alert of the WebhookCompactMessage.
+type WebhookCompactAlert struct {
+	Status      string      `json:"status"`
+	Labels      template.KV `json:"labels"`
+	Fingerprint string      `json:"fingerprint"`
+	StartsAt    time.Time   `json:"startsAt"`
+}
+
+// compactMessage returns the message with the compact verbosity.
+func (m *WebhookMessage) compactMessage() *WebhookCompactMessage {
+	msg := &WebhookCompactMessage{
+		Version:         m.Version,
+		GroupKey:        m.GroupKey,
+		TruncatedAlerts: m.TruncatedAlerts,
+		DroppedLabels:   m.DroppedLabels,
+		OrgID:           m.OrgID,
+		State:           m.State,
+		Alerts:          []WebhookCompactAlert{},
+	}
+	if m.ExtendedData == nil {
+		return msg
+	}
+	msg.Status = m.Status
+	for _, a := range m.Alerts {
+		msg.Alerts = append(msg.Alerts, WebhookCompactAlert{
+			Status:      a.Status,
+			Labels:      a.Labels,
+			Fingerprint: a.Fingerprint,
+			StartsAt:    a.StartsAt,
+		})
+	}
+	return msg
+}
+
+// formValues renders the message as form values. Label and annotation sets are flattened
+// into one value per key, e.g. commonLabels.alertname, and the alerts are sent as a JSON array.
+func (m *WebhookMessage) formValues() (url.Values, error) {
+	values := url.Values{}
+	values.Set("version", m.Version)
+	values.Set("groupKey", m.GroupKey)
+	values.Set("truncatedAlerts", strconv.Itoa(m.TruncatedAlerts))
+	if m.DroppedLabels > 0 {
+		values.Set("droppedLabels", strconv.Itoa(m.DroppedLabels))
+	}
+	values.Set("orgId", strconv.FormatInt(m.OrgID, 10))
+	values.Set("title", m.Title)
+	values.Set("state", m.State)
+	values.Set("message", m.Message)
+
+	if m.ExtendedData == nil {
+		return values, nil
+	}
+	values.Set("receiver", m.Receiver)
+	values.Set("status", m.Status)
+	values.Set("externalURL", m.ExternalURL)
+	for prefix, kv := range map[string]template.KV{
+		"groupLabels":       m.GroupLabels,
+		"commonLabels":      m.CommonLabels,
+		"commonAnnotations": m.CommonAnnotations,
+	} {
+		for k, v := range kv {
+			values.Set(prefix+"."+k, v)
+		}
+	}
+
+	alerts, err := json.Marshal(m.Alerts)
+	if err != nil {
+		return nil, err
+	}
+	values.Set("alerts", string(alerts))
+	return values, nil
 }
 
 func truncateAlerts(maxAlerts int, alerts []*types.Alert) ([]*types.Alert, int) {
@@ -219,6 +1001,45 @@ func truncateAlerts(maxAlerts int, alerts []*types.Alert) ([]*types.Alert, int)
 	return alerts, 0
 }
 
+// limitLabels keeps the first maxLabels labels of each alert, sorted by name with alertname
+// first, so the same labels are kept in every notification. The common labels are limited
+// the same way. It returns the number of labels dropped from the alerts.
+func limitLabels(data *ExtendedData, maxLabels int) int {
+	if maxLabels <= 0 {
+		return 0
+	}
+	dropped := 0
+	limit := func(kv template.KV) template.KV {
+		if len(kv) <= maxLabels {
+			return kv
+		}
+		res := make(template.KV, maxLabels)
+		for _, p := range kv.SortedPairs()[:maxLabels] {
+			res[p.Name] = p.Value
+		}
+		return res
+	}
+	for i := range data.Alerts {
+		if n := len(data.Alerts[i].Labels); n > maxLabels {
+			dropped += n - maxLabels
+		}
+		data.Alerts[i].Labels = limit(data.Alerts[i].Labels)
+	}
+	data.CommonLabels = limit(data.CommonLabels)
+	return dropped
+}
+
 func (wn *WebhookNotifier) SendResolved() bool {
 	return !wn.GetDisableResolveMessage()
 }
+
+// Stop cancels the pending reminders and deferred notifications of the notifier.
+func (wn *WebhookNotifier) Stop() {
+	wn.reminders.stop()
+	wn.deferred.stop()
+}
+
+// MaxDeferral returns the longest time a notification waits for its deferred send.
+func (wn *WebhookNotifier) MaxDeferral() time.Duration {
+	return wn.deferred.maxWait()
+}
@@ -2,16 +2,33 @@ package channels
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
 )
 
 func TestWebhookNotifier(t *testing.T) {
@@ -324,6 +341,71 @@ func TestWebhookNotifier(t *testing.T) {
 			}`,
 			expInitError: "both HTTP Basic Authentication and Authorization Header are set, only 1 is permitted",
 		},
+		{
+			name:     "with external URL override",
+			settings: `{"url": "http://localhost/test", "message": "Custom message", "externalURLOverride": "https://grafana.example.com/public"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1", "__dashboardUid__": "abcd", "__panelId__": "efgh"},
+					},
+				},
+			},
+			expUrl:        "http://localhost/test",
+			expHttpMethod: "POST",
+			expMsg: &WebhookMessage{
+				ExtendedData: &ExtendedData{
+					Receiver: "my_receiver",
+					Status:   "firing",
+					Alerts: ExtendedAlerts{
+						{
+							Status: "firing",
+							Labels: template.KV{
+								"alertname": "alert1",
+								"lbl1":      "val1",
+							},
+							Annotations: template.KV{
+								"ann1": "annv1",
+							},
+							Fingerprint:  "fac0861a85de433a",
+							DashboardURL: "https://grafana.example.com/public/d/abcd",
+							PanelURL:     "https://grafana.example.com/public/d/abcd?viewPanel=efgh",
+							SilenceURL:   "https://grafana.example.com/public/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1",
+						},
+					},
+					GroupLabels: template.KV{
+						"alertname": "",
+					},
+					CommonLabels: template.KV{
+						"alertname": "alert1",
+						"lbl1":      "val1",
+					},
+					CommonAnnotations: template.KV{
+						"ann1": "annv1",
+					},
+					ExternalURL: "https://grafana.example.com/public",
+				},
+				Version:  "1",
+				GroupKey: "alertname",
+				Title:    "[FIRING:1]  (val1)",
+				State:    "alerting",
+				Message:  "Custom message",
+				OrgID:    orgID,
+			},
+			expMsgError: nil,
+			expHeaders:  map[string]string{},
+		},
+		{
+			name:         "with invalid external URL override",
+			settings:     `{"url": "http://localhost/test", "externalURLOverride": "grafana.example.com"}`,
+			expInitError: `invalid external URL override "grafana.example.com": must be an absolute http or https URL`,
+		},
+		{
+			name:         "with invalid content type",
+			settings:     `{"url": "http://localhost/test", "contentType": "xml"}`,
+			expInitError: `invalid content type "xml", must be "json" or "form"`,
+		},
 		{
 			name:         "Error in initing",
 			settings:     `{}`,
@@ -390,3 +472,1507 @@ func TestWebhookNotifier(t *testing.T) {
 		})
 	}
 }
+
+func TestWebhookNotifierTemplateFallback(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := mockNotificationService()
+	fc := FactoryConfig{
+		Config: &NotificationChannelConfig{
+			OrgID:    1,
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(`{"url": "http://localhost/test", "title": "{{ template \"missing\" . }}", "message": "{{ .Missing.Field }}"}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	}
+	pn, err := buildWebhookNotifier(fc)
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	ctx = notify.WithReceiverName(ctx, "my_receiver")
+	ok, err := pn.Notify(ctx, &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+	require.Equal(t, "[FIRING:1]  (val1)", msg.Title)
+	require.True(t, strings.HasPrefix(msg.Message, TemplateErrorNotice+"\n\n**Firing**"), msg.Message)
+	require.Equal(t, "http://localhost/test", webhookSender.Webhook.Url)
+}
+
+func TestWebhookNotifierFormContentType(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	var contentType string
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		form, err = url.ParseQuery(string(b))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	fc := FactoryConfig{
+		Config: &NotificationChannelConfig{
+			OrgID:    1,
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(fmt.Sprintf(`{"url": %q, "contentType": "form", "message": "Custom message"}`, server.URL)),
+		},
+		NotificationService: createWebhookSender(t),
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	}
+	pn, err := buildWebhookNotifier(fc)
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	ctx = notify.WithReceiverName(ctx, "my_receiver")
+	ok, err := pn.Notify(ctx, &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"ann1": "annv1"},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Equal(t, "application/x-www-form-urlencoded", contentType)
+	require.Equal(t, "1", form.Get("version"))
+	require.Equal(t, "alertname", form.Get("groupKey"))
+	require.Equal(t, "1", form.Get("orgId"))
+	require.Equal(t, "0", form.Get("truncatedAlerts"))
+	require.Equal(t, "[FIRING:1] alert1 (val1)", form.Get("title"))
+	require.Equal(t, "alerting", form.Get("state"))
+	require.Equal(t, "Custom message", form.Get("message"))
+	require.Equal(t, "my_receiver", form.Get("receiver"))
+	require.Equal(t, "firing", form.Get("status"))
+	require.Equal(t, "http://localhost", form.Get("externalURL"))
+	require.Equal(t, "alert1", form.Get("groupLabels.alertname"))
+	require.Equal(t, "val1", form.Get("commonLabels.lbl1"))
+	require.Equal(t, "annv1", form.Get("commonAnnotations.ann1"))
+
+	var alerts ExtendedAlerts
+	require.NoError(t, json.Unmarshal([]byte(form.Get("alerts")), &alerts))
+	require.Len(t, alerts, 1)
+	require.Equal(t, "alert1", alerts[0].Labels["alertname"])
+}
+
+func TestWebhookNotifierTimeout(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	newConfig := func(settings string) FactoryConfig {
+		return FactoryConfig{
+			Config: &NotificationChannelConfig{
+				OrgID:    1,
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: createWebhookSender(t),
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+		}
+	}
+
+	t.Run("notify fails within the timeout", func(t *testing.T) {
+		pn, err := buildWebhookNotifier(newConfig(fmt.Sprintf(`{"url": %q, "timeout": "200ms", "connectTimeout": "100ms"}`, server.URL)))
+		require.NoError(t, err)
+
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+		start := time.Now()
+		ok, err := pn.Notify(ctx, &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert1"},
+			},
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.False(t, ok)
+		require.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("invalid timeouts should return error", func(t *testing.T) {
+		_, err := buildWebhookNotifier(newConfig(`{"url": "http://localhost", "timeout": "soon"}`))
+		require.ErrorContains(t, err, "invalid timeout")
+
+		_, err = buildWebhookNotifier(newConfig(`{"url": "http://localhost", "connectTimeout": "-1s"}`))
+		require.EqualError(t, err, "invalid connect timeout: timeout should be greater than 0")
+	})
+}
+
+func TestWebhookNotifierConnectionReuse(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	var mtx sync.Mutex
+	var conns int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mtx.Lock()
+			conns++
+			mtx.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	newConfig := func(settings string) FactoryConfig {
+		return FactoryConfig{
+			Config: &NotificationChannelConfig{
+				OrgID:    1,
+				UID:      "webhook-keep-alive",
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: createWebhookSender(t),
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+		}
+	}
+
+	t.Run("sequential notifications reuse the connection", func(t *testing.T) {
+		pn, err := buildWebhookNotifier(newConfig(fmt.Sprintf(`{"url": %q, "maxIdleConns": 2, "idleConnTimeout": "1m"}`, server.URL)))
+		require.NoError(t, err)
+		require.Equal(t, 2, pn.settings.MaxIdleConns)
+		require.Equal(t, time.Minute, pn.settings.IdleConnTimeout)
+
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+		for i := 0; i < 5; i++ {
+			ok, err := pn.Notify(ctx, &types.Alert{
+				Alert: model.Alert{
+					Labels: model.LabelSet{"alertname": "alert1"},
+				},
+			})
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		require.Equal(t, 1, conns)
+	})
+
+	t.Run("invalid keep-alive settings should return error", func(t *testing.T) {
+		_, err := buildWebhookNotifier(newConfig(`{"url": "http://localhost", "maxIdleConns": -1}`))
+		require.ErrorContains(t, err, "max idle connections")
+
+		_, err = buildWebhookNotifier(newConfig(`{"url": "http://localhost", "idleConnTimeout": "soon"}`))
+		require.ErrorContains(t, err, "invalid idle connection timeout")
+	})
+}
+
+func TestWebhookNotifierRequireImages(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := mockNotificationService()
+	pn, err := buildWebhookNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(`{"url": "http://localhost/test", "requireImages": true}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	ok, err := pn.Notify(ctx, &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{models.ImageTokenAnnotation: "test-image-1"},
+		},
+	})
+	require.ErrorIs(t, err, ErrRequiredImagesUnavailable)
+	require.True(t, ok)
+	require.Empty(t, webhookSender.Webhook.Url)
+}
+
+func TestWebhookNotifierImagesOnFiringOnly(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "alert1"},
+		Annotations: model.LabelSet{models.ImageTokenAnnotation: "test-image-1"},
+	}}
+	resolved := &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "alert1"},
+		Annotations: model.LabelSet{models.ImageTokenAnnotation: "test-image-1"},
+		StartsAt:    time.Now().Add(-time.Hour),
+		EndsAt:      time.Now().Add(-time.Minute),
+	}}
+
+	cases := []struct {
+		name     string
+		settings string
+		alert    *types.Alert
+		expImage string
+	}{
+		{
+			name:     "firing notifications include images",
+			settings: `{"url": "http://localhost/test", "imagesOnFiringOnly": true}`,
+			alert:    firing,
+			expImage: "https://www.example.com/test-image-1.jpg",
+		},
+		{
+			name:     "resolved notifications do not include images",
+			settings: `{"url": "http://localhost/test", "imagesOnFiringOnly": true}`,
+			alert:    resolved,
+		},
+		{
+			name:     "resolved notifications include images by default",
+			settings: `{"url": "http://localhost/test"}`,
+			alert:    resolved,
+			expImage: "https://www.example.com/test-image-1.jpg",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			webhookSender := mockNotificationService()
+			pn, err := buildWebhookNotifier(FactoryConfig{
+				Config: &NotificationChannelConfig{
+					Name:     "webhook_testing",
+					Type:     "webhook",
+					Settings: json.RawMessage(c.settings),
+				},
+				NotificationService: webhookSender,
+				DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+					return fallback
+				},
+				ImageStore: newFakeImageStore(1),
+				Template:   tmpl,
+				Logger:     &FakeLogger{},
+			})
+			require.NoError(t, err)
+
+			ok, err := pn.Notify(notify.WithGroupKey(context.Background(), "alertname"), c.alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			var body WebhookMessage
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+			require.Len(t, body.Alerts, 1)
+			require.Equal(t, c.expImage, body.Alerts[0].ImageURL)
+		})
+	}
+}
+
+func TestWebhookNotifierMaxLabelValueLength(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := mockNotificationService()
+	pn, err := buildWebhookNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(`{"url": "http://localhost/test", "maxLabelValueLength": 100}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	query := strings.Repeat("x", 5000)
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	ok, err := pn.Notify(ctx, &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "query": model.LabelValue(query)}},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+	// The message has the truncated value, the payload and silence URL the original one.
+	require.Contains(t, msg.Message, " - query = "+strings.Repeat("x", 99)+"…\n")
+	require.Equal(t, query, msg.Alerts[0].Labels["query"])
+}
+
+func TestWebhookNotifierMinAlerts(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newAlerts := func(n int) []*types.Alert {
+		alerts := make([]*types.Alert, 0, n)
+		for i := 0; i < n; i++ {
+			alerts = append(alerts, &types.Alert{
+				Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert%d", i))}},
+			})
+		}
+		return alerts
+	}
+
+	cases := []struct {
+		name    string
+		alerts  []*types.Alert
+		expSent bool
+	}{
+		{
+			name:    "group with too few firing alerts is skipped",
+			alerts:  newAlerts(2),
+			expSent: false,
+		},
+		{
+			name:    "group with enough firing alerts is sent",
+			alerts:  newAlerts(5),
+			expSent: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			webhookSender := mockNotificationService()
+			pn, err := buildWebhookNotifier(FactoryConfig{
+				Config: &NotificationChannelConfig{
+					Name:     "webhook_testing",
+					Type:     "webhook",
+					Settings: json.RawMessage(`{"url": "http://localhost/test", "minAlerts": 5}`),
+				},
+				NotificationService: webhookSender,
+				DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+					return fallback
+				},
+				ImageStore: &UnavailableImageStore{},
+				Template:   tmpl,
+				Logger:     &FakeLogger{},
+			})
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ok, err := pn.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, c.expSent, len(webhookSender.Webhooks) == 1)
+		})
+	}
+}
+
+func TestWebhookNotifierSort(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := mockNotificationService()
+	pn, err := buildWebhookNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(`{"url": "http://localhost/test", "sortBy": "severity", "sortOrder": "desc", "maxAlerts": 2}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "severity": "info"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2", "severity": "critical"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert3", "severity": "warning"}}},
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ok, err := pn.Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The least severe alerts are truncated.
+	var body WebhookMessage
+	require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+	require.Len(t, body.Alerts, 2)
+	require.Equal(t, "alert2", body.Alerts[0].Labels["alertname"])
+	require.Equal(t, "alert3", body.Alerts[1].Labels["alertname"])
+	require.Equal(t, 1, body.TruncatedAlerts)
+}
+
+func TestWebhookNotifierDelay(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	now := time.Date(2022, 12, 1, 10, 20, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	newNotifier := func(settings string) (*WebhookNotifier, *notificationServiceMock, error) {
+		webhookSender := mockNotificationService()
+		pn, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: webhookSender,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+		})
+		return pn, webhookSender, err
+	}
+
+	t.Run("the webhook is sent after the delay", func(t *testing.T) {
+		defer mockTimeNow(now)()
+		pn, webhookSender, err := newNotifier(`{"url": "http://localhost/test", "delay": "10m"}`)
+		require.NoError(t, err)
+
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		done := make(chan deferredResult, 2)
+		for i, name := range []model.LabelValue{"alert1", "alert2"} {
+			alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": name}}}
+			go func() {
+				retry, err := pn.Notify(ctx, alert)
+				done <- deferredResult{retry: retry, err: err}
+			}()
+			// The notifications are deferred in order, so that the second one is sent.
+			waiting := i + 1
+			require.Eventually(t, func() bool {
+				pn.deferred.mtx.Lock()
+				defer pn.deferred.mtx.Unlock()
+				n, ok := pn.deferred.pending["alertname"]
+				return ok && len(n.waiters) == waiting
+			}, time.Second, time.Millisecond)
+		}
+		require.Empty(t, webhookSender.Webhooks)
+
+		mockTimeNow(now.Add(10 * time.Minute))
+		pn.deferred.flush()
+		require.Equal(t, deferredResult{retry: true}, <-done)
+		require.Equal(t, deferredResult{retry: true}, <-done)
+		require.Len(t, webhookSender.Webhooks, 1)
+		var body WebhookMessage
+		require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+		require.Len(t, body.Alerts, 1)
+		require.Equal(t, "alert2", body.Alerts[0].Labels["alertname"])
+	})
+
+	t.Run("delay and scheduleAt cannot be used together", func(t *testing.T) {
+		_, _, err := newNotifier(`{"url": "http://localhost/test", "delay": "10m", "scheduleAt": "1h"}`)
+		require.EqualError(t, err, "delay and scheduleAt cannot be used together")
+	})
+}
+
+func TestWebhookNotifierMaxLabels(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := mockNotificationService()
+	pn, err := buildWebhookNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(`{"url": "http://localhost/test", "maxLabels": 3}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	labels := model.LabelSet{"alertname": "alert1"}
+	for i := 0; i < 1000; i++ {
+		labels[model.LabelName(fmt.Sprintf("label%03d", i))] = "value"
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	ok, err := pn.Notify(ctx, &types.Alert{Alert: model.Alert{Labels: labels}})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+	expected := template.KV{"alertname": "alert1", "label000": "value", "label001": "value"}
+	require.Equal(t, expected, msg.Alerts[0].Labels)
+	require.Equal(t, expected, msg.CommonLabels)
+	require.Equal(t, 998, msg.DroppedLabels)
+
+	t.Run("dropped labels are omitted if no labels are dropped", func(t *testing.T) {
+		ok, err := pn.Notify(ctx, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.NotContains(t, webhookSender.Webhook.Body, "droppedLabels")
+	})
+}
+
+func TestWebhookNotifierHMACSignature(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	sign := func(secret, body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		_, _ = mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	cases := []struct {
+		name       string
+		settings   string
+		expHeaders func(body string) map[string]string
+		expUnset   []string
+		expInitErr string
+	}{
+		{
+			name:     "no signature without a secret",
+			settings: `{"url": "http://localhost/test"}`,
+			expHeaders: func(string) map[string]string {
+				return map[string]string{}
+			},
+			expUnset: []string{WebhookSignatureHeader, WebhookSecondarySignatureHeader, WebhookSignatureKeyIDHeader},
+		},
+		{
+			name:     "primary secret signs the body",
+			settings: `{"url": "http://localhost/test", "hmacSecret": "new-secret", "hmacKeyId": "key-2"}`,
+			expHeaders: func(body string) map[string]string {
+				return map[string]string{
+					WebhookSignatureHeader:      sign("new-secret", body),
+					WebhookSignatureKeyIDHeader: "key-2",
+				}
+			},
+			expUnset: []string{WebhookSecondarySignatureHeader},
+		},
+		{
+			name:     "secondary secret adds a second signature during rotation",
+			settings: `{"url": "http://localhost/test", "hmacSecret": "new-secret", "hmacSecondarySecret": "old-secret", "hmacKeyId": "key-2"}`,
+			expHeaders: func(body string) map[string]string {
+				return map[string]string{
+					WebhookSignatureHeader:          sign("new-secret", body),
+					WebhookSecondarySignatureHeader: sign("old-secret", body),
+					WebhookSignatureKeyIDHeader:     "key-2",
+				}
+			},
+		},
+		{
+			name:       "secondary secret without a secret fails",
+			settings:   `{"url": "http://localhost/test", "hmacSecondarySecret": "old-secret"}`,
+			expInitErr: "the secondary HMAC secret requires an HMAC secret",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			webhookSender := mockNotificationService()
+			pn, err := buildWebhookNotifier(FactoryConfig{
+				Config: &NotificationChannelConfig{
+					Name:     "webhook_testing",
+					Type:     "webhook",
+					Settings: json.RawMessage(c.settings),
+				},
+				NotificationService: webhookSender,
+				DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+					return fallback
+				},
+				ImageStore: &UnavailableImageStore{},
+				Template:   tmpl,
+				Logger:     &FakeLogger{},
+			})
+			if c.expInitErr != "" {
+				require.EqualError(t, err, c.expInitErr)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+			ok, err := pn.Notify(ctx, &types.Alert{
+				Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}},
+			})
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			headers := webhookSender.Webhook.HttpHeader
+			for k, v := range c.expHeaders(webhookSender.Webhook.Body) {
+				require.Equal(t, v, headers[k])
+			}
+			for _, k := range c.expUnset {
+				require.NotContains(t, headers, k)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierEd25519Signature(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	seed := base64.StdEncoding.EncodeToString(privateKey.Seed())
+
+	newNotifier := func(settings map[string]string) (*WebhookNotifier, *notificationServiceMock, error) {
+		raw, err := json.Marshal(settings)
+		require.NoError(t, err)
+		webhookSender := mockNotificationService()
+		pn, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: raw,
+			},
+			NotificationService: webhookSender,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+		})
+		return pn, webhookSender, err
+	}
+
+	for name, key := range map[string]string{"PEM key": pemKey, "base64 seed": seed} {
+		t.Run("the signature of a "+name+" is verified with the public key", func(t *testing.T) {
+			pn, webhookSender, err := newNotifier(map[string]string{"url": "http://localhost/test", "ed25519PrivateKey": key})
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ok, err := pn.Notify(ctx, &types.Alert{
+				Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}},
+			})
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			headers := webhookSender.Webhook.HttpHeader
+			require.Equal(t, WebhookSignatureAlgorithmEd25519, headers[WebhookSignatureAlgorithmHeader])
+			signature, err := base64.StdEncoding.DecodeString(headers[WebhookSignatureHeader])
+			require.NoError(t, err)
+			require.True(t, ed25519.Verify(publicKey, []byte(webhookSender.Webhook.Body), signature))
+			require.False(t, ed25519.Verify(publicKey, []byte(webhookSender.Webhook.Body+" "), signature))
+		})
+	}
+
+	t.Run("an HMAC secret and a private key cannot be used together", func(t *testing.T) {
+		_, _, err := newNotifier(map[string]string{"url": "http://localhost/test", "ed25519PrivateKey": pemKey, "hmacSecret": "secret"})
+		require.EqualError(t, err, "both an HMAC secret and an Ed25519 private key are set, only 1 is permitted")
+	})
+
+	t.Run("invalid private keys fail", func(t *testing.T) {
+		_, _, err := newNotifier(map[string]string{"url": "http://localhost/test", "ed25519PrivateKey": "not a key"})
+		require.EqualError(t, err, "invalid Ed25519 private key: must be a PEM encoded PKCS #8 key or a base64 encoded seed of 32 bytes")
+	})
+}
+
+func TestWebhookNotifierMultipleURLs(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name     string
+		settings string
+		expErr   string
+	}{
+		{
+			name:     "any policy succeeds when one URL fails",
+			settings: `{"url": "http://primary/test", "urls": ["http://secondary/test"]}`,
+		},
+		{
+			name:     "all policy fails when one URL fails",
+			settings: `{"url": "http://primary/test", "urls": ["http://secondary/test"], "successPolicy": "all"}`,
+			expErr:   "failed to send webhook to 1 of 2 URLs: http://secondary/test: connection refused",
+		},
+		{
+			name:     "URLs from the UI are one per line",
+			settings: `{"urls": "http://primary/test\nhttp://secondary/test\n", "successPolicy": "any"}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			webhookSender := mockNotificationService()
+			webhookSender.WebhookErrors = map[string]error{"http://secondary/test": errors.New("connection refused")}
+			pn, err := buildWebhookNotifier(FactoryConfig{
+				Config: &NotificationChannelConfig{
+					Name:     "webhook_testing",
+					Type:     "webhook",
+					Settings: json.RawMessage(c.settings),
+				},
+				NotificationService: webhookSender,
+				DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+					return fallback
+				},
+				ImageStore: &UnavailableImageStore{},
+				Template:   tmpl,
+				Logger:     &FakeLogger{},
+			})
+			require.NoError(t, err)
+			// Retries of each URL are tested in TestSendHTTP.
+			pn.httpRetry = httpRetry{Attempts: 1}
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+			ok, err := pn.Notify(ctx, &types.Alert{
+				Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}},
+			})
+			if c.expErr != "" {
+				var deliveryErr *WebhookDeliveryError
+				require.ErrorAs(t, err, &deliveryErr)
+				require.EqualError(t, err, c.expErr)
+			} else {
+				require.NoError(t, err)
+				require.True(t, ok)
+			}
+
+			// The webhook is sent to all URLs, regardless of the policy.
+			require.Len(t, webhookSender.Webhooks, 2)
+			require.Equal(t, "http://primary/test", webhookSender.Webhooks[0].Url)
+			require.Equal(t, "http://secondary/test", webhookSender.Webhooks[1].Url)
+		})
+	}
+
+	t.Run("invalid success policy should return error", func(t *testing.T) {
+		_, err := buildWebhookSettings(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Settings: json.RawMessage(`{"url": "http://primary/test", "successPolicy": "most"}`),
+			},
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+		})
+		require.EqualError(t, err, `invalid success policy "most", must be "any" or "all"`)
+	})
+}
+
+func TestWebhookNotifierRequireAck(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name        string
+		status      int
+		body        string
+		expErr      string
+		expRequests int
+	}{
+		{
+			name:        "response with the ack marker succeeds",
+			status:      http.StatusAccepted,
+			body:        `{"status": "accepted", "id": "1"}`,
+			expRequests: 1,
+		},
+		{
+			name:        "response without the ack marker fails and is retried",
+			status:      http.StatusAccepted,
+			body:        `{"status": "queued"}`,
+			expErr:      "webhook failed validation: webhook was not acknowledged: response body does not contain the ack marker",
+			expRequests: 2,
+		},
+		{
+			name:        "successful response with another status fails and is retried",
+			status:      http.StatusOK,
+			body:        `{"status": "accepted"}`,
+			expErr:      "webhook failed validation: webhook was not acknowledged: response status 200, expected 202",
+			expRequests: 2,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(c.status)
+				_, _ = w.Write([]byte(c.body))
+			}))
+			defer server.Close()
+
+			pn, err := buildWebhookNotifier(FactoryConfig{
+				Config: &NotificationChannelConfig{
+					Name:     "webhook_testing",
+					Type:     "webhook",
+					Settings: json.RawMessage(fmt.Sprintf(`{"url": %q, "requireAck": true, "ackStatusCode": 202, "ackBodyMarker": "\"status\": \"accepted\""}`, server.URL)),
+				},
+				NotificationService: createWebhookSender(t),
+				DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+					return fallback
+				},
+				ImageStore:  &UnavailableImageStore{},
+				Template:    tmpl,
+				Logger:      &FakeLogger{},
+				RetryBudget: NewRetryBudget(2, time.Minute),
+			})
+			require.NoError(t, err)
+			pn.httpRetry = httpRetry{Attempts: 2, Budget: pn.retries}
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+			ok, err := pn.Notify(ctx, &types.Alert{
+				Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}},
+			})
+			// The notification is retried in both cases, within the retry budget.
+			require.True(t, ok)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, c.expRequests, requests)
+		})
+	}
+
+	t.Run("ack settings", func(t *testing.T) {
+		settingsCases := []struct {
+			settings  string
+			expStatus int
+			expErr    string
+		}{
+			{settings: `{"url": "http://localhost/test", "requireAck": true, "ackBodyMarker": "ok"}`, expStatus: http.StatusOK},
+			{settings: `{"url": "http://localhost/test", "requireAck": true}`, expErr: "could not find ack body marker property in settings"},
+			{settings: `{"url": "http://localhost/test", "requireAck": true, "ackStatusCode": 302, "ackBodyMarker": "ok"}`, expErr: "invalid ack status code 302, must be a 2xx status code"},
+			{settings: `{"url": "http://localhost/test", "ackBodyMarker": "ok"}`},
+		}
+		for _, c := range settingsCases {
+			settings, err := buildWebhookSettings(FactoryConfig{
+				Config: &NotificationChannelConfig{
+					Settings: json.RawMessage(c.settings),
+				},
+				DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+					return fallback
+				},
+			})
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				continue
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expStatus, settings.AckStatusCode)
+		}
+	})
+}
+
+func TestWebhookNotifierExcludeLabels(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := mockNotificationService()
+	pn, err := buildWebhookNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name: "webhook_testing",
+			Type: "webhook",
+			Settings: json.RawMessage(`{
+				"url": "http://localhost/test",
+				"excludeLabels": "replica, internal_*",
+				"message": "{{ range .Alerts }}{{ range .Labels.SortedPairs }}{{ .Name }}={{ .Value }} {{ end }}{{ end }}"
+			}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	})
+	require.NoError(t, err)
+	require.Equal(t, LabelPatterns{"replica", "internal_*"}, pn.settings.ExcludeLabels)
+
+	labels := model.LabelSet{"alertname": "alert1", "replica": "a", "internal_id": "42", "internal_source": "x", "team": "ops"}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	ok, err := pn.Notify(ctx, &types.Alert{Alert: model.Alert{Labels: labels}})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+	require.Equal(t, "alertname=alert1 team=ops ", msg.Message)
+	// The payload keeps the labels, and the fingerprint is of the original labels.
+	require.Len(t, msg.Alerts[0].Labels, 5)
+	require.Equal(t, labels.Fingerprint().String(), msg.Alerts[0].Fingerprint)
+
+	t.Run("wildcard not at the end should return error", func(t *testing.T) {
+		_, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: json.RawMessage(`{"url": "http://localhost/test", "excludeLabels": ["*_id"]}`),
+			},
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			Template: tmpl,
+			Logger:   &FakeLogger{},
+		})
+		require.ErrorContains(t, err, `invalid label pattern "*_id", the wildcard is only supported at the end`)
+	})
+}
+
+func TestWebhookNotifierRedactPatterns(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := mockNotificationService()
+	pn, err := buildWebhookNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name: "webhook_testing",
+			Type: "webhook",
+			Settings: json.RawMessage(`{
+				"url": "http://localhost/test",
+				"redactSecrets": true,
+				"message": "{{ range .Alerts }}{{ .Annotations.description }}{{ end }}"
+			}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	ok, err := pn.Notify(ctx, &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "alert1"},
+		Annotations: model.LabelSet{"description": "Exporter got 401 with Authorization: Bearer c2VjcmV0 from host-1"},
+	}})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+	require.Equal(t, "Exporter got 401 with Authorization: Bearer [REDACTED] from host-1", msg.Message)
+	// The payload is redacted too.
+	require.Equal(t, "Exporter got 401 with Authorization: Bearer [REDACTED] from host-1", msg.Alerts[0].Annotations["description"])
+	require.NotContains(t, webhookSender.Webhook.Body, "c2VjcmV0")
+}
+
+func TestWebhookNotifierTimezone(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(settings string) (*WebhookNotifier, *notificationServiceMock, error) {
+		webhookSender := mockNotificationService()
+		pn, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: webhookSender,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+		})
+		return pn, webhookSender, err
+	}
+	startsAt := time.Date(2022, time.November, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name       string
+		timezone   string
+		expMessage string
+	}{
+		{
+			name:       "timestamps render in UTC by default",
+			expMessage: "2022-11-01 12:00:00 UTC",
+		},
+		{
+			name:       "timestamps render in the time zone",
+			timezone:   "America/New_York",
+			expMessage: "2022-11-01 08:00:00 EDT",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pn, webhookSender, err := newNotifier(fmt.Sprintf(`{"url": "http://localhost/test", "timezone": %q, "message": "{{ range .Alerts }}{{ localTime .StartsAt }}{{ end }}"}`, c.timezone))
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+			ok, err := pn.Notify(ctx, &types.Alert{
+				Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}, StartsAt: startsAt.In(time.Local)},
+			})
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			var msg WebhookMessage
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+			require.Equal(t, c.expMessage, msg.Message)
+			// The timestamps of the payload are not converted.
+			require.True(t, startsAt.Equal(msg.Alerts[0].StartsAt))
+		})
+	}
+
+	t.Run("invalid timezone should return error", func(t *testing.T) {
+		_, _, err := newNotifier(`{"url": "http://localhost/test", "timezone": "Mars/Olympus_Mons"}`)
+		require.EqualError(t, err, "invalid timezone: unknown time zone Mars/Olympus_Mons")
+	})
+}
+
+func TestWebhookNotifierEstimateSize(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(settings string) (*WebhookNotifier, *notificationServiceMock, error) {
+		webhookSender := mockNotificationService()
+		pn, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: webhookSender,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+		})
+		return pn, webhookSender, err
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "instance": "host-1"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "instance": "host-2"}}},
+	}
+
+	for _, contentType := range []string{WebhookContentTypeJSON, WebhookContentTypeForm} {
+		t.Run("estimate matches the size of the sent body as "+contentType, func(t *testing.T) {
+			pn, webhookSender, err := newNotifier(fmt.Sprintf(`{"url": "http://localhost/test", "contentType": %q}`, contentType))
+			require.NoError(t, err)
+
+			size, err := pn.EstimateSize(ctx, alerts...)
+			require.NoError(t, err)
+			require.Empty(t, webhookSender.Webhooks, "estimating the size must not send the webhook")
+
+			ok, err := pn.Notify(ctx, alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+			// The body is rendered the same way, so the estimate is exact.
+			require.Equal(t, len(webhookSender.Webhook.Body), size)
+		})
+	}
+
+	t.Run("webhooks larger than the max body size are not sent", func(t *testing.T) {
+		pn, webhookSender, err := newNotifier(`{"url": "http://localhost/test", "maxBodySize": 100}`)
+		require.NoError(t, err)
+
+		size, err := pn.EstimateSize(ctx, alerts...)
+		require.NoError(t, err)
+		require.Greater(t, size, 100)
+
+		ok, err := pn.Notify(ctx, alerts...)
+		require.EqualError(t, err, fmt.Sprintf("webhook body of %d bytes exceeds the max body size of 100 bytes", size))
+		require.False(t, ok)
+		require.Empty(t, webhookSender.Webhooks)
+	})
+
+	t.Run("webhooks within the max body size are sent", func(t *testing.T) {
+		pn, webhookSender, err := newNotifier(`{"url": "http://localhost/test", "maxBodySize": 65536}`)
+		require.NoError(t, err)
+
+		ok, err := pn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, webhookSender.Webhooks, 1)
+	})
+
+	t.Run("invalid max body size should return error", func(t *testing.T) {
+		_, _, err := newNotifier(`{"url": "http://localhost/test", "maxBodySize": -1}`)
+		require.EqualError(t, err, "max body size should not be negative")
+	})
+
+	t.Run("notifiers that cannot estimate the size return an error", func(t *testing.T) {
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: json.RawMessage(`{"addresses": "someops@example.com"}`),
+		})
+		require.NoError(t, err)
+		en := NewEmailNotifier(cfg, &FakeLogger{}, mockNotificationService(), &UnavailableImageStore{}, tmpl, nil)
+
+		_, err = en.EstimateSize(ctx, alerts...)
+		require.ErrorIs(t, err, ErrSizeEstimateUnsupported)
+	})
+}
+
+func TestWebhookNotifierPayloadSchema(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(settings string) (*WebhookNotifier, *notificationServiceMock, error) {
+		webhookSender := mockNotificationService()
+		pn, err := buildWebhookNotifier(FactoryConfig{
+			Config: &NotificationChannelConfig{
+				OrgID:    1,
+				Name:     "webhook_testing",
+				Type:     "webhook",
+				Settings: json.RawMessage(settings),
+			},
+			NotificationService: webhookSender,
+			DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+				return fallback
+			},
+			ImageStore: &UnavailableImageStore{},
+			Template:   tmpl,
+			Logger:     &FakeLogger{},
+		})
+		return pn, webhookSender, err
+	}
+
+	notifyAndDecode := func(t *testing.T, pn *WebhookNotifier, webhookSender *notificationServiceMock) map[string]interface{} {
+		t.Helper()
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+		ctx = notify.WithReceiverName(ctx, "my_receiver")
+		ok, err := pn.Notify(ctx, &types.Alert{
+			Alert: model.Alert{
+				Labels:       model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+				Annotations:  model.LabelSet{"ann1": "annv1"},
+				GeneratorURL: "http://localhost/alerting/1",
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+		return body
+	}
+
+	keys := func(m interface{}) []string {
+		var res []string
+		for k := range m.(map[string]interface{}) {
+			res = append(res, k)
+		}
+		return res
+	}
+
+	t.Run("alertmanager schema has the fields of the Alertmanager webhook", func(t *testing.T) {
+		pn, webhookSender, err := newNotifier(`{"url": "http://localhost/test", "payloadSchema": "alertmanager"}`)
+		require.NoError(t, err)
+
+		body := notifyAndDecode(t, pn, webhookSender)
+		require.ElementsMatch(t, []string{
+			"version", "groupKey", "truncatedAlerts", "receiver", "status", "alerts",
+			"groupLabels", "commonLabels", "commonAnnotations", "externalURL",
+		}, keys(body))
+		require.Equal(t, "4", body["version"])
+		require.Equal(t, "alertname", body["groupKey"])
+		require.Equal(t, float64(0), body["truncatedAlerts"])
+		require.Equal(t, "my_receiver", body["receiver"])
+		require.Equal(t, "firing", body["status"])
+		require.Equal(t, "http://localhost", body["externalURL"])
+		require.Equal(t, map[string]interface{}{"alertname": "alert1"}, body["groupLabels"])
+		require.Equal(t, map[string]interface{}{"alertname": "alert1", "lbl1": "val1"}, body["commonLabels"])
+		require.Equal(t, map[string]interface{}{"ann1": "annv1"}, body["commonAnnotations"])
+
+		alerts := body["alerts"].([]interface{})
+		require.Len(t, alerts, 1)
+		require.ElementsMatch(t, []string{
+			"status", "labels", "annotations", "startsAt", "endsAt", "generatorURL", "fingerprint",
+		}, keys(alerts[0]))
+		alert := alerts[0].(map[string]interface{})
+		require.Equal(t, "firing", alert["status"])
+		require.Equal(t, map[string]interface{}{"alertname": "alert1", "lbl1": "val1"}, alert["labels"])
+		require.Equal(t, "http://localhost/alerting/1", alert["generatorURL"])
+	})
+
+	t.Run("grafana schema is the default", func(t *testing.T) {
+		pn, webhookSender, err := newNotifier(`{"url": "http://localhost/test"}`)
+		require.NoError(t, err)
+
+		body := notifyAndDecode(t, pn, webhookSender)
+		require.Equal(t, "1", body["version"])
+		require.Equal(t, float64(1), body["orgId"])
+		require.Contains(t, body, "title")
+		require.Contains(t, body, "state")
+		require.Contains(t, body, "message")
+		alert := body["alerts"].([]interface{})[0].(map[string]interface{})
+		require.Contains(t, alert, "silenceURL")
+		require.Contains(t, alert, "dashboardURL")
+	})
+
+	t.Run("invalid payload schema", func(t *testing.T) {
+		_, _, err := newNotifier(`{"url": "http://localhost/test", "payloadSchema": "slack"}`)
+		require.EqualError(t, err, `invalid payload schema "slack", must be "grafana" or "alertmanager"`)
+	})
+
+	t.Run("alertmanager schema cannot be sent as a form", func(t *testing.T) {
+		_, _, err := newNotifier(`{"url": "http://localhost/test", "payloadSchema": "alertmanager", "contentType": "form"}`)
+		require.EqualError(t, err, `the "alertmanager" payload schema requires the "json" content type`)
+	})
+
+	t.Run("compact verbosity only has the labels and status of the alerts", func(t *testing.T) {
+		pn, webhookSender, err := newNotifier(`{"url": "http://localhost/test", "verbosity": "compact"}`)
+		require.NoError(t, err)
+
+		body := notifyAndDecode(t, pn, webhookSender)
+		require.ElementsMatch(t, []string{
+			"version", "groupKey", "truncatedAlerts", "orgId", "state", "status", "alerts",
+		}, keys(body))
+		require.Equal(t, "1", body["version"])
+		require.Equal(t, "alerting", body["state"])
+		require.Equal(t, "firing", body["status"])
+
+		alerts := body["alerts"].([]interface{})
+		require.Len(t, alerts, 1)
+		require.ElementsMatch(t, []string{"status", "labels", "fingerprint", "startsAt"}, keys(alerts[0]))
+		alert := alerts[0].(map[string]interface{})
+		require.Equal(t, "firing", alert["status"])
+		require.Equal(t, map[string]interface{}{"alertname": "alert1", "lbl1": "val1"}, alert["labels"])
+		require.NotEmpty(t, alert["fingerprint"])
+	})
+
+	t.Run("full verbosity keeps all fields of the alerts", func(t *testing.T) {
+		pn, webhookSender, err := newNotifier(`{"url": "http://localhost/test", "verbosity": "full"}`)
+		require.NoError(t, err)
+
+		body := notifyAndDecode(t, pn, webhookSender)
+		for _, k := range []string{"title", "message", "commonLabels", "commonAnnotations", "externalURL"} {
+			require.Contains(t, body, k)
+		}
+		alert := body["alerts"].([]interface{})[0].(map[string]interface{})
+		for _, k := range []string{"annotations", "generatorURL", "silenceURL", "dashboardURL", "values", "endsAt"} {
+			require.Contains(t, alert, k)
+		}
+	})
+
+	t.Run("invalid verbosity", func(t *testing.T) {
+		_, _, err := newNotifier(`{"url": "http://localhost/test", "verbosity": "terse"}`)
+		require.EqualError(t, err, `invalid verbosity "terse", must be "full" or "compact"`)
+
+		_, _, err = newNotifier(`{"url": "http://localhost/test", "verbosity": "compact", "payloadSchema": "alertmanager"}`)
+		require.EqualError(t, err, `the "compact" verbosity requires the "grafana" payload schema`)
+
+		_, _, err = newNotifier(`{"url": "http://localhost/test", "verbosity": "compact", "contentType": "form"}`)
+		require.EqualError(t, err, `the "compact" verbosity requires the "json" content type`)
+	})
+}
+
+func TestWebhookNotifierIncludeRecentlyResolved(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	webhookSender := mockNotificationService()
+	wn, err := buildWebhookNotifier(FactoryConfig{
+		Config: &NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: json.RawMessage(`{"url": "http://localhost/test", "includeRecentlyResolved": true, "recentlyResolvedWindow": "30m"}`),
+		},
+		NotificationService: webhookSender,
+		DecryptFunc: func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+			return fallback
+		},
+		ImageStore: &UnavailableImageStore{},
+		Template:   tmpl,
+		Logger:     &FakeLogger{},
+	})
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	resolvedAlert := func(name string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": model.LabelValue(name)},
+			EndsAt: time.Now().Add(-time.Minute),
+		}}
+	}
+	notifyAt := func(t *testing.T, at time.Time, alerts ...*types.Alert) WebhookMessage {
+		t.Helper()
+		timeNow = func() time.Time { return at }
+		ok, err := wn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		var body WebhookMessage
+		require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+		return body
+	}
+	alertNames := func(alerts ExtendedAlerts) []string {
+		var names []string
+		for _, a := range alerts {
+			names = append(names, a.Labels["alertname"])
+		}
+		return names
+	}
+
+	body := notifyAt(t, now, resolvedAlert("first"))
+	require.Empty(t, body.RecentlyResolved)
+
+	// Alerts resolved within the window are included, except the alerts of the notification.
+	body = notifyAt(t, now.Add(10*time.Minute), resolvedAlert("second"), resolvedAlert("first"))
+	require.Equal(t, []string{"second", "first"}, alertNames(body.Alerts))
+	require.Empty(t, body.RecentlyResolved)
+
+	body = notifyAt(t, now.Add(20*time.Minute), resolvedAlert("third"))
+	require.Equal(t, []string{"second", "first"}, alertNames(body.RecentlyResolved))
+	require.Equal(t, "resolved", body.RecentlyResolved[0].Status)
+
+	// Firing notifications do not include them.
+	body = notifyAt(t, now.Add(25*time.Minute), &types.Alert{Alert: model.Alert{
+		Labels: model.LabelSet{"alertname": "fourth"},
+		EndsAt: time.Now().Add(time.Hour),
+	}})
+	require.Empty(t, body.RecentlyResolved)
+
+	// Alerts resolved before the window are pruned.
+	body = notifyAt(t, now.Add(45*time.Minute), resolvedAlert("fourth"))
+	require.Equal(t, []string{"third"}, alertNames(body.RecentlyResolved))
+
+	timeNow = func() time.Time { return now.Add(2 * time.Hour) }
+	require.Empty(t, wn.resolved.recent("alertname", nil))
+	wn.resolved.mtx.Lock()
+	require.Empty(t, wn.resolved.groups)
+	wn.resolved.mtx.Unlock()
+}
+
+func TestRecentlyResolvedBounds(t *testing.T) {
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	r := newRecentlyResolved(time.Hour)
+	alerts := make(ExtendedAlerts, 0, recentlyResolvedMaxAlerts+1)
+	for i := 0; i <= recentlyResolvedMaxAlerts; i++ {
+		alerts = append(alerts, ExtendedAlert{Status: "resolved", Fingerprint: fmt.Sprintf("%d", i)})
+	}
+	r.add("group", alerts)
+	recent := r.recent("group", nil)
+	require.Len(t, recent, recentlyResolvedMaxAlerts)
+	require.Equal(t, "1", recent[0].Fingerprint, "the oldest alerts are dropped first")
+
+	for i := 1; i < recentlyResolvedMaxGroups; i++ {
+		timeNow = func() time.Time { return now.Add(time.Duration(i) * time.Millisecond) }
+		r.add(fmt.Sprintf("group-%d", i), ExtendedAlerts{{Status: "resolved", Fingerprint: "a"}})
+	}
+	r.add("new-group", ExtendedAlerts{{Status: "resolved", Fingerprint: "a"}})
+	require.Len(t, r.groups, recentlyResolvedMaxGroups)
+	require.Empty(t, r.recent("group", nil), "the group resolved the longest ago is dropped")
+	require.Len(t, r.recent("new-group", nil), 1)
+}
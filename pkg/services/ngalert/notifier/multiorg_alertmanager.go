@@ -52,6 +52,11 @@ type MultiOrgAlertmanager struct {
 
 	metrics *metrics.MultiOrgAlertmanager
 	ns      notifications.Service
+
+	// retryBudget caps the retries of failed notifications across all organizations.
+	retryBudget *channels.RetryBudget
+	// imageLimiter caps the concurrent image requests of notifications across all organizations.
+	imageLimiter *channels.ImageLimiter
 }
 
 func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgStore store.OrgStore,
@@ -73,6 +78,13 @@ func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgSto
 		ns:            ns,
 	}
 
+	if limit := cfg.UnifiedAlerting.NotificationRetryBudget; limit > 0 {
+		moa.retryBudget = channels.NewRetryBudget(limit, cfg.UnifiedAlerting.NotificationRetryBudgetInterval)
+	}
+	if limit := cfg.UnifiedAlerting.Screenshots.MaxConcurrentImageRequests; limit > 0 {
+		moa.imageLimiter = channels.NewImageLimiter(limit)
+	}
+
 	clusterLogger := l.New("component", "cluster")
 	moa.peer = &NilPeer{}
 	if len(cfg.UnifiedAlerting.HAPeers) > 0 {
@@ -182,7 +194,7 @@ func (moa *MultiOrgAlertmanager) SyncAlertmanagersForOrgs(ctx context.Context, o
 			// To export them, we need to translate the metrics from each individual registry and,
 			// then aggregate them on the main registry.
 			m := metrics.NewAlertmanagerMetrics(moa.metrics.GetOrCreateOrgRegistry(orgID))
-			am, err := newAlertmanager(ctx, orgID, moa.settings, moa.configStore, moa.kvStore, moa.peer, moa.decryptFn, moa.ns, m)
+			am, err := newAlertmanager(ctx, orgID, moa.settings, moa.configStore, moa.kvStore, moa.peer, moa.decryptFn, moa.ns, m, moa.retryBudget, moa.imageLimiter)
 			if err != nil {
 				moa.logger.Error("unable to create Alertmanager for org", "org", orgID, "error", err)
 			}
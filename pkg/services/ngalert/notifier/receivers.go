@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-openapi/strfmt"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
 	"github.com/prometheus/alertmanager/api/v2/models"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -68,6 +69,8 @@ func (am *Alertmanager) TestReceivers(ctx context.Context, c apimodels.TestRecei
 
 	// we must set a group key that is unique per test as some receivers use this key to deduplicate alerts
 	ctx = notify.WithGroupKey(ctx, testAlert.Labels.String()+now.String())
+	// Test notifications are sent right away, so that their result can be reported.
+	ctx = channels.WithoutDeferral(ctx)
 
 	tmpl, err := am.getTemplate()
 	if err != nil {
@@ -78,7 +81,7 @@ func (am *Alertmanager) TestReceivers(ctx context.Context, c apimodels.TestRecei
 	type job struct {
 		Config       *apimodels.PostableGrafanaReceiver
 		ReceiverName string
-		Notifier     notify.Notifier
+		Notifier     channels.NotificationChannel
 	}
 
 	// result contains the receiver that was tested and an error that is non-nil if the test failed
@@ -191,6 +194,11 @@ func (am *Alertmanager) TestReceivers(ctx context.Context, c apimodels.TestRecei
 	g.Wait() // nolint
 	close(resultCh)
 
+	// The notifiers are only built for the test, they must not send reminders after it.
+	for _, job := range jobs {
+		job.Notifier.Stop()
+	}
+
 	results := make([]result, 0, len(jobs))
 	for next := range resultCh {
 		results = append(results, next)
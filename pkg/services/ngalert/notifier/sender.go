@@ -14,18 +14,44 @@ type sender struct {
 
 func (s sender) SendWebhook(ctx context.Context, cmd *channels.SendWebhookSettings) error {
 	return s.ns.SendWebhookSync(ctx, &models.SendWebhookSync{
-		Url:         cmd.Url,
-		User:        cmd.User,
-		Password:    cmd.Password,
-		Body:        cmd.Body,
-		HttpMethod:  cmd.HttpMethod,
-		HttpHeader:  cmd.HttpHeader,
-		ContentType: cmd.ContentType,
-		Validation:  cmd.Validation,
+		Url:             cmd.Url,
+		User:            cmd.User,
+		Password:        cmd.Password,
+		Body:            cmd.Body,
+		HttpMethod:      cmd.HttpMethod,
+		HttpHeader:      cmd.HttpHeader,
+		ContentType:     cmd.ContentType,
+		Timeout:         cmd.Timeout,
+		ConnectTimeout:  cmd.ConnectTimeout,
+		ConnectionPool:  cmd.ConnectionPool,
+		MaxIdleConns:    cmd.MaxIdleConns,
+		IdleConnTimeout: cmd.IdleConnTimeout,
+		Validation:      cmd.Validation,
 	})
 }
 
-func (s sender) SendEmail(ctx context.Context, cmd *channels.SendEmailSettings) error {
+// SendEmail sends the email and reports the delivery outcome for each recipient.
+// Unless a single email is sent to all recipients, each recipient gets its own email
+// so that a failure for one of them does not hide the outcome for the others.
+func (s sender) SendEmail(ctx context.Context, cmd *channels.SendEmailSettings) (channels.EmailSendResult, error) {
+	if cmd.SingleEmail || len(cmd.To) <= 1 {
+		err := s.ns.SendEmailCommandHandlerSync(ctx, buildSendEmailCommand(cmd, cmd.To, true))
+		res := channels.NewEmailSendResult(cmd.To, err)
+		return res, res.Err()
+	}
+
+	res := channels.EmailSendResult{Recipients: make([]channels.EmailRecipientResult, 0, len(cmd.To))}
+	for i, to := range cmd.To {
+		// Copies are only sent once, not for every recipient.
+		err := s.ns.SendEmailCommandHandlerSync(ctx, buildSendEmailCommand(cmd, []string{to}, i == 0))
+		res.Recipients = append(res.Recipients, channels.EmailRecipientResult{Address: to, Error: err})
+	}
+	return res, res.Err()
+}
+
+// buildSendEmailCommand builds the command to send the email to the recipients. The carbon
+// copies of the email are only included if withCopies is true.
+func buildSendEmailCommand(cmd *channels.SendEmailSettings, to []string, withCopies bool) *models.SendEmailCommandSync {
 	var attached []*models.SendEmailAttachFile
 	if cmd.AttachedFiles != nil {
 		attached = make([]*models.SendEmailAttachFile, 0, len(cmd.AttachedFiles))
@@ -36,19 +62,33 @@ func (s sender) SendEmail(ctx context.Context, cmd *channels.SendEmailSettings)
 			})
 		}
 	}
-	return s.ns.SendEmailCommandHandlerSync(ctx, &models.SendEmailCommandSync{
+	var cc []string
+	if withCopies {
+		cc = cmd.Cc
+	}
+	return &models.SendEmailCommandSync{
 		SendEmailCommand: models.SendEmailCommand{
-			To:            cmd.To,
-			SingleEmail:   cmd.SingleEmail,
-			Template:      cmd.Template,
-			Subject:       cmd.Subject,
-			Data:          cmd.Data,
-			Info:          cmd.Info,
-			ReplyTo:       cmd.ReplyTo,
-			EmbeddedFiles: cmd.EmbeddedFiles,
-			AttachedFiles: attached,
+			To:               to,
+			SingleEmail:      true,
+			Template:         cmd.Template,
+			Subject:          cmd.Subject,
+			Data:             cmd.Data,
+			Info:             cmd.Info,
+			ReplyTo:          cmd.ReplyTo,
+			EmbeddedFiles:    cmd.EmbeddedFiles,
+			AttachedFiles:    attached,
+			Cc:               cc,
+			CopyToSender:     cmd.CopyToSender && withCopies,
+			SkipArchive:      !withCopies,
+			OptOut:           cmd.OptOut,
+			Headers:          cmd.Headers,
+			TransferEncoding: cmd.TransferEncoding,
+			Charset:          cmd.Charset,
+			SMIMECertFile:    cmd.SMIMECertFile,
+			SMIMEKeyFile:     cmd.SMIMEKeyFile,
+			OrgID:            cmd.OrgID,
 		},
-	})
+	}
 }
 
 func NewNotificationSender(ns notifications.Service) channels.NotificationSender {
@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+func TestSenderSendEmail(t *testing.T) {
+	errInvalid := errors.New("invalid address")
+	ns := notifications.MockNotificationService()
+	var sent, cc [][]string
+	var skipArchive []bool
+	ns.EmailHandlerSync = func(ctx context.Context, cmd *models.SendEmailCommandSync) error {
+		sent = append(sent, cmd.To)
+		cc = append(cc, cmd.Cc)
+		skipArchive = append(skipArchive, cmd.SkipArchive)
+		if cmd.To[0] == "invalid" {
+			return errInvalid
+		}
+		return nil
+	}
+	s := NewNotificationSender(ns)
+
+	t.Run("reports the outcome for each recipient", func(t *testing.T) {
+		sent = nil
+		res, err := s.SendEmail(context.Background(), &channels.SendEmailSettings{
+			To: []string{"ops@example.com", "invalid", "dev@example.com"},
+		})
+
+		require.Equal(t, [][]string{{"ops@example.com"}, {"invalid"}, {"dev@example.com"}}, sent)
+		require.Equal(t, []channels.EmailRecipientResult{
+			{Address: "ops@example.com"},
+			{Address: "invalid", Error: errInvalid},
+			{Address: "dev@example.com"},
+		}, res.Recipients)
+		require.Equal(t, []channels.EmailRecipientResult{{Address: "invalid", Error: errInvalid}}, res.Failed())
+
+		var deliveryErr *channels.EmailDeliveryError
+		require.ErrorAs(t, err, &deliveryErr)
+		require.EqualError(t, err, "failed to send email to 1 of 3 recipients: invalid: invalid address")
+	})
+
+	t.Run("single email shares the outcome across recipients", func(t *testing.T) {
+		sent = nil
+		res, err := s.SendEmail(context.Background(), &channels.SendEmailSettings{
+			To:          []string{"invalid", "ops@example.com"},
+			SingleEmail: true,
+		})
+
+		require.Equal(t, [][]string{{"invalid", "ops@example.com"}}, sent)
+		require.Len(t, res.Failed(), 2)
+		require.Error(t, err)
+	})
+
+	t.Run("carbon copies are only sent with the first email", func(t *testing.T) {
+		sent, cc, skipArchive = nil, nil, nil
+		_, err := s.SendEmail(context.Background(), &channels.SendEmailSettings{
+			To: []string{"ops@example.com", "dev@example.com"},
+			Cc: []string{"manager@example.com"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, [][]string{{"ops@example.com"}, {"dev@example.com"}}, sent)
+		require.Equal(t, [][]string{{"manager@example.com"}, nil}, cc)
+		// The org archives a single copy of the notification.
+		require.Equal(t, []bool{false, true}, skipArchive)
+	})
+
+	t.Run("no error when all recipients succeed", func(t *testing.T) {
+		res, err := s.SendEmail(context.Background(), &channels.SendEmailSettings{
+			To: []string{"ops@example.com", "dev@example.com"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, res.Failed())
+	})
+}
@@ -0,0 +1,217 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// teamBudgetWindow is the length of the window the team notification budget applies to.
+const teamBudgetWindow = time.Hour
+
+// teamBudget keeps track of how many notifications each team has sent in the current window.
+// Teams are the values of an alert label, so the expired windows are pruned once per window
+// rather than kept for every team ever seen. It is safe to use concurrently.
+type teamBudget struct {
+	limit   int64
+	mtx     sync.Mutex
+	windows map[string]*teamBudgetUsage
+	// pruned is when the expired windows were last removed.
+	pruned time.Time
+}
+
+type teamBudgetUsage struct {
+	start time.Time
+	count int64
+}
+
+func newTeamBudget(limit int64) *teamBudget {
+	return &teamBudget{
+		limit:   limit,
+		windows: make(map[string]*teamBudgetUsage),
+	}
+}
+
+// take consumes one notification from the budget of the team. It returns false if the team
+// has already exhausted its budget for the window that contains now.
+func (b *teamBudget) take(team string, now time.Time) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if now.Sub(b.pruned) >= teamBudgetWindow {
+		b.prune(now)
+	}
+	usage, ok := b.windows[team]
+	if !ok || now.Sub(usage.start) >= teamBudgetWindow {
+		usage = &teamBudgetUsage{start: now}
+		b.windows[team] = usage
+	}
+	if usage.count >= b.limit {
+		return false
+	}
+	usage.count++
+	return true
+}
+
+// prune removes the windows that expired, a team without a window has its full budget.
+func (b *teamBudget) prune(now time.Time) {
+	for team, usage := range b.windows {
+		if now.Sub(usage.start) >= teamBudgetWindow {
+			delete(b.windows, team)
+		}
+	}
+	b.pruned = now
+}
+
+// teamBudgetFlushKey is the context key of the budget decisions of a flush.
+type teamBudgetFlushKey struct{}
+
+// teamBudgetFlush holds the budget decisions of a flush of a receiver, so that the budget of
+// a team is taken once per flush, however many integrations the receiver has.
+type teamBudgetFlush struct {
+	budget  *teamBudget
+	dropped *prometheus.CounterVec
+
+	mtx     sync.Mutex
+	allowed map[string]bool
+}
+
+// allow takes one notification from the budget of the team the first time it is called in the
+// flush, and returns the same decision for the other integrations of the receiver.
+func (f *teamBudgetFlush) allow(team string, now time.Time) bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	allowed, ok := f.allowed[team]
+	if !ok {
+		allowed = f.budget.take(team, now)
+		if !allowed {
+			f.dropped.WithLabelValues(team).Inc()
+		}
+		f.allowed[team] = allowed
+	}
+	return allowed
+}
+
+// teamBudgetFlushStage starts the budget decisions of a flush of a receiver. It must run
+// before the stages of the integrations of the receiver.
+type teamBudgetFlushStage struct {
+	budget  *teamBudget
+	dropped *prometheus.CounterVec
+}
+
+func newTeamBudgetFlushStage(budget *teamBudget, dropped *prometheus.CounterVec) *teamBudgetFlushStage {
+	return &teamBudgetFlushStage{
+		budget:  budget,
+		dropped: dropped,
+	}
+}
+
+func (s *teamBudgetFlushStage) Exec(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	flush := &teamBudgetFlush{
+		budget:  s.budget,
+		dropped: s.dropped,
+		allowed: make(map[string]bool),
+	}
+	return context.WithValue(ctx, teamBudgetFlushKey{}, flush), alerts, nil
+}
+
+// teamBudgetStage defers the alerts of teams that exceeded their hourly notification budget.
+// The team is read from the configured label, alerts without the label are never limited.
+//
+// It must run right after the DedupStage of the integration: deferred alerts are removed
+// from the alerts that the DedupStage put in the context, so they are not marked as notified
+// and are sent with the next flush once the team has budget available again.
+type teamBudgetStage struct {
+	teamLabel model.LabelName
+}
+
+func newTeamBudgetStage(teamLabel string) *teamBudgetStage {
+	return &teamBudgetStage{
+		teamLabel: model.LabelName(teamLabel),
+	}
+}
+
+func (s *teamBudgetStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	flush, ok := ctx.Value(teamBudgetFlushKey{}).(*teamBudgetFlush)
+	if !ok {
+		return ctx, nil, errors.New("team budget missing")
+	}
+	firing, ok := notify.FiringAlerts(ctx)
+	if !ok {
+		return ctx, nil, errors.New("firing alerts missing")
+	}
+	resolved, ok := notify.ResolvedAlerts(ctx)
+	if !ok {
+		return ctx, nil, errors.New("resolved alerts missing")
+	}
+	now, ok := notify.Now(ctx)
+	if !ok {
+		now = time.Now()
+	}
+
+	res := make([]*types.Alert, 0, len(alerts))
+	deferred := make(map[uint64]struct{})
+	for _, a := range alerts {
+		team, ok := a.Labels[s.teamLabel]
+		if !ok || team == "" || flush.allow(string(team), now) {
+			res = append(res, a)
+			continue
+		}
+		level.Debug(l).Log("msg", "team exceeded its notification budget, deferring alert", "team", team, "alert", a.Name())
+		deferred[hashAlert(a)] = struct{}{}
+	}
+
+	if len(deferred) == 0 {
+		return ctx, alerts, nil
+	}
+	if len(res) == 0 {
+		// Stop the pipeline, so that the integration is not notified and the notification
+		// log is not updated.
+		return ctx, nil, nil
+	}
+	ctx = notify.WithFiringAlerts(ctx, withoutHashes(firing, deferred))
+	ctx = notify.WithResolvedAlerts(ctx, withoutHashes(resolved, deferred))
+	return ctx, res, nil
+}
+
+func withoutHashes(hashes []uint64, remove map[uint64]struct{}) []uint64 {
+	res := make([]uint64, 0, len(hashes))
+	for _, h := range hashes {
+		if _, ok := remove[h]; !ok {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+// hashAlert returns the hash of the labels of the alert, the same as the DedupStage of the
+// Alertmanager records in the notification log.
+func hashAlert(a *types.Alert) uint64 {
+	const sep = '\xff'
+
+	names := make(model.LabelNames, 0, len(a.Labels))
+	for ln := range a.Labels {
+		names = append(names, ln)
+	}
+	sort.Sort(names)
+
+	b := make([]byte, 0, 1024)
+	for _, ln := range names {
+		b = append(b, string(ln)...)
+		b = append(b, sep)
+		b = append(b, string(a.Labels[ln])...)
+		b = append(b, sep)
+	}
+	return xxhash.Sum64(b)
+}
@@ -0,0 +1,173 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestTeamBudgetStage(t *testing.T) {
+	newAlert := func(labels model.LabelSet) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: labels}}
+	}
+	teamA := newAlert(model.LabelSet{"alertname": "a", "team": "a"})
+	teamB := newAlert(model.LabelSet{"alertname": "b", "team": "b"})
+	noTeam := newAlert(model.LabelSet{"alertname": "c"})
+
+	m := metrics.NewAlertmanagerMetrics(prometheus.NewRegistry())
+	stage := notify.MultiStage{
+		newTeamBudgetFlushStage(newTeamBudget(2), m.TeamBudgetDropped),
+		newTeamBudgetStage("team"),
+	}
+
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	exec := func(at time.Time, alerts ...*types.Alert) []*types.Alert {
+		t.Helper()
+		firing := make([]uint64, 0, len(alerts))
+		for _, a := range alerts {
+			firing = append(firing, hashAlert(a))
+		}
+		ctx := notify.WithNow(context.Background(), at)
+		ctx = notify.WithFiringAlerts(ctx, firing)
+		ctx = notify.WithResolvedAlerts(ctx, []uint64{})
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("team within its budget is notified", func(t *testing.T) {
+		require.Equal(t, []*types.Alert{teamA}, exec(now, teamA))
+		require.Equal(t, []*types.Alert{teamA}, exec(now.Add(time.Minute), teamA))
+	})
+
+	t.Run("team that exhausted its budget does not block other teams", func(t *testing.T) {
+		require.Empty(t, exec(now.Add(2*time.Minute), teamA))
+		require.Equal(t, []*types.Alert{teamB}, exec(now.Add(2*time.Minute), teamA, teamB))
+		require.Equal(t, []*types.Alert{teamB}, exec(now.Add(3*time.Minute), teamB))
+		require.Equal(t, 2.0, testutil.ToFloat64(m.TeamBudgetDropped.WithLabelValues("a")))
+		require.Equal(t, 0.0, testutil.ToFloat64(m.TeamBudgetDropped.WithLabelValues("b")))
+	})
+
+	t.Run("alerts without a team are never limited", func(t *testing.T) {
+		require.Equal(t, []*types.Alert{noTeam}, exec(now.Add(4*time.Minute), noTeam, teamA))
+	})
+
+	t.Run("budget is replenished after the window", func(t *testing.T) {
+		require.Equal(t, []*types.Alert{teamA}, exec(now.Add(time.Hour), teamA))
+	})
+}
+
+// recordingNotifier records the alerts of each notification.
+type recordingNotifier struct {
+	mtx   sync.Mutex
+	calls [][]*types.Alert
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, alerts ...*types.Alert) (bool, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.calls = append(n.calls, alerts)
+	return false, nil
+}
+
+func (n *recordingNotifier) SendResolved() bool {
+	return true
+}
+
+func (n *recordingNotifier) takeCalls() [][]*types.Alert {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	calls := n.calls
+	n.calls = nil
+	return calls
+}
+
+func TestTeamBudgetPrune(t *testing.T) {
+	b := newTeamBudget(1)
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	require.True(t, b.take("a", now))
+	require.True(t, b.take("b", now.Add(30*time.Minute)))
+	require.Len(t, b.windows, 2)
+
+	// The expired window of team a is removed, the window of team b is kept.
+	require.True(t, b.take("c", now.Add(teamBudgetWindow)))
+	require.Len(t, b.windows, 2)
+	require.NotContains(t, b.windows, "a")
+	require.False(t, b.take("b", now.Add(teamBudgetWindow)))
+}
+
+func TestTeamBudgetReceiverStage(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	am := &Alertmanager{
+		Settings: &setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{
+			TeamNotificationBudget: setting.UnifiedAlertingTeamNotificationBudgetSettings{TeamLabel: "team", HourlyLimit: 1},
+		}},
+		Metrics:      metrics.NewAlertmanagerMetrics(reg),
+		stageMetrics: notify.NewMetrics(reg),
+		teamBudget:   newTeamBudget(1),
+	}
+	nl, err := nflog.New(nflog.WithRetention(24 * time.Hour))
+	require.NoError(t, err)
+
+	first, second := &recordingNotifier{}, &recordingNotifier{}
+	stage := am.createReceiverStage("receiver", []*notify.Integration{
+		notify.NewIntegration(first, first, "webhook", 0),
+		notify.NewIntegration(second, second, "email", 1),
+	}, func() time.Duration { return 0 }, nl)
+
+	newAlert := func(labels model.LabelSet) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: labels, StartsAt: time.Now()}}
+	}
+	a1 := newAlert(model.LabelSet{"alertname": "a1", "team": "a"})
+	a2 := newAlert(model.LabelSet{"alertname": "a2", "team": "a"})
+	noTeam := newAlert(model.LabelSet{"alertname": "c"})
+
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	flush := func(at time.Time, alerts ...*types.Alert) {
+		t.Helper()
+		ctx := notify.WithGroupKey(context.Background(), "group")
+		ctx = notify.WithRepeatInterval(ctx, 4*time.Hour)
+		ctx = notify.WithNow(ctx, at)
+		_, _, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+	}
+
+	t.Run("budget is taken once per flush for all integrations", func(t *testing.T) {
+		flush(now, a1)
+		require.Equal(t, [][]*types.Alert{{a1}}, first.takeCalls())
+		require.Equal(t, [][]*types.Alert{{a1}}, second.takeCalls())
+		require.Equal(t, int64(1), am.teamBudget.windows["a"].count)
+	})
+
+	t.Run("integrations are not notified when all alerts are deferred", func(t *testing.T) {
+		flush(now.Add(5*time.Minute), a1, a2)
+		require.Empty(t, first.takeCalls())
+		require.Empty(t, second.takeCalls())
+		require.Equal(t, 1.0, testutil.ToFloat64(am.Metrics.TeamBudgetDropped.WithLabelValues("a")))
+	})
+
+	t.Run("deferred alerts are not marked as notified", func(t *testing.T) {
+		flush(now.Add(10*time.Minute), a1, a2, noTeam)
+		require.Equal(t, [][]*types.Alert{{noTeam}}, first.takeCalls())
+		require.Equal(t, [][]*types.Alert{{noTeam}}, second.takeCalls())
+
+		// The alerts of the team are sent once the budget is replenished, although they
+		// did not change since the previous flush.
+		flush(now.Add(time.Hour+10*time.Minute), a1, a2, noTeam)
+		require.Equal(t, [][]*types.Alert{{a1, a2, noTeam}}, first.takeCalls())
+		require.Equal(t, [][]*types.Alert{{a1, a2, noTeam}}, second.takeCalls())
+	})
+}
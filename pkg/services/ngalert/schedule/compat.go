@@ -44,6 +44,12 @@ func stateToPostableAlert(alertState *state.State, appURL *url.URL) *models.Post
 		}
 	}
 
+	if len(alertState.Transitions) > 0 {
+		if b, err := json.Marshal(alertState.Transitions); err == nil {
+			nA[ngModels.StateTransitionsAnnotation] = string(b)
+		}
+	}
+
 	if alertState.LastEvaluationString != "" {
 		nA[ngModels.ValueStringAnnotation] = alertState.LastEvaluationString
 	}
@@ -133,6 +133,24 @@ func Test_stateToPostableAlert(t *testing.T) {
 
 					require.Equal(t, expected, result.Annotations)
 				})
+
+				t.Run("add __state_transitions__ if the state changed", func(t *testing.T) {
+					alertState := randomState(tc.state)
+					alertState.Annotations = randomMapOfStrings()
+					alertState.Transitions = []ngModels.AlertStateTransition{
+						{PreviousState: "Pending", State: "Alerting", At: time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)},
+					}
+
+					result := stateToPostableAlert(alertState, appURL)
+
+					expected := make(models.LabelSet, len(alertState.Annotations)+1)
+					for k, v := range alertState.Annotations {
+						expected[k] = v
+					}
+					expected["__state_transitions__"] = `[{"previousState":"Pending","state":"Alerting","at":"2022-11-01T10:00:00Z"}]`
+
+					require.Equal(t, expected, result.Annotations)
+				})
 			})
 
 			t.Run("should add state reason annotation if not empty", func(t *testing.T) {
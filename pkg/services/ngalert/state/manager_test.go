@@ -430,6 +430,9 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Alerting", At: evaluationTime.Add(60 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(1 * time.Minute),
 					EndsAt:             evaluationTime.Add(1 * time.Minute).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(1 * time.Minute),
@@ -508,6 +511,10 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Pending", At: evaluationTime.Add(10 * time.Second)},
+						{PreviousState: "Pending", State: "Alerting", At: evaluationTime.Add(80 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(80 * time.Second),
 					EndsAt:             evaluationTime.Add(80 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(80 * time.Second),
@@ -598,6 +605,11 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Pending", At: evaluationTime.Add(10 * time.Second)},
+						{PreviousState: "Pending", State: "NoData", At: evaluationTime.Add(20 * time.Second)},
+						{PreviousState: "NoData", State: "Pending", At: evaluationTime.Add(30 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(30 * time.Second),
 					EndsAt:             evaluationTime.Add(30 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(40 * time.Second),
@@ -680,6 +692,10 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Pending", State: "Alerting", At: evaluationTime.Add(20 * time.Second)},
+						{PreviousState: "Alerting", State: "NoData", At: evaluationTime.Add(30 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(20 * time.Second),
 					EndsAt:             evaluationTime.Add(30 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(30 * time.Second),
@@ -745,6 +761,9 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Pending", At: evaluationTime.Add(10 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(10 * time.Second),
 					EndsAt:             evaluationTime.Add(10 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
@@ -876,6 +895,9 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Alerting (NoData)", At: evaluationTime.Add(10 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(10 * time.Second),
 					EndsAt:             evaluationTime.Add(10 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
@@ -941,6 +963,9 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "NoData", At: evaluationTime.Add(10 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(10 * time.Second),
 					EndsAt:             evaluationTime.Add(10 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
@@ -1307,6 +1332,9 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Normal (NoData)", At: evaluationTime.Add(10 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(10 * time.Second),
 					EndsAt:             evaluationTime.Add(10 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
@@ -1375,6 +1403,9 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Alerting (NoData)", At: evaluationTime.Add(10 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(10 * time.Second),
 					EndsAt:             evaluationTime.Add(10 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
@@ -1444,6 +1475,9 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Pending (Error)", At: evaluationTime.Add(10 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(10 * time.Second),
 					EndsAt:             evaluationTime.Add(10 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
@@ -1540,6 +1574,10 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Pending (Error)", At: evaluationTime.Add(10 * time.Second)},
+						{PreviousState: "Pending (Error)", State: "Alerting (Error)", At: evaluationTime.Add(40 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(40 * time.Second),
 					EndsAt:             evaluationTime.Add(40 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(40 * time.Second),
@@ -1620,6 +1658,9 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Error", At: evaluationTime.Add(10 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(10 * time.Second),
 					EndsAt:             evaluationTime.Add(10 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
@@ -1699,6 +1740,9 @@ func TestProcessEvalResults(t *testing.T) {
 					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
 					EvaluationDuration: evaluationDuration,
 					Annotations:        map[string]string{"annotation": "test"},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Normal (Error)", At: evaluationTime.Add(10 * time.Second)},
+					},
 				},
 			},
 		},
@@ -1770,6 +1814,9 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Pending", State: "Normal (Error)", At: evaluationTime.Add(10 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(10 * time.Second),
 					EndsAt:             evaluationTime.Add(10 * time.Second),
 					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
@@ -1868,6 +1915,10 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Pending", State: "Alerting", At: evaluationTime.Add(20 * time.Second)},
+						{PreviousState: "Alerting", State: "Error", At: evaluationTime.Add(30 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(30 * time.Second),
 					EndsAt:             evaluationTime.Add(50 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(50 * time.Second),
@@ -1955,6 +2006,11 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Pending", At: evaluationTime.Add(30 * time.Second)},
+						{PreviousState: "Pending", State: "Error", At: evaluationTime.Add(40 * time.Second)},
+						{PreviousState: "Error", State: "Pending", At: evaluationTime.Add(70 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(70 * time.Second),
 					EndsAt:             evaluationTime.Add(70 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(70 * time.Second),
@@ -2043,6 +2099,11 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
+					Transitions: []models.AlertStateTransition{
+						{PreviousState: "Normal", State: "Pending", At: evaluationTime.Add(30 * time.Second)},
+						{PreviousState: "Pending", State: "Error", At: evaluationTime.Add(40 * time.Second)},
+						{PreviousState: "Error", State: "NoData", At: evaluationTime.Add(50 * time.Second)},
+					},
 					StartsAt:           evaluationTime.Add(30 * time.Second),
 					EndsAt:             evaluationTime.Add(50 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(50 * time.Second),
@@ -58,6 +58,10 @@ type State struct {
 	// conditions.
 	Values map[string]float64
 
+	// Transitions contains the most recent changes of the state, oldest first. Changes from
+	// the initial state of a new alert instance are not recorded.
+	Transitions []models.AlertStateTransition
+
 	StartsAt             time.Time
 	EndsAt               time.Time
 	LastSentAt           time.Time
@@ -313,6 +317,21 @@ func (a *State) TrimResults(alertRule *models.AlertRule) {
 	a.Results = newResults
 }
 
+// maxStateTransitions is the number of transitions kept in the history of a state.
+const maxStateTransitions = 10
+
+// addTransition records a change of the state, dropping the oldest one once the history is full.
+func (a *State) addTransition(previousState eval.State, previousReason string, at time.Time) {
+	a.Transitions = append(a.Transitions, models.AlertStateTransition{
+		PreviousState: FormatStateAndReason(previousState, previousReason),
+		State:         FormatStateAndReason(a.State, a.StateReason),
+		At:            at,
+	})
+	if len(a.Transitions) > maxStateTransitions {
+		a.Transitions = a.Transitions[len(a.Transitions)-maxStateTransitions:]
+	}
+}
+
 func nextEndsTime(interval int64, evaluatedAt time.Time) time.Time {
 	ends := ResendDelay
 	intv := time.Second * time.Duration(interval)
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// InMemoryImageStore is an ImageAdminStore that keeps images in memory, for single-node
+// setups where images do not need to be shared with other instances or survive a restart.
+// The store is bounded: once it holds more than the max count of images or the max size,
+// the least recently saved or requested images are evicted. It is safe to use concurrently.
+type InMemoryImageStore struct {
+	retention time.Duration
+	maxCount  int
+	maxSize   int64
+
+	mtx    sync.Mutex
+	nextID int64
+	size   int64
+	// lru holds the images, the most recently used first.
+	lru    *list.List
+	images map[string]*list.Element
+}
+
+// NewInMemoryImageStore returns an in-memory store where images expire after retention,
+// or after 24 hours like in the database if retention is 0. The store holds at most
+// maxCount images and maxSize bytes of images, as approximated by imageSize. A maxCount
+// or maxSize of 0 means no limit.
+func NewInMemoryImageStore(retention time.Duration, maxCount int, maxSize int64) *InMemoryImageStore {
+	if retention <= 0 {
+		retention = imageExpirationDuration
+	}
+	return &InMemoryImageStore{
+		retention: retention,
+		maxCount:  maxCount,
+		maxSize:   maxSize,
+		lru:       list.New(),
+		images:    make(map[string]*list.Element),
+	}
+}
+
+func (s *InMemoryImageStore) GetImage(_ context.Context, token string) (*models.Image, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	image, ok := s.get(token)
+	if !ok {
+		return nil, models.ErrImageNotFound
+	}
+	return &image, nil
+}
+
+func (s *InMemoryImageStore) GetImages(_ context.Context, tokens []string) ([]models.Image, []string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	images := make([]models.Image, 0, len(tokens))
+	for _, token := range tokens {
+		if image, ok := s.get(token); ok {
+			images = append(images, image)
+		}
+	}
+	if len(images) < len(tokens) {
+		return images, unmatchedTokens(tokens, images), models.ErrImageNotFound
+	}
+	return images, nil, nil
+}
+
+// get returns a copy of the image with the token, and marks it as the most recently used.
+// Expired images are removed. It must be called with the mutex held.
+func (s *InMemoryImageStore) get(token string) (models.Image, bool) {
+	e, ok := s.images[token]
+	if !ok {
+		return models.Image{}, false
+	}
+	image := e.Value.(*models.Image)
+	if !image.ExpiresAt.After(TimeNow().UTC()) {
+		s.remove(e)
+		return models.Image{}, false
+	}
+	s.lru.MoveToFront(e)
+	return *image, true
+}
+
+// SaveImage saves the image like the database store: a new image, with an ID of zero, gets
+// an ID, a token and an expiration time, while an existing image is updated without
+// resetting its expiration time. Saving an image can evict other images.
+func (s *InMemoryImageStore) SaveImage(_ context.Context, img *models.Image) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if img.ID == 0 {
+		token, err := uuid.NewRandom()
+		if err != nil {
+			return fmt.Errorf("failed to create token: %w", err)
+		}
+		s.nextID++
+		img.ID = s.nextID
+		img.Token = token.String()
+		img.CreatedAt = TimeNow().UTC()
+		img.ExpiresAt = img.CreatedAt.Add(s.retention)
+		stored := *img
+		s.images[img.Token] = s.lru.PushFront(&stored)
+		s.size += imageSize(&stored)
+	} else {
+		e, ok := s.images[img.Token]
+		if !ok || e.Value.(*models.Image).ID != img.ID {
+			return models.ErrImageNotFound
+		}
+		stored := e.Value.(*models.Image)
+		s.size += imageSize(img) - imageSize(stored)
+		*stored = *img
+		s.lru.MoveToFront(e)
+	}
+
+	s.evict()
+	return nil
+}
+
+// DeleteExpiredImages deletes expired images. It returns the number of deleted images.
+func (s *InMemoryImageStore) DeleteExpiredImages(_ context.Context) (int64, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := TimeNow().UTC()
+	var n int64
+	for e := s.lru.Front(); e != nil; {
+		next := e.Next()
+		if e.Value.(*models.Image).ExpiresAt.Before(now) {
+			s.remove(e)
+			n++
+		}
+		e = next
+	}
+	return n, nil
+}
+
+// evict removes the least recently used images until the store is within its limits. The
+// most recently used image is never evicted, even if it is larger than the max size on its
+// own. It must be called with the mutex held.
+func (s *InMemoryImageStore) evict() {
+	for s.lru.Len() > 1 && ((s.maxCount > 0 && s.lru.Len() > s.maxCount) || (s.maxSize > 0 && s.size > s.maxSize)) {
+		s.remove(s.lru.Back())
+	}
+}
+
+// remove removes the image from the store. It must be called with the mutex held.
+func (s *InMemoryImageStore) remove(e *list.Element) {
+	image := s.lru.Remove(e).(*models.Image)
+	delete(s.images, image.Token)
+	s.size -= imageSize(image)
+}
+
+// imageSize returns the approximate size in bytes of the image in memory. The store only
+// holds the path and the URL of images, not their content.
+func imageSize(img *models.Image) int64 {
+	return int64(len(img.Token) + len(img.Path) + len(img.URL))
+}
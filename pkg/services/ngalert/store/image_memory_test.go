@@ -0,0 +1,129 @@
+package store_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+func TestInMemoryImageStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("saves and gets images", func(t *testing.T) {
+		s := store.NewInMemoryImageStore(0, 0, 0)
+
+		image := models.Image{Path: "example.png"}
+		require.NoError(t, s.SaveImage(ctx, &image))
+		require.NotEqual(t, int64(0), image.ID)
+		require.NotEqual(t, "", image.Token)
+		assert.Equal(t, image.CreatedAt.Add(24*time.Hour), image.ExpiresAt)
+
+		result, err := s.GetImage(ctx, image.Token)
+		require.NoError(t, err)
+		assert.Equal(t, image, *result)
+
+		// saving the image again updates it without changing its expiration time
+		ts := image.ExpiresAt
+		image.URL = "https://example.com/example.png"
+		require.NoError(t, s.SaveImage(ctx, &image))
+		assert.Equal(t, ts, image.ExpiresAt)
+		result, err = s.GetImage(ctx, image.Token)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/example.png", result.URL)
+
+		_, err = s.GetImage(ctx, "unknown")
+		assert.ErrorIs(t, err, models.ErrImageNotFound)
+
+		images, unmatched, err := s.GetImages(ctx, []string{image.Token, "unknown"})
+		assert.ErrorIs(t, err, models.ErrImageNotFound)
+		assert.Equal(t, []models.Image{image}, images)
+		assert.Equal(t, []string{"unknown"}, unmatched)
+
+		// an unknown image cannot be updated
+		err = s.SaveImage(ctx, &models.Image{ID: 100, Token: "unknown"})
+		assert.ErrorIs(t, err, models.ErrImageNotFound)
+	})
+
+	t.Run("evicts the least recently used images when the max count is exceeded", func(t *testing.T) {
+		s := store.NewInMemoryImageStore(0, 2, 0)
+
+		image1 := models.Image{Path: "1.png"}
+		image2 := models.Image{Path: "2.png"}
+		image3 := models.Image{Path: "3.png"}
+		require.NoError(t, s.SaveImage(ctx, &image1))
+		require.NoError(t, s.SaveImage(ctx, &image2))
+		// image1 is used after image2, so image2 is evicted
+		_, err := s.GetImage(ctx, image1.Token)
+		require.NoError(t, err)
+		require.NoError(t, s.SaveImage(ctx, &image3))
+
+		_, err = s.GetImage(ctx, image2.Token)
+		assert.ErrorIs(t, err, models.ErrImageNotFound)
+		images, _, err := s.GetImages(ctx, []string{image1.Token, image3.Token})
+		require.NoError(t, err)
+		assert.Equal(t, []models.Image{image1, image3}, images)
+	})
+
+	t.Run("evicts the least recently used images when the max size is exceeded", func(t *testing.T) {
+		// a token is 36 bytes, so the store holds two images with a URL of 64 bytes
+		s := store.NewInMemoryImageStore(0, 0, 250)
+		url := "https://example.com/" + strings.Repeat("x", 40) + ".png"
+
+		image1 := models.Image{URL: url}
+		image2 := models.Image{URL: url}
+		require.NoError(t, s.SaveImage(ctx, &image1))
+		require.NoError(t, s.SaveImage(ctx, &image2))
+		_, err := s.GetImage(ctx, image1.Token)
+		require.NoError(t, err)
+
+		image3 := models.Image{URL: url}
+		require.NoError(t, s.SaveImage(ctx, &image3))
+		_, err = s.GetImage(ctx, image2.Token)
+		assert.ErrorIs(t, err, models.ErrImageNotFound)
+		_, err = s.GetImage(ctx, image1.Token)
+		assert.NoError(t, err)
+
+		// a larger update of an image evicts the other images
+		image3.Path = strings.Repeat("x", 100) + ".png"
+		require.NoError(t, s.SaveImage(ctx, &image3))
+		_, err = s.GetImage(ctx, image1.Token)
+		assert.ErrorIs(t, err, models.ErrImageNotFound)
+		_, err = s.GetImage(ctx, image3.Token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("images expire after the retention", func(t *testing.T) {
+		now := time.Now()
+		store.TimeNow = func() time.Time { return now }
+		t.Cleanup(func() { store.TimeNow = time.Now })
+
+		s := store.NewInMemoryImageStore(time.Hour, 0, 0)
+		image1 := models.Image{Path: "1.png"}
+		require.NoError(t, s.SaveImage(ctx, &image1))
+		assert.Equal(t, image1.CreatedAt.Add(time.Hour), image1.ExpiresAt)
+
+		now = now.Add(30 * time.Minute)
+		image2 := models.Image{Path: "2.png"}
+		require.NoError(t, s.SaveImage(ctx, &image2))
+
+		now = now.Add(31 * time.Minute)
+		_, err := s.GetImage(ctx, image1.Token)
+		assert.ErrorIs(t, err, models.ErrImageNotFound)
+		_, err = s.GetImage(ctx, image2.Token)
+		assert.NoError(t, err)
+
+		now = now.Add(time.Hour)
+		n, err := s.DeleteExpiredImages(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = s.GetImage(ctx, image2.Token)
+		assert.ErrorIs(t, err, models.ErrImageNotFound)
+	})
+}
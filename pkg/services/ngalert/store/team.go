@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+
+	"github.com/prometheus/alertmanager/config"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+)
+
+// GetTeamMuteTimings returns the mute timings in the preferences of the team of the org with
+// the given name. It returns no mute timings if there is no such team.
+func (st DBstore) GetTeamMuteTimings(ctx context.Context, orgID int64, team string) ([]config.MuteTimeInterval, error) {
+	var rows []struct {
+		JSONData *pref.PreferenceJSONData `xorm:"json_data"`
+	}
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := "SELECT p.json_data FROM preferences AS p INNER JOIN team AS t ON t.id = p.team_id AND t.org_id = p.org_id WHERE p.org_id = ? AND p.user_id = 0 AND t.name = ?"
+		return sess.SQL(q, orgID, team).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var muteTimings []config.MuteTimeInterval
+	for _, r := range rows {
+		if r.JSONData != nil {
+			muteTimings = append(muteTimings, r.JSONData.MuteTimings...)
+		}
+	}
+	return muteTimings, nil
+}
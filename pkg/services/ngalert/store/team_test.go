@@ -0,0 +1,58 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+)
+
+func TestIntegrationGetTeamMuteTimings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	muteTimings := []config.MuteTimeInterval{{
+		Name:          "maintenance",
+		TimeIntervals: []timeinterval.TimeInterval{{Times: []timeinterval.TimeRange{{StartMinute: 60, EndMinute: 120}}}},
+	}}
+	err := dbstore.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		team := &models.Team{OrgId: 1, Name: "ops", Created: time.Now(), Updated: time.Now()}
+		if _, err := sess.Insert(team); err != nil {
+			return err
+		}
+		_, err := sess.Insert(&pref.Preference{
+			OrgID:    1,
+			TeamID:   team.Id,
+			Created:  time.Now(),
+			Updated:  time.Now(),
+			JSONData: &pref.PreferenceJSONData{MuteTimings: muteTimings},
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	result, err := dbstore.GetTeamMuteTimings(ctx, 1, "ops")
+	require.NoError(t, err)
+	require.Equal(t, muteTimings, result)
+
+	// teams are looked up by name in the org of the alerts
+	result, err = dbstore.GetTeamMuteTimings(ctx, 2, "ops")
+	require.NoError(t, err)
+	require.Empty(t, result)
+
+	result, err = dbstore.GetTeamMuteTimings(ctx, 1, "dev")
+	require.NoError(t, err)
+	require.Empty(t, result)
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// GetTemplate returns the content of the notification template of the org with the given
+// name, from the latest Alertmanager configuration of the org. It returns false if there is
+// no such template.
+func (st *DBstore) GetTemplate(ctx context.Context, orgID int64, name string) (string, bool, error) {
+	q := models.GetLatestAlertmanagerConfigurationQuery{OrgID: orgID}
+	if err := st.GetLatestAlertmanagerConfiguration(ctx, &q); err != nil {
+		if errors.Is(err, ErrNoAlertmanagerConfiguration) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	var cfg struct {
+		TemplateFiles map[string]string `json:"template_files"`
+	}
+	if err := json.Unmarshal([]byte(q.Result.AlertmanagerConfiguration), &cfg); err != nil {
+		return "", false, fmt.Errorf("failed to parse the Alertmanager configuration: %w", err)
+	}
+	content, ok := cfg.TemplateFiles[name]
+	return content, ok, nil
+}
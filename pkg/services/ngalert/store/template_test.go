@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestIntegrationGetTemplate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Logger:   log.NewNopLogger(),
+	}
+
+	t.Run("no template is found without a configuration", func(t *testing.T) {
+		_, found, err := store.GetTemplate(context.Background(), 1, "ops-message")
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+
+	_, _ = setupConfig(t, `{"template_files": {"ops-message": "{{ define \"ops-message\" }}v1{{ end }}"}}`, store)
+	_, _ = setupConfig(t, `{"template_files": {"ops-message": "{{ define \"ops-message\" }}v2{{ end }}"}}`, store)
+
+	t.Run("the template of the latest configuration is returned", func(t *testing.T) {
+		content, found, err := store.GetTemplate(context.Background(), 1, "ops-message")
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, `{{ define "ops-message" }}v2{{ end }}`, content)
+	})
+
+	t.Run("templates of other orgs are not found", func(t *testing.T) {
+		_, found, err := store.GetTemplate(context.Background(), 2, "ops-message")
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+
+	t.Run("missing templates are not found", func(t *testing.T) {
+		_, found, err := store.GetTemplate(context.Background(), 1, "missing")
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+}
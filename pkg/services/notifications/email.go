@@ -13,13 +13,30 @@ type AttachedFile struct {
 
 // Message is representation of the email message.
 type Message struct {
-	To            []string
-	SingleEmail   bool
-	From          string
-	Subject       string
-	Body          map[string]string
-	Info          string
-	ReplyTo       []string
+	To          []string
+	SingleEmail bool
+	From        string
+	Subject     string
+	Body        map[string]string
+	Info        string
+	ReplyTo     []string
+	Cc          []string
+	Bcc         []string
+	Headers     map[string]string
+	// SkipArchive does not send a copy of the email to the archive address of the org.
+	SkipArchive bool
+	// OptOut are the addresses removed from the blind carbon copies of the email.
+	OptOut []string
+	// TransferEncoding is the Content-Transfer-Encoding of the body, quoted-printable or
+	// base64. Quoted-printable is used if it is empty.
+	TransferEncoding string
+	// Charset is the charset of the body, UTF-8 or ISO-8859-1. UTF-8 is used if it is empty.
+	Charset string
+	// SMIMECertFile and SMIMEKeyFile sign the email with S/MIME, if set.
+	SMIMECertFile string
+	SMIMEKeyFile  string
+	// OrgID selects the SMTP settings of the org the email is sent with, if it has any.
+	OrgID         int64
 	EmbeddedFiles []string
 	AttachedFiles []*AttachedFile
 }
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/mail"
+	"strings"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/prometheus/client_golang/prometheus"
@@ -42,16 +43,61 @@ func (ns *NotificationService) Send(msg *Message) (int, error) {
 	messages := []*Message{}
 
 	if msg.SingleEmail {
-		messages = append(messages, msg)
+		copy := *msg
+		messages = append(messages, &copy)
 	} else {
-		for _, address := range msg.To {
+		for i, address := range msg.To {
 			copy := *msg
 			copy.To = []string{address}
+			// Copies are only sent once, not for every recipient.
+			if i > 0 {
+				copy.Cc = nil
+				copy.Bcc = nil
+			}
 			messages = append(messages, &copy)
 		}
 	}
 
-	return ns.mailer.Send(messages...)
+	// Orgs that archive their emails get a blind carbon copy of every message, regardless of
+	// the carbon copies of the message. It is sent once, not for every recipient.
+	if archive := ns.Cfg.Smtp.ForOrg(msg.OrgID).ArchiveAddress; archive != "" && !msg.SkipArchive && len(messages) > 0 {
+		messages[0].Bcc = append(append([]string(nil), messages[0].Bcc...), archive)
+	}
+	// The blind carbon copies are not sent to addresses that opted out, e.g. an archive
+	// address that opted out of alert emails.
+	for _, m := range messages {
+		m.Bcc = removeOptedOut(m.Bcc, msg.OptOut)
+	}
+
+	return ns.mailerForOrg(msg.OrgID).Send(messages...)
+}
+
+// removeOptedOut returns the addresses without the ones in optOut, compared regardless of
+// their case.
+func removeOptedOut(addresses []string, optOut []string) []string {
+	if len(optOut) == 0 {
+		return addresses
+	}
+	var kept []string
+next:
+	for _, a := range addresses {
+		for _, o := range optOut {
+			if strings.EqualFold(a, o) {
+				continue next
+			}
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
+
+// mailerForOrg returns the mailer of the org if it has its own SMTP settings, or the
+// global mailer otherwise.
+func (ns *NotificationService) mailerForOrg(orgID int64) Mailer {
+	if m, ok := ns.orgMailers[orgID]; ok {
+		return m
+	}
+	return ns.mailer
 }
 
 func (ns *NotificationService) buildEmailMessage(cmd *models.SendEmailCommand) (*Message, error) {
@@ -105,16 +151,31 @@ func (ns *NotificationService) buildEmailMessage(cmd *models.SendEmailCommand) (
 		subject = subjectBuffer.String()
 	}
 
-	addr := mail.Address{Name: ns.Cfg.Smtp.FromName, Address: ns.Cfg.Smtp.FromAddress}
+	smtp := ns.Cfg.Smtp.ForOrg(cmd.OrgID)
+	addr := mail.Address{Name: smtp.FromName, Address: smtp.FromAddress}
+	var bcc []string
+	if cmd.CopyToSender {
+		bcc = append(bcc, addr.Address)
+	}
 	return &Message{
-		To:            cmd.To,
-		SingleEmail:   cmd.SingleEmail,
-		From:          addr.String(),
-		Subject:       subject,
-		Body:          body,
-		EmbeddedFiles: cmd.EmbeddedFiles,
-		AttachedFiles: buildAttachedFiles(cmd.AttachedFiles),
-		ReplyTo:       cmd.ReplyTo,
+		To:               cmd.To,
+		SingleEmail:      cmd.SingleEmail,
+		From:             addr.String(),
+		Subject:          subject,
+		Body:             body,
+		EmbeddedFiles:    cmd.EmbeddedFiles,
+		AttachedFiles:    buildAttachedFiles(cmd.AttachedFiles),
+		ReplyTo:          cmd.ReplyTo,
+		Cc:               cmd.Cc,
+		Bcc:              bcc,
+		SkipArchive:      cmd.SkipArchive,
+		OptOut:           cmd.OptOut,
+		Headers:          cmd.Headers,
+		TransferEncoding: cmd.TransferEncoding,
+		Charset:          cmd.Charset,
+		SMIMECertFile:    cmd.SMIMECertFile,
+		SMIMEKeyFile:     cmd.SMIMEKeyFile,
+		OrgID:            cmd.OrgID,
 	}, nil
 }
 
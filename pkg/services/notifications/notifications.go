@@ -44,6 +44,7 @@ func ProvideService(bus bus.Bus, cfg *setting.Cfg, mailer Mailer, store TempUser
 		mailQueue:    make(chan *Message, 10),
 		webhookQueue: make(chan *Webhook, 10),
 		mailer:       mailer,
+		orgMailers:   make(map[int64]Mailer, len(cfg.Smtp.Orgs)),
 		store:        store,
 	}
 
@@ -68,6 +69,23 @@ func ProvideService(bus bus.Bus, cfg *setting.Cfg, mailer Mailer, store TempUser
 		return nil, errors.New("invalid email address for SMTP from_address config")
 	}
 
+	for orgID, smtp := range ns.Cfg.Smtp.Orgs {
+		if !util.IsEmail(smtp.FromAddress) {
+			return nil, fmt.Errorf("invalid email address for SMTP from_address config of org %d", orgID)
+		}
+		orgMailer, err := NewSmtpClient(smtp)
+		if err != nil {
+			return nil, err
+		}
+		ns.orgMailers[orgID] = orgMailer
+	}
+
+	webhookTargets, err := newWebhookTargetPolicy(cfg.WebhookAllowedHosts, cfg.WebhookBlockPrivateIPs)
+	if err != nil {
+		return nil, err
+	}
+	ns.webhookTargets = webhookTargets
+
 	if cfg.EmailCodeValidMinutes == 0 {
 		cfg.EmailCodeValidMinutes = 120
 	}
@@ -85,8 +103,13 @@ type NotificationService struct {
 	mailQueue    chan *Message
 	webhookQueue chan *Webhook
 	mailer       Mailer
-	log          log.Logger
-	store        TempUserStore
+	// orgMailers are the mailers of orgs that have their own SMTP settings.
+	orgMailers map[int64]Mailer
+	// webhookTargets restricts the addresses webhooks are sent to, it is nil if all are allowed.
+	webhookTargets *webhookTargetPolicy
+	webhookPools   webhookConnectionPools
+	log            log.Logger
+	store          TempUserStore
 }
 
 func (ns *NotificationService) Run(ctx context.Context) error {
@@ -122,14 +145,19 @@ func (ns *NotificationService) GetMailer() Mailer {
 
 func (ns *NotificationService) SendWebhookSync(ctx context.Context, cmd *models.SendWebhookSync) error {
 	return ns.sendWebRequestSync(ctx, &Webhook{
-		Url:         cmd.Url,
-		User:        cmd.User,
-		Password:    cmd.Password,
-		Body:        cmd.Body,
-		HttpMethod:  cmd.HttpMethod,
-		HttpHeader:  cmd.HttpHeader,
-		ContentType: cmd.ContentType,
-		Validation:  cmd.Validation,
+		Url:             cmd.Url,
+		User:            cmd.User,
+		Password:        cmd.Password,
+		Body:            cmd.Body,
+		HttpMethod:      cmd.HttpMethod,
+		HttpHeader:      cmd.HttpHeader,
+		ContentType:     cmd.ContentType,
+		Timeout:         cmd.Timeout,
+		ConnectTimeout:  cmd.ConnectTimeout,
+		ConnectionPool:  cmd.ConnectionPool,
+		MaxIdleConns:    cmd.MaxIdleConns,
+		IdleConnTimeout: cmd.IdleConnTimeout,
+		Validation:      cmd.Validation,
 	})
 }
 
@@ -140,15 +168,25 @@ func subjectTemplateFunc(obj map[string]interface{}, value string) string {
 
 func (ns *NotificationService) SendEmailCommandHandlerSync(ctx context.Context, cmd *models.SendEmailCommandSync) error {
 	message, err := ns.buildEmailMessage(&models.SendEmailCommand{
-		Data:          cmd.Data,
-		Info:          cmd.Info,
-		Template:      cmd.Template,
-		To:            cmd.To,
-		SingleEmail:   cmd.SingleEmail,
-		EmbeddedFiles: cmd.EmbeddedFiles,
-		AttachedFiles: cmd.AttachedFiles,
-		Subject:       cmd.Subject,
-		ReplyTo:       cmd.ReplyTo,
+		Data:             cmd.Data,
+		Info:             cmd.Info,
+		Template:         cmd.Template,
+		To:               cmd.To,
+		SingleEmail:      cmd.SingleEmail,
+		EmbeddedFiles:    cmd.EmbeddedFiles,
+		AttachedFiles:    cmd.AttachedFiles,
+		Subject:          cmd.Subject,
+		ReplyTo:          cmd.ReplyTo,
+		Cc:               cmd.Cc,
+		CopyToSender:     cmd.CopyToSender,
+		SkipArchive:      cmd.SkipArchive,
+		OptOut:           cmd.OptOut,
+		Headers:          cmd.Headers,
+		TransferEncoding: cmd.TransferEncoding,
+		Charset:          cmd.Charset,
+		SMIMECertFile:    cmd.SMIMECertFile,
+		SMIMEKeyFile:     cmd.SMIMEKeyFile,
+		OrgID:            cmd.OrgID,
 	})
 
 	if err != nil {
@@ -31,6 +31,16 @@ func TestProvideService(t *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("When invalid from_address in the configuration of an org", func(t *testing.T) {
+		cfg := createSmtpConfig()
+		org := cfg.Smtp
+		org.FromAddress = "@notanemail@"
+		cfg.Smtp.Orgs = map[int64]setting.SmtpSettings{2: org}
+		_, _, err := createSutWithConfig(t, bus, cfg)
+
+		require.EqualError(t, err, "invalid email address for SMTP from_address config of org 2")
+	})
+
 	t.Run("When template_patterns fails to parse", func(t *testing.T) {
 		cfg := createSmtpConfig()
 		cfg.Smtp.TemplatesPatterns = append(cfg.Smtp.TemplatesPatterns, "/usr/not-a-dir/**")
@@ -179,6 +189,113 @@ func TestSendEmailSync(t *testing.T) {
 
 		require.Error(t, err)
 	})
+
+	t.Run("When orgs have their own SMTP settings", func(t *testing.T) {
+		cfg := createSmtpConfig()
+		org2 := cfg.Smtp
+		org2.Host = "smtp.org2.com:25"
+		org2.FromAddress = "alerts@org2.com"
+		org3 := cfg.Smtp
+		org3.Host = "smtp.org3.com:25"
+		org3.FromAddress = "alerts@org3.com"
+		cfg.Smtp.Orgs = map[int64]setting.SmtpSettings{2: org2, 3: org3}
+
+		ns, mailer, err := createSutWithConfig(t, bus, cfg)
+		require.NoError(t, err)
+		org2Mailer, org3Mailer := NewFakeMailer(), NewFakeMailer()
+		ns.orgMailers = map[int64]Mailer{2: org2Mailer, 3: org3Mailer}
+
+		for _, orgID := range []int64{1, 2, 3} {
+			err := ns.SendEmailCommandHandlerSync(context.Background(), &models.SendEmailCommandSync{
+				SendEmailCommand: models.SendEmailCommand{
+					Subject:     "subject",
+					To:          []string{"asdf@grafana.com"},
+					SingleEmail: true,
+					Template:    "welcome_on_signup",
+					OrgID:       orgID,
+				},
+			})
+			require.NoError(t, err)
+		}
+
+		// Orgs without their own SMTP settings use the global relay.
+		require.Len(t, mailer.Sent, 1)
+		require.Equal(t, int64(1), mailer.Sent[0].OrgID)
+		require.Equal(t, `"Grafana Admin" <from@address.com>`, mailer.Sent[0].From)
+		require.Len(t, org2Mailer.Sent, 1)
+		require.Equal(t, `"Grafana Admin" <alerts@org2.com>`, org2Mailer.Sent[0].From)
+		require.Len(t, org3Mailer.Sent, 1)
+		require.Equal(t, `"Grafana Admin" <alerts@org3.com>`, org3Mailer.Sent[0].From)
+	})
+
+	t.Run("When orgs archive their emails", func(t *testing.T) {
+		cfg := createSmtpConfig()
+		org2 := cfg.Smtp
+		org2.ArchiveAddress = "archive@org2.com"
+		cfg.Smtp.Orgs = map[int64]setting.SmtpSettings{2: org2}
+
+		ns, mailer, err := createSutWithConfig(t, bus, cfg)
+		require.NoError(t, err)
+		org2Mailer := NewFakeMailer()
+		ns.orgMailers = map[int64]Mailer{2: org2Mailer}
+
+		send := func(orgID int64) {
+			t.Helper()
+			err := ns.SendEmailCommandHandlerSync(context.Background(), &models.SendEmailCommandSync{
+				SendEmailCommand: models.SendEmailCommand{
+					Subject:      "subject",
+					To:           []string{"1@grafana.com", "2@grafana.com"},
+					SingleEmail:  false,
+					Template:     "welcome_on_signup",
+					CopyToSender: true,
+					OrgID:        orgID,
+				},
+			})
+			require.NoError(t, err)
+		}
+
+		// Every email of an org with the policy is archived once, not for every recipient.
+		send(2)
+		require.Len(t, org2Mailer.Sent, 2)
+		require.Equal(t, []string{"from@address.com", "archive@org2.com"}, org2Mailer.Sent[0].Bcc)
+		require.Empty(t, org2Mailer.Sent[1].Bcc)
+
+		// Emails of orgs without the policy are not archived.
+		send(1)
+		require.Len(t, mailer.Sent, 2)
+		require.Equal(t, []string{"from@address.com"}, mailer.Sent[0].Bcc)
+		require.Empty(t, mailer.Sent[1].Bcc)
+	})
+
+	t.Run("When blind carbon copy recipients opted out", func(t *testing.T) {
+		cfg := createSmtpConfig()
+		cfg.Smtp.ArchiveAddress = "archive@grafana.com"
+
+		ns, mailer, err := createSutWithConfig(t, bus, cfg)
+		require.NoError(t, err)
+
+		send := func(optOut ...string) {
+			t.Helper()
+			err := ns.SendEmailCommandHandlerSync(context.Background(), &models.SendEmailCommandSync{
+				SendEmailCommand: models.SendEmailCommand{
+					Subject:      "subject",
+					To:           []string{"1@grafana.com"},
+					Template:     "welcome_on_signup",
+					CopyToSender: true,
+					OptOut:       optOut,
+				},
+			})
+			require.NoError(t, err)
+		}
+
+		send("ARCHIVE@grafana.com")
+		require.Len(t, mailer.Sent, 1)
+		require.Equal(t, []string{"from@address.com"}, mailer.Sent[0].Bcc)
+
+		send("from@address.com", "archive@grafana.com")
+		require.Len(t, mailer.Sent, 2)
+		require.Empty(t, mailer.Sent[1].Bcc)
+	})
 }
 
 func TestSendEmailAsync(t *testing.T) {
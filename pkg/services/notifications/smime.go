@@ -0,0 +1,263 @@
+package notifications
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	oidData              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAttrContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA256            = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// smimeSigner signs emails with S/MIME, as multipart/signed messages with a detached
+// PKCS #7 signature of the content.
+type smimeSigner struct {
+	cert  *x509.Certificate
+	chain [][]byte
+	key   crypto.Signer
+	now   func() time.Time
+}
+
+// newSMIMESigner loads the PEM certificate and private key files emails are signed with.
+// Only RSA and ECDSA keys are supported.
+func newSMIMESigner(certFile, keyFile string) (*smimeSigner, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load S/MIME cert or key file: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("could not parse S/MIME cert: %w", err)
+	}
+	switch pair.PrivateKey.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+	default:
+		return nil, fmt.Errorf("unsupported S/MIME key type %T, must be RSA or ECDSA", pair.PrivateKey)
+	}
+	return &smimeSigner{
+		cert:  cert,
+		chain: pair.Certificate,
+		key:   pair.PrivateKey.(crypto.Signer),
+		now:   time.Now,
+	}, nil
+}
+
+// sign returns the email as a multipart/signed message. The Content-* headers of the email
+// and its body are the signed content, the other headers are kept at the top.
+func (s *smimeSigner) sign(email []byte) ([]byte, error) {
+	headers, content, err := splitContentHeaders(email)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.signature(content)
+	if err != nil {
+		return nil, err
+	}
+
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.Write(headers)
+	fmt.Fprintf(&b, "Content-Type: multipart/signed; protocol=\"application/pkcs7-signature\"; micalg=sha-256; boundary=%q\r\n\r\n", boundary)
+	b.WriteString("This is an S/MIME signed message\r\n")
+	fmt.Fprintf(&b, "\r\n--%s\r\n", boundary)
+	b.Write(content)
+	fmt.Fprintf(&b, "\r\n--%s\r\n", boundary)
+	b.WriteString("Content-Type: application/pkcs7-signature; name=\"smime.p7s\"\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n")
+	b.WriteString("Content-Disposition: attachment; filename=\"smime.p7s\"\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString(signature)
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	fmt.Fprintf(&b, "\r\n--%s--\r\n", boundary)
+	return b.Bytes(), nil
+}
+
+// splitContentHeaders splits the email into its headers other than the Content-* headers,
+// and the MIME entity made of the Content-* headers and the body.
+func splitContentHeaders(email []byte) (headers []byte, content []byte, err error) {
+	i := bytes.Index(email, []byte("\r\n\r\n"))
+	if i < 0 {
+		return nil, nil, errors.New("email has no body")
+	}
+	var other, entity bytes.Buffer
+	// The current header, so that folded lines are kept with the header they continue.
+	current := &other
+	scanner := bufio.NewScanner(bytes.NewReader(email[:i+2]))
+	scanner.Buffer(nil, len(email))
+	scanner.Split(scanCRLFLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			current = &other
+			if strings.HasPrefix(strings.ToLower(line), "content-") {
+				current = &entity
+			}
+		}
+		current.WriteString(line + "\r\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	entity.WriteString("\r\n")
+	entity.Write(email[i+4:])
+	return other.Bytes(), entity.Bytes(), nil
+}
+
+func scanCRLFLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.Index(data, []byte("\r\n")); i >= 0 {
+		return i + 2, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func randomBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue `asn1:"optional"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerialNumber
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// signature returns the DER encoded PKCS #7 signed data of the content, without the content.
+func (s *smimeSigner) signature(content []byte) ([]byte, error) {
+	digest := sha256.Sum256(content)
+	attrs, err := signedAttributes(digest[:], s.now())
+	if err != nil {
+		return nil, err
+	}
+	// The attributes are signed as a SET, but are an implicitly tagged field of the signer info.
+	signed, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attrs})
+	if err != nil {
+		return nil, err
+	}
+	signedDigest := sha256.Sum256(signed)
+	sig, err := s.key.Sign(rand.Reader, signedDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign email: %w", err)
+	}
+
+	sigAlg := pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+	if _, ok := s.key.(*ecdsa.PrivateKey); ok {
+		sigAlg = pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	}
+	digestAlg := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	sd, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{digestAlg},
+		ContentInfo:      contentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: bytes.Join(s.chain, nil)},
+		SignerInfos: []signerInfo{{
+			Version: 1,
+			IssuerAndSerialNumber: issuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: s.cert.RawIssuer},
+				SerialNumber: s.cert.SerialNumber,
+			},
+			DigestAlgorithm:           digestAlg,
+			AuthenticatedAttributes:   asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs},
+			DigestEncryptionAlgorithm: sigAlg,
+			EncryptedDigest:           sig,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+// signedAttributes returns the encoded content type, signing time and message digest
+// attributes, sorted as required by DER for the elements of a SET.
+func signedAttributes(digest []byte, signingTime time.Time) ([]byte, error) {
+	values := []struct {
+		oid   asn1.ObjectIdentifier
+		value interface{}
+	}{
+		{oidAttrContentType, oidData},
+		{oidAttrSigningTime, signingTime.UTC()},
+		{oidAttrMessageDigest, digest},
+	}
+	encoded := make([][]byte, 0, len(values))
+	for _, v := range values {
+		value, err := asn1.Marshal(v.value)
+		if err != nil {
+			return nil, err
+		}
+		attr, err := asn1.Marshal(attribute{Type: v.oid, Values: []asn1.RawValue{{FullBytes: value}}})
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, attr)
+	}
+	sort.Slice(encoded, func(i, j int) bool {
+		return bytes.Compare(encoded[i], encoded[j]) < 0
+	})
+	return bytes.Join(encoded, nil), nil
+}
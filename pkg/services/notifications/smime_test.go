@@ -0,0 +1,152 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSMIMEKeyPair writes a self-signed certificate and its RSA key to PEM files.
+func writeSMIMEKeyPair(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(42),
+		Subject:        pkix.Name{CommonName: "alerts@example.com"},
+		EmailAddresses: []string{"alerts@example.com"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	return certFile, keyFile
+}
+
+func TestSMIMESigner(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.Smtp.ContentTypes = []string{"text/html", "text/plain"}
+	sc, err := NewSmtpClient(cfg.Smtp)
+	require.NoError(t, err)
+
+	certFile, keyFile := writeSMIMEKeyPair(t)
+	signer, err := newSMIMESigner(certFile, keyFile)
+	require.NoError(t, err)
+
+	var email bytes.Buffer
+	_, err = sc.buildEmail(&Message{
+		To:      []string{"to@address.com"},
+		From:    "from@address.com",
+		Subject: "Some subject",
+		Body: map[string]string{
+			"text/html":  "Some HTML body",
+			"text/plain": "Some plain text body",
+		},
+	}).WriteTo(&email)
+	require.NoError(t, err)
+
+	signed, err := signer.sign(email.Bytes())
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(signed))
+	require.NoError(t, err)
+	require.Equal(t, "Some subject", msg.Header.Get("Subject"))
+	require.Equal(t, "to@address.com", msg.Header.Get("To"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/signed", mediaType)
+	require.Equal(t, "application/pkcs7-signature", params["protocol"])
+	require.Equal(t, "sha-256", params["micalg"])
+
+	body, err := io.ReadAll(msg.Body)
+	require.NoError(t, err)
+	r := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+
+	content, err := r.NextPart()
+	require.NoError(t, err)
+	contentType, _, err := mime.ParseMediaType(content.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", contentType)
+
+	sigPart, err := r.NextPart()
+	require.NoError(t, err)
+	require.Equal(t, `application/pkcs7-signature; name="smime.p7s"`, sigPart.Header.Get("Content-Type"))
+	encoded, err := io.ReadAll(sigPart)
+	require.NoError(t, err)
+	der, err := base64.StdEncoding.DecodeString(string(bytes.ReplaceAll(encoded, []byte("\r\n"), nil)))
+	require.NoError(t, err)
+
+	_, err = r.NextPart()
+	require.ErrorIs(t, err, io.EOF)
+
+	t.Run("signature is valid for the signed content", func(t *testing.T) {
+		_, entity, err := splitContentHeaders(email.Bytes())
+		require.NoError(t, err)
+		// The signed content is the first part as it is in the message, with its headers.
+		require.Contains(t, string(body), "\r\n"+string(entity)+"\r\n--"+params["boundary"])
+
+		var ci contentInfo
+		_, err = asn1.Unmarshal(der, &ci)
+		require.NoError(t, err)
+		require.True(t, ci.ContentType.Equal(oidSignedData))
+		var sd signedData
+		_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+		require.NoError(t, err)
+		require.Len(t, sd.SignerInfos, 1)
+		si := sd.SignerInfos[0]
+		require.Equal(t, int64(42), si.IssuerAndSerialNumber.SerialNumber.Int64())
+
+		cert, err := x509.ParseCertificate(sd.Certificates.Bytes)
+		require.NoError(t, err)
+		signedAttrs, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: si.AuthenticatedAttributes.Bytes})
+		require.NoError(t, err)
+		digest := sha256.Sum256(signedAttrs)
+		require.NoError(t, rsa.VerifyPKCS1v15(cert.PublicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], si.EncryptedDigest))
+
+		contentDigest := sha256.Sum256(entity)
+		require.True(t, bytes.Contains(si.AuthenticatedAttributes.Bytes, contentDigest[:]), "the message digest attribute must be the digest of the content")
+	})
+}
+
+func TestNewSMIMESigner(t *testing.T) {
+	t.Run("fails when the cert cannot be loaded", func(t *testing.T) {
+		_, keyFile := writeSMIMEKeyPair(t)
+		_, err := newSMIMESigner(filepath.Join(t.TempDir(), "missing.pem"), keyFile)
+		require.ErrorContains(t, err, "could not load S/MIME cert or key file")
+	})
+
+	t.Run("fails when the key does not match the cert", func(t *testing.T) {
+		certFile, _ := writeSMIMEKeyPair(t)
+		_, keyFile := writeSMIMEKeyPair(t)
+		_, err := newSMIMESigner(certFile, keyFile)
+		require.ErrorContains(t, err, "could not load S/MIME cert or key file: tls: private key does not match public key")
+	})
+}
@@ -1,14 +1,18 @@
 package notifications
 
 import (
+	"bytes"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/setting"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
 	gomail "gopkg.in/mail.v2"
 )
 
@@ -38,7 +42,12 @@ func (sc *SmtpClient) Send(messages ...*Message) (int, error) {
 	for _, msg := range messages {
 		m := sc.buildEmail(msg)
 
-		innerError := dialer.DialAndSend(m)
+		var innerError error
+		if msg.SMIMECertFile != "" {
+			innerError = sendSigned(dialer, m, msg.SMIMECertFile, msg.SMIMEKeyFile)
+		} else {
+			innerError = dialer.DialAndSend(m)
+		}
 		emailsSentTotal.Inc()
 		if innerError != nil {
 			// As gomail does not returned typed errors we have to parse the error
@@ -58,29 +67,107 @@ func (sc *SmtpClient) Send(messages ...*Message) (int, error) {
 	return sentEmailsCount, err
 }
 
+// sendSigned sends the email signed with S/MIME with the certificate and key files.
+func sendSigned(dialer *gomail.Dialer, m *gomail.Message, certFile, keyFile string) error {
+	signer, err := newSMIMESigner(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	s, err := dialer.Dial()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = s.Close() }()
+
+	return gomail.Send(gomail.SendFunc(func(from string, to []string, msg io.WriterTo) error {
+		var b bytes.Buffer
+		if _, err := msg.WriteTo(&b); err != nil {
+			return err
+		}
+		signed, err := signer.sign(b.Bytes())
+		if err != nil {
+			return err
+		}
+		return s.Send(from, to, bytes.NewReader(signed))
+	}), m)
+}
+
+// transferEncodings are the Content-Transfer-Encodings of email bodies by name.
+var transferEncodings = map[string]gomail.Encoding{
+	"quoted-printable": gomail.QuotedPrintable,
+	"base64":           gomail.Base64,
+}
+
+// charsets are the encodings of the charsets email bodies can be transcoded to from UTF-8.
+var charsets = map[string]encoding.Encoding{
+	"ISO-8859-1": charmap.ISO8859_1,
+}
+
 // buildEmail converts the Message DTO to a gomail message.
 func (sc *SmtpClient) buildEmail(msg *Message) *gomail.Message {
 	m := gomail.NewMessage()
 	m.SetHeader("From", msg.From)
 	m.SetHeader("To", msg.To...)
+	if len(msg.Cc) > 0 {
+		m.SetHeader("Cc", msg.Cc...)
+	}
+	if len(msg.Bcc) > 0 {
+		m.SetHeader("Bcc", msg.Bcc...)
+	}
 	m.SetHeader("Subject", msg.Subject)
+	for name, value := range msg.Headers {
+		m.SetHeader(name, value)
+	}
 	sc.setFiles(m, msg)
 	for _, replyTo := range msg.ReplyTo {
 		m.SetAddressHeader("Reply-To", replyTo, "")
 	}
+	var partSettings []gomail.PartSetting
+	if encoding, ok := transferEncodings[msg.TransferEncoding]; ok {
+		partSettings = append(partSettings, gomail.SetPartEncoding(encoding))
+	}
+	body := msg.Body
+	if charset, ok := charsets[strings.ToUpper(msg.Charset)]; ok {
+		// The charset is set once the headers are encoded, so they stay encoded in UTF-8.
+		gomail.SetCharset(strings.ToUpper(msg.Charset))(m)
+		body = transcodeBody(msg.Body, strings.ToUpper(msg.Charset), charset)
+	}
 	// loop over content types from settings in reverse order as they are ordered in according to descending
 	// preference while the alternatives should be ordered according to ascending preference
 	for i := len(sc.cfg.ContentTypes) - 1; i >= 0; i-- {
 		if i == len(sc.cfg.ContentTypes)-1 {
-			m.SetBody(sc.cfg.ContentTypes[i], msg.Body[sc.cfg.ContentTypes[i]])
+			m.SetBody(sc.cfg.ContentTypes[i], body[sc.cfg.ContentTypes[i]], partSettings...)
 		} else {
-			m.AddAlternative(sc.cfg.ContentTypes[i], msg.Body[sc.cfg.ContentTypes[i]])
+			m.AddAlternative(sc.cfg.ContentTypes[i], body[sc.cfg.ContentTypes[i]], partSettings...)
 		}
 	}
 
 	return m
 }
 
+// htmlMetaCharset matches the UTF-8 charset declared by the meta tags of HTML bodies, e.g.
+// <meta http-equiv="Content-Type" content="text/html; charset=UTF-8"> or <meta charset="utf-8">.
+var htmlMetaCharset = regexp.MustCompile(`(?i)(<meta\b[^>]*\bcharset=["']?)utf-8`)
+
+// transcodeBody transcodes the UTF-8 body of each content type to the charset with the name.
+// Characters the charset cannot represent are replaced. The charset declared by the meta tags
+// of HTML bodies is replaced too, so that clients don't decode them as UTF-8.
+func transcodeBody(body map[string]string, name string, charset encoding.Encoding) map[string]string {
+	transcoded := make(map[string]string, len(body))
+	for contentType, content := range body {
+		if contentType == "text/html" {
+			content = htmlMetaCharset.ReplaceAllString(content, "${1}"+name)
+		}
+		s, err := encoding.ReplaceUnsupported(charset.NewEncoder()).String(content)
+		if err != nil {
+			// Replacing unsupported characters does not fail, keep the content as is if it does.
+			s = content
+		}
+		transcoded[contentType] = s
+	}
+	return transcoded
+}
+
 // setFiles attaches files in various forms.
 func (sc *SmtpClient) setFiles(
 	m *gomail.Message,
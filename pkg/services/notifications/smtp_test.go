@@ -2,12 +2,15 @@ package notifications
 
 import (
 	"bytes"
+	"net"
+	"net/textproto"
 	"strings"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
 )
 
 func TestBuildMail(t *testing.T) {
@@ -39,6 +42,114 @@ func TestBuildMail(t *testing.T) {
 		assert.Contains(t, buf.String(), "Some plain text body")
 		assert.Less(t, strings.Index(buf.String(), "Some plain text body"), strings.Index(buf.String(), "Some HTML body"))
 	})
+
+	t.Run("When building email with carbon copy recipients", func(t *testing.T) {
+		msg := *message
+		msg.Cc = []string{"cc@address.com"}
+		email := sc.buildEmail(&msg)
+
+		assert.Equal(t, []string{"cc@address.com"}, email.GetHeader("Cc"))
+	})
+
+	t.Run("When building email with blind carbon copy recipients", func(t *testing.T) {
+		msg := *message
+		msg.Bcc = []string{"bcc@address.com"}
+		email := sc.buildEmail(&msg)
+
+		assert.Equal(t, []string{"bcc@address.com"}, email.GetHeader("Bcc"))
+
+		buf := new(bytes.Buffer)
+		_, err := email.WriteTo(buf)
+		require.NoError(t, err)
+		assert.NotContains(t, buf.String(), "bcc@address.com")
+	})
+
+	t.Run("When building email with display names", func(t *testing.T) {
+		msg := *message
+		msg.To = []string{`"Payments On-Call" <payments@example.com>`, "ops@example.com"}
+		email := sc.buildEmail(&msg)
+
+		buf := new(bytes.Buffer)
+		_, err := email.WriteTo(buf)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "To: \"Payments On-Call\" <payments@example.com>, ops@example.com\r\n")
+	})
+
+	t.Run("When building email with additional headers", func(t *testing.T) {
+		msg := *message
+		msg.Headers = map[string]string{"Importance": "High", "X-Priority": "1 (Highest)"}
+		email := sc.buildEmail(&msg)
+
+		assert.Equal(t, []string{"High"}, email.GetHeader("Importance"))
+		assert.Equal(t, []string{"1 (Highest)"}, email.GetHeader("X-Priority"))
+	})
+
+	t.Run("When building email with a transfer encoding", func(t *testing.T) {
+		for _, encoding := range []string{"", "quoted-printable", "base64"} {
+			msg := *message
+			msg.TransferEncoding = encoding
+			email := sc.buildEmail(&msg)
+
+			buf := new(bytes.Buffer)
+			_, err := email.WriteTo(buf)
+			require.NoError(t, err)
+
+			expected := encoding
+			if expected == "" {
+				expected = "quoted-printable"
+			}
+			assert.Equal(t, 2, strings.Count(buf.String(), "Content-Transfer-Encoding: "+expected+"\r\n"), encoding)
+		}
+	})
+
+	t.Run("When building email with a charset", func(t *testing.T) {
+		msg := *message
+		msg.Subject = "Alerte déclenchée"
+		msg.Body = map[string]string{
+			"text/html":  "<p>Température élevée</p>",
+			"text/plain": "Température élevée ✓",
+		}
+
+		t.Run("the body is in UTF-8 by default", func(t *testing.T) {
+			email := sc.buildEmail(&msg)
+
+			buf := new(bytes.Buffer)
+			_, err := email.WriteTo(buf)
+			require.NoError(t, err)
+
+			assert.Equal(t, 2, strings.Count(buf.String(), "charset=UTF-8\r\n"))
+			assert.Contains(t, buf.String(), "Temp=C3=A9rature =C3=A9lev=C3=A9e =E2=9C=93")
+		})
+
+		t.Run("the body is transcoded to ISO-8859-1", func(t *testing.T) {
+			msg := msg
+			msg.Charset = "ISO-8859-1"
+			email := sc.buildEmail(&msg)
+
+			buf := new(bytes.Buffer)
+			_, err := email.WriteTo(buf)
+			require.NoError(t, err)
+
+			assert.Contains(t, buf.String(), "Content-Type: text/plain; charset=ISO-8859-1\r\n")
+			assert.Contains(t, buf.String(), "Content-Type: text/html; charset=ISO-8859-1\r\n")
+			assert.Contains(t, buf.String(), "<p>Temp=E9rature =E9lev=E9e</p>")
+			// Characters ISO-8859-1 cannot represent are replaced.
+			assert.Contains(t, buf.String(), "Temp=E9rature =E9lev=E9e =1A")
+			// Headers are still encoded in UTF-8.
+			assert.Contains(t, buf.String(), "Subject: =?UTF-8?q?Alerte_d=C3=A9clench=C3=A9e?=\r\n")
+		})
+
+		t.Run("the charset declared by the HTML body is replaced", func(t *testing.T) {
+			body := transcodeBody(map[string]string{
+				"text/html":  `<meta http-equiv="Content-Type" content="text/html; charset=UTF-8"><meta charset="utf-8"><p>Température</p>`,
+				"text/plain": "charset=UTF-8",
+			}, "ISO-8859-1", charmap.ISO8859_1)
+
+			assert.Equal(t, "<meta http-equiv=\"Content-Type\" content=\"text/html; charset=ISO-8859-1\"><meta charset=\"ISO-8859-1\"><p>Temp\xe9rature</p>", body["text/html"])
+			// Only HTML bodies declare their charset.
+			assert.Equal(t, "charset=UTF-8", body["text/plain"])
+		})
+	})
 }
 
 func TestSmtpDialer(t *testing.T) {
@@ -106,3 +217,91 @@ func TestSmtpDialer(t *testing.T) {
 		require.EqualError(t, err, "could not load cert or key file: open /var/certs/does-not-exist.pem: no such file or directory")
 	})
 }
+
+func TestSmtpEhloIdentity(t *testing.T) {
+	message := &Message{
+		From:    "from@address.com",
+		To:      []string{"asdf@grafana.com"},
+		Subject: "subject",
+		Body: map[string]string{
+			"text/html":  "body",
+			"text/plain": "body",
+		},
+	}
+
+	t.Run("Configured EHLO identity is used in the handshake", func(t *testing.T) {
+		cfg := createSmtpConfig()
+		var hellos <-chan string
+		cfg.Smtp.Host, hellos = startFakeSmtpServer(t)
+		cfg.Smtp.EhloIdentity = "relay-client.example.com"
+		client, err := ProvideSmtpService(cfg)
+		require.NoError(t, err)
+
+		count, err := client.Send(message)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+		require.Equal(t, "EHLO relay-client.example.com", <-hellos)
+	})
+
+	t.Run("Instance name is used in the handshake by default", func(t *testing.T) {
+		defer func(name string) { setting.InstanceName = name }(setting.InstanceName)
+		setting.InstanceName = "grafana.example.com"
+
+		cfg := createSmtpConfig()
+		var hellos <-chan string
+		cfg.Smtp.Host, hellos = startFakeSmtpServer(t)
+		client, err := ProvideSmtpService(cfg)
+		require.NoError(t, err)
+
+		count, err := client.Send(message)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+		require.Equal(t, "EHLO grafana.example.com", <-hellos)
+	})
+}
+
+// startFakeSmtpServer starts an SMTP server that accepts a single email and returns its
+// address and the HELO or EHLO command the client greeted it with.
+func startFakeSmtpServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	hellos := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		tc := textproto.NewConn(conn)
+		_ = tc.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tc.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO", "HELO":
+				hellos <- line
+				_ = tc.PrintfLine("250 localhost")
+			case "DATA":
+				_ = tc.PrintfLine("354 go ahead")
+				if _, err := tc.ReadDotBytes(); err != nil {
+					return
+				}
+				_ = tc.PrintfLine("250 ok")
+			case "QUIT":
+				_ = tc.PrintfLine("221 bye")
+				return
+			default:
+				_ = tc.PrintfLine("250 ok")
+			}
+		}
+	}()
+
+	return l.Addr().String(), hellos
+}
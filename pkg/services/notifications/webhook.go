@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/grafana/grafana/pkg/util"
@@ -22,6 +27,21 @@ type Webhook struct {
 	HttpHeader  map[string]string
 	ContentType string
 
+	// Timeout bounds the whole request: resolving the host, connecting, the TLS handshake
+	// and reading the response. DefaultWebhookTimeout is used if it is not set.
+	Timeout time.Duration
+	// ConnectTimeout bounds resolving the host and connecting to it.
+	// DefaultWebhookConnectTimeout is used if it is not set.
+	ConnectTimeout time.Duration
+
+	// ConnectionPool is the key of the connections kept alive for webhooks with the same
+	// pool, e.g. the UID of the contact point. MaxIdleConns is the number of idle
+	// connections kept per host of the pool, and IdleConnTimeout how long they are kept.
+	// The connections shared by all webhooks are used if none of them are set.
+	ConnectionPool  string
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+
 	// Validation is a function that will validate the response body and statusCode of the webhook. Any returned error will cause the webhook request to be considered failed.
 	// This can be useful when a webhook service communicates failures in creative ways, such as using the response body instead of the status code.
 	Validation func(body []byte, statusCode int) error
@@ -32,21 +52,296 @@ type WebhookClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+const (
+	DefaultWebhookTimeout        = 30 * time.Second
+	DefaultWebhookConnectTimeout = 30 * time.Second
+	// DefaultWebhookIdleConnTimeout is how long idle connections of a connection pool are
+	// kept if the webhook does not set it.
+	DefaultWebhookIdleConnTimeout = 90 * time.Second
+)
+
+type connectTimeoutKey struct{}
+
+type webhookTargetsKey struct{}
+
+// webhookDial is the state of the dial of a webhook request with restricted targets.
+type webhookDial struct {
+	targets *webhookTargetPolicy
+	// proxyAddr is the address of the proxy the request is sent through, if any. It is set
+	// by webhookProxy, which checks the target of the request instead. The proxy is configured
+	// by the administrator, so the connection to it is not checked.
+	proxyAddr string
+}
+
+// ErrWebhookTargetNotAllowed is returned when a webhook is sent to an address that is not
+// allowed by the webhook_allowed_hosts and webhook_block_private_ips settings.
+var ErrWebhookTargetNotAllowed = errors.New("webhook target is not allowed")
+
+// WebhookStatusError is returned when a webhook responds with a status code other than 2xx.
+type WebhookStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *WebhookStatusError) Error() string {
+	return fmt.Sprintf("webhook response status %v", e.Status)
+}
+
+// webhookTargetPolicy decides the addresses webhooks can be sent to. A nil policy allows all.
+type webhookTargetPolicy struct {
+	hosts        map[string]struct{}
+	nets         []*net.IPNet
+	blockPrivate bool
+}
+
+// newWebhookTargetPolicy returns the policy of the allowed hosts and CIDRs, or nil if all
+// addresses are allowed.
+func newWebhookTargetPolicy(allowed []string, blockPrivate bool) (*webhookTargetPolicy, error) {
+	if len(allowed) == 0 && !blockPrivate {
+		return nil, nil
+	}
+	p := &webhookTargetPolicy{hosts: map[string]struct{}{}, blockPrivate: blockPrivate}
+	for _, a := range allowed {
+		if strings.Contains(a, "/") {
+			_, ipNet, err := net.ParseCIDR(a)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q in webhook_allowed_hosts: %w", a, err)
+			}
+			p.nets = append(p.nets, ipNet)
+			continue
+		}
+		p.hosts[strings.ToLower(a)] = struct{}{}
+	}
+	return p, nil
+}
+
+// check returns an error wrapping ErrWebhookTargetNotAllowed if the webhook cannot be sent
+// to the host, which resolved to the IP. Allowed CIDRs take precedence, then private IPs
+// are blocked, even if the host is allowed, so that a host cannot be rebound to one.
+func (p *webhookTargetPolicy) check(host string, ip net.IP) error {
+	if p == nil {
+		return nil
+	}
+	for _, n := range p.nets {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+	if p.blockPrivate && isPrivateIP(ip) {
+		return fmt.Errorf("%w: %s resolves to private address %s", ErrWebhookTargetNotAllowed, host, ip)
+	}
+	if len(p.hosts) == 0 && len(p.nets) == 0 {
+		return nil
+	}
+	if _, ok := p.hosts[strings.ToLower(host)]; ok {
+		return nil
+	}
+	if _, ok := p.hosts[ip.String()]; ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not in webhook_allowed_hosts", ErrWebhookTargetNotAllowed, host)
+}
+
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// proxyFromEnvironment returns the proxy of the webhooks, it is replaced in tests.
+var proxyFromEnvironment = http.ProxyFromEnvironment
+
+// webhookProxy returns the proxy configured in the environment. If the targets of the webhook
+// are restricted, the proxy connects to the target instead of the dialer, so the addresses the
+// host of the request resolves to are checked here.
+func webhookProxy(req *http.Request) (*url.URL, error) {
+	proxy, err := proxyFromEnvironment(req)
+	if err != nil || proxy == nil {
+		return proxy, err
+	}
+	dial, ok := req.Context().Value(webhookTargetsKey{}).(*webhookDial)
+	if !ok || dial.targets == nil {
+		return proxy, nil
+	}
+	host := req.URL.Hostname()
+	ips, err := lookupWebhookHost(req.Context(), host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if err := dial.targets.check(host, ip); err != nil {
+			return nil, err
+		}
+	}
+	dial.proxyAddr = canonicalProxyAddr(proxy)
+	return proxy, nil
+}
+
+// lookupWebhookHost returns the IP addresses of the host.
+func lookupWebhookHost(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+	return ips, nil
+}
+
+// canonicalProxyAddr returns the address the transport dials to connect to the proxy.
+func canonicalProxyAddr(proxy *url.URL) string {
+	port := proxy.Port()
+	if port == "" {
+		switch proxy.Scheme {
+		case "https":
+			port = "443"
+		case "socks5":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
+
 var netTransport = &http.Transport{
 	TLSClientConfig: &tls.Config{
 		Renegotiation: tls.RenegotiateFreelyAsClient,
 	},
-	Proxy: http.ProxyFromEnvironment,
-	Dial: (&net.Dialer{
-		Timeout: 30 * time.Second,
-	}).Dial,
+	Proxy: webhookProxy,
+	DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		// The dialer timeout includes resolving the host.
+		dialer := &net.Dialer{Timeout: DefaultWebhookConnectTimeout}
+		if timeout, ok := ctx.Value(connectTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+			dialer.Timeout = timeout
+		}
+		if dial, ok := ctx.Value(webhookTargetsKey{}).(*webhookDial); ok && dial.targets != nil && addr != dial.proxyAddr {
+			// The resolved address is checked right before connecting to it, as checking the
+			// host before sending would not protect against DNS rebinding.
+			targets := dial.targets
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			dialer.Control = func(_, address string, _ syscall.RawConn) error {
+				ipStr, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				return targets.check(host, net.ParseIP(ipStr))
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	},
 	TLSHandshakeTimeout: 5 * time.Second,
 }
+
+// netClient has no timeout of its own, requests are bounded by the timeout of the webhook.
 var netClient WebhookClient = &http.Client{
-	Timeout:   time.Second * 30,
 	Transport: netTransport,
 }
 
+// webhookMaxConnectionPools is the maximum number of connection pools kept at the same time,
+// the least recently used pool is removed to make room for a new one.
+const webhookMaxConnectionPools = 1000
+
+// webhookConnectionPools keeps the connections of the webhooks with a connection pool, so
+// that they are reused by the webhooks of the pool, with the keep-alive settings of the pool.
+// A pool is removed once it is unused for longer than its idle connection timeout, as its
+// connections are closed by then, so that the pools of removed contact points are not kept.
+type webhookConnectionPools struct {
+	mtx   sync.Mutex
+	pools map[string]*webhookConnectionPool
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+}
+
+type webhookConnectionPool struct {
+	maxIdleConns    int
+	idleConnTimeout time.Duration
+	transport       *http.Transport
+	client          WebhookClient
+	lastUsed        time.Time
+}
+
+// client returns the client of the connection pool of the webhook, or netClient if it has
+// none. The connections of the pool are closed and a new pool is created if its settings
+// changed.
+func (p *webhookConnectionPools) client(webhook *Webhook) WebhookClient {
+	if webhook.ConnectionPool == "" || (webhook.MaxIdleConns <= 0 && webhook.IdleConnTimeout <= 0) {
+		return netClient
+	}
+	idleConnTimeout := webhook.IdleConnTimeout
+	if idleConnTimeout <= 0 {
+		idleConnTimeout = DefaultWebhookIdleConnTimeout
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+	p.removeExpired(now)
+
+	pool, ok := p.pools[webhook.ConnectionPool]
+	if ok && pool.maxIdleConns == webhook.MaxIdleConns && pool.idleConnTimeout == idleConnTimeout {
+		pool.lastUsed = now
+		return pool.client
+	}
+	if ok {
+		pool.transport.CloseIdleConnections()
+	} else if len(p.pools) >= webhookMaxConnectionPools {
+		p.removeLeastRecentlyUsed()
+	}
+
+	transport := netTransport.Clone()
+	if webhook.MaxIdleConns > 0 {
+		transport.MaxIdleConns = webhook.MaxIdleConns
+		transport.MaxIdleConnsPerHost = webhook.MaxIdleConns
+	}
+	transport.IdleConnTimeout = idleConnTimeout
+	pool = &webhookConnectionPool{
+		maxIdleConns:    webhook.MaxIdleConns,
+		idleConnTimeout: idleConnTimeout,
+		transport:       transport,
+		client:          &http.Client{Transport: transport},
+		lastUsed:        now,
+	}
+	if p.pools == nil {
+		p.pools = make(map[string]*webhookConnectionPool)
+	}
+	p.pools[webhook.ConnectionPool] = pool
+	return pool.client
+}
+
+// removeExpired removes the pools unused for longer than their idle connection timeout.
+func (p *webhookConnectionPools) removeExpired(now time.Time) {
+	for key, pool := range p.pools {
+		if now.Sub(pool.lastUsed) > pool.idleConnTimeout {
+			pool.transport.CloseIdleConnections()
+			delete(p.pools, key)
+		}
+	}
+}
+
+// removeLeastRecentlyUsed removes the pool that was used the longest time ago.
+func (p *webhookConnectionPools) removeLeastRecentlyUsed() {
+	var oldest string
+	for key, pool := range p.pools {
+		if oldest == "" || pool.lastUsed.Before(p.pools[oldest].lastUsed) {
+			oldest = key
+		}
+	}
+	if pool, ok := p.pools[oldest]; ok {
+		pool.transport.CloseIdleConnections()
+		delete(p.pools, oldest)
+	}
+}
+
 func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *Webhook) error {
 	if webhook.HttpMethod == "" {
 		webhook.HttpMethod = http.MethodPost
@@ -58,6 +353,15 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 		return fmt.Errorf("webhook only supports HTTP methods PUT or POST")
 	}
 
+	timeout := webhook.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, connectTimeoutKey{}, webhook.ConnectTimeout)
+	ctx = context.WithValue(ctx, webhookTargetsKey{}, &webhookDial{targets: ns.webhookTargets})
+
 	request, err := http.NewRequestWithContext(ctx, webhook.HttpMethod, webhook.Url, bytes.NewReader([]byte(webhook.Body)))
 	if err != nil {
 		return err
@@ -78,7 +382,7 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 		request.Header.Set(k, v)
 	}
 
-	resp, err := netClient.Do(request)
+	resp, err := ns.webhookPools.client(webhook).Do(request)
 	if err != nil {
 		return err
 	}
@@ -107,5 +411,5 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 	}
 
 	ns.log.Debug("Webhook failed", "url", webhook.Url, "statuscode", resp.Status, "body", string(body))
-	return fmt.Errorf("webhook response status %v", resp.Status)
+	return &WebhookStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 }
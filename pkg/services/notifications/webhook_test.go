@@ -0,0 +1,187 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestWebhookTargetPolicy(t *testing.T) {
+	policy, err := newWebhookTargetPolicy([]string{"hooks.example.com", "203.0.113.0/24", "192.168.1.0/24"}, true)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		host    string
+		ip      string
+		allowed bool
+	}{
+		{name: "allowed public host", host: "hooks.example.com", ip: "198.51.100.7", allowed: true},
+		{name: "allowed public CIDR", host: "other.example.com", ip: "203.0.113.5", allowed: true},
+		{name: "allowed private CIDR", host: "internal.example.com", ip: "192.168.1.5", allowed: true},
+		{name: "public host not in allowlist", host: "evil.example.com", ip: "198.51.100.8", allowed: false},
+		{name: "private IP", host: "10.0.0.1", ip: "10.0.0.1", allowed: false},
+		{name: "metadata endpoint", host: "169.254.169.254", ip: "169.254.169.254", allowed: false},
+		{name: "allowed host rebound to loopback", host: "hooks.example.com", ip: "127.0.0.1", allowed: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := policy.check(c.host, net.ParseIP(c.ip))
+			if c.allowed {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrWebhookTargetNotAllowed)
+			}
+		})
+	}
+
+	t.Run("all targets are allowed by default", func(t *testing.T) {
+		policy, err := newWebhookTargetPolicy(nil, false)
+		require.NoError(t, err)
+		require.Nil(t, policy)
+		require.NoError(t, policy.check("169.254.169.254", net.ParseIP("169.254.169.254")))
+	})
+
+	t.Run("invalid CIDR should return error", func(t *testing.T) {
+		_, err := newWebhookTargetPolicy([]string{"10.0.0.0/33"}, false)
+		require.ErrorContains(t, err, `invalid CIDR "10.0.0.0/33" in webhook_allowed_hosts`)
+	})
+}
+
+func TestSendWebRequestSyncTargets(t *testing.T) {
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	send := func(t *testing.T, allowed []string, webhookURL string) error {
+		t.Helper()
+		policy, err := newWebhookTargetPolicy(allowed, true)
+		require.NoError(t, err)
+		ns := &NotificationService{log: log.New("notifications.test"), webhookTargets: policy}
+		return ns.sendWebRequestSync(context.Background(), &Webhook{Url: webhookURL, HttpMethod: http.MethodPost})
+	}
+
+	t.Run("private IP is blocked", func(t *testing.T) {
+		received = 0
+		err := send(t, nil, server.URL)
+		require.ErrorIs(t, err, ErrWebhookTargetNotAllowed)
+		require.Equal(t, 0, received)
+	})
+
+	t.Run("allowed host that resolves to a private IP is blocked", func(t *testing.T) {
+		received = 0
+		err := send(t, []string{"localhost"}, "http://localhost:"+u.Port())
+		require.ErrorIs(t, err, ErrWebhookTargetNotAllowed)
+		require.ErrorContains(t, err, "localhost resolves to private address")
+		require.Equal(t, 0, received)
+	})
+
+	t.Run("allowed CIDR is sent to", func(t *testing.T) {
+		received = 0
+		err := send(t, []string{"127.0.0.0/8"}, server.URL)
+		require.NoError(t, err)
+		require.Equal(t, 1, received)
+	})
+
+	t.Run("proxy of the environment is used and the target is checked", func(t *testing.T) {
+		var proxied int
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied++
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(proxy.Close)
+		proxyURL, err := url.Parse(proxy.URL)
+		require.NoError(t, err)
+		orig := proxyFromEnvironment
+		proxyFromEnvironment = http.ProxyURL(proxyURL)
+		t.Cleanup(func() { proxyFromEnvironment = orig })
+
+		// The proxy has a private address that is not allowed, only the target is checked.
+		err = send(t, []string{"203.0.113.0/24"}, "http://203.0.113.1/hook")
+		require.NoError(t, err)
+		require.Equal(t, 1, proxied)
+
+		proxied = 0
+		err = send(t, []string{"203.0.113.0/24"}, "http://198.51.100.1/hook")
+		require.ErrorIs(t, err, ErrWebhookTargetNotAllowed)
+		require.Equal(t, 0, proxied)
+
+		err = send(t, []string{"localhost"}, "http://localhost/hook")
+		require.ErrorIs(t, err, ErrWebhookTargetNotAllowed)
+		require.ErrorContains(t, err, "localhost resolves to private address")
+		require.Equal(t, 0, proxied)
+	})
+}
+
+func TestWebhookConnectionPools(t *testing.T) {
+	var pools webhookConnectionPools
+
+	t.Run("webhooks without keep-alive settings use the shared client", func(t *testing.T) {
+		require.Equal(t, netClient, pools.client(&Webhook{ConnectionPool: "cp1"}))
+		require.Equal(t, netClient, pools.client(&Webhook{MaxIdleConns: 10}))
+	})
+
+	t.Run("webhooks of the same pool share a client", func(t *testing.T) {
+		client := pools.client(&Webhook{ConnectionPool: "cp1", MaxIdleConns: 10})
+		require.NotEqual(t, netClient, client)
+		require.Same(t, client, pools.client(&Webhook{ConnectionPool: "cp1", MaxIdleConns: 10}))
+		require.NotSame(t, client, pools.client(&Webhook{ConnectionPool: "cp2", MaxIdleConns: 10}))
+
+		transport := client.(*http.Client).Transport.(*http.Transport)
+		require.Equal(t, 10, transport.MaxIdleConnsPerHost)
+		require.Equal(t, DefaultWebhookIdleConnTimeout, transport.IdleConnTimeout)
+	})
+
+	t.Run("the client of a pool is replaced when its settings change", func(t *testing.T) {
+		client := pools.client(&Webhook{ConnectionPool: "cp1", MaxIdleConns: 10})
+		updated := pools.client(&Webhook{ConnectionPool: "cp1", MaxIdleConns: 10, IdleConnTimeout: time.Minute})
+		require.NotSame(t, client, updated)
+		require.Equal(t, time.Minute, updated.(*http.Client).Transport.(*http.Transport).IdleConnTimeout)
+	})
+
+	t.Run("pools unused for longer than their idle timeout are removed", func(t *testing.T) {
+		now := time.Now()
+		pools := webhookConnectionPools{now: func() time.Time { return now }}
+		client := pools.client(&Webhook{ConnectionPool: "cp1", IdleConnTimeout: time.Minute})
+		pools.client(&Webhook{ConnectionPool: "cp2", IdleConnTimeout: time.Hour})
+
+		now = now.Add(30 * time.Second)
+		require.Same(t, client, pools.client(&Webhook{ConnectionPool: "cp1", IdleConnTimeout: time.Minute}))
+
+		now = now.Add(2 * time.Minute)
+		pools.client(&Webhook{ConnectionPool: "cp2", IdleConnTimeout: time.Hour})
+		require.NotContains(t, pools.pools, "cp1")
+		require.Contains(t, pools.pools, "cp2")
+	})
+
+	t.Run("the least recently used pool is removed when there are too many pools", func(t *testing.T) {
+		now := time.Now()
+		pools := webhookConnectionPools{now: func() time.Time { return now }}
+		for i := 0; i < webhookMaxConnectionPools; i++ {
+			pools.client(&Webhook{ConnectionPool: fmt.Sprintf("cp%d", i), MaxIdleConns: 10})
+			now = now.Add(time.Millisecond)
+		}
+		// The first pool is used again, so the second pool is the least recently used.
+		pools.client(&Webhook{ConnectionPool: "cp0", MaxIdleConns: 10})
+
+		pools.client(&Webhook{ConnectionPool: "new", MaxIdleConns: 10})
+		require.Len(t, pools.pools, webhookMaxConnectionPools)
+		require.Contains(t, pools.pools, "cp0")
+		require.NotContains(t, pools.pools, "cp1")
+		require.Contains(t, pools.pools, "new")
+	})
+}
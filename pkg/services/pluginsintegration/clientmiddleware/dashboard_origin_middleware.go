@@ -0,0 +1,102 @@
+package clientmiddleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana/pkg/infra/httpclient/httpclientprovider"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+)
+
+// The headers of the dashboard and panel a request claims to be issued by. They are set by the
+// client and are not verified: any API caller can set them to any dashboard and panel.
+const (
+	ClientDashboardUIDHeaderName = "X-Dashboard-Uid"
+	ClientPanelIDHeaderName      = "X-Panel-Id"
+)
+
+// NewDashboardOriginMiddleware creates a new plugins.ClientMiddleware that will
+// forward the X-Dashboard-Uid and X-Panel-Id headers of the incoming HTTP request
+// on outgoing plugins.Client and HTTP requests, e.g. for cost attribution. Headers
+// are omitted when the incoming request does not have them.
+//
+// The headers are supplied by the client and forwarded as is, they are not checked
+// against the dashboards of the signed in user. Plugins must only use them for
+// attribution, never for access control.
+func NewDashboardOriginMiddleware() plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &DashboardOriginMiddleware{
+			next: next,
+		}
+	})
+}
+
+type DashboardOriginMiddleware struct {
+	next plugins.Client
+}
+
+// applyClientOrigin forwards the unverified origin headers of the incoming request.
+func (m *DashboardOriginMiddleware) applyClientOrigin(ctx context.Context, h backend.ForwardHTTPHeaders) context.Context {
+	reqCtx := contexthandler.FromContext(ctx)
+	// if no HTTP request context skip middleware
+	if h == nil || reqCtx == nil || reqCtx.Req == nil {
+		return ctx
+	}
+
+	httpHeaders := http.Header{}
+	for _, name := range []string{ClientDashboardUIDHeaderName, ClientPanelIDHeaderName} {
+		if value := reqCtx.Req.Header.Get(name); value != "" {
+			h.SetHTTPHeader(name, value)
+			httpHeaders.Set(name, value)
+		}
+	}
+
+	if len(httpHeaders) == 0 {
+		return ctx
+	}
+
+	return sdkhttpclient.WithContextualMiddleware(ctx, httpclientprovider.SetHeadersMiddleware(httpHeaders))
+}
+
+func (m *DashboardOriginMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	ctx = m.applyClientOrigin(ctx, req)
+
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *DashboardOriginMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.next.CallResource(ctx, req, sender)
+	}
+
+	ctx = m.applyClientOrigin(ctx, req)
+
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *DashboardOriginMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *DashboardOriginMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *DashboardOriginMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *DashboardOriginMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *DashboardOriginMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
@@ -0,0 +1,81 @@
+package clientmiddleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana/pkg/infra/httpclient/httpclientprovider"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardOriginMiddleware(t *testing.T) {
+	pluginCtx := backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+	}
+
+	t.Run("When the dashboard origin is known", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/api/ds/query", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Dashboard-Uid", "dash-uid")
+		req.Header.Set("X-Panel-Id", "2")
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{}),
+			clienttest.WithMiddlewares(NewDashboardOriginMiddleware()),
+		)
+
+		t.Run("Should forward origin headers when calling QueryData", func(t *testing.T) {
+			_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+				PluginContext: pluginCtx,
+			})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.QueryDataReq)
+			require.Equal(t, "dash-uid", cdt.QueryDataReq.GetHTTPHeader(ClientDashboardUIDHeaderName))
+			require.Equal(t, "2", cdt.QueryDataReq.GetHTTPHeader(ClientPanelIDHeaderName))
+
+			middlewares := httpclient.ContextualMiddlewareFromContext(cdt.QueryDataCtx)
+			require.Len(t, middlewares, 1)
+			require.Equal(t, httpclientprovider.SetHeadersMiddlewareName, middlewares[0].(httpclient.MiddlewareName).MiddlewareName())
+		})
+
+		t.Run("Should forward origin headers when calling CallResource", func(t *testing.T) {
+			err = cdt.Decorator.CallResource(req.Context(), &backend.CallResourceRequest{
+				PluginContext: pluginCtx,
+			}, nopCallResourceSender)
+			require.NoError(t, err)
+			require.NotNil(t, cdt.CallResourceReq)
+			require.Equal(t, "dash-uid", cdt.CallResourceReq.GetHTTPHeader(ClientDashboardUIDHeaderName))
+			require.Equal(t, "2", cdt.CallResourceReq.GetHTTPHeader(ClientPanelIDHeaderName))
+
+			middlewares := httpclient.ContextualMiddlewareFromContext(cdt.CallResourceCtx)
+			require.Len(t, middlewares, 1)
+			require.Equal(t, httpclientprovider.SetHeadersMiddlewareName, middlewares[0].(httpclient.MiddlewareName).MiddlewareName())
+		})
+	})
+
+	t.Run("When the dashboard origin is unknown", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/api/ds/query", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{}),
+			clienttest.WithMiddlewares(NewDashboardOriginMiddleware()),
+		)
+
+		t.Run("Should not forward origin headers when calling QueryData", func(t *testing.T) {
+			_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+				PluginContext: pluginCtx,
+			})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.QueryDataReq)
+			require.Empty(t, cdt.QueryDataReq.Headers)
+
+			middlewares := httpclient.ContextualMiddlewareFromContext(cdt.QueryDataCtx)
+			require.Len(t, middlewares, 0)
+		})
+	})
+}
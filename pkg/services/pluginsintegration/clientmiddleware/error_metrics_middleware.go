@@ -0,0 +1,213 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error types of the plugin request errors metric.
+const (
+	ErrorTypeTimeout    = "timeout"
+	ErrorTypeAuth       = "auth"
+	ErrorTypeConnection = "connection"
+	ErrorTypeDownstream = "downstream"
+	ErrorTypeInternal   = "internal"
+)
+
+var pluginRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Name:      "plugin_request_errors_total",
+	Help:      "The total amount of failed plugin requests by type of error",
+}, []string{"plugin_id", "endpoint", "error_type"})
+
+// NewErrorMetricsMiddleware creates a new plugins.ClientMiddleware that will
+// count the errors of QueryData and CallResource requests by type: timeout,
+// auth, connection, downstream or internal. The errors of single queries of
+// QueryData responses, and CallResource responses with an error status code,
+// are counted too.
+func NewErrorMetricsMiddleware() plugins.ClientMiddleware {
+	return newErrorMetricsMiddleware(pluginRequestErrors)
+}
+
+func newErrorMetricsMiddleware(counter *prometheus.CounterVec) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &ErrorMetricsMiddleware{
+			next:    next,
+			counter: counter,
+		}
+	})
+}
+
+type ErrorMetricsMiddleware struct {
+	next    plugins.Client
+	counter *prometheus.CounterVec
+}
+
+func (m *ErrorMetricsMiddleware) inc(pluginCtx backend.PluginContext, endpoint, errorType string) {
+	if errorType == "" {
+		return
+	}
+	m.counter.WithLabelValues(pluginCtx.PluginID, endpoint, errorType).Inc()
+}
+
+func (m *ErrorMetricsMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp, err := m.next.QueryData(ctx, req)
+	if req == nil {
+		return resp, err
+	}
+	if err != nil {
+		m.inc(req.PluginContext, "queryData", requestErrorType(err))
+		return resp, err
+	}
+	if resp != nil {
+		for _, r := range resp.Responses {
+			m.inc(req.PluginContext, "queryData", dataResponseErrorType(r))
+		}
+	}
+	return resp, nil
+}
+
+func (m *ErrorMetricsMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.next.CallResource(ctx, req, sender)
+	}
+
+	// Only the first response of a stream has the status code. A request whose response
+	// has an error status code is counted once, even if it also returns an error.
+	sent, failed := false, false
+	err := m.next.CallResource(ctx, req, callResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+		if !sent && res != nil {
+			sent = true
+			if t := statusErrorType(res.Status); t != "" {
+				failed = true
+				m.inc(req.PluginContext, "callResource", t)
+			}
+		}
+		return sender.Send(res)
+	}))
+	if err != nil && !failed {
+		m.inc(req.PluginContext, "callResource", requestErrorType(err))
+	}
+	return err
+}
+
+func (m *ErrorMetricsMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *ErrorMetricsMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *ErrorMetricsMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *ErrorMetricsMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *ErrorMetricsMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
+
+// requestErrorType returns the type of an error returned by a plugin request. Errors that
+// are not a timeout, auth or connection error are downstream errors if the plugin returned
+// them, and internal errors otherwise. Requests canceled by the client are not counted.
+func requestErrorType(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return ""
+	}
+	if t := errorType(err); t != "" {
+		return t
+	}
+	if errors.Is(err, plugins.ErrPluginDownstreamError) {
+		return ErrorTypeDownstream
+	}
+	return ErrorTypeInternal
+}
+
+// dataResponseErrorType returns the type of the error of a single query, which is a
+// downstream error unless its status code or error tell otherwise.
+func dataResponseErrorType(r backend.DataResponse) string {
+	if r.Error == nil && r.Status < http.StatusBadRequest {
+		return ""
+	}
+	if t := statusErrorType(int(r.Status)); t != "" {
+		return t
+	}
+	if t := errorType(r.Error); t != "" {
+		return t
+	}
+	return ErrorTypeDownstream
+}
+
+// statusErrorType returns the type of error of an HTTP status code. Client errors other
+// than auth errors and timeouts are not counted.
+func statusErrorType(code int) string {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrorTypeAuth
+	case code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout:
+		return ErrorTypeTimeout
+	case code >= http.StatusInternalServerError:
+		return ErrorTypeDownstream
+	}
+	return ""
+}
+
+// errorType returns the type of err if it is a timeout, auth or connection error, or an
+// empty string otherwise.
+func errorType(err error) string {
+	if err == nil {
+		return ""
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorTypeTimeout
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.Is(err, backendplugin.ErrPluginUnavailable) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return ErrorTypeConnection
+	}
+
+	// The errors of the plugin client wrap the errors of the plugin, so every error of the
+	// chain is checked rather than only the first one of each type.
+	for ; err != nil; err = errors.Unwrap(err) {
+		if s, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+			switch s.GRPCStatus().Code() {
+			case codes.DeadlineExceeded:
+				return ErrorTypeTimeout
+			case codes.Unauthenticated, codes.PermissionDenied:
+				return ErrorTypeAuth
+			case codes.Unavailable:
+				return ErrorTypeConnection
+			}
+		}
+		if e, ok := err.(errutil.Error); ok && e.Reason != nil {
+			switch e.Reason.Status() {
+			case errutil.StatusTimeout:
+				return ErrorTypeTimeout
+			case errutil.StatusUnauthorized, errutil.StatusForbidden:
+				return ErrorTypeAuth
+			}
+		}
+	}
+	return ""
+}
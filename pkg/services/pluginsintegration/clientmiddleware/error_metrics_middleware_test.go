@@ -0,0 +1,169 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorMetricsMiddleware(t *testing.T) {
+	pluginCtx := backend.PluginContext{PluginID: "test-datasource"}
+
+	newTest := func(t *testing.T) (*clienttest.ClientDecoratorTest, *prometheus.CounterVec) {
+		counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_errors_total"}, []string{"plugin_id", "endpoint", "error_type"})
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(newErrorMetricsMiddleware(counter)))
+		return cdt, counter
+	}
+
+	t.Run("Should classify errors returned by QueryData", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			err     error
+			expType string
+		}{
+			{
+				name:    "deadline exceeded",
+				err:     plugins.ErrPluginDownstreamError.Errorf("failed to query data: %w", context.DeadlineExceeded),
+				expType: ErrorTypeTimeout,
+			},
+			{
+				name:    "gRPC deadline exceeded",
+				err:     plugins.ErrPluginDownstreamError.Errorf("failed to query data: %w", status.Error(codes.DeadlineExceeded, "too slow")),
+				expType: ErrorTypeTimeout,
+			},
+			{
+				name:    "gRPC unauthenticated",
+				err:     plugins.ErrPluginDownstreamError.Errorf("failed to query data: %w", status.Error(codes.Unauthenticated, "invalid token")),
+				expType: ErrorTypeAuth,
+			},
+			{
+				name:    "forbidden",
+				err:     errutil.NewBase(errutil.StatusForbidden, "test.forbidden").Errorf("no access"),
+				expType: ErrorTypeAuth,
+			},
+			{
+				name:    "plugin unavailable",
+				err:     plugins.ErrPluginUnavailable.Errorf("%w", backendplugin.ErrPluginUnavailable),
+				expType: ErrorTypeConnection,
+			},
+			{
+				name:    "connection refused",
+				err:     fmt.Errorf("query: %w", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}),
+				expType: ErrorTypeConnection,
+			},
+			{
+				name:    "downstream error",
+				err:     plugins.ErrPluginDownstreamError.Errorf("failed to query data: %w", errors.New("syntax error")),
+				expType: ErrorTypeDownstream,
+			},
+			{
+				name:    "internal error",
+				err:     errors.New("req cannot be nil"),
+				expType: ErrorTypeInternal,
+			},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				cdt, counter := newTest(t)
+				cdt.TestClient.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+					return nil, c.err
+				}
+
+				_, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: pluginCtx})
+				require.ErrorIs(t, err, c.err)
+				require.Equal(t, 1, testutil.CollectAndCount(counter))
+				require.Equal(t, float64(1), testutil.ToFloat64(counter.WithLabelValues("test-datasource", "queryData", c.expType)))
+			})
+		}
+	})
+
+	t.Run("Should not count canceled QueryData requests", func(t *testing.T) {
+		cdt, counter := newTest(t)
+		cdt.TestClient.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			return nil, plugins.ErrPluginDownstreamError.Errorf("failed to query data: %w", context.Canceled)
+		}
+
+		_, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: pluginCtx})
+		require.Error(t, err)
+		require.Equal(t, 0, testutil.CollectAndCount(counter))
+	})
+
+	t.Run("Should classify the errors of single queries", func(t *testing.T) {
+		cdt, counter := newTest(t)
+		cdt.TestClient.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{}
+			resp.Responses["B"] = backend.ErrDataResponse(backend.StatusUnauthorized, "invalid credentials")
+			resp.Responses["C"] = backend.ErrDataResponse(backend.StatusBadRequest, "syntax error")
+			resp.Responses["D"] = backend.DataResponse{Error: context.DeadlineExceeded}
+			return resp, nil
+		}
+
+		_, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+		require.Equal(t, 3, testutil.CollectAndCount(counter))
+		require.Equal(t, float64(1), testutil.ToFloat64(counter.WithLabelValues("test-datasource", "queryData", ErrorTypeAuth)))
+		require.Equal(t, float64(1), testutil.ToFloat64(counter.WithLabelValues("test-datasource", "queryData", ErrorTypeDownstream)))
+		require.Equal(t, float64(1), testutil.ToFloat64(counter.WithLabelValues("test-datasource", "queryData", ErrorTypeTimeout)))
+	})
+
+	t.Run("Should classify CallResource responses by status code", func(t *testing.T) {
+		cases := []struct {
+			status  int
+			expType string
+		}{
+			{status: http.StatusOK},
+			{status: http.StatusNotFound},
+			{status: http.StatusForbidden, expType: ErrorTypeAuth},
+			{status: http.StatusGatewayTimeout, expType: ErrorTypeTimeout},
+			{status: http.StatusBadGateway, expType: ErrorTypeDownstream},
+		}
+		for _, c := range cases {
+			t.Run(http.StatusText(c.status), func(t *testing.T) {
+				cdt, counter := newTest(t)
+				cdt.TestClient.CallResourceFunc = func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+					return sender.Send(&backend.CallResourceResponse{Status: c.status})
+				}
+
+				err := cdt.Decorator.CallResource(context.Background(), &backend.CallResourceRequest{PluginContext: pluginCtx}, nopCallResourceSender)
+				require.NoError(t, err)
+				if c.expType == "" {
+					require.Equal(t, 0, testutil.CollectAndCount(counter))
+					return
+				}
+				require.Equal(t, 1, testutil.CollectAndCount(counter))
+				require.Equal(t, float64(1), testutil.ToFloat64(counter.WithLabelValues("test-datasource", "callResource", c.expType)))
+			})
+		}
+	})
+
+	t.Run("Should classify errors returned by CallResource", func(t *testing.T) {
+		cdt, counter := newTest(t)
+		cdt.TestClient.CallResourceFunc = func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+			if err := sender.Send(&backend.CallResourceResponse{Status: http.StatusOK}); err != nil {
+				return err
+			}
+			return status.Error(codes.Unavailable, "connection closed")
+		}
+
+		err := cdt.Decorator.CallResource(context.Background(), &backend.CallResourceRequest{PluginContext: pluginCtx}, nopCallResourceSender)
+		require.Error(t, err)
+		require.Equal(t, 1, testutil.CollectAndCount(counter))
+		require.Equal(t, float64(1), testutil.ToFloat64(counter.WithLabelValues("test-datasource", "callResource", ErrorTypeConnection)))
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"golang.org/x/oauth2"
 )
 
 // NewOAuthTokenMiddleware creates a new plugins.ClientMiddleware that will
@@ -58,7 +59,21 @@ func (m *OAuthTokenMiddleware) applyToken(ctx context.Context, pCtx backend.Plug
 	}
 
 	if m.oAuthTokenService.IsOAuthPassThruEnabled(ds) {
-		if token := m.oAuthTokenService.GetCurrentOAuthToken(ctx, reqCtx.SignedInUser); token != nil {
+		var token *oauth2.Token
+		// Anonymous users have no identity to forward. For everyone else the token
+		// service refreshes the access token if it is about to expire.
+		if !reqCtx.IsAnonymous {
+			token = m.oAuthTokenService.GetCurrentOAuthToken(ctx, reqCtx.SignedInUser)
+		}
+
+		if token == nil {
+			// Make sure no identity that does not belong to the current user is forwarded.
+			if h, ok := req.(backend.ForwardHTTPHeaders); ok {
+				h.DeleteHTTPHeader(tokenHeaderName)
+				h.DeleteHTTPHeader(idTokenHeaderName)
+			}
+			ctx = httpclient.WithContextualMiddleware(ctx, httpclientprovider.DeleteHeadersMiddleware(tokenHeaderName, idTokenHeaderName))
+		} else {
 			authorizationHeader := fmt.Sprintf("%s %s", token.Type(), token.AccessToken)
 			idTokenHeader := ""
 
@@ -194,4 +194,80 @@ func TestOAuthTokenMiddleware(t *testing.T) {
 			require.Equal(t, "id-token", reqClone.Header.Get(idTokenHeaderName))
 		})
 	})
+
+	for _, tc := range []struct {
+		desc  string
+		user  *user.SignedInUser
+		token *oauth2.Token
+	}{
+		{
+			desc: "When oauthPassThru configured for a datasource and the user has no OAuth token",
+			user: &user.SignedInUser{},
+		},
+		{
+			desc:  "When oauthPassThru configured for a datasource and the user is anonymous",
+			user:  &user.SignedInUser{IsAnonymous: true},
+			token: &oauth2.Token{TokenType: "bearer", AccessToken: "access-token"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+			require.NoError(t, err)
+
+			oAuthTokenService := &oauthtokentest.Service{Token: tc.token}
+			cdt := clienttest.NewClientDecoratorTest(t,
+				clienttest.WithReqContext(req, tc.user),
+				clienttest.WithMiddlewares(NewOAuthTokenMiddleware(oAuthTokenService)),
+			)
+
+			jsonDataBytes, err := json.Marshal(map[string]interface{}{"oauthPassThru": true})
+			require.NoError(t, err)
+
+			pluginCtx := backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					JSONData: jsonDataBytes,
+				},
+			}
+
+			t.Run("Should strip OAuth Identity when calling QueryData", func(t *testing.T) {
+				_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+					PluginContext: pluginCtx,
+					Headers: map[string]string{
+						otherHeader:       "test",
+						tokenHeaderName:   "Bearer someone-elses-token",
+						idTokenHeaderName: "someone-elses-id-token",
+					},
+				})
+				require.NoError(t, err)
+				require.NotNil(t, cdt.QueryDataReq)
+				require.Equal(t, map[string]string{otherHeader: "test"}, cdt.QueryDataReq.Headers)
+
+				middlewares := httpclient.ContextualMiddlewareFromContext(cdt.QueryDataCtx)
+				require.Len(t, middlewares, 1)
+				require.Equal(t, httpclientprovider.DeleteHeadersMiddlewareName, middlewares[0].(httpclient.MiddlewareName).MiddlewareName())
+
+				reqClone := req.Clone(req.Context())
+				reqClone.Header.Set(tokenHeaderName, "Bearer someone-elses-token")
+				reqClone.Header.Set(idTokenHeaderName, "someone-elses-id-token")
+				res, err := middlewares[0].CreateMiddleware(httpclient.Options{}, finalRoundTripper).RoundTrip(reqClone)
+				require.NoError(t, err)
+				require.NoError(t, res.Body.Close())
+				require.Empty(t, reqClone.Header.Get(tokenHeaderName))
+				require.Empty(t, reqClone.Header.Get(idTokenHeaderName))
+			})
+
+			t.Run("Should strip OAuth Identity when calling CallResource", func(t *testing.T) {
+				err = cdt.Decorator.CallResource(req.Context(), &backend.CallResourceRequest{
+					PluginContext: pluginCtx,
+					Headers: map[string][]string{
+						otherHeader:     {"test"},
+						tokenHeaderName: {"Bearer someone-elses-token"},
+					},
+				}, nopCallResourceSender)
+				require.NoError(t, err)
+				require.NotNil(t, cdt.CallResourceReq)
+				require.Equal(t, map[string][]string{otherHeader: {"test"}}, cdt.CallResourceReq.Headers)
+			})
+		})
+	}
 }
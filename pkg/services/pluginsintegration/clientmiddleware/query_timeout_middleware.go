@@ -0,0 +1,135 @@
+package clientmiddleware
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+)
+
+// queryTimeoutCacheTTL is how long the query timeout of an org and teams is cached, so that
+// the preferences are not read for every query.
+const queryTimeoutCacheTTL = time.Minute
+
+// NewQueryTimeoutMiddleware creates a new plugins.ClientMiddleware that will
+// apply the query timeout preference of the teams of the signed in user to
+// QueryData requests that don't already have a deadline.
+func NewQueryTimeoutMiddleware(preferenceService pref.Service) plugins.ClientMiddleware {
+	cache := localcache.New(queryTimeoutCacheTTL, 2*queryTimeoutCacheTTL)
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &QueryTimeoutMiddleware{
+			next:              next,
+			preferenceService: preferenceService,
+			cache:             cache,
+			log:               log.New("query-timeout-middleware"),
+		}
+	})
+}
+
+type QueryTimeoutMiddleware struct {
+	next              plugins.Client
+	preferenceService pref.Service
+	cache             *localcache.CacheService
+	log               log.Logger
+}
+
+// queryTimeoutCacheKey returns the cache key of the query timeout of the org and teams,
+// the preferences it is resolved from.
+func queryTimeoutCacheKey(orgID int64, teams []int64) string {
+	sorted := append([]int64(nil), teams...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return fmt.Sprintf("query-timeout-%d-%v", orgID, sorted)
+}
+
+// queryTimeout returns the query timeout preference of the teams of the signed in user,
+// falling back to the one of the organization. It returns 0 if no timeout is configured.
+// The timeout is cached for a short time, failures to read the preferences are not.
+func (m *QueryTimeoutMiddleware) queryTimeout(ctx context.Context) time.Duration {
+	reqCtx := contexthandler.FromContext(ctx)
+	if reqCtx == nil || reqCtx.SignedInUser == nil {
+		return 0
+	}
+
+	key := queryTimeoutCacheKey(reqCtx.OrgID, reqCtx.Teams)
+	if cached, ok := m.cache.Get(key); ok {
+		return cached.(time.Duration)
+	}
+	timeout, ok := m.resolveQueryTimeout(ctx, reqCtx.OrgID, reqCtx.Teams)
+	if ok {
+		m.cache.Set(key, timeout, queryTimeoutCacheTTL)
+	}
+	return timeout
+}
+
+// resolveQueryTimeout reads the query timeout preference of the teams and org. It returns
+// false if the preferences could not be read.
+func (m *QueryTimeoutMiddleware) resolveQueryTimeout(ctx context.Context, orgID int64, teams []int64) (time.Duration, bool) {
+	preference, err := m.preferenceService.GetWithDefaults(ctx, &pref.GetPreferenceWithDefaultsQuery{
+		Teams: teams,
+		OrgID: orgID,
+	})
+	if err != nil {
+		m.log.Warn("Failed to get query timeout preference", "error", err)
+		return 0, false
+	}
+	if preference == nil || preference.JSONData == nil || preference.JSONData.QueryTimeout == "" {
+		return 0, true
+	}
+
+	timeout, err := time.ParseDuration(preference.JSONData.QueryTimeout)
+	if err != nil {
+		m.log.Warn("Invalid query timeout preference", "queryTimeout", preference.JSONData.QueryTimeout, "error", err)
+		return 0, true
+	}
+	return timeout, true
+}
+
+func (m *QueryTimeoutMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	// the request already specifies a timeout
+	if _, ok := ctx.Deadline(); ok {
+		return m.next.QueryData(ctx, req)
+	}
+
+	if timeout := m.queryTimeout(ctx); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *QueryTimeoutMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *QueryTimeoutMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *QueryTimeoutMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *QueryTimeoutMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *QueryTimeoutMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *QueryTimeoutMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
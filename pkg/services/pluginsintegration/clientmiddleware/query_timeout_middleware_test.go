@@ -0,0 +1,133 @@
+package clientmiddleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+	"github.com/grafana/grafana/pkg/services/preference/preftest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTimeoutMiddleware(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+	require.NoError(t, err)
+
+	pluginCtx := backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+	}
+
+	t.Run("When the teams of the user have a query timeout", func(t *testing.T) {
+		preferenceService := &preftest.FakePreferenceService{
+			ExpectedPreference: &pref.Preference{
+				JSONData: &pref.PreferenceJSONData{QueryTimeout: "30s"},
+			},
+		}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{OrgID: 1, Teams: []int64{2}}),
+			clienttest.WithMiddlewares(NewQueryTimeoutMiddleware(preferenceService)),
+		)
+
+		t.Run("Should apply the team query timeout when calling QueryData", func(t *testing.T) {
+			start := time.Now()
+			_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+				PluginContext: pluginCtx,
+			})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.QueryDataReq)
+
+			deadline, ok := cdt.QueryDataCtx.Deadline()
+			require.True(t, ok)
+			require.WithinDuration(t, start.Add(30*time.Second), deadline, 5*time.Second)
+		})
+
+		t.Run("Should keep the timeout of the request when calling QueryData", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(req.Context(), time.Hour)
+			defer cancel()
+			expected, _ := ctx.Deadline()
+
+			_, err = cdt.Decorator.QueryData(ctx, &backend.QueryDataRequest{
+				PluginContext: pluginCtx,
+			})
+			require.NoError(t, err)
+
+			deadline, ok := cdt.QueryDataCtx.Deadline()
+			require.True(t, ok)
+			require.Equal(t, expected, deadline)
+		})
+
+		t.Run("Should not apply a timeout when calling CallResource", func(t *testing.T) {
+			err = cdt.Decorator.CallResource(req.Context(), &backend.CallResourceRequest{
+				PluginContext: pluginCtx,
+			}, nopCallResourceSender)
+			require.NoError(t, err)
+
+			_, ok := cdt.CallResourceCtx.Deadline()
+			require.False(t, ok)
+		})
+	})
+
+	t.Run("When the query timeout was already resolved for the teams of the user", func(t *testing.T) {
+		preferenceService := &countingPreferenceService{
+			FakePreferenceService: preftest.FakePreferenceService{
+				ExpectedPreference: &pref.Preference{
+					JSONData: &pref.PreferenceJSONData{QueryTimeout: "30s"},
+				},
+			},
+		}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{OrgID: 1, Teams: []int64{2}}),
+			clienttest.WithMiddlewares(NewQueryTimeoutMiddleware(preferenceService)),
+		)
+
+		t.Run("Should not read the preferences again when calling QueryData", func(t *testing.T) {
+			for i := 0; i < 3; i++ {
+				_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+					PluginContext: pluginCtx,
+				})
+				require.NoError(t, err)
+				_, ok := cdt.QueryDataCtx.Deadline()
+				require.True(t, ok)
+			}
+			require.Equal(t, 1, preferenceService.calls)
+		})
+	})
+
+	t.Run("When the teams of the user don't have a query timeout", func(t *testing.T) {
+		preferenceService := &preftest.FakePreferenceService{
+			ExpectedPreference: &pref.Preference{
+				JSONData: &pref.PreferenceJSONData{},
+			},
+		}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{OrgID: 1, Teams: []int64{2}}),
+			clienttest.WithMiddlewares(NewQueryTimeoutMiddleware(preferenceService)),
+		)
+
+		t.Run("Should not apply a timeout when calling QueryData", func(t *testing.T) {
+			_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+				PluginContext: pluginCtx,
+			})
+			require.NoError(t, err)
+
+			_, ok := cdt.QueryDataCtx.Deadline()
+			require.False(t, ok)
+		})
+	})
+}
+
+// countingPreferenceService counts how many times the preferences are read.
+type countingPreferenceService struct {
+	preftest.FakePreferenceService
+	calls int
+}
+
+func (s *countingPreferenceService) GetWithDefaults(ctx context.Context, query *pref.GetPreferenceWithDefaultsQuery) (*pref.Preference, error) {
+	s.calls++
+	return s.FakePreferenceService.GetWithDefaults(ctx, query)
+}
@@ -0,0 +1,71 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// ErrReadOnly is returned when a resource request that can write is rejected because the
+// plugins are in read-only mode.
+var ErrReadOnly = errors.New("plugins are in read-only mode")
+
+// NewReadOnlyMiddleware creates a new plugins.ClientMiddleware that will reject CallResource
+// requests with methods other than GET, HEAD and OPTIONS while readOnly returns true, before
+// they reach the plugin. Queries, health checks and streams are not affected.
+func NewReadOnlyMiddleware(readOnly func() bool) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &ReadOnlyMiddleware{
+			next:     next,
+			readOnly: readOnly,
+		}
+	})
+}
+
+type ReadOnlyMiddleware struct {
+	next     plugins.Client
+	readOnly func() bool
+}
+
+func (m *ReadOnlyMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *ReadOnlyMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.next.CallResource(ctx, req, sender)
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if m.readOnly() {
+			return fmt.Errorf("%w: %s requests to the resources of plugin %s are not allowed", ErrReadOnly, req.Method, req.PluginContext.PluginID)
+		}
+	}
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *ReadOnlyMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *ReadOnlyMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *ReadOnlyMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *ReadOnlyMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *ReadOnlyMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
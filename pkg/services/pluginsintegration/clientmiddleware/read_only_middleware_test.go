@@ -0,0 +1,59 @@
+package clientmiddleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	newRequest := func(method string) *backend.CallResourceRequest {
+		return &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{PluginID: "plugin"},
+			Method:        method,
+			Path:          "items",
+		}
+	}
+
+	t.Run("Should reject POST CallResource requests in read-only mode before they reach the plugin", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewReadOnlyMiddleware(func() bool { return true })))
+
+		err := cdt.Decorator.CallResource(context.Background(), newRequest(http.MethodPost), &collectingSender{})
+		require.ErrorIs(t, err, ErrReadOnly)
+		require.EqualError(t, err, "plugins are in read-only mode: POST requests to the resources of plugin plugin are not allowed")
+		require.Nil(t, cdt.CallResourceReq)
+	})
+
+	t.Run("Should pass GET CallResource requests, queries and health checks in read-only mode to the plugin", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewReadOnlyMiddleware(func() bool { return true })))
+
+		err := cdt.Decorator.CallResource(context.Background(), newRequest(http.MethodGet), &collectingSender{})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.CallResourceReq)
+
+		_, err = cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+
+		_, err = cdt.Decorator.CheckHealth(context.Background(), &backend.CheckHealthRequest{})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.CheckHealthReq)
+	})
+
+	t.Run("Should pass all CallResource requests to the plugin when read-only mode is not active", func(t *testing.T) {
+		readOnly := false
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewReadOnlyMiddleware(func() bool { return readOnly })))
+
+		err := cdt.Decorator.CallResource(context.Background(), newRequest(http.MethodDelete), &collectingSender{})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.CallResourceReq)
+
+		readOnly = true
+		err = cdt.Decorator.CallResource(context.Background(), newRequest(http.MethodDelete), &collectingSender{})
+		require.ErrorIs(t, err, ErrReadOnly)
+	})
+}
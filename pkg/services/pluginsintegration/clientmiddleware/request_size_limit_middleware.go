@@ -0,0 +1,79 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// ErrRequestTooLarge is returned when the body of a resource request exceeds the size limit
+// of the plugin.
+var ErrRequestTooLarge = errors.New("plugin request body exceeds the size limit")
+
+// NewRequestSizeLimitMiddleware creates a new plugins.ClientMiddleware that will reject
+// CallResource requests with a body larger than the limit of the plugin, before they reach
+// the plugin. The limit of a plugin is its entry in pluginMaxBytes, or maxBytes if it has
+// none. A limit of 0 means no limit.
+//
+// The HTTP API already limits the bodies of resource requests while reading them, this is a
+// secondary check for the requests of other callers of the plugin client.
+func NewRequestSizeLimitMiddleware(maxBytes int, pluginMaxBytes map[string]int) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &RequestSizeLimitMiddleware{
+			next:           next,
+			maxBytes:       maxBytes,
+			pluginMaxBytes: pluginMaxBytes,
+		}
+	})
+}
+
+type RequestSizeLimitMiddleware struct {
+	next           plugins.Client
+	maxBytes       int
+	pluginMaxBytes map[string]int
+}
+
+func (m *RequestSizeLimitMiddleware) limit(pluginID string) int {
+	if maxBytes, ok := m.pluginMaxBytes[pluginID]; ok {
+		return maxBytes
+	}
+	return m.maxBytes
+}
+
+func (m *RequestSizeLimitMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *RequestSizeLimitMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.next.CallResource(ctx, req, sender)
+	}
+
+	if maxBytes := m.limit(req.PluginContext.PluginID); maxBytes > 0 && len(req.Body) > maxBytes {
+		return fmt.Errorf("%w: %d bytes, limit is %d bytes", ErrRequestTooLarge, len(req.Body), maxBytes)
+	}
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *RequestSizeLimitMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *RequestSizeLimitMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *RequestSizeLimitMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *RequestSizeLimitMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *RequestSizeLimitMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
@@ -0,0 +1,57 @@
+package clientmiddleware
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestSizeLimitMiddleware(t *testing.T) {
+	const maxBytes = 1024
+
+	newRequest := func(pluginID string, size int) *backend.CallResourceRequest {
+		return &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{PluginID: pluginID},
+			Body:          bytes.Repeat([]byte("x"), size),
+		}
+	}
+
+	t.Run("Should pass CallResource requests within the limit to the plugin", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewRequestSizeLimitMiddleware(maxBytes, nil)))
+
+		err := cdt.Decorator.CallResource(context.Background(), newRequest("plugin", maxBytes), &collectingSender{})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.CallResourceReq)
+		require.Len(t, cdt.CallResourceReq.Body, maxBytes)
+	})
+
+	t.Run("Should reject CallResource requests over the limit before they reach the plugin", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewRequestSizeLimitMiddleware(maxBytes, nil)))
+
+		err := cdt.Decorator.CallResource(context.Background(), newRequest("plugin", maxBytes+1), &collectingSender{})
+		require.ErrorIs(t, err, ErrRequestTooLarge)
+		require.EqualError(t, err, "plugin request body exceeds the size limit: 1025 bytes, limit is 1024 bytes")
+		require.Nil(t, cdt.CallResourceReq)
+	})
+
+	t.Run("Should use the limit of the plugin if it has one", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewRequestSizeLimitMiddleware(maxBytes, map[string]int{
+			"large-plugin": 4 * maxBytes,
+			"small-plugin": 10,
+			"no-limit":     0,
+		})))
+
+		err := cdt.Decorator.CallResource(context.Background(), newRequest("large-plugin", 2*maxBytes), &collectingSender{})
+		require.NoError(t, err)
+		err = cdt.Decorator.CallResource(context.Background(), newRequest("no-limit", 10*maxBytes), &collectingSender{})
+		require.NoError(t, err)
+		err = cdt.Decorator.CallResource(context.Background(), newRequest("small-plugin", 11), &collectingSender{})
+		require.ErrorIs(t, err, ErrRequestTooLarge)
+		err = cdt.Decorator.CallResource(context.Background(), newRequest("other-plugin", maxBytes+1), &collectingSender{})
+		require.ErrorIs(t, err, ErrRequestTooLarge)
+	})
+}
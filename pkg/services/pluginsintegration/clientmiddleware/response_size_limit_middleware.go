@@ -0,0 +1,99 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// ErrResponseTooLarge is returned when the response of a plugin exceeds the size limit.
+var ErrResponseTooLarge = errors.New("plugin response exceeds the size limit")
+
+// NewResponseSizeLimitMiddleware creates a new plugins.ClientMiddleware that will
+// fail QueryData and CallResource requests whose responses are larger than
+// maxBytes, so that huge responses don't propagate further. The frames of
+// QueryData responses are measured in their serialized form. The chunks of
+// CallResource responses are counted as they are streamed.
+func NewResponseSizeLimitMiddleware(maxBytes int) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &ResponseSizeLimitMiddleware{
+			next:     next,
+			maxBytes: maxBytes,
+		}
+	})
+}
+
+type ResponseSizeLimitMiddleware struct {
+	next     plugins.Client
+	maxBytes int
+}
+
+func (m *ResponseSizeLimitMiddleware) limitErr(size int) error {
+	return fmt.Errorf("%w: at least %d bytes, limit is %d bytes", ErrResponseTooLarge, size, m.maxBytes)
+}
+
+func (m *ResponseSizeLimitMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp, err := m.next.QueryData(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	size := 0
+	for _, r := range resp.Responses {
+		frames, err := r.Frames.MarshalArrow()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range frames {
+			size += len(f)
+		}
+		if size > m.maxBytes {
+			return nil, m.limitErr(size)
+		}
+	}
+	return resp, nil
+}
+
+func (m *ResponseSizeLimitMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return m.next.CallResource(ctx, req, &sizeLimitSender{sender: sender, m: m})
+}
+
+// sizeLimitSender fails sending once the total size of the sent bodies exceeds the limit.
+type sizeLimitSender struct {
+	sender backend.CallResourceResponseSender
+	m      *ResponseSizeLimitMiddleware
+	size   int
+}
+
+func (s *sizeLimitSender) Send(resp *backend.CallResourceResponse) error {
+	if resp != nil {
+		s.size += len(resp.Body)
+	}
+	if s.size > s.m.maxBytes {
+		return s.m.limitErr(s.size)
+	}
+	return s.sender.Send(resp)
+}
+
+func (m *ResponseSizeLimitMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *ResponseSizeLimitMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *ResponseSizeLimitMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *ResponseSizeLimitMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *ResponseSizeLimitMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
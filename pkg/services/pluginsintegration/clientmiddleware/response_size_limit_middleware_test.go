@@ -0,0 +1,84 @@
+package clientmiddleware
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/stretchr/testify/require"
+)
+
+type collectingSender struct {
+	bodies [][]byte
+}
+
+func (s *collectingSender) Send(resp *backend.CallResourceResponse) error {
+	s.bodies = append(s.bodies, resp.Body)
+	return nil
+}
+
+func TestResponseSizeLimitMiddleware(t *testing.T) {
+	const maxBytes = 4096
+
+	queryDataFunc := func(values int) backend.QueryDataHandlerFunc {
+		return func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			frame := data.NewFrame("frame", data.NewField("value", nil, make([]int64, values)))
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{frame}}
+			return resp, nil
+		}
+	}
+
+	t.Run("Should return QueryData responses within the limit", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewResponseSizeLimitMiddleware(maxBytes)))
+		cdt.TestClient.QueryDataFunc = queryDataFunc(10)
+
+		resp, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Responses["A"].Frames, 1)
+	})
+
+	t.Run("Should fail QueryData responses over the limit", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewResponseSizeLimitMiddleware(maxBytes)))
+		cdt.TestClient.QueryDataFunc = queryDataFunc(10000)
+
+		resp, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{})
+		require.ErrorIs(t, err, ErrResponseTooLarge)
+		require.Nil(t, resp)
+	})
+
+	callResourceFunc := func(chunks int) backend.CallResourceHandlerFunc {
+		return func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+			for i := 0; i < chunks; i++ {
+				if err := sender.Send(&backend.CallResourceResponse{Status: 200, Body: bytes.Repeat([]byte("x"), 1024)}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	t.Run("Should stream CallResource responses within the limit", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewResponseSizeLimitMiddleware(maxBytes)))
+		cdt.TestClient.CallResourceFunc = callResourceFunc(4)
+
+		sender := &collectingSender{}
+		err := cdt.Decorator.CallResource(context.Background(), &backend.CallResourceRequest{}, sender)
+		require.NoError(t, err)
+		require.Len(t, sender.bodies, 4)
+	})
+
+	t.Run("Should stop streaming CallResource responses over the limit", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewResponseSizeLimitMiddleware(maxBytes)))
+		cdt.TestClient.CallResourceFunc = callResourceFunc(10)
+
+		sender := &collectingSender{}
+		err := cdt.Decorator.CallResource(context.Background(), &backend.CallResourceRequest{}, sender)
+		require.ErrorIs(t, err, ErrResponseTooLarge)
+		// the chunk that exceeds the cumulative limit and the ones after it are not sent
+		require.Len(t, sender.bodies, 4)
+	})
+}
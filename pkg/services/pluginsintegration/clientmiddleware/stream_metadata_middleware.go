@@ -0,0 +1,99 @@
+package clientmiddleware
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	UserMetadataKey   = "grafana-user"
+	OrgIDMetadataKey  = "grafana-org-id"
+	TenantMetadataKey = "grafana-tenant-id"
+)
+
+// NewStreamMetadataMiddleware creates a new plugins.ClientMiddleware that will
+// add the user, org and tenant of the request to the outgoing gRPC metadata of
+// the streaming calls of plugins.Client. Unlike the other calls, streaming
+// calls have no HTTP headers to forward them in. Empty values are omitted.
+func NewStreamMetadataMiddleware(tenantID string) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &StreamMetadataMiddleware{
+			next:     next,
+			tenantID: tenantID,
+		}
+	})
+}
+
+type StreamMetadataMiddleware struct {
+	next     plugins.Client
+	tenantID string
+}
+
+func (m *StreamMetadataMiddleware) applyMetadata(ctx context.Context, pCtx backend.PluginContext) context.Context {
+	var kv []string
+	if pCtx.User != nil && pCtx.User.Login != "" {
+		kv = append(kv, UserMetadataKey, pCtx.User.Login)
+	}
+	if pCtx.OrgID != 0 {
+		kv = append(kv, OrgIDMetadataKey, strconv.FormatInt(pCtx.OrgID, 10))
+	}
+	if m.tenantID != "" {
+		kv = append(kv, TenantMetadataKey, m.tenantID)
+	}
+
+	if len(kv) == 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+func (m *StreamMetadataMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *StreamMetadataMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *StreamMetadataMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *StreamMetadataMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *StreamMetadataMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if req == nil {
+		return m.next.SubscribeStream(ctx, req)
+	}
+
+	ctx = m.applyMetadata(ctx, req.PluginContext)
+
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *StreamMetadataMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	if req == nil {
+		return m.next.PublishStream(ctx, req)
+	}
+
+	ctx = m.applyMetadata(ctx, req.PluginContext)
+
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *StreamMetadataMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	if req == nil {
+		return m.next.RunStream(ctx, req, sender)
+	}
+
+	ctx = m.applyMetadata(ctx, req.PluginContext)
+
+	return m.next.RunStream(ctx, req, sender)
+}
@@ -0,0 +1,85 @@
+package clientmiddleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestStreamMetadataMiddleware(t *testing.T) {
+	t.Run("When user, org and tenant are known", func(t *testing.T) {
+		pluginCtx := backend.PluginContext{
+			OrgID: 2,
+			User:  &backend.User{Login: "admin"},
+		}
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithMiddlewares(NewStreamMetadataMiddleware("tenant-1")),
+		)
+
+		requireMetadata := func(t *testing.T, ctx context.Context) {
+			t.Helper()
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, []string{"admin"}, md.Get(UserMetadataKey))
+			require.Equal(t, []string{"2"}, md.Get(OrgIDMetadataKey))
+			require.Equal(t, []string{"tenant-1"}, md.Get(TenantMetadataKey))
+		}
+
+		t.Run("Should add metadata when calling SubscribeStream", func(t *testing.T) {
+			_, err := cdt.Decorator.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{
+				PluginContext: pluginCtx,
+			})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.SubscribeStreamReq)
+			requireMetadata(t, cdt.SubscribeStreamCtx)
+		})
+
+		t.Run("Should add metadata when calling PublishStream", func(t *testing.T) {
+			_, err := cdt.Decorator.PublishStream(context.Background(), &backend.PublishStreamRequest{
+				PluginContext: pluginCtx,
+			})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.PublishStreamReq)
+			requireMetadata(t, cdt.PublishStreamCtx)
+		})
+
+		t.Run("Should add metadata when calling RunStream", func(t *testing.T) {
+			err := cdt.Decorator.RunStream(context.Background(), &backend.RunStreamRequest{
+				PluginContext: pluginCtx,
+			}, &backend.StreamSender{})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.RunStreamReq)
+			requireMetadata(t, cdt.RunStreamCtx)
+		})
+
+		t.Run("Should not add metadata when calling QueryData", func(t *testing.T) {
+			_, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{
+				PluginContext: pluginCtx,
+			})
+			require.NoError(t, err)
+			_, ok := metadata.FromOutgoingContext(cdt.QueryDataCtx)
+			require.False(t, ok)
+		})
+	})
+
+	t.Run("When user, org and tenant are unknown", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithMiddlewares(NewStreamMetadataMiddleware("")),
+		)
+
+		t.Run("Should not add metadata when calling RunStream", func(t *testing.T) {
+			err := cdt.Decorator.RunStream(context.Background(), &backend.RunStreamRequest{
+				PluginContext: backend.PluginContext{},
+			}, &backend.StreamSender{})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.RunStreamReq)
+			_, ok := metadata.FromOutgoingContext(cdt.RunStreamCtx)
+			require.False(t, ok)
+		})
+	})
+}
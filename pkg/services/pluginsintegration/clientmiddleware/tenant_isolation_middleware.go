@@ -0,0 +1,100 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+)
+
+// ErrCrossTenantAccess is returned when a query is rejected because its datasource does not
+// belong to the organization of the user.
+var ErrCrossTenantAccess = errors.New("datasource does not belong to the organization of the user")
+
+// NewTenantIsolationMiddleware creates a new plugins.ClientMiddleware that will reject
+// QueryData requests of users for datasources outside of their organization, before they
+// reach the plugin. Requests without a signed in user, such as alert rule evaluations, and
+// requests for the built-in Grafana and expression datasources are not checked.
+func NewTenantIsolationMiddleware(dataSourceCache datasources.CacheService) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &TenantIsolationMiddleware{
+			next:            next,
+			dataSourceCache: dataSourceCache,
+		}
+	})
+}
+
+type TenantIsolationMiddleware struct {
+	next            plugins.Client
+	dataSourceCache datasources.CacheService
+}
+
+// checkDataSource returns an error if the datasource of the request does not belong to the
+// organization of the signed in user.
+func (m *TenantIsolationMiddleware) checkDataSource(ctx context.Context, pCtx backend.PluginContext) error {
+	reqCtx := contexthandler.FromContext(ctx)
+	settings := pCtx.DataSourceInstanceSettings
+	if reqCtx == nil || reqCtx.SignedInUser == nil || settings == nil {
+		return nil
+	}
+	if settings.UID == grafanads.DatasourceUID || expr.IsDataSource(settings.UID) {
+		return nil
+	}
+
+	user := reqCtx.SignedInUser
+	if pCtx.OrgID != user.OrgID {
+		return fmt.Errorf("%w: datasource %s is queried for organization %d", ErrCrossTenantAccess, settings.UID, pCtx.OrgID)
+	}
+	ds, err := m.dataSourceCache.GetDatasourceByUID(ctx, settings.UID, user, false)
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return fmt.Errorf("%w: datasource %s", ErrCrossTenantAccess, settings.UID)
+		}
+		return err
+	}
+	if ds.OrgId != user.OrgID || (settings.ID != 0 && ds.Id != settings.ID) {
+		return fmt.Errorf("%w: datasource %s", ErrCrossTenantAccess, settings.UID)
+	}
+	return nil
+}
+
+func (m *TenantIsolationMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	if err := m.checkDataSource(ctx, req.PluginContext); err != nil {
+		return nil, err
+	}
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *TenantIsolationMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *TenantIsolationMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *TenantIsolationMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *TenantIsolationMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *TenantIsolationMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *TenantIsolationMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
@@ -0,0 +1,98 @@
+package clientmiddleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	datasourcefakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantIsolationMiddleware(t *testing.T) {
+	dataSourceCache := &datasourcefakes.FakeCacheService{
+		DataSources: []*datasources.DataSource{
+			{Id: 1, Uid: "org1-ds", OrgId: 1},
+			{Id: 2, Uid: "org2-ds", OrgId: 2},
+		},
+	}
+	newRequest := func(orgID, dsID int64, dsUID string) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				OrgID:                      orgID,
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: dsID, UID: dsUID},
+			},
+		}
+	}
+	newDecoratorTest := func(t *testing.T) (*clienttest.ClientDecoratorTest, context.Context) {
+		req, err := http.NewRequest(http.MethodPost, "/api/ds/query", nil)
+		require.NoError(t, err)
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{OrgID: 1}),
+			clienttest.WithMiddlewares(NewTenantIsolationMiddleware(dataSourceCache)),
+		)
+		return cdt, req.Context()
+	}
+
+	t.Run("Should pass queries for a datasource of the org of the user to the plugin", func(t *testing.T) {
+		cdt, ctx := newDecoratorTest(t)
+
+		_, err := cdt.Decorator.QueryData(ctx, newRequest(1, 1, "org1-ds"))
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+	})
+
+	t.Run("Should reject queries for a datasource of another org before they reach the plugin", func(t *testing.T) {
+		cdt, ctx := newDecoratorTest(t)
+
+		_, err := cdt.Decorator.QueryData(ctx, newRequest(1, 2, "org2-ds"))
+		require.ErrorIs(t, err, ErrCrossTenantAccess)
+		require.EqualError(t, err, "datasource does not belong to the organization of the user: datasource org2-ds")
+		require.Nil(t, cdt.QueryDataReq)
+	})
+
+	t.Run("Should reject queries for another org than the org of the user", func(t *testing.T) {
+		cdt, ctx := newDecoratorTest(t)
+
+		_, err := cdt.Decorator.QueryData(ctx, newRequest(2, 2, "org2-ds"))
+		require.ErrorIs(t, err, ErrCrossTenantAccess)
+		require.Nil(t, cdt.QueryDataReq)
+	})
+
+	t.Run("Should reject queries with the uid of a datasource and the id of another", func(t *testing.T) {
+		cdt, ctx := newDecoratorTest(t)
+
+		_, err := cdt.Decorator.QueryData(ctx, newRequest(1, 2, "org1-ds"))
+		require.ErrorIs(t, err, ErrCrossTenantAccess)
+		require.Nil(t, cdt.QueryDataReq)
+	})
+
+	t.Run("Should reject queries for an unknown datasource", func(t *testing.T) {
+		cdt, ctx := newDecoratorTest(t)
+
+		_, err := cdt.Decorator.QueryData(ctx, newRequest(1, 3, "unknown"))
+		require.ErrorIs(t, err, ErrCrossTenantAccess)
+		require.Nil(t, cdt.QueryDataReq)
+	})
+
+	t.Run("Should pass queries for the Grafana datasource to the plugin", func(t *testing.T) {
+		cdt, ctx := newDecoratorTest(t)
+
+		_, err := cdt.Decorator.QueryData(ctx, newRequest(1, grafanads.DatasourceID, grafanads.DatasourceUID))
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+	})
+
+	t.Run("Should pass queries without a signed in user to the plugin", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewTenantIsolationMiddleware(dataSourceCache)))
+
+		_, err := cdt.Decorator.QueryData(context.Background(), newRequest(2, 2, "org2-ds"))
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+	})
+}
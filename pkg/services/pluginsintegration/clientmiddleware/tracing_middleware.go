@@ -0,0 +1,131 @@
+package clientmiddleware
+
+import (
+	"context"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/plugins"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Attributes of the spans of plugin requests.
+const (
+	spanAttributePluginID       = "plugin_id"
+	spanAttributeDataSourceUID  = "datasource_uid"
+	spanAttributeQueryCount     = "query_count"
+	spanAttributeQueryTypes     = "query_types"
+	spanAttributeResourcePath   = "resource_path"
+	spanAttributeResourceMethod = "resource_method"
+	spanAttributeStatusCode     = "status_code"
+)
+
+// NewTracingMiddleware creates a new plugins.ClientMiddleware that will start a span for
+// QueryData and CallResource requests, as a child of the span of the request context if
+// any. The span has the plugin id and datasource uid of the request, and the number and
+// types of the queries of QueryData requests. Errors are recorded on the span.
+func NewTracingMiddleware(tracer tracing.Tracer) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &TracingMiddleware{
+			next:   next,
+			tracer: tracer,
+		}
+	})
+}
+
+type TracingMiddleware struct {
+	next   plugins.Client
+	tracer tracing.Tracer
+}
+
+// start starts the span of a request and sets the attributes of its plugin context.
+func (m *TracingMiddleware) start(ctx context.Context, spanName string, pCtx backend.PluginContext) (context.Context, tracing.Span) {
+	ctx, span := m.tracer.Start(ctx, spanName)
+	span.SetAttributes(spanAttributePluginID, pCtx.PluginID, attribute.String(spanAttributePluginID, pCtx.PluginID))
+	if settings := pCtx.DataSourceInstanceSettings; settings != nil {
+		span.SetAttributes(spanAttributeDataSourceUID, settings.UID, attribute.String(spanAttributeDataSourceUID, settings.UID))
+	}
+	return ctx, span
+}
+
+// endSpan records the error of a request, if any, and ends its span.
+func endSpan(span tracing.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (m *TracingMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (resp *backend.QueryDataResponse, err error) {
+	if req == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	ctx, span := m.start(ctx, "plugins.queryData", req.PluginContext)
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(spanAttributeQueryCount, len(req.Queries), attribute.Int(spanAttributeQueryCount, len(req.Queries)))
+	if types := queryTypes(req.Queries); len(types) > 0 {
+		span.SetAttributes(spanAttributeQueryTypes, types, attribute.StringSlice(spanAttributeQueryTypes, types))
+	}
+
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *TracingMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) (err error) {
+	if req == nil {
+		return m.next.CallResource(ctx, req, sender)
+	}
+
+	ctx, span := m.start(ctx, "plugins.callResource", req.PluginContext)
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(spanAttributeResourcePath, req.Path, attribute.String(spanAttributeResourcePath, req.Path))
+	span.SetAttributes(spanAttributeResourceMethod, req.Method, attribute.String(spanAttributeResourceMethod, req.Method))
+
+	// Only the first response of a stream has the status code.
+	sent := false
+	return m.next.CallResource(ctx, req, callResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+		if !sent && res != nil {
+			sent = true
+			span.SetAttributes(spanAttributeStatusCode, res.Status, attribute.Int(spanAttributeStatusCode, res.Status))
+		}
+		return sender.Send(res)
+	}))
+}
+
+func (m *TracingMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *TracingMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *TracingMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *TracingMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *TracingMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
+
+// queryTypes returns the sorted distinct query types of the queries, without empty types.
+func queryTypes(queries []backend.DataQuery) []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, q := range queries {
+		if q.QueryType == "" || seen[q.QueryType] {
+			continue
+		}
+		seen[q.QueryType] = true
+		types = append(types, q.QueryType)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,187 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	pluginCtx := backend.PluginContext{
+		PluginID:                   "test-datasource",
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds-uid"},
+	}
+
+	newTest := func(t *testing.T) (*clienttest.ClientDecoratorTest, *fakeTracer) {
+		tracer := &fakeTracer{}
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewTracingMiddleware(tracer)))
+		return cdt, tracer
+	}
+
+	t.Run("Should start a span with the query metadata of QueryData requests", func(t *testing.T) {
+		cdt, tracer := newTest(t)
+
+		_, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pluginCtx,
+			Queries: []backend.DataQuery{
+				{RefID: "A", QueryType: "metrics"},
+				{RefID: "B", QueryType: "logs"},
+				{RefID: "C", QueryType: "metrics"},
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		require.Equal(t, "plugins.queryData", span.name)
+		require.True(t, span.ended)
+		require.Equal(t, map[string]interface{}{
+			"plugin_id":      "test-datasource",
+			"datasource_uid": "ds-uid",
+			"query_count":    3,
+			"query_types":    []string{"logs", "metrics"},
+		}, span.attributes)
+		require.Nil(t, span.err)
+		require.Equal(t, span, tracer.fromContext(cdt.QueryDataCtx), "the plugin should be called with the context of the span")
+	})
+
+	t.Run("Should end the span and record the error of failed QueryData requests", func(t *testing.T) {
+		cdt, tracer := newTest(t)
+		queryErr := errors.New("plugin unavailable")
+		cdt.TestClient.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			return nil, queryErr
+		}
+
+		_, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pluginCtx,
+			Queries:       []backend.DataQuery{{RefID: "A"}},
+		})
+		require.ErrorIs(t, err, queryErr)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		require.True(t, span.ended)
+		require.Equal(t, queryErr, span.err)
+		require.Equal(t, codes.Error, span.status)
+		require.Equal(t, 1, span.attributes["query_count"])
+		require.NotContains(t, span.attributes, "query_types")
+	})
+
+	t.Run("Should start a span with the resource metadata of CallResource requests", func(t *testing.T) {
+		cdt, tracer := newTest(t)
+		cdt.TestClient.CallResourceFunc = func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+			return sender.Send(&backend.CallResourceResponse{Status: http.StatusOK})
+		}
+
+		err := cdt.Decorator.CallResource(context.Background(), &backend.CallResourceRequest{
+			PluginContext: pluginCtx,
+			Path:          "api/v1/labels",
+			Method:        http.MethodGet,
+		}, nopCallResourceSender)
+		require.NoError(t, err)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		require.Equal(t, "plugins.callResource", span.name)
+		require.True(t, span.ended)
+		require.Equal(t, map[string]interface{}{
+			"plugin_id":       "test-datasource",
+			"datasource_uid":  "ds-uid",
+			"resource_path":   "api/v1/labels",
+			"resource_method": http.MethodGet,
+			"status_code":     http.StatusOK,
+		}, span.attributes)
+	})
+
+	t.Run("Should end the span and record the error of failed CallResource requests", func(t *testing.T) {
+		cdt, tracer := newTest(t)
+		callErr := errors.New("connection refused")
+		cdt.TestClient.CallResourceFunc = func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+			return callErr
+		}
+
+		err := cdt.Decorator.CallResource(context.Background(), &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{PluginID: "test-app"},
+			Path:          "settings",
+		}, nopCallResourceSender)
+		require.ErrorIs(t, err, callErr)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		require.True(t, span.ended)
+		require.Equal(t, callErr, span.err)
+		require.Equal(t, "test-app", span.attributes["plugin_id"])
+		require.NotContains(t, span.attributes, "datasource_uid")
+	})
+
+	t.Run("Should not start a span for other requests", func(t *testing.T) {
+		cdt, tracer := newTest(t)
+
+		_, err := cdt.Decorator.CheckHealth(context.Background(), &backend.CheckHealthRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+		require.Empty(t, tracer.spans)
+	})
+}
+
+type fakeSpanKey struct{}
+
+// fakeTracer records the spans it starts.
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Run(context.Context) error {
+	return nil
+}
+
+func (t *fakeTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, tracing.Span) {
+	span := &fakeSpan{name: spanName, attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, fakeSpanKey{}, span), span
+}
+
+func (t *fakeTracer) Inject(context.Context, http.Header, tracing.Span) {}
+
+func (t *fakeTracer) fromContext(ctx context.Context) *fakeSpan {
+	span, _ := ctx.Value(fakeSpanKey{}).(*fakeSpan)
+	return span
+}
+
+type fakeSpan struct {
+	name       string
+	attributes map[string]interface{}
+	status     codes.Code
+	err        error
+	ended      bool
+}
+
+func (s *fakeSpan) End() {
+	s.ended = true
+}
+
+func (s *fakeSpan) SetAttributes(key string, value interface{}, kv attribute.KeyValue) {
+	s.attributes[key] = value
+}
+
+func (s *fakeSpan) SetName(name string) {
+	s.name = name
+}
+
+func (s *fakeSpan) SetStatus(code codes.Code, description string) {
+	s.status = code
+}
+
+func (s *fakeSpan) RecordError(err error, options ...trace.EventOption) {
+	s.err = err
+}
+
+func (s *fakeSpan) AddEvents(keys []string, values []tracing.EventValue) {}
@@ -14,17 +14,20 @@ import (
 
 // NewUserHeaderMiddleware creates a new plugins.ClientMiddleware that will
 // populate the X-Grafana-User header on outgoing plugins.Client and HTTP
-// requests.
-func NewUserHeaderMiddleware() plugins.ClientMiddleware {
+// requests. The identity controls which user attribute is forwarded, see
+// proxyutil.UserHeaderValue.
+func NewUserHeaderMiddleware(identity string) plugins.ClientMiddleware {
 	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
 		return &UserHeaderMiddleware{
-			next: next,
+			next:     next,
+			identity: identity,
 		}
 	})
 }
 
 type UserHeaderMiddleware struct {
-	next plugins.Client
+	next     plugins.Client
+	identity string
 }
 
 func (m *UserHeaderMiddleware) applyToken(ctx context.Context, pCtx backend.PluginContext, h backend.ForwardHTTPHeaders) context.Context {
@@ -34,16 +37,18 @@ func (m *UserHeaderMiddleware) applyToken(ctx context.Context, pCtx backend.Plug
 		return ctx
 	}
 
+	userHeaderValue := proxyutil.UserHeaderValue(m.identity, reqCtx.SignedInUser)
+
 	h.DeleteHTTPHeader(proxyutil.UserHeaderName)
 	if !reqCtx.IsAnonymous {
-		h.SetHTTPHeader(proxyutil.UserHeaderName, reqCtx.Login)
+		h.SetHTTPHeader(proxyutil.UserHeaderName, userHeaderValue)
 	}
 
 	middlewares := []sdkhttpclient.Middleware{}
 
 	if !reqCtx.IsAnonymous {
 		httpHeaders := http.Header{
-			proxyutil.UserHeaderName: []string{reqCtx.Login},
+			proxyutil.UserHeaderName: []string{userHeaderValue},
 		}
 
 		middlewares = append(middlewares, httpclientprovider.SetHeadersMiddleware(httpHeaders))
@@ -1,6 +1,7 @@
 package clientmiddleware
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -24,7 +25,7 @@ func TestUserHeaderMiddleware(t *testing.T) {
 					IsAnonymous: true,
 					Login:       "anonymous"},
 				),
-				clienttest.WithMiddlewares(NewUserHeaderMiddleware()),
+				clienttest.WithMiddlewares(NewUserHeaderMiddleware(proxyutil.UserHeaderIdentityLogin)),
 			)
 
 			pluginCtx := backend.PluginContext{
@@ -80,7 +81,7 @@ func TestUserHeaderMiddleware(t *testing.T) {
 					IsAnonymous: true,
 					Login:       "anonymous"},
 				),
-				clienttest.WithMiddlewares(NewUserHeaderMiddleware()),
+				clienttest.WithMiddlewares(NewUserHeaderMiddleware(proxyutil.UserHeaderIdentityLogin)),
 			)
 
 			pluginCtx := backend.PluginContext{
@@ -140,7 +141,7 @@ func TestUserHeaderMiddleware(t *testing.T) {
 				clienttest.WithReqContext(req, &user.SignedInUser{
 					Login: "admin",
 				}),
-				clienttest.WithMiddlewares(NewUserHeaderMiddleware()),
+				clienttest.WithMiddlewares(NewUserHeaderMiddleware(proxyutil.UserHeaderIdentityLogin)),
 			)
 
 			pluginCtx := backend.PluginContext{
@@ -198,7 +199,7 @@ func TestUserHeaderMiddleware(t *testing.T) {
 				clienttest.WithReqContext(req, &user.SignedInUser{
 					Login: "admin",
 				}),
-				clienttest.WithMiddlewares(NewUserHeaderMiddleware()),
+				clienttest.WithMiddlewares(NewUserHeaderMiddleware(proxyutil.UserHeaderIdentityLogin)),
 			)
 
 			pluginCtx := backend.PluginContext{
@@ -251,4 +252,59 @@ func TestUserHeaderMiddleware(t *testing.T) {
 			})
 		})
 	})
+
+	t.Run("When real user in reqContext and identity is configured", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		signedInUser := &user.SignedInUser{
+			UserID: 42,
+			Login:  "admin",
+			Email:  "admin@example.com",
+		}
+
+		pluginCtx := backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+		}
+
+		tcs := []struct {
+			identity string
+			expected string
+		}{
+			{identity: proxyutil.UserHeaderIdentityLogin, expected: "admin"},
+			{identity: proxyutil.UserHeaderIdentityEmail, expected: "admin@example.com"},
+			{identity: proxyutil.UserHeaderIdentityID, expected: "42"},
+			{identity: "", expected: "admin"},
+		}
+
+		for _, tc := range tcs {
+			t.Run(fmt.Sprintf("Should forward %q identity when calling QueryData", tc.identity), func(t *testing.T) {
+				cdt := clienttest.NewClientDecoratorTest(t,
+					clienttest.WithReqContext(req, signedInUser),
+					clienttest.WithMiddlewares(NewUserHeaderMiddleware(tc.identity)),
+				)
+
+				_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+					PluginContext: pluginCtx,
+					Headers:       map[string]string{},
+				})
+				require.NoError(t, err)
+				require.NotNil(t, cdt.QueryDataReq)
+				require.Len(t, cdt.QueryDataReq.Headers, 1)
+				require.Equal(t, tc.expected, cdt.QueryDataReq.GetHTTPHeader(proxyutil.UserHeaderName))
+
+				middlewares := httpclient.ContextualMiddlewareFromContext(cdt.QueryDataCtx)
+				require.Len(t, middlewares, 1)
+				require.Equal(t, httpclientprovider.SetHeadersMiddlewareName, middlewares[0].(httpclient.MiddlewareName).MiddlewareName())
+
+				outReq, err := http.NewRequest(http.MethodGet, "http://localhost", nil)
+				require.NoError(t, err)
+				_, err = middlewares[0].CreateMiddleware(httpclient.Options{}, httpclient.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+					require.Equal(t, tc.expected, r.Header.Get(proxyutil.UserHeaderName))
+					return &http.Response{StatusCode: http.StatusOK}, nil
+				})).RoundTrip(outReq)
+				require.NoError(t, err)
+			})
+		}
+	})
 }
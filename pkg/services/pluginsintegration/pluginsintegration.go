@@ -2,6 +2,7 @@ package pluginsintegration
 
 import (
 	"github.com/google/wire"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin/provider"
@@ -15,8 +16,10 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/manager/store"
 	"github.com/grafana/grafana/pkg/plugins/plugincontext"
 	"github.com/grafana/grafana/pkg/plugins/repo"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/clientmiddleware"
+	pref "github.com/grafana/grafana/pkg/services/preference"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -56,29 +59,50 @@ var WireExtensionSet = wire.NewSet(
 
 func ProvideClientDecorator(cfg *setting.Cfg, pCfg *config.Cfg,
 	pluginRegistry registry.Service,
-	oAuthTokenService oauthtoken.OAuthTokenService) (*client.Decorator, error) {
-	return NewClientDecorator(cfg, pCfg, pluginRegistry, oAuthTokenService)
+	oAuthTokenService oauthtoken.OAuthTokenService,
+	preferenceService pref.Service,
+	dataSourceCache datasources.CacheService,
+	tracer tracing.Tracer) (*client.Decorator, error) {
+	return NewClientDecorator(cfg, pCfg, pluginRegistry, oAuthTokenService, preferenceService, dataSourceCache, tracer)
 }
 
 func NewClientDecorator(cfg *setting.Cfg, pCfg *config.Cfg,
 	pluginRegistry registry.Service,
-	oAuthTokenService oauthtoken.OAuthTokenService) (*client.Decorator, error) {
+	oAuthTokenService oauthtoken.OAuthTokenService,
+	preferenceService pref.Service,
+	dataSourceCache datasources.CacheService,
+	tracer tracing.Tracer) (*client.Decorator, error) {
 	c := client.ProvideService(pluginRegistry, pCfg)
-	middlewares := CreateMiddlewares(cfg, oAuthTokenService)
+	middlewares := CreateMiddlewares(cfg, oAuthTokenService, preferenceService, dataSourceCache, tracer)
 
 	return client.NewDecorator(c, middlewares...)
 }
 
-func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthTokenService) []plugins.ClientMiddleware {
+func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthTokenService, preferenceService pref.Service, dataSourceCache datasources.CacheService, tracer tracing.Tracer) []plugins.ClientMiddleware {
 	skipCookiesNames := []string{cfg.LoginCookieName}
 	middlewares := []plugins.ClientMiddleware{
+		clientmiddleware.NewTracingMiddleware(tracer),
+		clientmiddleware.NewErrorMetricsMiddleware(),
 		clientmiddleware.NewClearAuthHeadersMiddleware(),
 		clientmiddleware.NewOAuthTokenMiddleware(oAuthTokenService),
 		clientmiddleware.NewCookiesMiddleware(skipCookiesNames),
+		clientmiddleware.NewQueryTimeoutMiddleware(preferenceService),
+		clientmiddleware.NewDashboardOriginMiddleware(),
+		clientmiddleware.NewStreamMetadataMiddleware(cfg.PluginsTenantID),
+		clientmiddleware.NewReadOnlyMiddleware(func() bool { return cfg.PluginsReadOnly }),
+		clientmiddleware.NewTenantIsolationMiddleware(dataSourceCache),
+	}
+
+	if cfg.PluginsMaxRequestBodySize > 0 || len(cfg.PluginsMaxRequestBodySizes) > 0 {
+		middlewares = append(middlewares, clientmiddleware.NewRequestSizeLimitMiddleware(cfg.PluginsMaxRequestBodySize, cfg.PluginsMaxRequestBodySizes))
+	}
+
+	if cfg.PluginsMaxResponseSize > 0 {
+		middlewares = append(middlewares, clientmiddleware.NewResponseSizeLimitMiddleware(cfg.PluginsMaxResponseSize))
 	}
 
 	if cfg.SendUserHeader {
-		middlewares = append(middlewares, clientmiddleware.NewUserHeaderMiddleware())
+		middlewares = append(middlewares, clientmiddleware.NewUserHeaderMiddleware(cfg.SendUserHeaderIdentity))
 	}
 
 	return middlewares
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/prometheus/alertmanager/config"
 )
 
 var ErrPrefNotFound = errors.New("preference not found")
@@ -44,14 +46,18 @@ type SavePreferenceCommand struct {
 	OrgID  int64
 	TeamID int64
 
-	HomeDashboardID  int64                   `json:"homeDashboardId,omitempty"`
-	HomeDashboardUID *string                 `json:"homeDashboardUID,omitempty"`
-	Timezone         string                  `json:"timezone,omitempty"`
-	WeekStart        string                  `json:"weekStart,omitempty"`
-	Theme            string                  `json:"theme,omitempty"`
-	Language         string                  `json:"language,omitempty"`
-	Navbar           *NavbarPreference       `json:"navbar,omitempty"`
-	QueryHistory     *QueryHistoryPreference `json:"queryHistory,omitempty"`
+	HomeDashboardID  int64                     `json:"homeDashboardId,omitempty"`
+	HomeDashboardUID *string                   `json:"homeDashboardUID,omitempty"`
+	Timezone         string                    `json:"timezone,omitempty"`
+	WeekStart        string                    `json:"weekStart,omitempty"`
+	Theme            string                    `json:"theme,omitempty"`
+	Language         string                    `json:"language,omitempty"`
+	Navbar           *NavbarPreference         `json:"navbar,omitempty"`
+	QueryHistory     *QueryHistoryPreference   `json:"queryHistory,omitempty"`
+	QueryTimeout     string                    `json:"queryTimeout,omitempty"`
+	MuteTimings      []config.MuteTimeInterval `json:"muteTimings,omitempty"`
+	// DefaultTeamID is only used for the preferences of orgs.
+	DefaultTeamID int64 `json:"defaultTeamId,omitempty"`
 }
 
 type PatchPreferenceCommand struct {
@@ -67,6 +73,11 @@ type PatchPreferenceCommand struct {
 	Language         *string                 `json:"language,omitempty"`
 	Navbar           *NavbarPreference       `json:"navbar,omitempty"`
 	QueryHistory     *QueryHistoryPreference `json:"queryHistory,omitempty"`
+	QueryTimeout     *string                 `json:"queryTimeout,omitempty"`
+	// MuteTimings are left unchanged if nil.
+	MuteTimings []config.MuteTimeInterval `json:"muteTimings,omitempty"`
+	// DefaultTeamID is left unchanged if nil, and only used for the preferences of orgs.
+	DefaultTeamID *int64 `json:"defaultTeamId,omitempty"`
 }
 
 type NavLink struct {
@@ -84,6 +95,14 @@ type PreferenceJSONData struct {
 	Language     string                 `json:"language"`
 	Navbar       NavbarPreference       `json:"navbar"`
 	QueryHistory QueryHistoryPreference `json:"queryHistory"`
+	// QueryTimeout is the default timeout of datasource queries, as a duration string (e.g. 30s).
+	QueryTimeout string `json:"queryTimeout,omitempty"`
+	// MuteTimings are the time intervals in which the alert notifications of a team are muted.
+	// They apply to the alerts with the team label set to the name of the team.
+	MuteTimings []config.MuteTimeInterval `json:"muteTimings,omitempty"`
+	// DefaultTeamID is the id of the team the users added to an org are added to. It is only
+	// set in the preferences of orgs, and no team is used if it is 0.
+	DefaultTeamID int64 `json:"defaultTeamId,omitempty"`
 }
 
 type QueryHistoryPreference struct {
@@ -72,6 +72,10 @@ func (s *Service) GetWithDefaults(ctx context.Context, query *pref.GetPreference
 			if p.JSONData.QueryHistory.HomeTab != "" {
 				res.JSONData.QueryHistory.HomeTab = p.JSONData.QueryHistory.HomeTab
 			}
+
+			if p.JSONData.QueryTimeout != "" {
+				res.JSONData.QueryTimeout = p.JSONData.QueryTimeout
+			}
 		}
 	}
 
@@ -113,7 +117,10 @@ func (s *Service) Save(ctx context.Context, cmd *pref.SavePreferenceCommand) err
 				Created:         time.Now(),
 				Updated:         time.Now(),
 				JSONData: &pref.PreferenceJSONData{
-					Language: cmd.Language,
+					Language:      cmd.Language,
+					QueryTimeout:  cmd.QueryTimeout,
+					MuteTimings:   cmd.MuteTimings,
+					DefaultTeamID: cmd.DefaultTeamID,
 				},
 			}
 			_, err = s.store.Insert(ctx, preference)
@@ -131,7 +138,10 @@ func (s *Service) Save(ctx context.Context, cmd *pref.SavePreferenceCommand) err
 	preference.Version += 1
 	preference.HomeDashboardID = cmd.HomeDashboardID
 	preference.JSONData = &pref.PreferenceJSONData{
-		Language: cmd.Language,
+		Language:      cmd.Language,
+		QueryTimeout:  cmd.QueryTimeout,
+		MuteTimings:   cmd.MuteTimings,
+		DefaultTeamID: cmd.DefaultTeamID,
 	}
 
 	if cmd.Navbar != nil {
@@ -191,6 +201,27 @@ func (s *Service) Patch(ctx context.Context, cmd *pref.PatchPreferenceCommand) e
 		}
 	}
 
+	if cmd.QueryTimeout != nil {
+		if preference.JSONData == nil {
+			preference.JSONData = &pref.PreferenceJSONData{}
+		}
+		preference.JSONData.QueryTimeout = *cmd.QueryTimeout
+	}
+
+	if cmd.MuteTimings != nil {
+		if preference.JSONData == nil {
+			preference.JSONData = &pref.PreferenceJSONData{}
+		}
+		preference.JSONData.MuteTimings = cmd.MuteTimings
+	}
+
+	if cmd.DefaultTeamID != nil {
+		if preference.JSONData == nil {
+			preference.JSONData = &pref.PreferenceJSONData{}
+		}
+		preference.JSONData.DefaultTeamID = *cmd.DefaultTeamID
+	}
+
 	if cmd.HomeDashboardID != nil {
 		preference.HomeDashboardID = *cmd.HomeDashboardID
 	}
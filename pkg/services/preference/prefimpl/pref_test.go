@@ -458,6 +458,44 @@ func TestSave(t *testing.T) {
 	})
 }
 
+func TestTeamQueryTimeout(t *testing.T) {
+	prefService := &Service{
+		store:    newFake(),
+		cfg:      setting.NewCfg(),
+		features: featuremgmt.WithFeatures(),
+	}
+
+	err := prefService.Save(context.Background(), &pref.SavePreferenceCommand{
+		OrgID:        1,
+		TeamID:       2,
+		QueryTimeout: "30s",
+	})
+	require.NoError(t, err)
+
+	stored, err := prefService.Get(context.Background(), &pref.GetPreferenceQuery{OrgID: 1, TeamID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "30s", stored.JSONData.QueryTimeout)
+
+	query := &pref.GetPreferenceWithDefaultsQuery{OrgID: 1, Teams: []int64{2}}
+	preference, err := prefService.GetWithDefaults(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, "30s", preference.JSONData.QueryTimeout)
+
+	t.Run("patch", func(t *testing.T) {
+		queryTimeout := "1m"
+		err := prefService.Patch(context.Background(), &pref.PatchPreferenceCommand{
+			OrgID:        1,
+			TeamID:       2,
+			QueryTimeout: &queryTimeout,
+		})
+		require.NoError(t, err)
+
+		preference, err := prefService.GetWithDefaults(context.Background(), query)
+		require.NoError(t, err)
+		assert.Equal(t, "1m", preference.JSONData.QueryTimeout)
+	})
+}
+
 func insertPrefs(t testing.TB, store store, preferences ...pref.Preference) {
 	t.Helper()
 	for _, p := range preferences {
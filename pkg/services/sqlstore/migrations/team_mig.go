@@ -60,4 +60,16 @@ func addTeamMigrations(mg *Migrator) {
 	mg.AddMigration("Add column permission to team_member table", NewAddColumnMigration(teamMemberV1, &Column{
 		Name: "permission", Type: DB_SmallInt, Nullable: true,
 	}))
+
+	mg.AddMigration("Add column expires_at to team_member table", NewAddColumnMigration(teamMemberV1, &Column{
+		Name: "expires_at", Type: DB_DateTime, Nullable: true,
+	}))
+
+	mg.AddMigration("Add column last_activity_at to team table", NewAddColumnMigration(teamV1, &Column{
+		Name: "last_activity_at", Type: DB_DateTime, Nullable: true,
+	}))
+	mg.AddMigration("Set team.last_activity_at to team.updated", NewRawSQLMigration("UPDATE team SET last_activity_at = updated WHERE last_activity_at IS NULL"))
+	mg.AddMigration("add index team.org_id_last_activity_at", NewAddIndexMigration(teamV1, &Index{
+		Cols: []string{"org_id", "last_activity_at"},
+	}))
 }
@@ -15,9 +15,12 @@ type Service interface {
 	GetTeamsByUser(ctx context.Context, query *models.GetTeamsByUserQuery) error
 	AddTeamMember(userID, orgID, teamID int64, isExternal bool, permission models.PermissionType) error
 	UpdateTeamMember(ctx context.Context, cmd *models.UpdateTeamMemberCommand) error
+	SetTeamMemberExpiry(ctx context.Context, cmd *models.SetTeamMemberExpiryCommand) error
+	GetExpiredTeamMembers(ctx context.Context, query *models.GetExpiredTeamMembersQuery) error
 	IsTeamMember(orgId int64, teamId int64, userId int64) (bool, error)
 	RemoveTeamMember(ctx context.Context, cmd *models.RemoveTeamMemberCommand) error
 	GetUserTeamMemberships(ctx context.Context, orgID, userID int64, external bool) ([]*models.TeamMemberDTO, error)
 	GetTeamMembers(ctx context.Context, query *models.GetTeamMembersQuery) error
+	GetTeamAdmins(ctx context.Context, query *models.GetTeamAdminsQuery) error
 	IsAdminOfTeams(ctx context.Context, query *models.IsAdminOfTeamsQuery) error
 }
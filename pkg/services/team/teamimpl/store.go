@@ -23,10 +23,13 @@ type store interface {
 	GetByUser(ctx context.Context, query *models.GetTeamsByUserQuery) error
 	AddMember(userID, orgID, teamID int64, isExternal bool, permission models.PermissionType) error
 	UpdateMember(ctx context.Context, cmd *models.UpdateTeamMemberCommand) error
+	SetMemberExpiry(ctx context.Context, cmd *models.SetTeamMemberExpiryCommand) error
+	GetExpiredMembers(ctx context.Context, query *models.GetExpiredTeamMembersQuery) error
 	IsMember(orgId int64, teamId int64, userId int64) (bool, error)
 	RemoveMember(ctx context.Context, cmd *models.RemoveTeamMemberCommand) error
 	GetMemberships(ctx context.Context, orgID, userID int64, external bool) ([]*models.TeamMemberDTO, error)
 	GetMembers(ctx context.Context, query *models.GetTeamMembersQuery) error
+	GetAdmins(ctx context.Context, query *models.GetTeamAdminsQuery) error
 	IsAdmin(ctx context.Context, query *models.IsAdminOfTeamsQuery) error
 }
 
@@ -51,47 +54,66 @@ func getFilteredUsers(signedInUser *user.SignedInUser, hiddenUsers map[string]st
 	return filteredUsers
 }
 
-func getTeamMemberCount(db db.DB, filteredUsers []string) string {
+// getTeamMemberCountJoin returns a join that counts the members of the teams of an org in a
+// single aggregate instead of a subquery per team, and its arguments. The members of a single
+// team are counted if teamID is not 0. Hidden users are not counted.
+func getTeamMemberCountJoin(db db.DB, orgID, teamID int64, filteredUsers []string) (string, []interface{}) {
+	filter := ` WHERE team_member.org_id = ?`
+	params := []interface{}{orgID}
+	if teamID != 0 {
+		filter += ` AND team_member.team_id = ?`
+		params = append(params, teamID)
+	}
+	join := ""
 	if len(filteredUsers) > 0 {
-		return `(SELECT COUNT(*) FROM team_member
-			INNER JOIN ` + db.GetDialect().Quote("user") + ` ON team_member.user_id = ` + db.GetDialect().Quote("user") + `.id
-			WHERE team_member.team_id = team.id AND ` + db.GetDialect().Quote("user") + `.login NOT IN (?` +
-			strings.Repeat(",?", len(filteredUsers)-1) + ")" +
-			`) AS member_count `
+		join = ` INNER JOIN ` + db.GetDialect().Quote("user") + ` ON team_member.user_id = ` + db.GetDialect().Quote("user") + `.id`
+		filter += ` AND ` + db.GetDialect().Quote("user") + `.login NOT IN (?` +
+			strings.Repeat(",?", len(filteredUsers)-1) + ")"
+		for _, user := range filteredUsers {
+			params = append(params, user)
+		}
 	}
 
-	return "(SELECT COUNT(*) FROM team_member WHERE team_member.team_id = team.id) AS member_count "
+	return ` LEFT JOIN (SELECT team_member.team_id, COUNT(*) AS member_count FROM team_member` + join + filter + `
+		GROUP BY team_member.team_id) AS team_member_count ON team_member_count.team_id = team.id `, params
 }
 
-func getTeamSelectSQLBase(db db.DB, filteredUsers []string) string {
+func getTeamSelectSQLBase(db db.DB, orgID, teamID int64, filteredUsers []string) (string, []interface{}) {
+	join, params := getTeamMemberCountJoin(db, orgID, teamID, filteredUsers)
 	return `SELECT
 		team.id as id,
 		team.org_id,
 		team.name as name,
-		team.email as email, ` +
-		getTeamMemberCount(db, filteredUsers) +
-		` FROM team as team `
+		team.email as email,
+		team.last_activity_at as last_activity_at,
+		COALESCE(team_member_count.member_count, 0) AS member_count
+		FROM team as team ` + join, params
 }
 
-func getTeamSelectWithPermissionsSQLBase(db db.DB, filteredUsers []string) string {
+func getTeamSelectWithPermissionsSQLBase(db db.DB, orgID int64, filteredUsers []string) (string, []interface{}) {
+	join, params := getTeamMemberCountJoin(db, orgID, 0, filteredUsers)
 	return `SELECT
 		team.id AS id,
 		team.org_id,
 		team.name AS name,
 		team.email AS email,
-		team_member.permission, ` +
-		getTeamMemberCount(db, filteredUsers) +
-		` FROM team AS team
-		INNER JOIN team_member ON team.id = team_member.team_id AND team_member.user_id = ? `
+		team.last_activity_at AS last_activity_at,
+		team_member.permission,
+		COALESCE(team_member_count.member_count, 0) AS member_count
+		FROM team AS team ` +
+		join +
+		` INNER JOIN team_member ON team.id = team_member.team_id AND team_member.user_id = ? `, params
 }
 
 func (ss *xormStore) Create(name, email string, orgID int64) (models.Team, error) {
+	now := time.Now()
 	team := models.Team{
-		Name:    name,
-		Email:   email,
-		OrgId:   orgID,
-		Created: time.Now(),
-		Updated: time.Now(),
+		Name:           name,
+		Email:          email,
+		OrgId:          orgID,
+		Created:        now,
+		Updated:        now,
+		LastActivityAt: now,
 	}
 	err := ss.db.WithTransactionalDbSession(context.Background(), func(sess *db.Session) error {
 		if isNameTaken, err := isTeamNameTaken(orgID, name, 0, sess); err != nil {
@@ -114,10 +136,12 @@ func (ss *xormStore) Update(ctx context.Context, cmd *models.UpdateTeamCommand)
 			return models.ErrTeamNameTaken
 		}
 
+		now := time.Now()
 		team := models.Team{
-			Name:    cmd.Name,
-			Email:   cmd.Email,
-			Updated: time.Now(),
+			Name:           cmd.Name,
+			Email:          cmd.Email,
+			Updated:        now,
+			LastActivityAt: now,
 		}
 
 		sess.MustCols("email")
@@ -173,6 +197,12 @@ func teamExists(orgID int64, teamID int64, sess *db.Session) (bool, error) {
 	return true, nil
 }
 
+// touchTeam sets the last activity of the team to now, after a change of its members
+func touchTeam(sess *db.Session, orgID, teamID int64) error {
+	_, err := sess.Exec("UPDATE team SET last_activity_at = ? WHERE org_id = ? AND id = ?", time.Now(), orgID, teamID)
+	return err
+}
+
 func isTeamNameTaken(orgId int64, name string, existingId int64, sess *db.Session) (bool, error) {
 	var team models.Team
 	exists, err := sess.Where("org_id=? and name=?", orgId, name).Get(&team)
@@ -195,17 +225,17 @@ func (ss *xormStore) Search(ctx context.Context, query *models.SearchTeamsQuery)
 		queryWithWildcards := "%" + query.Query + "%"
 
 		var sql bytes.Buffer
-		params := make([]interface{}, 0)
+		var params []interface{}
 
 		filteredUsers := getFilteredUsers(query.SignedInUser, query.HiddenUsers)
-		for _, user := range filteredUsers {
-			params = append(params, user)
-		}
-
 		if query.UserIdFilter == models.FilterIgnoreUser {
-			sql.WriteString(getTeamSelectSQLBase(ss.db, filteredUsers))
+			base, baseParams := getTeamSelectSQLBase(ss.db, query.OrgId, 0, filteredUsers)
+			sql.WriteString(base)
+			params = append(params, baseParams...)
 		} else {
-			sql.WriteString(getTeamSelectWithPermissionsSQLBase(ss.db, filteredUsers))
+			base, baseParams := getTeamSelectWithPermissionsSQLBase(ss.db, query.OrgId, filteredUsers)
+			sql.WriteString(base)
+			params = append(params, baseParams...)
 			params = append(params, query.UserIdFilter)
 		}
 
@@ -222,6 +252,11 @@ func (ss *xormStore) Search(ctx context.Context, query *models.SearchTeamsQuery)
 			params = append(params, query.Name)
 		}
 
+		if query.InactiveSince != nil {
+			sql.WriteString(` and team.last_activity_at < ?`)
+			params = append(params, *query.InactiveSince)
+		}
+
 		var (
 			acFilter ac.SQLFilter
 			err      error
@@ -258,6 +293,10 @@ func (ss *xormStore) Search(ctx context.Context, query *models.SearchTeamsQuery)
 			countSess.Where("name=?", query.Name)
 		}
 
+		if query.InactiveSince != nil {
+			countSess.Where("last_activity_at < ?", *query.InactiveSince)
+		}
+
 		// If we're not retrieving all results, then only search for teams that this user has access to
 		if query.UserIdFilter != models.FilterIgnoreUser {
 			countSess.
@@ -285,13 +324,10 @@ func (ss *xormStore) Search(ctx context.Context, query *models.SearchTeamsQuery)
 func (ss *xormStore) GetById(ctx context.Context, query *models.GetTeamByIdQuery) error {
 	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
 		var sql bytes.Buffer
-		params := make([]interface{}, 0)
 
 		filteredUsers := getFilteredUsers(query.SignedInUser, query.HiddenUsers)
-		sql.WriteString(getTeamSelectSQLBase(ss.db, filteredUsers))
-		for _, user := range filteredUsers {
-			params = append(params, user)
-		}
+		base, params := getTeamSelectSQLBase(ss.db, query.OrgId, query.Id, filteredUsers)
+		sql.WriteString(base)
 
 		if query.UserIdFilter != models.FilterIgnoreUser {
 			sql.WriteString(` INNER JOIN team_member ON team.id = team_member.team_id AND team_member.user_id = ?`)
@@ -323,10 +359,10 @@ func (ss *xormStore) GetByUser(ctx context.Context, query *models.GetTeamsByUser
 		query.Result = make([]*models.TeamDTO, 0)
 
 		var sql bytes.Buffer
-		var params []interface{}
+		base, params := getTeamSelectSQLBase(ss.db, query.OrgId, 0, []string{})
 		params = append(params, query.OrgId, query.UserId)
 
-		sql.WriteString(getTeamSelectSQLBase(ss.db, []string{}))
+		sql.WriteString(base)
 		sql.WriteString(` INNER JOIN team_member on team.id = team_member.team_id`)
 		sql.WriteString(` WHERE team.org_id = ? and team_member.user_id = ?`)
 
@@ -379,6 +415,31 @@ func (ss *xormStore) UpdateMember(ctx context.Context, cmd *models.UpdateTeamMem
 	})
 }
 
+// SetMemberExpiry sets the time a team member is removed at, a nil expiry makes the membership permanent
+func (ss *xormStore) SetMemberExpiry(ctx context.Context, cmd *models.SetTeamMemberExpiryCommand) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		member, err := getTeamMember(sess, cmd.OrgId, cmd.TeamId, cmd.UserId)
+		if err != nil {
+			return err
+		}
+
+		member.ExpiresAt = cmd.ExpiresAt
+		member.Updated = time.Now()
+		if _, err := sess.Cols("expires_at", "updated").Where("org_id=? and team_id=? and user_id=?", cmd.OrgId, cmd.TeamId, cmd.UserId).Update(&member); err != nil {
+			return err
+		}
+		return touchTeam(sess, cmd.OrgId, cmd.TeamId)
+	})
+}
+
+// GetExpiredMembers returns the team members of all orgs whose membership expired at query.Now
+func (ss *xormStore) GetExpiredMembers(ctx context.Context, query *models.GetExpiredTeamMembersQuery) error {
+	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		query.Result = make([]*models.TeamMember, 0)
+		return sess.Where("expires_at IS NOT NULL AND expires_at <= ?", query.Now).Find(&query.Result)
+	})
+}
+
 func (ss *xormStore) IsMember(orgId int64, teamId int64, userId int64) (bool, error) {
 	var isMember bool
 
@@ -433,8 +494,10 @@ func addTeamMember(sess *db.Session, orgID, teamID, userID int64, isExternal boo
 		Permission: permission,
 	}
 
-	_, err := sess.Insert(&entity)
-	return err
+	if _, err := sess.Insert(&entity); err != nil {
+		return err
+	}
+	return touchTeam(sess, orgID, teamID)
 }
 
 func updateTeamMember(sess *db.Session, orgID, teamID, userID int64, permission models.PermissionType) error {
@@ -448,8 +511,10 @@ func updateTeamMember(sess *db.Session, orgID, teamID, userID int64, permission
 	}
 
 	member.Permission = permission
-	_, err = sess.Cols("permission").Where("org_id=? and team_id=? and user_id=?", orgID, teamID, userID).Update(member)
-	return err
+	if _, err := sess.Cols("permission").Where("org_id=? and team_id=? and user_id=?", orgID, teamID, userID).Update(member); err != nil {
+		return err
+	}
+	return touchTeam(sess, orgID, teamID)
 }
 
 // RemoveTeamMember removes a member from a team
@@ -476,11 +541,14 @@ func removeTeamMember(sess *db.Session, cmd *models.RemoveTeamMemberCommand) err
 		return err
 	}
 	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
 	if rows == 0 {
 		return models.ErrTeamMemberNotFound
 	}
 
-	return err
+	return touchTeam(sess, cmd.OrgId, cmd.TeamId)
 }
 
 // GetUserTeamMemberships return a list of memberships to teams granted to a user
@@ -516,6 +584,16 @@ func (ss *xormStore) GetMembers(ctx context.Context, query *models.GetTeamMember
 	return ss.getTeamMembers(ctx, query, acFilter)
 }
 
+// GetAdmins returns the ids of the admins of the specified team
+func (ss *xormStore) GetAdmins(ctx context.Context, query *models.GetTeamAdminsQuery) error {
+	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		query.Result = make([]int64, 0)
+		return sess.Table("team_member").Cols("user_id").
+			Where("org_id=? AND team_id=? AND permission=?", query.OrgId, query.TeamId, models.PERMISSION_ADMIN).
+			Find(&query.Result)
+	})
+}
+
 // getTeamMembers return a list of members for the specified team
 func (ss *xormStore) getTeamMembers(ctx context.Context, query *models.GetTeamMembersQuery, acUserFilter *ac.SQLFilter) error {
 	return ss.db.WithDbSession(ctx, func(dbSess *db.Session) error {
@@ -561,6 +639,7 @@ func (ss *xormStore) getTeamMembers(ctx context.Context, query *models.GetTeamMe
 			"user.login",
 			"team_member.external",
 			"team_member.permission",
+			"team_member.expires_at",
 			"user_auth.auth_module",
 		)
 		sess.Asc("user.login", "user.email")
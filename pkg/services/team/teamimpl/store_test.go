@@ -172,6 +172,11 @@ func TestIntegrationTeamCommandsAndQueries(t *testing.T) {
 				err = teamSvc.GetTeamMembers(context.Background(), qAfterUpdate)
 				require.NoError(t, err)
 				require.Equal(t, qAfterUpdate.Result[0].Permission, models.PERMISSION_ADMIN)
+
+				adminsQuery := &models.GetTeamAdminsQuery{OrgId: testOrgID, TeamId: team.Id}
+				err = teamSvc.GetTeamAdmins(context.Background(), adminsQuery)
+				require.NoError(t, err)
+				require.Equal(t, []int64{userId}, adminsQuery.Result)
 			})
 
 			t.Run("Should default to member permission level when updating a user with invalid permission level", func(t *testing.T) {
@@ -494,6 +499,238 @@ func TestIntegrationSQLStore_SearchTeams(t *testing.T) {
 	}
 }
 
+func TestIntegrationSQLStore_SearchTeamsMemberCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	const testOrgID int64 = 1
+	store := db.InitTestDB(t, db.InitTestDBOpt{})
+	teamSvc := ProvideService(store, store.Cfg)
+	quotaService := quotaimpl.ProvideService(store, store.Cfg)
+	orgSvc, err := orgimpl.ProvideService(store, store.Cfg, quotaService)
+	require.NoError(t, err)
+	userSvc, err := userimpl.ProvideService(store, orgSvc, store.Cfg, teamSvc, nil, quotaService)
+	require.NoError(t, err)
+
+	userIds := make([]int64, 0, 4)
+	for i := 0; i < 4; i++ {
+		usr, err := userSvc.Create(context.Background(), &user.CreateUserCommand{
+			Email: fmt.Sprint("user", i, "@test.com"),
+			Name:  fmt.Sprint("user", i),
+			Login: fmt.Sprint("loginuser", i),
+		})
+		require.NoError(t, err)
+		userIds = append(userIds, usr.ID)
+	}
+
+	// team-a has 3 members, team-b has 1 member and team-c has none.
+	members := map[string][]int64{
+		"team-a": {userIds[0], userIds[1], userIds[2]},
+		"team-b": {userIds[3]},
+		"team-c": {},
+	}
+	teamIDs := make(map[string]int64, len(members))
+	for name, teamMembers := range members {
+		team, err := teamSvc.CreateTeam(name, "", testOrgID)
+		require.NoError(t, err)
+		teamIDs[name] = team.Id
+		for _, userID := range teamMembers {
+			require.NoError(t, teamSvc.AddTeamMember(userID, testOrgID, team.Id, false, 0))
+		}
+	}
+
+	signedInUser := &user.SignedInUser{
+		Login: "loginuser3",
+		OrgID: testOrgID,
+		Permissions: map[int64]map[string][]string{
+			testOrgID: {ac.ActionTeamsRead: []string{ac.ScopeTeamsAll}},
+		},
+	}
+	memberCounts := func(query *models.SearchTeamsQuery) map[string]int64 {
+		t.Helper()
+		require.NoError(t, teamSvc.SearchTeams(context.Background(), query))
+		counts := make(map[string]int64, len(query.Result.Teams))
+		for _, team := range query.Result.Teams {
+			counts[team.Name] = team.MemberCount
+		}
+		return counts
+	}
+
+	t.Run("should return the member count of every team", func(t *testing.T) {
+		counts := memberCounts(&models.SearchTeamsQuery{OrgId: testOrgID, Page: 1, Limit: 10, SignedInUser: signedInUser})
+		require.Equal(t, map[string]int64{"team-a": 3, "team-b": 1, "team-c": 0}, counts)
+	})
+
+	t.Run("should not count hidden users", func(t *testing.T) {
+		hiddenUsers := map[string]struct{}{"loginuser0": {}, "loginuser1": {}, "loginuser3": {}}
+		counts := memberCounts(&models.SearchTeamsQuery{OrgId: testOrgID, Page: 1, Limit: 10, SignedInUser: signedInUser, HiddenUsers: hiddenUsers})
+		// loginuser3 is the signed in user and is therefore never hidden from themselves.
+		require.Equal(t, map[string]int64{"team-a": 1, "team-b": 1, "team-c": 0}, counts)
+	})
+
+	t.Run("should return the member count when filtering by user", func(t *testing.T) {
+		counts := memberCounts(&models.SearchTeamsQuery{OrgId: testOrgID, Page: 1, Limit: 10, UserIdFilter: userIds[0], SignedInUser: signedInUser})
+		require.Equal(t, map[string]int64{"team-a": 3}, counts)
+	})
+
+	t.Run("should return the member count of a single team", func(t *testing.T) {
+		query := &models.GetTeamByIdQuery{
+			OrgId:        testOrgID,
+			Id:           teamIDs["team-a"],
+			SignedInUser: signedInUser,
+			HiddenUsers:  map[string]struct{}{"loginuser0": {}},
+		}
+		require.NoError(t, teamSvc.GetTeamById(context.Background(), query))
+		require.EqualValues(t, 2, query.Result.MemberCount)
+	})
+}
+
+func TestIntegrationSQLStore_TeamMemberExpiry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	const testOrgID int64 = 1
+	store := db.InitTestDB(t, db.InitTestDBOpt{})
+	teamSvc := ProvideService(store, store.Cfg)
+	quotaService := quotaimpl.ProvideService(store, store.Cfg)
+	orgSvc, err := orgimpl.ProvideService(store, store.Cfg, quotaService)
+	require.NoError(t, err)
+	userSvc, err := userimpl.ProvideService(store, orgSvc, store.Cfg, teamSvc, nil, quotaService)
+	require.NoError(t, err)
+
+	contractor, err := userSvc.Create(context.Background(), &user.CreateUserCommand{Login: "contractor", Email: "contractor@test.com"})
+	require.NoError(t, err)
+	employee, err := userSvc.Create(context.Background(), &user.CreateUserCommand{Login: "employee", Email: "employee@test.com"})
+	require.NoError(t, err)
+	team, err := teamSvc.CreateTeam("team", "", testOrgID)
+	require.NoError(t, err)
+	require.NoError(t, teamSvc.AddTeamMember(contractor.ID, testOrgID, team.Id, false, 0))
+	require.NoError(t, teamSvc.AddTeamMember(employee.ID, testOrgID, team.Id, false, 0))
+
+	expiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	err = teamSvc.SetTeamMemberExpiry(context.Background(), &models.SetTeamMemberExpiryCommand{
+		OrgId:     testOrgID,
+		TeamId:    team.Id,
+		UserId:    contractor.ID,
+		ExpiresAt: &expiresAt,
+	})
+	require.NoError(t, err)
+
+	t.Run("should return the expiry in the members listing", func(t *testing.T) {
+		query := &models.GetTeamMembersQuery{
+			OrgId:  testOrgID,
+			TeamId: team.Id,
+			SignedInUser: &user.SignedInUser{
+				OrgID:       testOrgID,
+				Permissions: map[int64]map[string][]string{testOrgID: {ac.ActionOrgUsersRead: {ac.ScopeUsersAll}}},
+			},
+		}
+		require.NoError(t, teamSvc.GetTeamMembers(context.Background(), query))
+		require.Len(t, query.Result, 2)
+		require.Equal(t, "contractor", query.Result[0].Login)
+		require.NotNil(t, query.Result[0].ExpiresAt)
+		require.True(t, expiresAt.Equal(*query.Result[0].ExpiresAt))
+		require.Nil(t, query.Result[1].ExpiresAt)
+	})
+
+	t.Run("should only return members that expired", func(t *testing.T) {
+		query := &models.GetExpiredTeamMembersQuery{Now: time.Now()}
+		require.NoError(t, teamSvc.GetExpiredTeamMembers(context.Background(), query))
+		require.Empty(t, query.Result)
+
+		query = &models.GetExpiredTeamMembersQuery{Now: expiresAt.Add(time.Minute)}
+		require.NoError(t, teamSvc.GetExpiredTeamMembers(context.Background(), query))
+		require.Len(t, query.Result, 1)
+		require.Equal(t, contractor.ID, query.Result[0].UserId)
+		require.Equal(t, team.Id, query.Result[0].TeamId)
+	})
+
+	t.Run("should not return members whose expiry was removed", func(t *testing.T) {
+		err := teamSvc.SetTeamMemberExpiry(context.Background(), &models.SetTeamMemberExpiryCommand{
+			OrgId:  testOrgID,
+			TeamId: team.Id,
+			UserId: contractor.ID,
+		})
+		require.NoError(t, err)
+
+		query := &models.GetExpiredTeamMembersQuery{Now: expiresAt.Add(time.Minute)}
+		require.NoError(t, teamSvc.GetExpiredTeamMembers(context.Background(), query))
+		require.Empty(t, query.Result)
+	})
+}
+
+func TestIntegrationSQLStore_TeamLastActivity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	const testOrgID int64 = 1
+	store := db.InitTestDB(t, db.InitTestDBOpt{})
+	teamSvc := ProvideService(store, store.Cfg)
+	quotaService := quotaimpl.ProvideService(store, store.Cfg)
+	orgSvc, err := orgimpl.ProvideService(store, store.Cfg, quotaService)
+	require.NoError(t, err)
+	userSvc, err := userimpl.ProvideService(store, orgSvc, store.Cfg, teamSvc, nil, quotaService)
+	require.NoError(t, err)
+
+	member, err := userSvc.Create(context.Background(), &user.CreateUserCommand{Login: "member", Email: "member@test.com"})
+	require.NoError(t, err)
+	staleTeam, err := teamSvc.CreateTeam("stale", "", testOrgID)
+	require.NoError(t, err)
+	activeTeam, err := teamSvc.CreateTeam("active", "", testOrgID)
+	require.NoError(t, err)
+
+	longAgo := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	setLastActivity := func(t *testing.T, teamID int64, at time.Time) {
+		t.Helper()
+		err := store.WithDbSession(context.Background(), func(sess *db.Session) error {
+			_, err := sess.Exec("UPDATE team SET last_activity_at = ? WHERE id = ?", at, teamID)
+			return err
+		})
+		require.NoError(t, err)
+	}
+	getTeam := func(t *testing.T, teamID int64) *models.TeamDTO {
+		t.Helper()
+		query := &models.GetTeamByIdQuery{OrgId: testOrgID, Id: teamID}
+		require.NoError(t, teamSvc.GetTeamById(context.Background(), query))
+		return query.Result
+	}
+
+	t.Run("should update the last activity when a member is added", func(t *testing.T) {
+		setLastActivity(t, activeTeam.Id, longAgo)
+		require.True(t, longAgo.Equal(getTeam(t, activeTeam.Id).LastActivityAt))
+
+		require.NoError(t, teamSvc.AddTeamMember(member.ID, testOrgID, activeTeam.Id, false, 0))
+		require.True(t, getTeam(t, activeTeam.Id).LastActivityAt.After(longAgo))
+	})
+
+	t.Run("should update the last activity when a member is removed", func(t *testing.T) {
+		setLastActivity(t, activeTeam.Id, longAgo)
+
+		err := teamSvc.RemoveTeamMember(context.Background(), &models.RemoveTeamMemberCommand{OrgId: testOrgID, TeamId: activeTeam.Id, UserId: member.ID})
+		require.NoError(t, err)
+		require.True(t, getTeam(t, activeTeam.Id).LastActivityAt.After(longAgo))
+	})
+
+	t.Run("should only return teams inactive since the date", func(t *testing.T) {
+		setLastActivity(t, staleTeam.Id, longAgo)
+		inactiveSince := time.Now().Add(-24 * time.Hour)
+
+		query := &models.SearchTeamsQuery{
+			OrgId:         testOrgID,
+			InactiveSince: &inactiveSince,
+			SignedInUser: &user.SignedInUser{
+				OrgID:       testOrgID,
+				Permissions: map[int64]map[string][]string{testOrgID: {ac.ActionTeamsRead: {ac.ScopeTeamsAll}}},
+			},
+		}
+		require.NoError(t, teamSvc.SearchTeams(context.Background(), query))
+		require.Len(t, query.Result.Teams, 1)
+		require.Equal(t, "stale", query.Result.Teams[0].Name)
+		require.True(t, longAgo.Equal(query.Result.Teams[0].LastActivityAt))
+		require.EqualValues(t, 1, query.Result.TotalCount)
+	})
+}
+
 // TestSQLStore_GetTeamMembers_ACFilter tests the accesscontrol filtering of
 // team members based on the signed in user permissions
 func TestIntegrationSQLStore_GetTeamMembers_ACFilter(t *testing.T) {
@@ -49,6 +49,14 @@ func (s *Service) UpdateTeamMember(ctx context.Context, cmd *models.UpdateTeamMe
 	return s.store.UpdateMember(ctx, cmd)
 }
 
+func (s *Service) SetTeamMemberExpiry(ctx context.Context, cmd *models.SetTeamMemberExpiryCommand) error {
+	return s.store.SetMemberExpiry(ctx, cmd)
+}
+
+func (s *Service) GetExpiredTeamMembers(ctx context.Context, query *models.GetExpiredTeamMembersQuery) error {
+	return s.store.GetExpiredMembers(ctx, query)
+}
+
 func (s *Service) IsTeamMember(orgId int64, teamId int64, userId int64) (bool, error) {
 	return s.store.IsMember(orgId, teamId, userId)
 }
@@ -65,6 +73,10 @@ func (s *Service) GetTeamMembers(ctx context.Context, query *models.GetTeamMembe
 	return s.store.GetMembers(ctx, query)
 }
 
+func (s *Service) GetTeamAdmins(ctx context.Context, query *models.GetTeamAdminsQuery) error {
+	return s.store.GetAdmins(ctx, query)
+}
+
 func (s *Service) IsAdminOfTeams(ctx context.Context, query *models.IsAdminOfTeamsQuery) error {
 	return s.store.IsAdmin(ctx, query)
 }
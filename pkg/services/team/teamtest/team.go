@@ -10,7 +10,9 @@ type FakeService struct {
 	ExpectedTeam        models.Team
 	ExpectedTeamDTO     *models.TeamDTO
 	ExpectedTeamsByUser []*models.TeamDTO
+	ExpectedSearchTeams []*models.TeamDTO
 	ExpectedMembers     []*models.TeamMemberDTO
+	ExpectedAdmins      []int64
 	ExpectedError       error
 }
 
@@ -31,6 +33,7 @@ func (s *FakeService) DeleteTeam(ctx context.Context, cmd *models.DeleteTeamComm
 }
 
 func (s *FakeService) SearchTeams(ctx context.Context, query *models.SearchTeamsQuery) error {
+	query.Result = models.SearchTeamQueryResult{Teams: s.ExpectedSearchTeams, TotalCount: int64(len(s.ExpectedSearchTeams))}
 	return s.ExpectedError
 }
 
@@ -52,6 +55,14 @@ func (s *FakeService) UpdateTeamMember(ctx context.Context, cmd *models.UpdateTe
 	return s.ExpectedError
 }
 
+func (s *FakeService) SetTeamMemberExpiry(ctx context.Context, cmd *models.SetTeamMemberExpiryCommand) error {
+	return s.ExpectedError
+}
+
+func (s *FakeService) GetExpiredTeamMembers(ctx context.Context, query *models.GetExpiredTeamMembersQuery) error {
+	return s.ExpectedError
+}
+
 func (s *FakeService) IsTeamMember(orgId int64, teamId int64, userId int64) (bool, error) {
 	return false, s.ExpectedError
 }
@@ -68,6 +79,11 @@ func (s *FakeService) GetTeamMembers(ctx context.Context, query *models.GetTeamM
 	return s.ExpectedError
 }
 
+func (s *FakeService) GetTeamAdmins(ctx context.Context, query *models.GetTeamAdminsQuery) error {
+	query.Result = s.ExpectedAdmins
+	return s.ExpectedError
+}
+
 func (s *FakeService) IsAdminOfTeams(ctx context.Context, query *models.IsAdminOfTeamsQuery) error {
 	return s.ExpectedError
 }
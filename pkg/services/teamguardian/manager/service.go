@@ -17,6 +17,8 @@ func ProvideService(store teamguardian.Store) teamguardian.TeamGuardian {
 	return &Service{store: store}
 }
 
+// CanAdmin returns nil if the user can administer the team. Org admins can administer all
+// teams, regardless of their team membership.
 func (s *Service) CanAdmin(ctx context.Context, orgId int64, teamId int64, user *user.SignedInUser) error {
 	if user.OrgRole == org.RoleAdmin {
 		return nil
@@ -85,6 +85,13 @@ func TestUpdateTeam(t *testing.T) {
 				err := teamGuardianService.CanAdmin(context.Background(), testTeam.OrgId, testTeam.Id, &admin)
 				require.NoError(t, err)
 			})
+
+			t.Run("Should not need to be a team admin", func(t *testing.T) {
+				adminStore := new(database.TeamGuardianStoreMock)
+				err := ProvideService(adminStore).CanAdmin(context.Background(), testTeam.OrgId, testTeam.Id, &admin)
+				require.NoError(t, err)
+				adminStore.AssertNotCalled(t, "GetTeamMembers", mock.Anything, mock.Anything)
+			})
 		})
 	})
 }
@@ -51,6 +51,14 @@ const (
 	ApplicationName  = "Grafana"
 )
 
+// Avatar providers that user and team avatars are fetched from.
+const (
+	AvatarProviderGravatar   = "gravatar"
+	AvatarProviderLibravatar = "libravatar"
+	AvatarProviderCustom     = "custom"
+	AvatarProviderDisabled   = "disabled"
+)
+
 // zoneInfo names environment variable for setting the path to look for the timezone database in go
 const zoneInfo = "ZONEINFO"
 
@@ -268,6 +276,16 @@ type Cfg struct {
 	CSPReportOnlyEnabled bool
 	// CSPReportOnlyTemplate contains the Content Security Policy Report Only template.
 	CSPReportOnlyTemplate string
+	// WebhookAllowedHosts are the hosts and CIDRs notification webhooks can be sent to. All
+	// hosts are allowed if it is empty.
+	WebhookAllowedHosts []string
+	// WebhookBlockPrivateIPs blocks notification webhooks to private, loopback and link-local
+	// addresses that are not in WebhookAllowedHosts.
+	WebhookBlockPrivateIPs bool
+	// AvatarProvider is the service avatars are fetched from, AvatarURL its base URL if the
+	// provider is custom. Avatars are disabled if the provider is disabled.
+	AvatarProvider        string
+	AvatarURL             string
 	AngularSupportEnabled bool
 
 	TempDataLifetime time.Duration
@@ -281,6 +299,18 @@ type Cfg struct {
 	PluginCatalogHiddenPlugins       []string
 	PluginAdminEnabled               bool
 	PluginAdminExternalManageEnabled bool
+	PluginsTenantID                  string
+	// PluginsMaxResponseSize is the maximum size in bytes of the responses of backend plugins.
+	// Responses are not limited if it is 0.
+	PluginsMaxResponseSize int
+	// PluginsMaxRequestBodySize is the maximum size in bytes of the bodies of the resource
+	// requests to backend plugins, unless the plugin has its own limit in
+	// PluginsMaxRequestBodySizes. Requests are not limited if it is 0.
+	PluginsMaxRequestBodySize  int
+	PluginsMaxRequestBodySizes map[string]int
+	// PluginsReadOnly rejects the resource requests to backend plugins that can write,
+	// e.g. during maintenance.
+	PluginsReadOnly bool
 
 	// Panels
 	DisableSanitizeHtml bool
@@ -350,6 +380,7 @@ type Cfg struct {
 
 	// Dataproxy
 	SendUserHeader                 bool
+	SendUserHeaderIdentity         string
 	DataProxyLogging               bool
 	DataProxyTimeout               int
 	DataProxyDialTimeout           int
@@ -1287,6 +1318,19 @@ func readSecuritySettings(iniFile *ini.File, cfg *Cfg) error {
 	SecretKey = valueAsString(security, "secret_key", "")
 	cfg.SecretKey = SecretKey
 	DisableGravatar = security.Key("disable_gravatar").MustBool(true)
+	cfg.AvatarProvider = valueAsString(security, "avatar_provider", AvatarProviderGravatar)
+	cfg.AvatarURL = valueAsString(security, "avatar_url", "")
+	switch cfg.AvatarProvider {
+	case AvatarProviderGravatar, AvatarProviderLibravatar:
+	case AvatarProviderCustom:
+		if cfg.AvatarURL == "" {
+			return errors.New("avatar_url is required if avatar_provider is custom")
+		}
+	case AvatarProviderDisabled:
+		DisableGravatar = true
+	default:
+		return fmt.Errorf("invalid avatar_provider %q", cfg.AvatarProvider)
+	}
 	cfg.DisableBruteForceLoginProtection = security.Key("disable_brute_force_login_protection").MustBool(false)
 
 	CookieSecure = security.Key("cookie_secure").MustBool(false)
@@ -1342,6 +1386,10 @@ func readSecuritySettings(iniFile *ini.File, cfg *Cfg) error {
 		DataProxyWhiteList[hostAndIP] = true
 	}
 
+	// outbound notification webhooks
+	cfg.WebhookAllowedHosts = util.SplitString(valueAsString(security, "webhook_allowed_hosts", ""))
+	cfg.WebhookBlockPrivateIPs = security.Key("webhook_block_private_ips").MustBool(false)
+
 	// admin
 	cfg.DisableInitAdminCreation = security.Key("disable_initial_admin_creation").MustBool(false)
 	cfg.AdminUser = valueAsString(security, "admin_user", "")
@@ -7,6 +7,7 @@ const defaultDataProxyRowLimit = int64(1000000)
 func readDataProxySettings(iniFile *ini.File, cfg *Cfg) error {
 	dataproxy := iniFile.Section("dataproxy")
 	cfg.SendUserHeader = dataproxy.Key("send_user_header").MustBool(false)
+	cfg.SendUserHeaderIdentity = dataproxy.Key("send_user_header_identity").In("login", []string{"login", "email", "id"})
 	cfg.DataProxyLogging = dataproxy.Key("logging").MustBool(false)
 	cfg.DataProxyTimeout = dataproxy.Key("timeout").MustInt(10)
 	cfg.DataProxyDialTimeout = dataproxy.Key("dialTimeout").MustInt(30)
@@ -1,6 +1,8 @@
 package setting
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"gopkg.in/ini.v1"
@@ -40,6 +42,22 @@ func (cfg *Cfg) readPluginSettings(iniFile *ini.File) error {
 	cfg.PluginCatalogURL = pluginsSection.Key("plugin_catalog_url").MustString("https://grafana.com/grafana/plugins/")
 	cfg.PluginAdminEnabled = pluginsSection.Key("plugin_admin_enabled").MustBool(true)
 	cfg.PluginAdminExternalManageEnabled = pluginsSection.Key("plugin_admin_external_manage_enabled").MustBool(false)
+	cfg.PluginsTenantID = pluginsSection.Key("tenant_id").MustString("")
+	cfg.PluginsMaxResponseSize = pluginsSection.Key("max_response_size").MustInt(0)
+	cfg.PluginsMaxRequestBodySize = pluginsSection.Key("max_request_body_size").MustInt(0)
+	cfg.PluginsReadOnly = pluginsSection.Key("read_only").MustBool(false)
+	cfg.PluginsMaxRequestBodySizes = map[string]int{}
+	for pluginID, settings := range cfg.PluginSettings {
+		value, ok := settings["max_request_body_size"]
+		if !ok {
+			continue
+		}
+		maxBytes, err := strconv.Atoi(value)
+		if err != nil || maxBytes < 0 {
+			return fmt.Errorf("invalid max_request_body_size %q of plugin %s, must be a non-negative number of bytes", value, pluginID)
+		}
+		cfg.PluginsMaxRequestBodySizes[pluginID] = maxBytes
+	}
 	catalogHiddenPlugins := pluginsSection.Key("plugin_catalog_hidden_plugins").MustString("")
 
 	for _, plug := range strings.Split(catalogHiddenPlugins, ",") {
@@ -1,6 +1,16 @@
 package setting
 
-import "github.com/grafana/grafana/pkg/util"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util"
+	"gopkg.in/ini.v1"
+)
+
+// smtpOrgSectionPrefix is the prefix of the sections that override the SMTP settings of an org,
+// e.g. [smtp.org.2].
+const smtpOrgSectionPrefix = "smtp.org."
 
 type SmtpSettings struct {
 	Enabled        bool
@@ -18,24 +28,64 @@ type SmtpSettings struct {
 	SendWelcomeEmailOnSignUp bool
 	TemplatesPatterns        []string
 	ContentTypes             []string
+
+	// ArchiveAddress receives a blind carbon copy of every email of an org. It is only set in
+	// the settings of orgs, so that only the orgs it is configured for archive their emails.
+	ArchiveAddress string
+
+	// Orgs are the SMTP settings of orgs that send emails through their own relay. Settings
+	// that are not set for an org are inherited from the global settings.
+	Orgs map[int64]SmtpSettings
+}
+
+// ForOrg returns the SMTP settings emails of the org are sent with.
+func (s SmtpSettings) ForOrg(orgID int64) SmtpSettings {
+	if org, ok := s.Orgs[orgID]; ok {
+		return org
+	}
+	return s
 }
 
 func (cfg *Cfg) readSmtpSettings() {
 	sec := cfg.Raw.Section("smtp")
 	cfg.Smtp.Enabled = sec.Key("enabled").MustBool(false)
-	cfg.Smtp.Host = sec.Key("host").String()
-	cfg.Smtp.User = sec.Key("user").String()
-	cfg.Smtp.Password = sec.Key("password").String()
-	cfg.Smtp.CertFile = sec.Key("cert_file").String()
-	cfg.Smtp.KeyFile = sec.Key("key_file").String()
-	cfg.Smtp.FromAddress = sec.Key("from_address").String()
-	cfg.Smtp.FromName = sec.Key("from_name").String()
-	cfg.Smtp.EhloIdentity = sec.Key("ehlo_identity").String()
-	cfg.Smtp.StartTLSPolicy = sec.Key("startTLS_policy").String()
-	cfg.Smtp.SkipVerify = sec.Key("skip_verify").MustBool(false)
+	readSmtpRelaySettings(sec, &cfg.Smtp)
 
 	emails := cfg.Raw.Section("emails")
 	cfg.Smtp.SendWelcomeEmailOnSignUp = emails.Key("welcome_email_on_sign_up").MustBool(false)
 	cfg.Smtp.TemplatesPatterns = util.SplitString(emails.Key("templates_pattern").MustString("emails/*.html, emails/*.txt"))
 	cfg.Smtp.ContentTypes = util.SplitString(emails.Key("content_types").MustString("text/html"))
+
+	cfg.Smtp.Orgs = make(map[int64]SmtpSettings)
+	for _, sec := range cfg.Raw.Sections() {
+		if !strings.HasPrefix(sec.Name(), smtpOrgSectionPrefix) {
+			continue
+		}
+		orgID, err := strconv.ParseInt(strings.TrimPrefix(sec.Name(), smtpOrgSectionPrefix), 10, 64)
+		if err != nil {
+			cfg.Logger.Warn("Ignoring SMTP section with invalid org ID", "section", sec.Name())
+			continue
+		}
+
+		org := cfg.Smtp
+		org.Orgs = nil
+		readSmtpRelaySettings(sec, &org)
+		org.ArchiveAddress = sec.Key("archive_address").MustString("")
+		cfg.Smtp.Orgs[orgID] = org
+	}
+}
+
+// readSmtpRelaySettings reads the settings of the SMTP relay. Keys that are not in the section
+// keep their current value.
+func readSmtpRelaySettings(sec *ini.Section, s *SmtpSettings) {
+	s.Host = sec.Key("host").MustString(s.Host)
+	s.User = sec.Key("user").MustString(s.User)
+	s.Password = sec.Key("password").MustString(s.Password)
+	s.CertFile = sec.Key("cert_file").MustString(s.CertFile)
+	s.KeyFile = sec.Key("key_file").MustString(s.KeyFile)
+	s.FromAddress = sec.Key("from_address").MustString(s.FromAddress)
+	s.FromName = sec.Key("from_name").MustString(s.FromName)
+	s.EhloIdentity = sec.Key("ehlo_identity").MustString(s.EhloIdentity)
+	s.StartTLSPolicy = sec.Key("startTLS_policy").MustString(s.StartTLSPolicy)
+	s.SkipVerify = sec.Key("skip_verify").MustBool(s.SkipVerify)
 }
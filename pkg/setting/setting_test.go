@@ -768,3 +768,78 @@ func TestAlertingEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestSmtpOrgSettings(t *testing.T) {
+	f := ini.Empty()
+	cfg := NewCfg()
+	cfg.Raw = f
+	sec, err := f.NewSection("smtp")
+	require.NoError(t, err)
+	_, err = sec.NewKey("host", "smtp.example.com:25")
+	require.NoError(t, err)
+	_, err = sec.NewKey("from_address", "admin@example.com")
+	require.NoError(t, err)
+	sec, err = f.NewSection("smtp.org.2")
+	require.NoError(t, err)
+	_, err = sec.NewKey("host", "smtp.org2.com:25")
+	require.NoError(t, err)
+	_, err = sec.NewKey("archive_address", "archive@org2.com")
+	require.NoError(t, err)
+	_, err = f.NewSection("smtp.org.invalid")
+	require.NoError(t, err)
+
+	cfg.readSmtpSettings()
+
+	require.Len(t, cfg.Smtp.Orgs, 1)
+	org := cfg.Smtp.ForOrg(2)
+	require.Equal(t, "smtp.org2.com:25", org.Host)
+	require.Equal(t, "admin@example.com", org.FromAddress)
+	require.Equal(t, "archive@org2.com", org.ArchiveAddress)
+	require.Equal(t, "smtp.example.com:25", cfg.Smtp.ForOrg(1).Host)
+	require.Empty(t, cfg.Smtp.ForOrg(1).ArchiveAddress)
+}
+
+func TestAvatarProviderSettings(t *testing.T) {
+	disableGravatar := DisableGravatar
+	t.Cleanup(func() { DisableGravatar = disableGravatar })
+
+	cases := []struct {
+		name               string
+		provider           string
+		url                string
+		expErr             string
+		expDisableGravatar bool
+	}{
+		{name: "gravatar is the default"},
+		{name: "libravatar", provider: AvatarProviderLibravatar},
+		{name: "custom with URL", provider: AvatarProviderCustom, url: "https://avatars.example.com/avatar/"},
+		{name: "custom without URL", provider: AvatarProviderCustom, expErr: "avatar_url is required if avatar_provider is custom"},
+		{name: "disabled", provider: AvatarProviderDisabled, expDisableGravatar: true},
+		{name: "unknown", provider: "robohash", expErr: `invalid avatar_provider "robohash"`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := ini.Empty()
+			sec, err := f.NewSection("security")
+			require.NoError(t, err)
+			_, err = sec.NewKey("disable_gravatar", "false")
+			require.NoError(t, err)
+			if c.provider != "" {
+				_, err = sec.NewKey("avatar_provider", c.provider)
+				require.NoError(t, err)
+			}
+			_, err = sec.NewKey("avatar_url", c.url)
+			require.NoError(t, err)
+
+			cfg := NewCfg()
+			err = readSecuritySettings(f, cfg)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.url, cfg.AvatarURL)
+			require.Equal(t, c.expDisableGravatar, DisableGravatar)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package setting
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -52,6 +53,10 @@ const (
 	screenshotsDefaultCapture               = false
 	screenshotsDefaultMaxConcurrent         = 5
 	screenshotsDefaultUploadImageStorage    = false
+	teamNotificationBudgetDefaultTeamLabel  = "team"
+	notificationRetryBudgetDefaultInterval  = time.Minute
+	runbookDefaultMaxSize                   = 64 * 1024
+	runbookDefaultTimeout                   = 5 * time.Second
 	// SchedulerBaseInterval base interval of the scheduler. Controls how often the scheduler fetches database for new changes as well as schedules evaluation of a rule
 	// changing this value is discouraged because this could cause existing alert definition
 	// with intervals that are not exactly divided by this number not to be evaluated
@@ -60,6 +65,9 @@ const (
 	DefaultRuleEvaluationInterval = SchedulerBaseInterval * 6 // == 60 seconds
 )
 
+// hexColorRegex matches hex colors without the leading #, which starts comments in ini files.
+var hexColorRegex = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
 type UnifiedAlertingSettings struct {
 	AdminConfigPollInterval        time.Duration
 	AlertmanagerConfigPollInterval time.Duration
@@ -83,18 +91,45 @@ type UnifiedAlertingSettings struct {
 	DefaultRuleEvaluationInterval time.Duration
 	Screenshots                   UnifiedAlertingScreenshotSettings
 	ReservedLabels                UnifiedAlertingReservedLabelSettings
+	TeamNotificationBudget        UnifiedAlertingTeamNotificationBudgetSettings
+	// NotificationRetryBudget is the maximum number of failed notifications that are retried per
+	// NotificationRetryBudgetInterval, across all contact points. 0 disables retries.
+	NotificationRetryBudget         int64
+	NotificationRetryBudgetInterval time.Duration
+	// EmailOptOut are the addresses that never receive alert emails. They are removed from
+	// the recipients of the emails of all contact points.
+	EmailOptOut []string
+	// SeverityColors are the hex colors of chat messages about firing alerts, by the value of
+	// their severity label. They override the default colors.
+	SeverityColors map[string]string
+	// RunbookAllowedHosts are the hosts the runbooks included in alert emails can be fetched
+	// from. No runbook is fetched if it is empty. RunbookMaxSize is the number of bytes of a
+	// runbook that are read, and RunbookTimeout bounds the time to fetch it.
+	RunbookAllowedHosts []string
+	RunbookMaxSize      int64
+	RunbookTimeout      time.Duration
 }
 
 type UnifiedAlertingScreenshotSettings struct {
 	Capture                    bool
 	MaxConcurrentScreenshots   int64
 	UploadExternalImageStorage bool
+	// MaxConcurrentImageRequests is the maximum number of images that notifications can
+	// request at the same time, across all contact points. 0 means no limit.
+	MaxConcurrentImageRequests int64
 }
 
 type UnifiedAlertingReservedLabelSettings struct {
 	DisabledLabels map[string]struct{}
 }
 
+type UnifiedAlertingTeamNotificationBudgetSettings struct {
+	// TeamLabel is the alert label that identifies the team an alert belongs to.
+	TeamLabel string
+	// HourlyLimit is the maximum number of notifications a team can send per hour. 0 disables the budget.
+	HourlyLimit int64
+}
+
 // IsEnabled returns true if UnifiedAlertingSettings.Enabled is either nil or true.
 // It hides the implementation details of the Enabled and simplifies its usage.
 func (u *UnifiedAlertingSettings) IsEnabled() bool {
@@ -283,6 +318,10 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	uaCfgScreenshots.Capture = screenshots.Key("capture").MustBool(screenshotsDefaultCapture)
 	uaCfgScreenshots.MaxConcurrentScreenshots = screenshots.Key("max_concurrent_screenshots").MustInt64(screenshotsDefaultMaxConcurrent)
 	uaCfgScreenshots.UploadExternalImageStorage = screenshots.Key("upload_external_image_storage").MustBool(screenshotsDefaultUploadImageStorage)
+	uaCfgScreenshots.MaxConcurrentImageRequests = screenshots.Key("max_concurrent_image_requests").MustInt64(0)
+	if uaCfgScreenshots.MaxConcurrentImageRequests < 0 {
+		return fmt.Errorf("value of setting 'max_concurrent_image_requests' in section 'unified_alerting.screenshots' should not be negative")
+	}
 	uaCfg.Screenshots = uaCfgScreenshots
 
 	reservedLabels := iniFile.Section("unified_alerting.reserved_labels")
@@ -294,6 +333,55 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	}
 	uaCfg.ReservedLabels = uaCfgReservedLabels
 
+	teamNotificationBudget := iniFile.Section("unified_alerting.team_notification_budget")
+	uaCfg.TeamNotificationBudget = UnifiedAlertingTeamNotificationBudgetSettings{
+		TeamLabel:   teamNotificationBudget.Key("team_label").MustString(teamNotificationBudgetDefaultTeamLabel),
+		HourlyLimit: teamNotificationBudget.Key("hourly_limit").MustInt64(0),
+	}
+	if uaCfg.TeamNotificationBudget.HourlyLimit < 0 {
+		return fmt.Errorf("value of setting 'hourly_limit' in section 'unified_alerting.team_notification_budget' should not be negative")
+	}
+
+	notificationRetryBudget := iniFile.Section("unified_alerting.notification_retry_budget")
+	uaCfg.NotificationRetryBudget = notificationRetryBudget.Key("limit").MustInt64(0)
+	if uaCfg.NotificationRetryBudget < 0 {
+		return fmt.Errorf("value of setting 'limit' in section 'unified_alerting.notification_retry_budget' should not be negative")
+	}
+	uaCfg.NotificationRetryBudgetInterval, err = gtime.ParseDuration(valueAsString(notificationRetryBudget, "interval", notificationRetryBudgetDefaultInterval.String()))
+	if err != nil {
+		return err
+	}
+	if uaCfg.NotificationRetryBudgetInterval <= 0 {
+		return fmt.Errorf("value of setting 'interval' in section 'unified_alerting.notification_retry_budget' should be greater than 0")
+	}
+
+	emailOptOut := iniFile.Section("unified_alerting.email_opt_out")
+	uaCfg.EmailOptOut = util.SplitString(emailOptOut.Key("addresses").MustString(""))
+
+	severityColors := iniFile.Section("unified_alerting.severity_colors")
+	uaCfg.SeverityColors = make(map[string]string, len(severityColors.Keys()))
+	for _, key := range severityColors.Keys() {
+		color := strings.TrimSpace(key.String())
+		if !hexColorRegex.MatchString(color) {
+			return fmt.Errorf("value of setting '%s' in section 'unified_alerting.severity_colors' should be a hex color without #, e.g. D63232", key.Name())
+		}
+		uaCfg.SeverityColors[key.Name()] = "#" + color
+	}
+
+	runbooks := iniFile.Section("unified_alerting.runbooks")
+	uaCfg.RunbookAllowedHosts = util.SplitString(runbooks.Key("allowed_hosts").MustString(""))
+	uaCfg.RunbookMaxSize = runbooks.Key("max_size").MustInt64(runbookDefaultMaxSize)
+	if uaCfg.RunbookMaxSize <= 0 {
+		return fmt.Errorf("value of setting 'max_size' in section 'unified_alerting.runbooks' should be greater than 0")
+	}
+	uaCfg.RunbookTimeout, err = gtime.ParseDuration(valueAsString(runbooks, "timeout", runbookDefaultTimeout.String()))
+	if err != nil {
+		return err
+	}
+	if uaCfg.RunbookTimeout <= 0 {
+		return fmt.Errorf("value of setting 'timeout' in section 'unified_alerting.runbooks' should be greater than 0")
+	}
+
 	cfg.UnifiedAlerting = uaCfg
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"net"
 	"net/http"
 	"sort"
+	"strconv"
 
 	"github.com/grafana/grafana/pkg/services/user"
 )
@@ -11,6 +12,30 @@ import (
 // UserHeaderName name of the header used when forwarding the Grafana user login.
 const UserHeaderName = "X-Grafana-User"
 
+// Supported identities that can be forwarded in the X-Grafana-User header.
+const (
+	UserHeaderIdentityLogin = "login"
+	UserHeaderIdentityEmail = "email"
+	UserHeaderIdentityID    = "id"
+)
+
+// UserHeaderValue returns the value of the X-Grafana-User header for the given user
+// based on the configured identity. Unknown identities fall back to the user login.
+func UserHeaderValue(identity string, user *user.SignedInUser) string {
+	if user == nil {
+		return ""
+	}
+
+	switch identity {
+	case UserHeaderIdentityEmail:
+		return user.Email
+	case UserHeaderIdentityID:
+		return strconv.FormatInt(user.UserID, 10)
+	default:
+		return user.Login
+	}
+}
+
 // PrepareProxyRequest prepares a request for being proxied.
 // Removes X-Forwarded-Host, X-Forwarded-Port, X-Forwarded-Proto, Origin, Referer headers.
 // Set X-Grafana-Referer based on contents of Referer.
@@ -76,9 +101,10 @@ func SetProxyResponseHeaders(header http.Header) {
 }
 
 // ApplyUserHeader Set the X-Grafana-User header if needed (and remove if not).
-func ApplyUserHeader(sendUserHeader bool, req *http.Request, user *user.SignedInUser) {
+// The header identifies the user with the given identity, see UserHeaderValue.
+func ApplyUserHeader(sendUserHeader bool, identity string, req *http.Request, user *user.SignedInUser) {
 	req.Header.Del(UserHeaderName)
 	if sendUserHeader && user != nil && !user.IsAnonymous {
-		req.Header.Set(UserHeaderName, user.Login)
+		req.Header.Set(UserHeaderName, UserHeaderValue(identity, user))
 	}
 }
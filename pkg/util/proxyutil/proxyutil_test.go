@@ -117,7 +117,7 @@ func TestApplyUserHeader(t *testing.T) {
 		require.NoError(t, err)
 		req.Header.Set("X-Grafana-User", "admin")
 
-		ApplyUserHeader(false, req, &user.SignedInUser{Login: "admin"})
+		ApplyUserHeader(false, UserHeaderIdentityLogin, req, &user.SignedInUser{Login: "admin"})
 		require.NotContains(t, req.Header, "X-Grafana-User")
 	})
 
@@ -126,7 +126,7 @@ func TestApplyUserHeader(t *testing.T) {
 		require.NoError(t, err)
 		req.Header.Set("X-Grafana-User", "admin")
 
-		ApplyUserHeader(false, req, nil)
+		ApplyUserHeader(false, UserHeaderIdentityLogin, req, nil)
 		require.NotContains(t, req.Header, "X-Grafana-User")
 	})
 
@@ -134,7 +134,7 @@ func TestApplyUserHeader(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet, "/", nil)
 		require.NoError(t, err)
 
-		ApplyUserHeader(true, req, &user.SignedInUser{IsAnonymous: true})
+		ApplyUserHeader(true, UserHeaderIdentityLogin, req, &user.SignedInUser{IsAnonymous: true})
 		require.NotContains(t, req.Header, "X-Grafana-User")
 	})
 
@@ -142,7 +142,25 @@ func TestApplyUserHeader(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet, "/", nil)
 		require.NoError(t, err)
 
-		ApplyUserHeader(true, req, &user.SignedInUser{Login: "admin"})
+		ApplyUserHeader(true, UserHeaderIdentityLogin, req, &user.SignedInUser{Login: "admin"})
 		require.Equal(t, "admin", req.Header.Get("X-Grafana-User"))
 	})
+
+	t.Run("Should apply user header with the configured identity", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+
+		ApplyUserHeader(true, UserHeaderIdentityEmail, req, &user.SignedInUser{Login: "admin", Email: "admin@example.com"})
+		require.Equal(t, "admin@example.com", req.Header.Get("X-Grafana-User"))
+	})
+}
+
+func TestUserHeaderValue(t *testing.T) {
+	u := &user.SignedInUser{UserID: 3, Login: "admin", Email: "admin@example.com"}
+
+	require.Equal(t, "admin", UserHeaderValue(UserHeaderIdentityLogin, u))
+	require.Equal(t, "admin@example.com", UserHeaderValue(UserHeaderIdentityEmail, u))
+	require.Equal(t, "3", UserHeaderValue(UserHeaderIdentityID, u))
+	require.Equal(t, "admin", UserHeaderValue("unknown", u))
+	require.Empty(t, UserHeaderValue(UserHeaderIdentityLogin, nil))
 }
@@ -20,6 +20,7 @@ export interface TeamMember {
   login: string;
   labels: string[];
   permission: number;
+  expiresAt?: string;
 }
 
 export interface TeamGroup {